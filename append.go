@@ -0,0 +1,150 @@
+package edn
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AppendValue appends the EDN representation of v to dst, as String
+// does, and returns the extended buffer.  Unlike String it fails for
+// values of types the reader does not produce.
+//
+// Like the Append functions of strconv, it does not allocate for
+// scalar values if dst has enough capacity.  Maps and sets allocate,
+// as their entries are sorted.
+func AppendValue(dst []byte, v interface{}) ([]byte, error) {
+	if err := checkEncodable(v); err != nil {
+		return dst, err
+	}
+
+	return appendValue(dst, v), nil
+}
+
+// AppendKeyword appends kw to dst, e.g. :user/name.
+func AppendKeyword(dst []byte, kw Keyword) []byte {
+	dst = append(dst, ':')
+	if kw.Namespace != "" {
+		dst = append(dst, kw.Namespace...)
+		dst = append(dst, '/')
+	}
+	return append(dst, kw.Name...)
+}
+
+// AppendSymbol appends sym to dst, e.g. user/name.
+func AppendSymbol(dst []byte, sym Symbol) []byte {
+	if sym.Namespace != "" {
+		dst = append(dst, sym.Namespace...)
+		dst = append(dst, '/')
+	}
+	return append(dst, sym.Name...)
+}
+
+// AppendInt appends the integer i to dst.
+func AppendInt(dst []byte, i int64) []byte {
+	return strconv.AppendInt(dst, i, 10)
+}
+
+// AppendFloat appends the float f to dst, always with a decimal point
+// or an exponent so that it is read as a float.
+func AppendFloat(dst []byte, f float64) []byte {
+	return appendFloat(dst, f)
+}
+
+// AppendString appends s as a string literal to dst, see QuoteString.
+func AppendString(dst []byte, s string) []byte {
+	return appendString(dst, s)
+}
+
+// checkEncodable returns an error if v is or contains a value that
+// appendValue only writes as a string of its fmt.Sprint representation,
+// or a keyword, symbol, tag or composite key that would not be read
+// back as itself, such as the symbol nil or the keyword ":a b".
+func checkEncodable(v interface{}) error {
+	return plainPrinter.check(v)
+}
+
+// check is checkEncodable for the output of p, which also rejects the
+// literals its target doesn't read.
+func (p *printer) check(v interface{}) error {
+	switch v := v.(type) {
+	case float64:
+		if p.target == TargetEDN2012 && (math.IsInf(v, 0) || math.IsNaN(v)) {
+			return fmt.Errorf("cannot encode %s for EDN 2012", String(v))
+		}
+		return nil
+	case nil, bool, int64, int, string, UUID,
+		time.Time, LocalDate, LocalTime, time.Duration, net.IP, *net.IPNet, *url.URL,
+		*big.Int, *big.Rat:
+		return nil
+	case Keyword:
+		if !validKeyword(v) {
+			return fmt.Errorf("cannot encode invalid keyword %q", v.String())
+		}
+		return nil
+	case Symbol:
+		if !validSymbol(v) {
+			return fmt.Errorf("cannot encode invalid symbol %q", v.String())
+		}
+		return nil
+	case CompositeKey:
+		val, rest, err := DecodeStringPrefix(string(v))
+		if err != nil || strings.TrimSpace(rest) != "" {
+			return fmt.Errorf("cannot encode invalid composite key %q", string(v))
+		}
+		return p.check(val)
+	case []interface{}:
+		for _, elem := range v {
+			if err := p.check(elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[interface{}]interface{}:
+		for key, val := range v {
+			if err := p.check(key); err != nil {
+				return err
+			}
+			if err := p.check(val); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[interface{}]bool:
+		for elem := range v {
+			if err := p.check(elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Tagged:
+		if !validTag(v.Tag) {
+			return fmt.Errorf("cannot encode invalid tag %q", v.Tag.String())
+		}
+		return p.check(v.Value)
+	default:
+		tagged, ok, err := writeTagged(v)
+		if rv := reflect.ValueOf(v); !ok && rv.Kind() == reflect.Map {
+			for iter := rv.MapRange(); iter.Next(); {
+				if err := p.check(iter.Key().Interface()); err != nil {
+					return err
+				}
+				if err := p.check(iter.Value().Interface()); err != nil {
+					return err
+				}
+			}
+			return nil
+		} else if !ok {
+			return fmt.Errorf("cannot encode value of type %T", v)
+		} else if err != nil {
+			return fmt.Errorf("cannot encode value of type %T: %w", v, err)
+		}
+		return p.check(tagged)
+	}
+}
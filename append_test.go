@@ -0,0 +1,79 @@
+package edn
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestAppendValue(t *testing.T) {
+	val := mustDecode(t, `{:a [1 2.5 "three" sym] :b #{:x} :c #inst "2020-01-01T00:00:00Z" :d #foo 4N}`)
+
+	buf, err := AppendValue([]byte("prefix "), val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "prefix "+String(val) {
+		t.Errorf("unexpected output %s", buf)
+	}
+
+	for _, invalid := range []interface{}{
+		struct{}{},
+		[]interface{}{int64(1), make(chan int)},
+		map[interface{}]interface{}{Keyword{"", "a"}: int32(1)},
+		map[interface{}]interface{}{int8(1): nil},
+		map[interface{}]bool{uint(1): true},
+		Tagged{Tag: Symbol{"", "foo"}, Value: []string{"a"}},
+	} {
+		buf, err := AppendValue([]byte("x"), invalid)
+		if err == nil {
+			t.Errorf("expected error for %#v", invalid)
+		}
+		if string(buf) != "x" {
+			t.Errorf("buffer should be unchanged on error, but was %q", buf)
+		}
+	}
+
+	for _, valid := range []interface{}{nil, big.NewRat(1, 3), time.Duration(0), LocalDate{2020, 1, 1}} {
+		if _, err := AppendValue(nil, valid); err != nil {
+			t.Errorf("unexpected error for %#v: %v", valid, err)
+		}
+	}
+}
+
+func TestAppendScalars(t *testing.T) {
+	var buf []byte
+	buf = AppendKeyword(buf, Keyword{"user", "name"})
+	buf = append(buf, ' ')
+	buf = AppendKeyword(buf, Keyword{"", "id"})
+	buf = append(buf, ' ')
+	buf = AppendSymbol(buf, Symbol{"clojure.core", "map"})
+	buf = append(buf, ' ')
+	buf = AppendInt(buf, -42)
+	buf = append(buf, ' ')
+	buf = AppendFloat(buf, 3)
+	buf = append(buf, ' ')
+	buf = AppendString(buf, "say \"hi\"")
+
+	expected := `:user/name :id clojure.core/map -42 3.0 "say \"hi\""`
+	if string(buf) != expected {
+		t.Errorf("expected %s, got %s", expected, buf)
+	}
+}
+
+func TestAppendAllocations(t *testing.T) {
+	buf := make([]byte, 0, 1024)
+	kw := Keyword{"event", "type"}
+	var val interface{} = []interface{}{kw, "message", int64(1234567)}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		b := AppendKeyword(buf[:0], kw)
+		b = AppendInt(b, 1234567)
+		b = AppendFloat(b, 1.5)
+		b = AppendString(b, "message")
+		AppendValue(b, val)
+	})
+	if allocs > 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
@@ -0,0 +1,84 @@
+package edn
+
+import (
+	"unsafe"
+)
+
+// Arena is memory for decoded values that is reused as a whole, see
+// Decoder.SetArena.  It is meant for request-scoped parses: decode the
+// request with an arena, handle it, and Reset the arena for the next
+// one, so that the text of strings and the elements of vectors and
+// lists don't have to be collected as garbage.
+//
+// Maps, sets and all other values are allocated as usual.  An Arena
+// must not be used by several decoders at the same time.
+type Arena struct {
+	size  int
+	bytes []byte
+	elems []interface{}
+}
+
+// NewArena returns an arena which allocates blocks of size bytes, or
+// larger ones if a single value needs more.
+func NewArena(size int) *Arena {
+	return &Arena{size: size}
+}
+
+// Reset makes the memory of all values decoded with the arena available
+// for the following decodes.  Strings, vectors and lists decoded before
+// must not be used afterwards, their contents will change.  Only the
+// last blocks are kept, earlier ones are left to the garbage collector.
+func (a *Arena) Reset() {
+	a.bytes = a.bytes[:0]
+	clear(a.elems)
+	a.elems = a.elems[:0]
+}
+
+// SetArena makes the decoder allocate the text of strings and the
+// elements of vectors and lists from a instead of the heap, until it is
+// set to nil again.  See Arena for when the values may be used.
+func (d *Decoder) SetArena(a *Arena) {
+	d.arena = a
+}
+
+// buffer returns an empty slice for appending the bytes of a string,
+// which is then passed to string.
+func (a *Arena) buffer() []byte {
+	return a.bytes[len(a.bytes):]
+}
+
+// string returns buf, a slice returned by buffer, as a string in the
+// arena.
+func (a *Arena) string(buf []byte) string {
+	if len(buf) == 0 {
+		return ""
+	}
+
+	n := len(a.bytes)
+	if cap(buf) == cap(a.bytes)-n {
+		a.bytes = a.bytes[:n+len(buf)]
+	} else {
+		// appending to buf has moved it out of the block
+		a.bytes = make([]byte, 0, max(a.size, len(buf)))
+		a.bytes = append(a.bytes, buf...)
+		n = 0
+	}
+
+	return unsafe.String(&a.bytes[n], len(buf))
+}
+
+// vector returns a copy of elems in the arena.
+func (a *Arena) vector(elems []interface{}) []interface{} {
+	if len(elems) == 0 {
+		return []interface{}{}
+	}
+
+	n := len(a.elems)
+	if cap(a.elems)-n < len(elems) {
+		a.elems = make([]interface{}, 0, max(a.size/int(unsafe.Sizeof(elems[0])), len(elems)))
+		n = 0
+	}
+	a.elems = append(a.elems, elems...)
+
+	return a.elems[n:len(a.elems):len(a.elems)]
+}
@@ -0,0 +1,91 @@
+package edn
+
+import (
+	"strings"
+	"testing"
+)
+
+func decodeWithArena(t *testing.T, a *Arena, s string) interface{} {
+	d := NewDecoder(strings.NewReader(s))
+	d.SetArena(a)
+	val, err := d.Decode()
+	if err != nil {
+		t.Fatalf("decoding %q: %s", s, err)
+	}
+	return val
+}
+
+func TestArena(t *testing.T) {
+	tests := []string{
+		`"hello"`,
+		`""`,
+		`[]`,
+		`()`,
+		`[1 "two" [3 "four" ("five")] {:six "seven"} #{"eight"}]`,
+		`{:a ["b" "c"], ["d"] "e"}`,
+		`#inst "2024-01-02T03:04:05Z"`,
+		`["été" "😀"]`,
+	}
+
+	a := NewArena(16)
+	for _, s := range tests {
+		val := decodeWithArena(t, a, s)
+		if expected := mustDecode(t, s); !Equal(val, expected) {
+			t.Errorf("%s: expected %s, got %s", s, String(expected), String(val))
+		}
+	}
+}
+
+func TestArenaLargeValues(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	s := `["` + long + `" ` + strings.Repeat("1 ", 100) + `]`
+
+	a := NewArena(8)
+	val := decodeWithArena(t, a, s).([]interface{})
+	if len(val) != 101 || val[0] != long {
+		t.Errorf("expected %s, got %s", s, String(val))
+	}
+}
+
+func TestArenaReset(t *testing.T) {
+	a := NewArena(1024)
+	first := decodeWithArena(t, a, `["a" "b"]`).([]interface{})
+	if first[0] != "a" || first[1] != "b" {
+		t.Fatalf("got %s", String(first))
+	}
+
+	a.Reset()
+	second := decodeWithArena(t, a, `["c" "d"]`).([]interface{})
+	if second[0] != "c" || second[1] != "d" {
+		t.Fatalf("got %s", String(second))
+	}
+
+	// the first value shares the memory of the second now
+	if &first[0] != &second[0] {
+		t.Errorf("expected memory to be reused after Reset")
+	}
+}
+
+func TestArenaAllocations(t *testing.T) {
+	input := `[{:id 1 :tags ["a" "b" "c"]} {:id 2 :tags ["d" "e" "f"]} ["g" "h" "i" "j"]]`
+
+	count := func(a *Arena) float64 {
+		r := strings.NewReader(input)
+		return testing.AllocsPerRun(100, func() {
+			r.Reset(input)
+			d := NewDecoder(r)
+			d.SetArena(a)
+			if _, err := d.Decode(); err != nil {
+				t.Fatal(err)
+			}
+			if a != nil {
+				a.Reset()
+			}
+		})
+	}
+
+	without, with := count(nil), count(NewArena(4096))
+	if with >= without {
+		t.Errorf("expected fewer allocations with an arena, got %v with and %v without", with, without)
+	}
+}
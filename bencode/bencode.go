@@ -0,0 +1,343 @@
+// Package bencode reads and writes bencode and converts between bencode
+// structures and EDN values.
+//
+// Bencode is the transport encoding used by nREPL.  It knows only byte
+// strings, integers, lists and dictionaries, which are represented as
+//
+//  - byte strings as string
+//  - integers as int64
+//  - lists as []interface{}
+//  - dictionaries as map[string]interface{}
+//
+// ToEDN and FromEDN convert these to and from the values produced by
+// the edn package, so that messages can be handled as EDN maps keyed by
+// keywords, e.g. {:op "eval" :code "(+ 1 2)"}.
+//
+// References:
+//  - https://wiki.theory.org/BitTorrentSpecification#Bencoding
+//  - https://nrepl.org/nrepl/design/transports.html
+package bencode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/heyLu/edn"
+)
+
+// Decode reads the next bencode value.
+func Decode(r io.ByteScanner) (interface{}, error) {
+	ch, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ch == 'i':
+		return readInt(r, 'e')
+	case ch == 'l':
+		list := []interface{}{}
+		for {
+			ch, err := r.ReadByte()
+			if err != nil {
+				return nil, eofError("list", err)
+			}
+			if ch == 'e' {
+				return list, nil
+			}
+			if err := r.UnreadByte(); err != nil {
+				return nil, err
+			}
+
+			val, err := Decode(r)
+			if err != nil {
+				return nil, eofError("list", err)
+			}
+
+			list = append(list, val)
+		}
+	case ch == 'd':
+		dict := map[string]interface{}{}
+		for {
+			ch, err := r.ReadByte()
+			if err != nil {
+				return nil, eofError("dictionary", err)
+			}
+			if ch == 'e' {
+				return dict, nil
+			}
+			if !isDigit(ch) {
+				return nil, fmt.Errorf("dictionary key must be a string")
+			}
+
+			key, err := readString(r, ch)
+			if err != nil {
+				return nil, eofError("dictionary", err)
+			}
+
+			val, err := Decode(r)
+			if err != nil {
+				return nil, eofError("dictionary", err)
+			}
+
+			dict[key] = val
+		}
+	case isDigit(ch):
+		return readString(r, ch)
+	default:
+		return nil, fmt.Errorf("invalid bencode character: '%c'", ch)
+	}
+}
+
+func readInt(r io.ByteScanner, delim byte) (int64, error) {
+	buf := []byte{}
+
+	for {
+		ch, err := r.ReadByte()
+		if err != nil {
+			return 0, eofError("integer", err)
+		}
+
+		if ch == delim {
+			break
+		}
+
+		buf = append(buf, ch)
+	}
+
+	return strconv.ParseInt(string(buf), 10, 64)
+}
+
+func readString(r io.ByteScanner, ch byte) (string, error) {
+	if err := r.UnreadByte(); err != nil {
+		return "", err
+	}
+
+	n, err := readInt(r, ':')
+	if err != nil {
+		return "", err
+	}
+	if n < 0 {
+		return "", fmt.Errorf("invalid string length: %d", n)
+	}
+
+	// copy instead of allocating n bytes up front, so that a huge length
+	// fails at the end of the input instead of exhausting memory
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, reader(r), n); err != nil {
+		return "", eofError("string", err)
+	}
+
+	return buf.String(), nil
+}
+
+// reader returns r as an io.Reader, reading it byte by byte if it
+// doesn't implement Read itself.
+func reader(r io.ByteScanner) io.Reader {
+	if r, ok := r.(io.Reader); ok {
+		return r
+	}
+	return byteReader{r}
+}
+
+type byteReader struct {
+	r io.ByteScanner
+}
+
+func (b byteReader) Read(p []byte) (int, error) {
+	for i := range p {
+		ch, err := b.r.ReadByte()
+		if err != nil {
+			return i, err
+		}
+		p[i] = ch
+	}
+	return len(p), nil
+}
+
+func eofError(what string, err error) error {
+	if err == io.EOF {
+		return fmt.Errorf("eof while reading %s", what)
+	}
+
+	return err
+}
+
+func isDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9'
+}
+
+// Encode writes v as bencode.
+//
+// v must be composed of the types Decode produces.  Additionally,
+// []byte and int are accepted.  Dictionary keys are written in sorted
+// order, as required by the specification.
+func Encode(w io.Writer, v interface{}) error {
+	buf, err := appendValue(nil, v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+func appendValue(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		buf = strconv.AppendInt(buf, int64(len(v)), 10)
+		buf = append(buf, ':')
+		return append(buf, v...), nil
+	case []byte:
+		buf = strconv.AppendInt(buf, int64(len(v)), 10)
+		buf = append(buf, ':')
+		return append(buf, v...), nil
+	case int64:
+		buf = append(buf, 'i')
+		buf = strconv.AppendInt(buf, v, 10)
+		return append(buf, 'e'), nil
+	case int:
+		return appendValue(buf, int64(v))
+	case []interface{}:
+		buf = append(buf, 'l')
+		for _, elem := range v {
+			var err error
+			buf, err = appendValue(buf, elem)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, 'e'), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf = append(buf, 'd')
+		for _, key := range keys {
+			buf, _ = appendValue(buf, key)
+
+			var err error
+			buf, err = appendValue(buf, v[key])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, 'e'), nil
+	default:
+		return nil, fmt.Errorf("cannot encode %T as bencode", v)
+	}
+}
+
+// ToEDN converts a decoded bencode value to an EDN value.
+//
+// Dictionaries become maps with keyword keys, where keys containing a
+// slash are split into namespace and name ("nrepl.middleware/op"
+// becomes :nrepl.middleware/op).  All other values are kept as they are.
+func ToEDN(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, elem := range v {
+			list[i] = ToEDN(elem)
+		}
+		return list
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{}, len(v))
+		for key, val := range v {
+			m[toKeyword(key)] = ToEDN(val)
+		}
+		return m
+	default:
+		return v
+	}
+}
+
+func toKeyword(s string) edn.Keyword {
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '/' {
+			return edn.Keyword{Namespace: s[:i], Name: s[i+1:]}
+		}
+	}
+
+	return edn.Keyword{Namespace: "", Name: s}
+}
+
+// FromEDN converts an EDN value to a value that can be encoded as
+// bencode.
+//
+// Keywords and symbols become strings without the leading colon, UUIDs
+// become their string representation.  Vectors, lists and sets become
+// lists, maps become dictionaries and must have keyword, symbol or
+// string keys.  Integers must fit into an int64.  Other values, such as
+// nil, booleans or floats, have no bencode representation and result
+// in an error.
+func FromEDN(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string, int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case *big.Int:
+		if !v.IsInt64() {
+			return nil, fmt.Errorf("integer %s does not fit into int64", v)
+		}
+		return v.Int64(), nil
+	case edn.Keyword:
+		return v.FullName(), nil
+	case edn.Symbol:
+		return v.String(), nil
+	case edn.UUID:
+		return v.String(), nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, elem := range v {
+			val, err := FromEDN(elem)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = val
+		}
+		return list, nil
+	case map[interface{}]bool:
+		list := make([]interface{}, 0, len(v))
+		for elem := range v {
+			val, err := FromEDN(elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, val)
+		}
+		return list, nil
+	case map[interface{}]interface{}:
+		dict := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			var k string
+			switch key := key.(type) {
+			case edn.Keyword:
+				k = key.FullName()
+			case edn.Symbol:
+				k = key.String()
+			case string:
+				k = key
+			default:
+				return nil, fmt.Errorf("dictionary key must be a keyword, symbol or string, but was %#v", key)
+			}
+
+			bval, err := FromEDN(val)
+			if err != nil {
+				return nil, err
+			}
+			dict[k] = bval
+		}
+		return dict, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %#v to bencode", v)
+	}
+}
@@ -0,0 +1,81 @@
+package bencode
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/heyLu/edn"
+)
+
+func TestDecodeEncode(t *testing.T) {
+	msg := "d4:code7:(+ 1 2)2:idi42e2:op4:eval7:sessionl1:a1:bee"
+
+	val, err := Decode(strings.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"code":    "(+ 1 2)",
+		"id":      int64(42),
+		"op":      "eval",
+		"session": []interface{}{"a", "b"},
+	}
+	if !reflect.DeepEqual(val, expected) {
+		t.Errorf("unexpected value %#v", val)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := Encode(buf, val); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != msg {
+		t.Errorf("expected %q, got %q", msg, buf.String())
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, s := range []string{"", "i42", "l1:a", "d1:a", "di1ei2ee", "5:abc", "x", "999999999999999999:x"} {
+		if _, err := Decode(strings.NewReader(s)); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestEDNConversion(t *testing.T) {
+	val, err := edn.DecodeString(`{:op "eval" :id 3 :nrepl.middleware/status #{:done}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bval, err := FromEDN(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"op":                      "eval",
+		"id":                      int64(3),
+		"nrepl.middleware/status": []interface{}{"done"},
+	}
+	if !reflect.DeepEqual(bval, expected) {
+		t.Errorf("unexpected value %#v", bval)
+	}
+
+	eval := ToEDN(bval).(map[interface{}]interface{})
+	if eval[edn.Keyword{Namespace: "", Name: "op"}] != "eval" {
+		t.Errorf("unexpected value %#v", eval)
+	}
+	if _, ok := eval[edn.Keyword{Namespace: "nrepl.middleware", Name: "status"}]; !ok {
+		t.Errorf("unexpected value %#v", eval)
+	}
+
+	if _, err := FromEDN(map[interface{}]interface{}{int64(1): "a"}); err == nil {
+		t.Error("expected error for integer key")
+	}
+	if _, err := FromEDN(3.14); err == nil {
+		t.Error("expected error for float")
+	}
+}
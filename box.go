@@ -0,0 +1,56 @@
+package edn
+
+// Integers from smallIntMin to smallIntMax are boxed once, so that
+// reading them doesn't allocate.
+const (
+	smallIntMin = -128
+	smallIntMax = 1023
+)
+
+var smallInts [smallIntMax - smallIntMin + 1]interface{}
+
+func init() {
+	for i := range smallInts {
+		smallInts[i] = int64(i + smallIntMin)
+	}
+}
+
+// boxInt returns i as an interface{}, without allocating for small
+// integers.
+func boxInt(i int64) interface{} {
+	if smallIntMin <= i && i <= smallIntMax {
+		return smallInts[i-smallIntMin]
+	}
+	return i
+}
+
+// SetKeywordCache makes the decoder keep the first n distinct keywords
+// it reads, so that reading them again allocates nothing, e.g. the keys
+// of maps in a stream of similar values.  A value of 0, the default,
+// disables the cache.
+func (d *Decoder) SetKeywordCache(n int) {
+	d.keywordLimit = n
+	d.keywords = nil
+}
+
+// cachedKeyword returns the cached keyword for token.
+func (d *Decoder) cachedKeyword(token []byte) (interface{}, bool) {
+	if d.keywords == nil {
+		return nil, false
+	}
+
+	val, ok := d.keywords[string(token)]
+	return val, ok
+}
+
+// cacheKeyword caches val if it is a keyword and the cache is not full.
+func (d *Decoder) cacheKeyword(token string, val interface{}) {
+	if _, ok := val.(Keyword); !ok || len(d.keywords) >= d.keywordLimit {
+		return
+	}
+
+	if d.keywords == nil {
+		d.keywords = make(map[string]interface{})
+	}
+	d.keywords[token] = val
+}
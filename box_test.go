@@ -0,0 +1,65 @@
+package edn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBoxInt(t *testing.T) {
+	for _, i := range []int64{smallIntMin - 1, smallIntMin, -1, 0, 1, 255, 256, smallIntMax, smallIntMax + 1} {
+		if v := boxInt(i); v != i {
+			t.Errorf("expected %d, got %v", i, v)
+		}
+	}
+
+	for _, s := range []string{"-129", "-128", "-1", "1", "1000", "1024", "+7"} {
+		val := mustDecode(t, s)
+		if _, ok := val.(int64); !ok {
+			t.Errorf("%s: expected an int64, got %#v", s, val)
+		}
+	}
+}
+
+func TestKeywordCache(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`:a :old/b :a :c :old/b :c`))
+	d.SetKeywordCache(2)
+	d.RemapNamespace("old", "new")
+
+	expected := []Keyword{{"", "a"}, {"new", "b"}, {"", "a"}, {"", "c"}, {"new", "b"}, {"", "c"}}
+	for _, kw := range expected {
+		val, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val != kw {
+			t.Errorf("expected %s, got %v", kw, val)
+		}
+	}
+
+	if len(d.keywords) != 2 {
+		t.Errorf("expected 2 cached keywords, got %v", d.keywords)
+	}
+}
+
+func TestKeywordCacheAllocations(t *testing.T) {
+	input := `:id :name :tags 7 1000 true`
+
+	count := func(n int) float64 {
+		r := strings.NewReader(input)
+		d := NewDecoder(r)
+		d.SetKeywordCache(n)
+		return testing.AllocsPerRun(100, func() {
+			r.Reset(input)
+			for i := 0; i < 6; i++ {
+				if _, err := d.Decode(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+
+	without, with := count(0), count(10)
+	if with >= without {
+		t.Errorf("expected fewer allocations with the cache, got %v with and %v without", with, without)
+	}
+}
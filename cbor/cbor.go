@@ -0,0 +1,591 @@
+// Package cbor converts between EDN values and CBOR.
+//
+// CBOR is a compact binary format, which makes it useful for caching
+// or sending EDN data.  Encode and Decode map the values produced by
+// the edn package to CBOR as follows:
+//
+//   - nil, booleans, integers, floats and strings as the CBOR types
+//   - big integers as bignums (tags 2 and 3)
+//   - ratios as rational numbers (tag 30)
+//   - keywords and symbols as identifiers (tag 39), keywords with a
+//     leading colon, e.g. ":user/id"
+//   - vectors and lists as arrays, maps as maps
+//   - sets as finite sets (tag 258)
+//   - instants as epoch-based date/time (tag 1), with a precision of
+//     about a microsecond
+//   - UUIDs as binary UUIDs (tag 37)
+//   - other tagged values as [tag value] arrays (tag 27)
+//
+// Decode also reads RFC 3339 date/time strings (tag 0), byte strings as
+// []byte, and integers beyond the int64 range as big integers.
+//
+// References:
+//   - https://www.rfc-editor.org/rfc/rfc8949
+//   - https://www.iana.org/assignments/cbor-tags/cbor-tags.xhtml
+//   - https://github.com/greglook/clj-cbor
+package cbor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+const (
+	majorUint   = 0
+	majorNegint = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+
+	tagDateTime   = 0
+	tagEpoch      = 1
+	tagBignum     = 2
+	tagNegBignum  = 3
+	tagObject     = 27
+	tagRational   = 30
+	tagUUID       = 37
+	tagIdentifier = 39
+	tagSet        = 258
+)
+
+// Encode writes v encoded as CBOR to w.
+func Encode(w io.Writer, v interface{}) error {
+	buf, err := appendValue(nil, v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// Marshal returns v encoded as CBOR.
+func Marshal(v interface{}) ([]byte, error) {
+	return appendValue(nil, v)
+}
+
+func appendHead(buf []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, major|27), n)
+	}
+}
+
+func appendValue(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, 0xf6), nil
+	case bool:
+		if v {
+			return append(buf, 0xf5), nil
+		}
+		return append(buf, 0xf4), nil
+	case int:
+		return appendInt(buf, int64(v)), nil
+	case int64:
+		return appendInt(buf, v), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(append(buf, 0xfb), math.Float64bits(v)), nil
+	case string:
+		buf = appendHead(buf, majorText, uint64(len(v)))
+		return append(buf, v...), nil
+	case []byte:
+		buf = appendHead(buf, majorBytes, uint64(len(v)))
+		return append(buf, v...), nil
+	case *big.Int:
+		if v == nil {
+			return append(buf, 0xf6), nil
+		}
+		if v.Sign() < 0 {
+			n := new(big.Int).Neg(v)
+			buf = appendHead(buf, majorTag, tagNegBignum)
+			return appendValue(buf, n.Sub(n, big.NewInt(1)).Bytes())
+		}
+		buf = appendHead(buf, majorTag, tagBignum)
+		return appendValue(buf, v.Bytes())
+	case *big.Rat:
+		if v == nil {
+			return append(buf, 0xf6), nil
+		}
+		buf = appendHead(buf, majorTag, tagRational)
+		buf = appendHead(buf, majorArray, 2)
+		buf = appendBigInt(buf, v.Num())
+		return appendBigInt(buf, v.Denom()), nil
+	case edn.Keyword:
+		buf = appendHead(buf, majorTag, tagIdentifier)
+		return appendValue(buf, v.String())
+	case edn.Symbol:
+		buf = appendHead(buf, majorTag, tagIdentifier)
+		return appendValue(buf, v.String())
+	case time.Time:
+		buf = appendHead(buf, majorTag, tagEpoch)
+		if v.Nanosecond() == 0 {
+			return appendInt(buf, v.Unix()), nil
+		}
+		return appendValue(buf, float64(v.Unix())+float64(v.Nanosecond())/1e9)
+	case edn.UUID:
+		b := v.Bytes()
+		buf = appendHead(buf, majorTag, tagUUID)
+		return appendValue(buf, b[:])
+	case edn.Tagged:
+		buf = appendHead(buf, majorTag, tagObject)
+		buf = appendHead(buf, majorArray, 2)
+		buf, _ = appendValue(buf, v.Tag.String())
+		return appendValue(buf, v.Value)
+	case edn.CompositeKey:
+		val, err := v.Value()
+		if err != nil {
+			return nil, err
+		}
+		return appendValue(buf, val)
+	case []interface{}:
+		buf = appendHead(buf, majorArray, uint64(len(v)))
+		for _, elem := range v {
+			var err error
+			if buf, err = appendValue(buf, elem); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[interface{}]bool:
+		elems := make([][]byte, 0, len(v))
+		for elem := range v {
+			b, err := appendValue(nil, elem)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, b)
+		}
+		sortBytes(elems)
+
+		buf = appendHead(buf, majorTag, tagSet)
+		buf = appendHead(buf, majorArray, uint64(len(v)))
+		for _, elem := range elems {
+			buf = append(buf, elem...)
+		}
+		return buf, nil
+	case map[interface{}]interface{}:
+		entries := make([][]byte, 0, len(v))
+		for key, val := range v {
+			b, err := appendValue(nil, key)
+			if err != nil {
+				return nil, err
+			}
+			if b, err = appendValue(b, val); err != nil {
+				return nil, err
+			}
+			entries = append(entries, b)
+		}
+		sortBytes(entries)
+
+		buf = appendHead(buf, majorMap, uint64(len(v)))
+		for _, entry := range entries {
+			buf = append(buf, entry...)
+		}
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("cannot encode %#v as CBOR", v)
+	}
+}
+
+func appendInt(buf []byte, n int64) []byte {
+	if n < 0 {
+		return appendHead(buf, majorNegint, uint64(-1-n))
+	}
+	return appendHead(buf, majorUint, uint64(n))
+}
+
+// appendBigInt writes n as integer if it fits, and as bignum otherwise.
+func appendBigInt(buf []byte, n *big.Int) []byte {
+	if n.IsInt64() {
+		return appendInt(buf, n.Int64())
+	}
+
+	buf, _ = appendValue(buf, n)
+	return buf
+}
+
+// sortBytes sorts the encoded elements of sets and entries of maps, so
+// that the encoding of a value is always the same.
+func sortBytes(elems [][]byte) {
+	sort.Slice(elems, func(i, j int) bool { return bytes.Compare(elems[i], elems[j]) < 0 })
+}
+
+// MaxDepth is the maximum nesting of arrays, maps and tags Decode
+// accepts.
+var MaxDepth = 10000
+
+// Decode reads the next CBOR value from r.  io.EOF is returned if r
+// ends before the start of a value.  If r is not an io.ByteReader, it
+// is buffered and Decode may read beyond the value.
+func Decode(r io.Reader) (interface{}, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+		r = br.(io.Reader)
+	}
+
+	d := &decoder{r: r, br: br}
+	v, err := d.readValue()
+	if v == breakCode {
+		return nil, fmt.Errorf("unexpected break")
+	}
+	return v, err
+}
+
+// Unmarshal decodes the CBOR value in data, which must contain exactly
+// one value.
+func Unmarshal(data []byte) (interface{}, error) {
+	r := bytes.NewReader(data)
+	v, err := Decode(r)
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("%d bytes of trailing data", r.Len())
+	}
+	return v, nil
+}
+
+type decoder struct {
+	r     io.Reader
+	br    io.ByteReader
+	depth int
+}
+
+// breakCode is returned when reading the end of an item of indefinite
+// length.
+type breakMarker struct{}
+
+var breakCode = breakMarker{}
+
+// readHead reads the initial byte of an item and its argument.
+// indefinite is true for items of indefinite length.
+func (d *decoder) readHead() (major byte, info byte, arg uint64, err error) {
+	b, err := d.br.ReadByte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	major, info = b>>5, b&0x1f
+	var n int
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		n = 1 << (info - 24)
+	case info == 31:
+		return major, info, 0, nil
+	default:
+		return 0, 0, 0, fmt.Errorf("invalid additional information %d", info)
+	}
+
+	var buf [8]byte
+	if _, err := io.ReadFull(d.r, buf[8-n:]); err != nil {
+		return 0, 0, 0, unexpectedEOF(err)
+	}
+	return major, info, binary.BigEndian.Uint64(buf[:]), nil
+}
+
+func (d *decoder) readValue() (interface{}, error) {
+	major, info, arg, err := d.readHead()
+	if err != nil {
+		return nil, err
+	}
+
+	indefinite := info == 31
+	if indefinite && (major == majorUint || major == majorNegint || major == majorTag) {
+		return nil, fmt.Errorf("invalid indefinite length for major type %d", major)
+	}
+
+	switch major {
+	case majorUint:
+		if arg > math.MaxInt64 {
+			return new(big.Int).SetUint64(arg), nil
+		}
+		return int64(arg), nil
+	case majorNegint:
+		if arg > math.MaxInt64 {
+			n := new(big.Int).SetUint64(arg)
+			return n.Neg(n.Add(n, big.NewInt(1))), nil
+		}
+		return -1 - int64(arg), nil
+	case majorBytes, majorText:
+		b, err := d.readString(major, arg, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if major == majorText {
+			return string(b), nil
+		}
+		return b, nil
+	case majorArray:
+		arr := []interface{}{}
+		for i := uint64(0); indefinite || i < arg; i++ {
+			elem, err := d.readElement()
+			if err != nil {
+				return nil, err
+			}
+			if elem == breakCode {
+				if !indefinite {
+					return nil, fmt.Errorf("unexpected break")
+				}
+				break
+			}
+			arr = append(arr, elem)
+		}
+		return arr, nil
+	case majorMap:
+		m := map[interface{}]interface{}{}
+		for i := uint64(0); indefinite || i < arg; i++ {
+			key, err := d.readElement()
+			if err != nil {
+				return nil, err
+			}
+			if key == breakCode {
+				if !indefinite {
+					return nil, fmt.Errorf("unexpected break")
+				}
+				break
+			}
+
+			val, err := d.readElement()
+			if err != nil {
+				return nil, err
+			}
+			if val == breakCode {
+				return nil, fmt.Errorf("unexpected break")
+			}
+			m[edn.KeyOf(key)] = val
+		}
+		return m, nil
+	case majorTag:
+		val, err := d.readElement()
+		if err != nil {
+			return nil, err
+		}
+		if val == breakCode {
+			return nil, fmt.Errorf("unexpected break")
+		}
+		return readTagged(arg, val)
+	default:
+		return readSimple(info, arg)
+	}
+}
+
+// readElement reads an element of an array, map or tag, for which the
+// input must not end.
+func (d *decoder) readElement() (interface{}, error) {
+	d.depth++
+	defer func() { d.depth-- }()
+	if d.depth > MaxDepth {
+		return nil, fmt.Errorf("items nested deeper than %d levels", MaxDepth)
+	}
+
+	v, err := d.readValue()
+	return v, unexpectedEOF(err)
+}
+
+func (d *decoder) readString(major byte, n uint64, indefinite bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if !indefinite {
+		if _, err := io.CopyN(&buf, d.r, int64(n)); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	for {
+		chunkMajor, info, arg, err := d.readHead()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if chunkMajor == majorSimple && info == 31 {
+			return buf.Bytes(), nil
+		}
+		if chunkMajor != major || info == 31 {
+			return nil, fmt.Errorf("invalid chunk of indefinite length string")
+		}
+
+		if _, err := io.CopyN(&buf, d.r, int64(arg)); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+}
+
+func readSimple(info byte, arg uint64) (interface{}, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		return halfToFloat(uint16(arg)), nil
+	case 26:
+		return float64(math.Float32frombits(uint32(arg))), nil
+	case 27:
+		return math.Float64frombits(arg), nil
+	case 31:
+		return breakCode, nil
+	default:
+		return nil, fmt.Errorf("unsupported simple value %d", arg)
+	}
+}
+
+func halfToFloat(h uint16) float64 {
+	exp := int(h >> 10 & 0x1f)
+	mant := float64(h & 0x3ff)
+
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
+
+func readTagged(tag uint64, val interface{}) (interface{}, error) {
+	switch tag {
+	case tagDateTime:
+		s, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("date/time must be a string, but was %#v", val)
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case tagEpoch:
+		switch val := val.(type) {
+		case int64:
+			return time.Unix(val, 0).UTC(), nil
+		case float64:
+			sec, frac := math.Modf(val)
+			return time.Unix(int64(sec), int64(math.Round(frac*1e6))*1e3).UTC(), nil
+		default:
+			return nil, fmt.Errorf("epoch date/time must be a number, but was %#v", val)
+		}
+	case tagBignum, tagNegBignum:
+		b, ok := val.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("bignum must be a byte string, but was %#v", val)
+		}
+		n := new(big.Int).SetBytes(b)
+		if tag == tagNegBignum {
+			n.Neg(n.Add(n, big.NewInt(1)))
+		}
+		return n, nil
+	case tagRational:
+		arr, ok := val.([]interface{})
+		if !ok || len(arr) != 2 {
+			return nil, fmt.Errorf("rational must be an array of two integers, but was %#v", val)
+		}
+		num, ok1 := toBigInt(arr[0])
+		denom, ok2 := toBigInt(arr[1])
+		if !ok1 || !ok2 || denom.Sign() <= 0 {
+			return nil, fmt.Errorf("rational must be an array of two integers, but was %#v", val)
+		}
+		return new(big.Rat).SetFrac(num, denom), nil
+	case tagIdentifier:
+		s, ok := val.(string)
+		if !ok || s == "" || s == ":" {
+			return nil, fmt.Errorf("identifier must be a non-empty string, but was %#v", val)
+		}
+		if s[0] == ':' {
+			sym := toSymbol(s[1:])
+			return edn.Keyword{Namespace: sym.Namespace, Name: sym.Name}, nil
+		}
+		return toSymbol(s), nil
+	case tagUUID:
+		b, ok := val.([]byte)
+		if !ok || len(b) != 16 {
+			return nil, fmt.Errorf("uuid must be a byte string of length 16, but was %#v", val)
+		}
+		return edn.UUID{Msb: binary.BigEndian.Uint64(b[:8]), Lsb: binary.BigEndian.Uint64(b[8:])}, nil
+	case tagSet:
+		arr, ok := val.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("set must be an array, but was %#v", val)
+		}
+		set := make(map[interface{}]bool, len(arr))
+		for _, elem := range arr {
+			set[edn.KeyOf(elem)] = true
+		}
+		return set, nil
+	case tagObject:
+		arr, ok := val.([]interface{})
+		if !ok || len(arr) != 2 {
+			return nil, fmt.Errorf("tagged value must be an array of tag and value, but was %#v", val)
+		}
+		name, ok := arr[0].(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("tag must be a non-empty string, but was %#v", arr[0])
+		}
+		return edn.ApplyTag(toSymbol(name), arr[1])
+	default:
+		return nil, fmt.Errorf("unsupported tag %d", tag)
+	}
+}
+
+func toBigInt(v interface{}) (*big.Int, bool) {
+	switch v := v.(type) {
+	case int64:
+		return big.NewInt(v), true
+	case *big.Int:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+func toSymbol(s string) edn.Symbol {
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '/' {
+			return edn.Symbol{Namespace: s[:i], Name: s[i+1:]}
+		}
+	}
+
+	return edn.Symbol{Namespace: "", Name: s}
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
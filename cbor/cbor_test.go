@@ -0,0 +1,121 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+func TestRoundTrip(t *testing.T) {
+	input := `{:nil nil :bool [true false] :ints [0 23 24 -1 -25 255 256 65536 4294967296 9223372036854775807 -9223372036854775807]
+		:big [12N -12N 18446744073709551616N -18446744073709551617N] :float [1.5 -0.0 1e300] :ratio [1/3 -18446744073709551616/7]
+		:str ["" "héllo"] :ident [:kw :ns/kw sym ns/sym / :/] :set #{1 [2] :three}
+		:inst #inst "1985-04-12T23:20:50.52Z" :uuid #uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"
+		:tagged #my/tag {:a 1} [1 2] "composite key"}`
+	val, err := edn.DecodeString(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := Marshal(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	back, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !edn.Equal(back, val) {
+		t.Errorf("expected %s, got %s", edn.String(val), edn.String(back))
+	}
+
+	again, _ := Marshal(back)
+	if !bytes.Equal(again, data) {
+		t.Errorf("encoding is not deterministic")
+	}
+
+	var buf bytes.Buffer
+	Encode(&buf, val)
+	Encode(&buf, edn.Keyword{Namespace: "", Name: "next"})
+	r := strings.NewReader(buf.String())
+	for _, expected := range []interface{}{val, edn.Keyword{Namespace: "", Name: "next"}} {
+		got, err := Decode(r)
+		if err != nil || !edn.Equal(got, expected) {
+			t.Errorf("expected %s, got %s, %v", edn.String(expected), edn.String(got), err)
+		}
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		val interface{}
+		hex string
+	}{
+		{int64(10), "0a"},
+		{int64(-500), "3901f3"},
+		{"a", "6161"},
+		{edn.Keyword{Namespace: "", Name: "a"}, "d827623a61"},
+		{big.NewInt(1), "c24101"},
+		{time.Unix(1363896240, 0), "c11a514b67b0"},
+		{[]interface{}{nil, true}, "82f6f5"},
+		{map[interface{}]bool{int64(1): true}, "d9010281" + "01"},
+	}
+
+	for _, test := range tests {
+		data, err := Marshal(test.val)
+		if err != nil {
+			t.Errorf("%s: %v", edn.String(test.val), err)
+		} else if hex.EncodeToString(data) != test.hex {
+			t.Errorf("%s: expected %s, got %x", edn.String(test.val), test.hex, data)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := map[string]interface{}{
+		// from the examples of RFC 8949, appendix A
+		"f93c00":               1.0,
+		"f97bff":               65504.0,
+		"f90001":               5.960464477539063e-8,
+		"fa47c35000":           100000.0,
+		"c1fb41d452d9ec200000": time.Date(2013, 3, 21, 20, 4, 0, 500000000, time.UTC),
+		"c074323031332d30332d32315432303a30343a30305a": time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC),
+		"4401020304":                 []byte{1, 2, 3, 4},
+		"5f42010243030405ff":         []byte{1, 2, 3, 4, 5},
+		"7f657374726561646d696e67ff": "streaming",
+		"9f018202039f0405ffff":       []interface{}{int64(1), []interface{}{int64(2), int64(3)}, []interface{}{int64(4), int64(5)}},
+		"bf61610161629f0203ffff":     map[interface{}]interface{}{"a": int64(1), "b": []interface{}{int64(2), int64(3)}},
+		"1bffffffffffffffff":         new(big.Int).SetUint64(math.MaxUint64),
+		"f7":                         nil,
+	}
+
+	for h, expected := range tests {
+		data, _ := hex.DecodeString(h)
+		val, err := Unmarshal(data)
+		if err != nil {
+			t.Errorf("%s: %v", h, err)
+		} else if !edn.Equal(val, expected) {
+			t.Errorf("%s: expected %#v, got %#v", h, expected, val)
+		}
+	}
+
+	if v, _ := Unmarshal([]byte{0xf9, 0x7e, 0x00}); !math.IsNaN(v.(float64)) {
+		t.Errorf("expected NaN, got %v", v)
+	}
+
+	invalid := []string{"", "18", "62616", "ff", "9f01", "8201", "c2", "d82701", "d8256161", "c363616263", "0101", "1c", strings.Repeat("81", 20000) + "01"}
+	for _, h := range invalid {
+		data, _ := hex.DecodeString(h)
+		if _, err := Unmarshal(data); err == nil {
+			t.Errorf("%s: expected error", h)
+		}
+	}
+}
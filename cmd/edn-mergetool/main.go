@@ -0,0 +1,215 @@
+// Command edn-mergetool lets git diff and merge EDN files by their
+// values instead of their lines.
+//
+// Usage:
+//
+//	edn-mergetool merge base ours theirs
+//	edn-mergetool textconv file
+//
+// merge is a git merge driver: it merges the values of the three
+// versions of a file with edn.Merge3 and writes the result to ours.
+// Files are merged value by value if all versions contain the same
+// number of values.  The exit status is 1 if there were conflicts,
+// which are marked with #edn/conflict in the result.
+//
+// The merged values are written anew, which would turn lists into
+// vectors and drop comments and discarded forms.  If any version
+// contains them, the versions are merged line by line with git
+// merge-file instead, with the usual conflict markers.
+//
+// textconv writes the values of a file to standard output in a
+// canonical layout, so that diffs only show changes of values.
+//
+// Both lay out values with the style of the closest .ednfmt.edn, see
+// edn.FindStyle, so the merge driver is best used for generated or
+// machine-edited files, whose layout is that style anyway.  To use
+// them for all EDN files of a repository:
+//
+//	# .gitattributes
+//	*.edn merge=edn diff=edn
+//
+//	# .git/config
+//	[merge "edn"]
+//		name = EDN merge driver
+//		driver = edn-mergetool merge %O %A %B
+//	[diff "edn"]
+//		textconv = edn-mergetool textconv
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/heyLu/edn"
+)
+
+func main() {
+	args := os.Args[1:]
+	switch {
+	case len(args) == 4 && args[0] == "merge":
+		clean, err := merge(args[1], args[2], args[3])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "edn-mergetool: %v\n", err)
+			os.Exit(2)
+		}
+		if !clean {
+			fmt.Fprintf(os.Stderr, "edn-mergetool: conflicts in %s\n", args[2])
+			os.Exit(1)
+		}
+	case len(args) == 2 && args[0] == "textconv":
+		if err := textconv(args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "edn-mergetool: %v\n", err)
+			os.Exit(2)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "usage: edn-mergetool merge base ours theirs")
+		fmt.Fprintln(os.Stderr, "       edn-mergetool textconv file")
+		os.Exit(2)
+	}
+}
+
+func merge(basePath, oursPath, theirsPath string) (bool, error) {
+	var versions [3][]interface{}
+	for i, path := range []string{basePath, oursPath, theirsPath} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+		if !plain(data) {
+			return mergeLines(basePath, oursPath, theirsPath)
+		}
+
+		vals, err := edn.ReadAllValues(bytes.NewReader(data))
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", path, err)
+		}
+		versions[i] = vals
+	}
+
+	style, err := edn.FindStyle(filepath.Dir(oursPath))
+	if err != nil {
+		return false, err
+	}
+
+	base, ours, theirs := versions[0], versions[1], versions[2]
+
+	var merged []interface{}
+	clean := true
+	if len(base) == len(ours) && len(base) == len(theirs) {
+		for i := range base {
+			val, ok := edn.Merge3(base[i], ours[i], theirs[i])
+			merged = append(merged, val)
+			clean = clean && ok
+		}
+	} else {
+		// the values of the whole files conflict, write them as one
+		val, ok := edn.Merge3(base, ours, theirs)
+		merged = append(merged, val)
+		clean = ok
+	}
+
+	return clean, writeFile(oursPath, style, merged)
+}
+
+// plain reports whether data contains no lists, comments or discarded
+// forms, which would be lost when its values are written anew.
+func plain(data []byte) bool {
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '(', ';':
+			return false
+		case '#':
+			if i+1 < len(data) && data[i+1] == '_' {
+				return false
+			}
+		case '\\':
+			// characters such as \( and \"
+			i++
+		case '"':
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+// mergeLines merges the versions line by line with git merge-file,
+// which writes the result to ours.
+func mergeLines(basePath, oursPath, theirsPath string) (bool, error) {
+	cmd := exec.Command("git", "merge-file", "-L", "ours", "-L", "base", "-L", "theirs", oursPath, basePath, theirsPath)
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+
+	// the exit status is the number of conflicts, or negative on errors
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128 {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("git merge-file: %w", err)
+	}
+	return true, nil
+}
+
+func textconv(path string) error {
+	vals, err := readFile(path)
+	if err != nil {
+		return err
+	}
+
+	style, err := edn.FindStyle(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+
+	for _, val := range vals {
+		data, err := edn.Canonical(val)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		canonical, err := edn.DecodeBytes(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Println(style.Pretty(canonical))
+	}
+
+	return nil
+}
+
+func readFile(path string) ([]interface{}, error) {
+	r, err := edn.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	vals, err := edn.ReadAllValues(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return vals, nil
+}
+
+func writeFile(path string, style edn.Style, vals []interface{}) error {
+	var sb strings.Builder
+	for _, val := range vals {
+		sb.WriteString(style.Pretty(val))
+		sb.WriteByte('\n')
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(sb.String()), info.Mode().Perm())
+}
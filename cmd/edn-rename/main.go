@@ -0,0 +1,87 @@
+// Command edn-rename renames a keyword in EDN files, keeping their
+// formatting and comments.
+//
+// Usage:
+//
+//	edn-rename [-w] :old/name :new/name file.edn...
+//
+// Without -w, the files are listed with the number of occurrences that
+// would be renamed.  With -w, they are rewritten.  See edn.RenameKeyword
+// for which occurrences are renamed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/heyLu/edn"
+)
+
+func main() {
+	write := flag.Bool("w", false, "write the renamed files instead of listing them")
+	flag.Parse()
+
+	if flag.NArg() < 3 {
+		fmt.Fprintln(os.Stderr, "usage: edn-rename [-w] :old/name :new/name file.edn...")
+		os.Exit(2)
+	}
+
+	from, err := parseKeyword(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "edn-rename: %v\n", err)
+		os.Exit(2)
+	}
+	to, err := parseKeyword(flag.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "edn-rename: %v\n", err)
+		os.Exit(2)
+	}
+
+	status := 0
+	for _, path := range flag.Args()[2:] {
+		if err := rename(path, from, to, *write); err != nil {
+			fmt.Fprintf(os.Stderr, "edn-rename: %s: %v\n", path, err)
+			status = 1
+		}
+	}
+
+	os.Exit(status)
+}
+
+func parseKeyword(s string) (edn.Keyword, error) {
+	if !edn.IsValidKeyword(s) {
+		return edn.Keyword{}, fmt.Errorf("invalid keyword %s", s)
+	}
+
+	val, err := edn.DecodeString(s)
+	if err != nil {
+		return edn.Keyword{}, err
+	}
+
+	return val.(edn.Keyword), nil
+}
+
+func rename(path string, from, to edn.Keyword, write bool) error {
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	out, n, err := edn.RenameKeyword(doc, from, to)
+	if err != nil || n == 0 {
+		return err
+	}
+
+	if !write {
+		fmt.Printf("%s: %d\n", path, n)
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, out, info.Mode().Perm())
+}
@@ -0,0 +1,74 @@
+// Command edn-sortkeys sorts the entries of the maps in EDN files,
+// keeping comments with their entries.
+//
+// Usage:
+//
+//	edn-sortkeys [-l | -w] file.edn...
+//
+// Without flags, the sorted files are written to standard output.
+// With -l, the files whose maps are not sorted are listed, with -w,
+// they are rewritten.  See edn.SortKeys for how entries are moved.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/heyLu/edn"
+)
+
+func main() {
+	list := flag.Bool("l", false, "list files whose maps are not sorted")
+	write := flag.Bool("w", false, "write the sorted files instead of printing them")
+	flag.Parse()
+
+	if flag.NArg() == 0 || (*list && *write) {
+		fmt.Fprintln(os.Stderr, "usage: edn-sortkeys [-l | -w] file.edn...")
+		os.Exit(2)
+	}
+
+	status := 0
+	for _, path := range flag.Args() {
+		if err := sortKeys(path, *list, *write); err != nil {
+			fmt.Fprintf(os.Stderr, "edn-sortkeys: %s: %v\n", path, err)
+			status = 1
+		}
+	}
+
+	os.Exit(status)
+}
+
+func sortKeys(path string, list, write bool) error {
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	sorted, err := edn.SortKeys(doc)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case list:
+		if !bytes.Equal(doc, sorted) {
+			fmt.Println(path)
+		}
+		return nil
+	case write:
+		if bytes.Equal(doc, sorted) {
+			return nil
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, sorted, info.Mode().Perm())
+	default:
+		_, err := os.Stdout.Write(sorted)
+		return err
+	}
+}
@@ -0,0 +1,396 @@
+// Command edn reads, checks and converts EDN files.
+//
+// Usage:
+//
+//	edn fmt [-l | -w] [file.edn...]
+//	edn validate [file.edn...]
+//	edn get path [file.edn...]
+//	edn diff a.edn b.edn
+//	edn to-json [file.edn...]
+//	edn from-json [file.json...]
+//	edn repl
+//
+// Without files, the commands read standard input.
+//
+// fmt lays out the files like ednfmt does.  validate checks that the
+// files consist of well-formed values, printing the position of the
+// errors.  get prints the value at path, an EDN vector of keys and
+// indices such as [:db :hosts 0], of each value in the files that has
+// one, see edn.GetIn.  diff prints a unified diff of the values of two
+// files, see edn.Unified.  to-json writes each value as a line of JSON,
+// from-json each JSON value as EDN, with the keys of objects that are
+// valid keywords as keywords, see package ednjson.  The values are
+// laid out with the style of the closest .ednfmt.edn in the current
+// directory or its parents, see edn.FindStyle.
+//
+// repl reads values from standard input and prints them laid out with
+// the style, colored if standard output is a terminal.
+//
+// The exit status is 1 if validate finds errors or diff differences,
+// and 2 for invalid arguments.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/heyLu/edn"
+	"github.com/heyLu/edn/ednjson"
+)
+
+const usage = `usage: edn fmt [-l | -w] [file.edn...]
+       edn validate [file.edn...]
+       edn get path [file.edn...]
+       edn diff a.edn b.edn
+       edn to-json [file.edn...]
+       edn from-json [file.json...]
+       edn repl`
+
+var commands = map[string]func(args []string) error{
+	"fmt":       format,
+	"validate":  validate,
+	"get":       get,
+	"diff":      diff,
+	"to-json":   toJSON,
+	"from-json": fromJSON,
+	"repl":      repl,
+}
+
+// errUsage is returned for invalid arguments.
+var errUsage = errors.New("invalid arguments")
+
+// errFailed is returned if the command failed after reporting why.
+var errFailed = errors.New("failed")
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch err := commands[os.Args[1]](os.Args[2:]); {
+	case err == nil:
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	case errors.Is(err, errFailed):
+		os.Exit(1)
+	default:
+		fmt.Fprintf(os.Stderr, "edn %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// input is the contents of a file named by the arguments, or of
+// standard input.
+type input struct {
+	path string
+	data []byte
+}
+
+func readInputs(paths []string) ([]input, error) {
+	if len(paths) == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		return []input{{"<stdin>", data}}, err
+	}
+
+	inputs := make([]input, len(paths))
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		inputs[i] = input{path, data}
+	}
+	return inputs, nil
+}
+
+// values returns the values of in, with the position of syntax errors
+// in the error.
+func (in input) values() ([]interface{}, error) {
+	var vals []interface{}
+	d := edn.NewDecoder(bytes.NewReader(in.data))
+	for {
+		val, err := d.Decode()
+		if err == io.EOF {
+			return vals, nil
+		} else if err != nil {
+			var serr *edn.SyntaxError
+			if errors.As(err, &serr) {
+				line, col := position(in.data, int(serr.Offset))
+				return nil, fmt.Errorf("%s:%d:%d: %v", in.path, line, col, err)
+			}
+			return nil, fmt.Errorf("%s: %v", in.path, err)
+		}
+		vals = append(vals, val)
+	}
+}
+
+// position returns the line and column of offset in data, counted from
+// 1.
+func position(data []byte, offset int) (line, col int) {
+	offset = min(offset, len(data))
+	line = bytes.Count(data[:offset], []byte("\n")) + 1
+	col = offset - bytes.LastIndexByte(data[:offset], '\n')
+	return line, col
+}
+
+func readValues(paths []string) ([]interface{}, error) {
+	inputs, err := readInputs(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var vals []interface{}
+	for _, in := range inputs {
+		v, err := in.values()
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v...)
+	}
+	return vals, nil
+}
+
+func format(args []string) error {
+	var list, write bool
+	if len(args) > 0 && (args[0] == "-l" || args[0] == "-w") {
+		list, write = args[0] == "-l", args[0] == "-w"
+		args = args[1:]
+	}
+	if (list || write) && len(args) == 0 {
+		return errUsage
+	}
+
+	inputs, err := readInputs(args)
+	if err != nil {
+		return err
+	}
+
+	for _, in := range inputs {
+		dir := "."
+		if len(args) > 0 {
+			dir = filepath.Dir(in.path)
+		}
+		style, err := edn.FindStyle(dir)
+		if err != nil {
+			return err
+		}
+
+		formatted, err := style.Format(in.data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", in.path, err)
+		}
+
+		switch {
+		case list:
+			if !bytes.Equal(in.data, formatted) {
+				fmt.Println(in.path)
+			}
+		case write:
+			if bytes.Equal(in.data, formatted) {
+				continue
+			}
+
+			info, err := os.Stat(in.path)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(in.path, formatted, info.Mode().Perm()); err != nil {
+				return err
+			}
+		default:
+			if _, err := os.Stdout.Write(formatted); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validate(args []string) error {
+	inputs, err := readInputs(args)
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, in := range inputs {
+		if _, err := in.values(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+func get(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	val, err := edn.DecodeString(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid path %s: %v", args[0], err)
+	}
+	path, ok := val.([]interface{})
+	if !ok {
+		return fmt.Errorf("path must be a vector, got %s", args[0])
+	}
+
+	vals, err := readValues(args[1:])
+	if err != nil {
+		return err
+	}
+
+	style, err := edn.FindStyle(".")
+	if err != nil {
+		return err
+	}
+
+	for _, val := range vals {
+		if v, ok := edn.GetIn(val, path...); ok {
+			fmt.Println(style.Pretty(v))
+		}
+	}
+	return nil
+}
+
+func diff(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	var sides [2]interface{}
+	for i, path := range args {
+		vals, err := readValues([]string{path})
+		if err != nil {
+			return err
+		}
+
+		// files with a single value are compared by that value
+		sides[i] = vals
+		if len(vals) == 1 {
+			sides[i] = vals[0]
+		}
+	}
+
+	style, err := edn.FindStyle(".")
+	if err != nil {
+		return err
+	}
+
+	if d := edn.Unified(sides[0], sides[1], style.Width); d != "" {
+		fmt.Print(d)
+		return errFailed
+	}
+	return nil
+}
+
+func toJSON(args []string) error {
+	vals, err := readValues(args)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, val := range vals {
+		j, err := ednjson.ToJSON(val)
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fromJSON(args []string) error {
+	inputs, err := readInputs(args)
+	if err != nil {
+		return err
+	}
+
+	style, err := edn.FindStyle(".")
+	if err != nil {
+		return err
+	}
+
+	for _, in := range inputs {
+		dec := json.NewDecoder(bytes.NewReader(in.data))
+		dec.UseNumber()
+		for {
+			var j interface{}
+			if err := dec.Decode(&j); err == io.EOF {
+				break
+			} else if err != nil {
+				return fmt.Errorf("%s: %v", in.path, err)
+			}
+
+			val, err := ednjson.FromJSON(j)
+			if err != nil {
+				return fmt.Errorf("%s: %v", in.path, err)
+			}
+			fmt.Println(style.Pretty(edn.KeywordizeKeys(val)))
+		}
+	}
+	return nil
+}
+
+func repl(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	style, err := edn.FindStyle(".")
+	if err != nil {
+		return err
+	}
+	style.Color = isTerminal(os.Stdout)
+	prompt := isTerminal(os.Stdin)
+
+	in := bufio.NewReader(os.Stdin)
+	d := edn.NewDecoder(in)
+	for {
+		if prompt {
+			fmt.Print("edn> ")
+		}
+
+		val, err := d.Decode()
+		if err == io.EOF {
+			if prompt {
+				fmt.Println()
+			}
+			return nil
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+
+			// start over on the next line
+			if _, err := in.ReadString('\n'); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			continue
+		}
+
+		fmt.Println(style.Pretty(val))
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
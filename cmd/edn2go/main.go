@@ -0,0 +1,85 @@
+// Command edn2go writes Go struct definitions for EDN documents.
+//
+// Usage:
+//
+//	edn2go -pkg users -type User -o user.go samples.edn...
+//	edn2go -schema -type User user-schema.edn
+//
+// The structs are inferred from the sample documents, or read from a
+// schema in the form written by ednschema if -schema is given.  See
+// package github.com/heyLu/edn/edn2go for the generated types.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/heyLu/edn"
+	"github.com/heyLu/edn/edn2go"
+	"github.com/heyLu/edn/ednschema"
+)
+
+func main() {
+	pkg := flag.String("pkg", "main", "package name of the generated file")
+	typ := flag.String("type", "T", "name of the generated struct")
+	schema := flag.Bool("schema", false, "read a schema instead of sample documents")
+	out := flag.String("o", "", "output file (default standard output)")
+	flag.Parse()
+
+	if flag.NArg() == 0 || (*schema && flag.NArg() != 1) {
+		fmt.Fprintln(os.Stderr, "usage: edn2go [-pkg name] [-type name] [-o file] file.edn...")
+		fmt.Fprintln(os.Stderr, "       edn2go -schema [-pkg name] [-type name] [-o file] schema.edn")
+		os.Exit(2)
+	}
+
+	var samples []interface{}
+	for _, path := range flag.Args() {
+		vals, err := readFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "edn2go: %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		samples = append(samples, vals...)
+	}
+
+	var s *ednschema.Schema
+	if *schema {
+		if len(samples) != 1 {
+			fmt.Fprintf(os.Stderr, "edn2go: %s: expected a single schema\n", flag.Arg(0))
+			os.Exit(1)
+		}
+
+		var err error
+		s, err = ednschema.Parse(samples[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "edn2go: %s: %v\n", flag.Arg(0), err)
+			os.Exit(1)
+		}
+	} else {
+		s = ednschema.Infer(samples...)
+	}
+
+	src, err := edn2go.Generate(*pkg, *typ, s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "edn2go: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(src)
+	} else if err := os.WriteFile(*out, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "edn2go: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func readFile(path string) ([]interface{}, error) {
+	r, err := edn.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return edn.ReadAllValues(r)
+}
@@ -0,0 +1,81 @@
+// Command ednfmt formats EDN files in the style of their project.
+//
+// Usage:
+//
+//	ednfmt [-l | -w] file.edn...
+//
+// The style is read from the closest .ednfmt.edn file in the directory
+// of each file or its parents, see edn.FindStyle.  Without flags, the
+// formatted files are written to standard output.  With -l, the files
+// that are not formatted are listed, with -w, they are rewritten.  See
+// edn.Style.Format for which forms are laid out.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/heyLu/edn"
+)
+
+func main() {
+	list := flag.Bool("l", false, "list files that are not formatted")
+	write := flag.Bool("w", false, "write the formatted files instead of printing them")
+	flag.Parse()
+
+	if flag.NArg() == 0 || (*list && *write) {
+		fmt.Fprintln(os.Stderr, "usage: ednfmt [-l | -w] file.edn...")
+		os.Exit(2)
+	}
+
+	status := 0
+	for _, path := range flag.Args() {
+		if err := format(path, *list, *write); err != nil {
+			fmt.Fprintf(os.Stderr, "ednfmt: %s: %v\n", path, err)
+			status = 1
+		}
+	}
+
+	os.Exit(status)
+}
+
+func format(path string, list, write bool) error {
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	style, err := edn.FindStyle(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+
+	formatted, err := style.Format(doc)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case list:
+		if !bytes.Equal(doc, formatted) {
+			fmt.Println(path)
+		}
+		return nil
+	case write:
+		if bytes.Equal(doc, formatted) {
+			return nil
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, formatted, info.Mode().Perm())
+	default:
+		_, err := os.Stdout.Write(formatted)
+		return err
+	}
+}
@@ -0,0 +1,61 @@
+// Command ednkw writes Go variables for the keywords used in EDN files.
+//
+// Usage:
+//
+//	ednkw -pkg kw -o keywords.go samples.edn...
+//
+// See package github.com/heyLu/edn/ednkw for the generated names.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/heyLu/edn"
+	"github.com/heyLu/edn/ednkw"
+)
+
+func main() {
+	pkg := flag.String("pkg", "main", "package name of the generated file")
+	out := flag.String("o", "", "output file (default standard output)")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: ednkw [-pkg name] [-o file] file.edn...")
+		os.Exit(2)
+	}
+
+	var samples []interface{}
+	for _, path := range flag.Args() {
+		vals, err := readFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ednkw: %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		samples = append(samples, vals...)
+	}
+
+	src, err := ednkw.Generate(*pkg, ednkw.Keywords(samples...))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ednkw: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(src)
+	} else if err := os.WriteFile(*out, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "ednkw: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func readFile(path string) ([]interface{}, error) {
+	r, err := edn.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return edn.ReadAllValues(r)
+}
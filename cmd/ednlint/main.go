@@ -0,0 +1,76 @@
+// Command ednlint checks EDN files against the rules of package
+// github.com/heyLu/edn/ednlint.
+//
+// Usage:
+//
+//	ednlint [-rules .ednlint.edn] file.edn...
+//
+// Each finding is printed as a line of the form file: path: message.
+// The exit status is 1 if there were findings, 2 for other errors.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/heyLu/edn"
+	"github.com/heyLu/edn/ednlint"
+)
+
+func main() {
+	rulesPath := flag.String("rules", ".ednlint.edn", "file containing the rules")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: ednlint [-rules file] file.edn...")
+		os.Exit(2)
+	}
+
+	rules, err := readRules(*rulesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ednlint: %s: %v\n", *rulesPath, err)
+		os.Exit(2)
+	}
+
+	status := 0
+	for _, path := range flag.Args() {
+		vals, err := readFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ednlint: %s: %v\n", path, err)
+			os.Exit(2)
+		}
+
+		for _, val := range vals {
+			for _, f := range rules.Lint(val) {
+				fmt.Printf("%s: %s\n", path, f)
+				status = 1
+			}
+		}
+	}
+
+	os.Exit(status)
+}
+
+func readRules(path string) (*ednlint.Rules, error) {
+	vals, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vals) != 1 {
+		return nil, fmt.Errorf("expected a single map of rules, but found %d values", len(vals))
+	}
+
+	return ednlint.Parse(vals[0])
+}
+
+func readFile(path string) ([]interface{}, error) {
+	r, err := edn.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return edn.ReadAllValues(r)
+}
@@ -0,0 +1,108 @@
+// Command ednmerge merges EDN files, e.g. a base configuration and the
+// overlays for a deployment, and writes the result to standard output.
+//
+// Usage:
+//
+//	ednmerge [-s strategy] [-r path=strategy]... file.edn...
+//
+// Later files take precedence.  The strategy is one of deep-merge, the
+// default, last-wins, replace and append, see edn.MergeStrategy.  -r
+// sets the strategy for the value at a path, an EDN vector of keys,
+// and can be given several times:
+//
+//	ednmerge -s last-wins -r '[:db]=deep-merge' -r '[:hosts]=append' base.edn prod.edn
+//
+// The result is laid out with the style of the closest .ednfmt.edn in
+// the current directory or its parents, see edn.FindStyle.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/heyLu/edn"
+)
+
+var strategies = map[string]edn.MergeStrategy{
+	"deep-merge": edn.DeepMerge,
+	"last-wins":  edn.LastWins,
+	"replace":    edn.Replace,
+	"append":     edn.Append,
+}
+
+// rules are the values of -r.
+type rules []edn.MergeRule
+
+func (r *rules) String() string {
+	return fmt.Sprint(*r)
+}
+
+func (r *rules) Set(s string) error {
+	// keywords in the path may contain =, strategies don't
+	i := strings.LastIndexByte(s, '=')
+	if i < 0 {
+		return fmt.Errorf("expected path=strategy, got %s", s)
+	}
+	path, name := s[:i], s[i+1:]
+
+	val, err := edn.DecodeString(path)
+	if err != nil {
+		return fmt.Errorf("invalid path %s: %v", path, err)
+	}
+	vec, ok := val.([]interface{})
+	if !ok {
+		return fmt.Errorf("path must be a vector, got %s", path)
+	}
+
+	strategy, ok := strategies[name]
+	if !ok {
+		return fmt.Errorf("unknown strategy %s", name)
+	}
+
+	*r = append(*r, edn.MergeRule{Path: vec, Strategy: strategy})
+	return nil
+}
+
+func main() {
+	name := flag.String("s", "deep-merge", "merge `strategy`: deep-merge, last-wins, replace or append")
+	var options edn.MergeOptions
+	flag.Var((*rules)(&options.Rules), "r", "`path=strategy` for the value at path, e.g. [:hosts]=append")
+	flag.Parse()
+
+	strategy, ok := strategies[*name]
+	if flag.NArg() == 0 || !ok {
+		fmt.Fprintln(os.Stderr, "usage: ednmerge [-s strategy] [-r path=strategy]... file.edn...")
+		os.Exit(2)
+	}
+	options.Strategy = strategy
+
+	if err := merge(options, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "ednmerge: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func merge(options edn.MergeOptions, paths []string) error {
+	vals := make([]interface{}, len(paths))
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		vals[i], err = edn.DecodeBytes(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	style, err := edn.FindStyle(".")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(style.Pretty(options.Merge(vals...)))
+	return nil
+}
@@ -0,0 +1,135 @@
+// Command ednq selects, filters and projects the values of EDN
+// streams, such as log or database exports, like jq does for JSON.
+//
+// Usage:
+//
+//	ednq [-c | -json] query [file.edn...]
+//
+// The query is a sequence of EDN forms, each of which turns every
+// value it is given into zero or more values for the next one:
+//
+//	:key or [:key 0 *]  the value at a path, see edn.GetIn, with *
+//	                    selecting all elements of vectors, lists and
+//	                    sets and all values of maps
+//	(= path value)      the value if one of the values at path is equal
+//	                    to value, also not=, <, <=, > and >= for
+//	                    numbers, strings and instants
+//	(exists path)       the value if there is a value at path
+//	{:k path ...}       a map of the keys to the values at the paths, a
+//	                    vector of them if there are several
+//	#{:k ...}           the entries of a map for the keys
+//
+// For example, the names of the active users in an export of users:
+//
+//	ednq '[:users *] (= :status :active) {:name [:user/name]}' export.edn
+//
+// The values of the files, or of standard input without files, are
+// read one by one, so they may be larger than memory as a whole.  The
+// results are laid out with the style of the closest .ednfmt.edn in
+// the current directory or its parents, see edn.FindStyle, or on one
+// line each with -c.  With -json, they are written as lines of JSON,
+// see package ednjson.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/heyLu/edn"
+	"github.com/heyLu/edn/ednjson"
+)
+
+func main() {
+	compact := flag.Bool("c", false, "write each result on one line")
+	asJSON := flag.Bool("json", false, "write the results as lines of JSON")
+	flag.Parse()
+
+	if flag.NArg() == 0 || (*compact && *asJSON) {
+		fmt.Fprintln(os.Stderr, "usage: ednq [-c | -json] query [file.edn...]")
+		os.Exit(2)
+	}
+
+	q, err := parseQuery(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ednq: invalid query: %v\n", err)
+		os.Exit(2)
+	}
+
+	style, err := edn.FindStyle(".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ednq: %v\n", err)
+		os.Exit(1)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	write := func(v interface{}) error {
+		switch {
+		case *asJSON:
+			j, err := ednjson.ToJSON(v)
+			if err != nil {
+				return err
+			}
+			return json.NewEncoder(out).Encode(j)
+		case *compact:
+			_, err := fmt.Fprintln(out, edn.String(v))
+			return err
+		default:
+			_, err := fmt.Fprintln(out, style.Pretty(v))
+			return err
+		}
+	}
+
+	status := 0
+	paths := flag.Args()[1:]
+	if len(paths) == 0 {
+		if err := run(q, os.Stdin, write); err != nil {
+			fmt.Fprintf(os.Stderr, "ednq: %v\n", err)
+			status = 1
+		}
+	}
+	for _, path := range paths {
+		if err := runFile(q, path, write); err != nil {
+			fmt.Fprintf(os.Stderr, "ednq: %s: %v\n", path, err)
+			status = 1
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "ednq: %v\n", err)
+		status = 1
+	}
+	os.Exit(status)
+}
+
+func runFile(q query, path string, write func(interface{}) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return run(q, f, write)
+}
+
+// run writes the results of q for the values read from r.
+func run(q query, r io.Reader, write func(interface{}) error) error {
+	d := edn.NewDecoder(bufio.NewReader(r))
+	for {
+		val, err := d.Decode()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		for _, res := range q.run(val) {
+			if err := write(res); err != nil {
+				return err
+			}
+		}
+	}
+}
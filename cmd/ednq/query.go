@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+// wildcard is the path element selecting all elements of a collection.
+var wildcard = edn.Symbol{Namespace: "", Name: "*"}
+
+// stage turns a value into the values passed on to the next stage.
+type stage func(v interface{}) []interface{}
+
+// query is a pipeline of stages.
+type query []stage
+
+func parseQuery(q string) (query, error) {
+	var stages query
+	d := edn.NewDecoder(strings.NewReader(q))
+	for {
+		form, err := d.Decode()
+		if err == io.EOF {
+			return stages, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		s, err := parseStage(form)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", edn.String(form), err)
+		}
+		stages = append(stages, s)
+	}
+}
+
+// run returns the values the query selects from v.
+func (q query) run(v interface{}) []interface{} {
+	vals := []interface{}{v}
+	for _, s := range q {
+		var next []interface{}
+		for _, val := range vals {
+			next = append(next, s(val)...)
+		}
+		vals = next
+	}
+	return vals
+}
+
+func parseStage(form interface{}) (stage, error) {
+	switch form := form.(type) {
+	case edn.Keyword:
+		return pathStage([]interface{}{form}), nil
+	case []interface{}:
+		if len(form) > 0 {
+			if op, ok := form[0].(edn.Symbol); ok && op != wildcard {
+				return predicateStage(op, form[1:])
+			}
+		}
+		return pathStage(form), nil
+	case map[interface{}]interface{}:
+		paths := make(map[interface{}][]interface{}, len(form))
+		for key, p := range form {
+			path, ok := pathOf(p)
+			if !ok {
+				return nil, fmt.Errorf("projection of %s must be a path", edn.String(key))
+			}
+			paths[key] = path
+		}
+		return projectionStage(paths), nil
+	case map[interface{}]bool:
+		keys := make([]interface{}, 0, len(form))
+		for key := range form {
+			keys = append(keys, key)
+		}
+		return func(v interface{}) []interface{} {
+			m, ok := v.(map[interface{}]interface{})
+			if !ok {
+				return nil
+			}
+			return []interface{}{edn.SelectKeys(m, keys...)}
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown stage")
+	}
+}
+
+// pathOf returns the path of a vector or keyword.
+func pathOf(v interface{}) ([]interface{}, bool) {
+	switch v := v.(type) {
+	case edn.Keyword:
+		return []interface{}{v}, true
+	case []interface{}:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+func pathStage(path []interface{}) stage {
+	return func(v interface{}) []interface{} {
+		return selectPath(v, path)
+	}
+}
+
+// selectPath returns the values at path in v, which are several if it
+// contains wildcards.
+func selectPath(v interface{}, path []interface{}) []interface{} {
+	if len(path) == 0 {
+		return []interface{}{v}
+	}
+
+	if path[0] == wildcard {
+		var vals []interface{}
+		for _, elem := range elements(v) {
+			vals = append(vals, selectPath(elem, path[1:])...)
+		}
+		return vals
+	}
+
+	next, ok := edn.GetIn(v, path[0])
+	if !ok {
+		return nil
+	}
+	return selectPath(next, path[1:])
+}
+
+// elements returns the elements of vectors, lists and sets and the
+// values of maps, sorted by their String representation for sets and
+// by their key for maps.
+func elements(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return v
+	case map[interface{}]bool:
+		elems := make([]interface{}, 0, len(v))
+		for elem := range v {
+			elems = append(elems, elem)
+		}
+		sort.Slice(elems, func(i, j int) bool { return edn.String(elems[i]) < edn.String(elems[j]) })
+		return elems
+	case map[interface{}]interface{}:
+		keys := make([]interface{}, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return edn.String(keys[i]) < edn.String(keys[j]) })
+
+		vals := make([]interface{}, len(keys))
+		for i, key := range keys {
+			vals[i] = v[key]
+		}
+		return vals
+	default:
+		return nil
+	}
+}
+
+// projectionStage returns maps of the keys of paths to the values at
+// the paths, a vector of them if there are several.
+func projectionStage(paths map[interface{}][]interface{}) stage {
+	return func(v interface{}) []interface{} {
+		m := make(map[interface{}]interface{}, len(paths))
+		for key, path := range paths {
+			switch vals := selectPath(v, path); len(vals) {
+			case 0:
+			case 1:
+				m[key] = vals[0]
+			default:
+				m[key] = vals
+			}
+		}
+		return []interface{}{m}
+	}
+}
+
+// predicates compare the values at the path of a predicate with its
+// argument.
+var predicates = map[string]func(a, b interface{}) bool{
+	"=":    edn.Equal,
+	"not=": func(a, b interface{}) bool { return !edn.Equal(a, b) },
+	"<":    func(a, b interface{}) bool { c, ok := compare(a, b); return ok && c < 0 },
+	"<=":   func(a, b interface{}) bool { c, ok := compare(a, b); return ok && c <= 0 },
+	">":    func(a, b interface{}) bool { c, ok := compare(a, b); return ok && c > 0 },
+	">=":   func(a, b interface{}) bool { c, ok := compare(a, b); return ok && c >= 0 },
+}
+
+// predicateStage returns a stage passing on the values for which one of
+// the values at the path satisfies the predicate op.
+func predicateStage(op edn.Symbol, args []interface{}) (stage, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing path")
+	}
+	path, ok := pathOf(args[0])
+	if !ok {
+		return nil, fmt.Errorf("%s is not a path", edn.String(args[0]))
+	}
+
+	var test func(v interface{}) bool
+	if op.String() == "exists" {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("exists takes a path")
+		}
+		test = func(interface{}) bool { return true }
+	} else {
+		pred, ok := predicates[op.String()]
+		if !ok {
+			return nil, fmt.Errorf("unknown operator %s", op)
+		} else if len(args) != 2 {
+			return nil, fmt.Errorf("%s takes a path and a value", op)
+		}
+		test = func(v interface{}) bool { return pred(v, args[1]) }
+	}
+
+	return func(v interface{}) []interface{} {
+		for _, val := range selectPath(v, path) {
+			if test(val) {
+				return []interface{}{v}
+			}
+		}
+		return nil
+	}, nil
+}
+
+// compare compares numbers, strings and instants.  ok is false for
+// other values and values of different kinds.
+func compare(a, b interface{}) (c int, ok bool) {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		if !ok {
+			return 0, false
+		}
+		return x.Cmp(y), true
+	}
+
+	switch a := a.(type) {
+	case string:
+		b, ok := b.(string)
+		return strings.Compare(a, b), ok
+	case time.Time:
+		b, ok := b.(time.Time)
+		return a.Compare(b), ok
+	default:
+		return 0, false
+	}
+}
+
+func number(v interface{}) (*big.Float, bool) {
+	switch v := v.(type) {
+	case int64:
+		return new(big.Float).SetInt64(v), true
+	case float64:
+		if v != v {
+			// NaN
+			return nil, false
+		}
+		return big.NewFloat(v), true
+	case *big.Int:
+		if v == nil {
+			return nil, false
+		}
+		return new(big.Float).SetInt(v), true
+	case *big.Rat:
+		if v == nil {
+			return nil, false
+		}
+		return new(big.Float).SetRat(v), true
+	default:
+		return nil, false
+	}
+}
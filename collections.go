@@ -0,0 +1,153 @@
+package edn
+
+import (
+	"fmt"
+)
+
+// GetIn returns the value at path in the decoded value v, like
+// clojure.core/get-in.  The elements of path are map keys, compared
+// with Equal, and indices of vectors and lists as int or int64.
+func GetIn(v interface{}, path ...interface{}) (interface{}, bool) {
+	for _, key := range path {
+		switch coll := v.(type) {
+		case map[interface{}]interface{}:
+			val, ok := MapGet(coll, key)
+			if !ok {
+				return nil, false
+			}
+			v = val
+		case []interface{}:
+			i, ok := index(key)
+			if !ok || i < 0 || i >= len(coll) {
+				return nil, false
+			}
+			v = coll[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+// AssocIn returns a copy of v with the value at path set to val, like
+// clojure.core/assoc-in.  Missing maps along the path are created, an
+// index of a vector may be its length to append val.  Only the maps
+// and vectors along the path are copied, v itself is not modified.
+func AssocIn(v interface{}, path []interface{}, val interface{}) (interface{}, error) {
+	return UpdateIn(v, path, func(interface{}) interface{} { return val })
+}
+
+// UpdateIn returns a copy of v with the value at path replaced by the
+// result of fn, like clojure.core/update-in.  fn is called with nil if
+// there is no value at path.  See AssocIn for how v is copied.
+func UpdateIn(v interface{}, path []interface{}, fn func(interface{}) interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return fn(v), nil
+	}
+
+	key := path[0]
+	switch coll := v.(type) {
+	case nil:
+		val, err := UpdateIn(nil, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		return map[interface{}]interface{}{KeyOf(key): val}, nil
+	case map[interface{}]interface{}:
+		k := mapKey(coll, key)
+		val, err := UpdateIn(coll[k], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+
+		res := make(map[interface{}]interface{}, len(coll)+1)
+		for k, v := range coll {
+			res[k] = v
+		}
+		res[k] = val
+		return res, nil
+	case []interface{}:
+		i, ok := index(key)
+		if !ok || i < 0 || i > len(coll) {
+			return nil, fmt.Errorf("index %s out of bounds for vector of length %d", String(key), len(coll))
+		}
+
+		var old interface{}
+		if i < len(coll) {
+			old = coll[i]
+		}
+		val, err := UpdateIn(old, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+
+		res := make([]interface{}, len(coll), len(coll)+1)
+		copy(res, coll)
+		if i == len(coll) {
+			return append(res, val), nil
+		}
+		res[i] = val
+		return res, nil
+	default:
+		return nil, fmt.Errorf("can't update %s in %s", String(key), String(v))
+	}
+}
+
+// Dissoc returns a copy of the decoded map m without the keys, like
+// clojure.core/dissoc.  Keys are compared with Equal.
+func Dissoc(m map[interface{}]interface{}, keys ...interface{}) map[interface{}]interface{} {
+	res := make(map[interface{}]interface{}, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+
+	for _, key := range keys {
+		delete(res, mapKey(res, key))
+	}
+
+	return res
+}
+
+// SelectKeys returns a map with only the entries of m for the keys,
+// like clojure.core/select-keys.  Keys are compared with Equal.
+func SelectKeys(m map[interface{}]interface{}, keys ...interface{}) map[interface{}]interface{} {
+	res := make(map[interface{}]interface{}, len(keys))
+	for _, key := range keys {
+		k := mapKey(m, key)
+		if val, ok := m[k]; ok {
+			res[k] = val
+		}
+	}
+
+	return res
+}
+
+// mapKey returns the key for key in m: an existing key equal to it, or
+// the key it would have in decoded maps.
+func mapKey(m map[interface{}]interface{}, key interface{}) interface{} {
+	k := KeyOf(key)
+	if _, ok := m[k]; ok {
+		return k
+	}
+
+	for existing := range m {
+		if Equal(existing, key) {
+			return existing
+		}
+	}
+
+	return k
+}
+
+// index returns key as the index of a vector.
+func index(key interface{}) (int, bool) {
+	switch i := key.(type) {
+	case int:
+		return i, true
+	case int64:
+		return int(i), int64(int(i)) == i
+	default:
+		return 0, false
+	}
+}
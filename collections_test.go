@@ -0,0 +1,107 @@
+package edn
+
+import (
+	"testing"
+)
+
+func mustDecode(t *testing.T, s string) interface{} {
+	t.Helper()
+	val, err := DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return val
+}
+
+func TestGetIn(t *testing.T) {
+	v := mustDecode(t, `{:a {:b [1 {:c "found"}]} [1 2] :vec 3N :big}`)
+
+	tests := []struct {
+		path     []interface{}
+		expected interface{}
+		ok       bool
+	}{
+		{[]interface{}{Keyword{"", "a"}, Keyword{"", "b"}, 1, Keyword{"", "c"}}, "found", true},
+		{[]interface{}{Keyword{"", "a"}, Keyword{"", "b"}, int64(0)}, int64(1), true},
+		{[]interface{}{[]interface{}{int64(1), int64(2)}}, Keyword{"", "vec"}, true},
+		{[]interface{}{int64(3)}, Keyword{"", "big"}, true},
+		{[]interface{}{Keyword{"", "a"}, Keyword{"", "b"}, 2}, nil, false},
+		{[]interface{}{Keyword{"", "a"}, Keyword{"", "x"}}, nil, false},
+		{[]interface{}{Keyword{"", "a"}, Keyword{"", "b"}, 0, 0}, nil, false},
+	}
+
+	for _, test := range tests {
+		val, ok := GetIn(v, test.path...)
+		if ok != test.ok || !Equal(val, test.expected) {
+			t.Errorf("GetIn(%s) = %s, %v, expected %s, %v", String(test.path), String(val), ok, String(test.expected), test.ok)
+		}
+	}
+
+	if val, ok := GetIn(v); !ok || !Equal(val, v) {
+		t.Errorf("GetIn without path should return the value itself")
+	}
+}
+
+func TestAssocUpdateIn(t *testing.T) {
+	src := `{:a {:b [1 2]} 3N :big}`
+	v := mustDecode(t, src)
+
+	res, err := AssocIn(v, []interface{}{Keyword{"", "a"}, Keyword{"", "b"}, 2}, int64(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(res, mustDecode(t, `{:a {:b [1 2 3]} 3N :big}`)) {
+		t.Errorf("unexpected result %s", String(res))
+	}
+
+	res, err = AssocIn(res, []interface{}{Keyword{"", "x"}, "y"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(res, mustDecode(t, `{:a {:b [1 2 3]} 3N :big :x {"y" true}}`)) {
+		t.Errorf("unexpected result %s", String(res))
+	}
+
+	res, err = UpdateIn(v, []interface{}{int64(3)}, func(old interface{}) interface{} {
+		return []interface{}{old}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := res.(map[interface{}]interface{}); len(m) != 2 || !Equal(res, mustDecode(t, `{:a {:b [1 2]} 3 [:big]}`)) {
+		t.Errorf("unexpected result %s", String(res))
+	}
+
+	if !Equal(v, mustDecode(t, src)) {
+		t.Errorf("original value was modified: %s", String(v))
+	}
+
+	for _, path := range [][]interface{}{
+		{Keyword{"", "a"}, Keyword{"", "b"}, 5},
+		{Keyword{"", "a"}, Keyword{"", "b"}, Keyword{"", "c"}},
+		{Keyword{"", "a"}, Keyword{"", "b"}, 0, Keyword{"", "c"}},
+	} {
+		if _, err := AssocIn(v, path, nil); err == nil {
+			t.Errorf("expected error for path %s", String(path))
+		}
+	}
+}
+
+func TestDissocSelectKeys(t *testing.T) {
+	src := `{:a 1 :b 2 [1 2] 3 4N 5}`
+	m := mustDecode(t, src).(map[interface{}]interface{})
+
+	res := Dissoc(m, Keyword{"", "a"}, []interface{}{int64(1), int64(2)}, int64(4), Keyword{"", "missing"})
+	if !Equal(res, mustDecode(t, `{:b 2}`)) {
+		t.Errorf("unexpected result %s", String(res))
+	}
+
+	res = SelectKeys(m, Keyword{"", "a"}, []interface{}{int64(1), int64(2)}, Keyword{"", "missing"})
+	if !Equal(res, mustDecode(t, `{:a 1 [1 2] 3}`)) {
+		t.Errorf("unexpected result %s", String(res))
+	}
+
+	if !Equal(m, mustDecode(t, src)) {
+		t.Errorf("original map was modified: %s", String(m))
+	}
+}
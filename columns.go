@@ -0,0 +1,152 @@
+package edn
+
+import (
+	"fmt"
+)
+
+// Column is a column of a table of records, see Columns.
+type Column struct {
+	// Key is the map key of the column, e.g. :user/id, or the index
+	// for records that are tuples.
+	Key interface{}
+
+	// Type is the type of the values that are not nil, as in
+	// Report.Types, "mixed" if they have different types or "nil" if
+	// all values are nil.
+	Type string
+
+	// Values contains the value of each record, nil for records without
+	// an entry for Key.
+	Values []interface{}
+}
+
+// Columns converts records to a columnar representation, e.g. for
+// exporting query results to analytics tools.
+//
+// records must be a vector of maps, such as [{:id 1 :name "a"} ...], or
+// a vector of tuples of the same length, such as [[1 "a"] ...].  The
+// columns of maps are sorted by the String representation of their
+// keys.
+func Columns(records interface{}) ([]Column, error) {
+	rows, ok := records.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("records must be a vector, but was %s", typeName(records))
+	}
+
+	if len(rows) == 0 {
+		return []Column{}, nil
+	}
+
+	var columns []Column
+	switch first := rows[0].(type) {
+	case map[interface{}]interface{}:
+		index := map[interface{}]int{}
+		var keys []interface{}
+		for i, row := range rows {
+			m, ok := row.(map[interface{}]interface{})
+			if !ok {
+				return nil, fmt.Errorf("record %d must be a map, but was %s", i, typeName(row))
+			}
+
+			for key := range m {
+				if _, ok := index[key]; !ok {
+					index[key] = 0
+					keys = append(keys, key)
+				}
+			}
+		}
+		sortByString(keys)
+
+		columns = make([]Column, len(keys))
+		for i, key := range keys {
+			index[key] = i
+			columns[i] = Column{Key: key, Values: make([]interface{}, len(rows))}
+		}
+
+		for i, row := range rows {
+			for key, val := range row.(map[interface{}]interface{}) {
+				columns[index[key]].Values[i] = val
+			}
+		}
+	case []interface{}:
+		columns = make([]Column, len(first))
+		for j := range columns {
+			columns[j] = Column{Key: j, Values: make([]interface{}, len(rows))}
+		}
+
+		for i, row := range rows {
+			tuple, ok := row.([]interface{})
+			if !ok || len(tuple) != len(first) {
+				return nil, fmt.Errorf("record %d must be a vector of length %d, but was %s", i, len(first), String(row))
+			}
+
+			for j, val := range tuple {
+				columns[j].Values[i] = val
+			}
+		}
+	default:
+		return nil, fmt.Errorf("records must be maps or vectors, but were %s", typeName(first))
+	}
+
+	for i := range columns {
+		columns[i].Type = columnType(columns[i].Values)
+	}
+
+	return columns, nil
+}
+
+func columnType(values []interface{}) string {
+	typ := "nil"
+	for _, val := range values {
+		if val == nil {
+			continue
+		}
+
+		if t := typeName(val); typ == "nil" {
+			typ = t
+		} else if t != typ {
+			return "mixed"
+		}
+	}
+
+	return typ
+}
+
+// Int64s returns the values of an integer column.  ok is false if the
+// column contains nil or values that are not int64.
+func (c Column) Int64s() (vals []int64, ok bool) {
+	vals = make([]int64, len(c.Values))
+	for i, val := range c.Values {
+		if vals[i], ok = val.(int64); !ok {
+			return nil, false
+		}
+	}
+
+	return vals, true
+}
+
+// Float64s returns the values of a float column.  ok is false if the
+// column contains nil or values that are not float64.
+func (c Column) Float64s() (vals []float64, ok bool) {
+	vals = make([]float64, len(c.Values))
+	for i, val := range c.Values {
+		if vals[i], ok = val.(float64); !ok {
+			return nil, false
+		}
+	}
+
+	return vals, true
+}
+
+// Strings returns the values of a string column.  ok is false if the
+// column contains nil or values that are not strings.
+func (c Column) Strings() (vals []string, ok bool) {
+	vals = make([]string, len(c.Values))
+	for i, val := range c.Values {
+		if vals[i], ok = val.(string); !ok {
+			return nil, false
+		}
+	}
+
+	return vals, true
+}
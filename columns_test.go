@@ -0,0 +1,62 @@
+package edn
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestColumns(t *testing.T) {
+	records, err := DecodeString(`[{:id 1 :name "a" :score 1.5} {:id 2 :name "b" :tags #{:x}} {:id 3 :name "c" :score 2}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns, err := Columns(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Column{
+		{Keyword{"", "id"}, "integer", []interface{}{int64(1), int64(2), int64(3)}},
+		{Keyword{"", "name"}, "string", []interface{}{"a", "b", "c"}},
+		{Keyword{"", "score"}, "mixed", []interface{}{1.5, nil, int64(2)}},
+		{Keyword{"", "tags"}, "set", []interface{}{nil, map[interface{}]bool{Keyword{"", "x"}: true}, nil}},
+	}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("expected %#v, got %#v", expected, columns)
+	}
+
+	if ids, ok := columns[0].Int64s(); !ok || !reflect.DeepEqual(ids, []int64{1, 2, 3}) {
+		t.Errorf("unexpected ids %v", ids)
+	}
+	if names, ok := columns[1].Strings(); !ok || !reflect.DeepEqual(names, []string{"a", "b", "c"}) {
+		t.Errorf("unexpected names %v", names)
+	}
+	if _, ok := columns[2].Float64s(); ok {
+		t.Error("score column should not be float64s")
+	}
+
+	tuples, _ := DecodeString(`[[1 "a"] [2 nil]]`)
+	columns, err = Columns(tuples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []Column{
+		{0, "integer", []interface{}{int64(1), int64(2)}},
+		{1, "string", []interface{}{"a", nil}},
+	}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("expected %#v, got %#v", expected, columns)
+	}
+
+	for _, s := range []string{`{:a 1}`, `[1 2]`, `[{:a 1} [1]]`, `[[1 2] [1]]`, `[[1] {:a 1}]`} {
+		v, _ := DecodeString(s)
+		if _, err := Columns(v); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+
+	if columns, err := Columns([]interface{}{}); err != nil || len(columns) != 0 {
+		t.Errorf("unexpected columns %#v, %v", columns, err)
+	}
+}
@@ -0,0 +1,96 @@
+package edn
+
+import (
+	"io"
+	"strings"
+)
+
+// Comment is a line comment of a document, see Comments.
+type Comment struct {
+	// Offset is the offset of the leading semicolon in the document,
+	// Line and Column its position, both starting at 1.  Columns count
+	// bytes.
+	Offset       int
+	Line, Column int
+
+	// Text is the comment without its leading semicolons and the
+	// whitespace after them, e.g. "the port" for ";; the port".
+	Text string
+
+	// Form is the raw text of the form following the comment in the
+	// same collection, or nil if the comment is the last thing in the
+	// collection or document.  FormOffset is the offset of the form.
+	Form       []byte
+	FormOffset int
+}
+
+// Comments returns the comments of data with their positions and the
+// forms they precede, e.g. for extracting documentation from
+// configuration files:
+//
+//	{;; the port to listen on
+//	 :port 8080}
+//
+// returns a comment with the text "the port to listen on" and the form
+// :port.  All comments of a block of comment lines share the form
+// after the block.  Comments within discarded forms are returned as
+// well.
+//
+// An error is returned if data does not consist of well-formed forms,
+// see SplitTopLevel.
+func Comments(data []byte) ([]Comment, error) {
+	if _, err := SplitTopLevel(data); err != nil {
+		return nil, err
+	}
+
+	var comments []Comment
+	line, lineStart := 1, 0
+	for pos := 0; pos < len(data); {
+		switch data[pos] {
+		case '\n':
+			pos++
+			line, lineStart = line+1, pos
+		case '"':
+			// strings may span lines
+			end, _ := scanString(data, pos+1)
+			for ; pos < end; pos++ {
+				if data[pos] == '\n' {
+					line, lineStart = line+1, pos+1
+				}
+			}
+		case '\\':
+			// character literals such as \; or \"
+			pos += 2
+		case ';':
+			end := pos
+			for end < len(data) && data[end] != '\n' && data[end] != '\r' {
+				end++
+			}
+
+			c := Comment{
+				Offset: pos,
+				Line:   line,
+				Column: pos - lineStart + 1,
+				Text:   strings.TrimSpace(strings.TrimLeft(string(data[pos:end]), ";")),
+			}
+
+			next, err := skipSpace(data, end)
+			if err != io.EOF && !isClosingDelimiter(data[next]) {
+				start, formEnd, _ := scanForm(data, next)
+				c.Form = data[start:formEnd:formEnd]
+				c.FormOffset = start
+			}
+
+			comments = append(comments, c)
+			pos = end
+		default:
+			pos++
+		}
+	}
+
+	return comments, nil
+}
+
+func isClosingDelimiter(ch byte) bool {
+	return ch == ']' || ch == ')' || ch == '}'
+}
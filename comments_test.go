@@ -0,0 +1,54 @@
+package edn
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComments(t *testing.T) {
+	doc := `;; config
+{;; the port
+ ;; to listen on
+ :port 8080 ; trailing
+ :name "a ; b" #_ [1 ; discarded
+ ]}
+;; end`
+
+	comments, err := Comments([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type comment struct {
+		line, column int
+		text, form   string
+	}
+
+	expected := []comment{
+		{1, 1, "config", doc[10 : len(doc)-7]},
+		{2, 2, "the port", ":port"},
+		{3, 2, "to listen on", ":port"},
+		{4, 13, "trailing", ":name"},
+		{5, 22, "discarded", ""},
+		{7, 1, "end", ""},
+	}
+
+	got := make([]comment, len(comments))
+	for i, c := range comments {
+		got[i] = comment{c.Line, c.Column, c.Text, string(c.Form)}
+		if c.Form != nil && string(c.Form) != doc[c.FormOffset:c.FormOffset+len(c.Form)] {
+			t.Errorf("%q: form offset %d does not match form %q", c.Text, c.FormOffset, c.Form)
+		}
+		if doc[c.Offset] != ';' {
+			t.Errorf("%q: offset %d is not at a semicolon", c.Text, c.Offset)
+		}
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	if _, err := Comments([]byte("[1 ; unterminated")); err == nil {
+		t.Error("expected error for malformed input")
+	}
+}
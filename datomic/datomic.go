@@ -0,0 +1,379 @@
+// Package datomic helps with the data of Datomic databases.
+//
+// A schema is read from the attribute definitions of a schema file,
+//
+//	[{:db/ident :person/name
+//	  :db/valueType :db.type/string
+//	  :db/cardinality :db.cardinality/one}
+//	 {:db/ident :person/friends
+//	  :db/valueType :db.type/ref
+//	  :db/cardinality :db.cardinality/many}]
+//
+// and Validate reports the attributes of a transaction that are not
+// defined, or whose values don't match their definitions.  Only the
+// structure is checked: lookup refs, tempids and idents are not
+// resolved, so a transaction without violations may still fail.
+//
+// Flatten and Expand convert the nested entity maps of pull results to
+// datoms and back.
+package datomic
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+func kw(ns, name string) edn.Keyword {
+	return edn.Keyword{Namespace: ns, Name: name}
+}
+
+var (
+	dbID          = kw("db", "id")
+	dbIdent       = kw("db", "ident")
+	dbValueType   = kw("db", "valueType")
+	dbCardinality = kw("db", "cardinality")
+	dbUnique      = kw("db", "unique")
+	dbIsComponent = kw("db", "isComponent")
+	dbAdd         = kw("db", "add")
+	dbRetract     = kw("db", "retract")
+
+	cardinalityOne  = kw("db.cardinality", "one")
+	cardinalityMany = kw("db.cardinality", "many")
+)
+
+// Attribute is the definition of an attribute.
+type Attribute struct {
+	Ident edn.Keyword
+
+	// ValueType is the :db/valueType, e.g. :db.type/string.
+	ValueType edn.Keyword
+
+	// Many is set for attributes of :db.cardinality/many.
+	Many bool
+
+	// Unique is the :db/unique, e.g. :db.unique/identity, if any.
+	Unique *edn.Keyword
+
+	IsComponent bool
+}
+
+// Schema maps attribute idents to their definitions.
+type Schema map[edn.Keyword]*Attribute
+
+// ParseSchema returns the attributes defined in v, the decoded contents
+// of a schema file.  v is a vector of attribute maps, or of vectors of
+// them if the file contains several transactions.  Maps without a
+// :db/valueType, e.g. enum values, are not attributes and are skipped.
+func ParseSchema(v interface{}) (Schema, error) {
+	s := Schema{}
+	if err := s.add(v); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s Schema) add(v interface{}) error {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, elem := range v {
+			if err := s.add(elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[interface{}]interface{}:
+		attr, err := parseAttribute(v)
+		if err != nil || attr == nil {
+			return err
+		}
+		if _, ok := s[attr.Ident]; ok {
+			return fmt.Errorf("attribute %s is defined twice", attr.Ident)
+		}
+		s[attr.Ident] = attr
+		return nil
+	default:
+		return fmt.Errorf("schema must contain attribute maps, but contains %s", edn.String(v))
+	}
+}
+
+func parseAttribute(m map[interface{}]interface{}) (*Attribute, error) {
+	if _, ok := m[dbValueType]; !ok {
+		return nil, nil
+	}
+
+	ident, ok := m[dbIdent].(edn.Keyword)
+	if !ok {
+		return nil, fmt.Errorf(":db/ident must be a keyword, but was %s in %s", edn.String(m[dbIdent]), edn.String(m))
+	}
+
+	attr := &Attribute{Ident: ident}
+	if attr.ValueType, ok = m[dbValueType].(edn.Keyword); !ok {
+		return nil, fmt.Errorf("%s: :db/valueType must be a keyword, but was %s", ident, edn.String(m[dbValueType]))
+	}
+
+	switch m[dbCardinality] {
+	case cardinalityOne:
+	case cardinalityMany:
+		attr.Many = true
+	default:
+		return nil, fmt.Errorf("%s: invalid :db/cardinality %s", ident, edn.String(m[dbCardinality]))
+	}
+
+	if unique, ok := m[dbUnique]; ok {
+		kw, ok := unique.(edn.Keyword)
+		if !ok {
+			return nil, fmt.Errorf("%s: :db/unique must be a keyword, but was %s", ident, edn.String(unique))
+		}
+		attr.Unique = &kw
+	}
+
+	if isComponent, ok := m[dbIsComponent]; ok {
+		b, ok := isComponent.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s: :db/isComponent must be a boolean, but was %s", ident, edn.String(isComponent))
+		}
+		attr.IsComponent = b
+	}
+
+	return attr, nil
+}
+
+// Violation is a problem with an attribute in a transaction.
+type Violation struct {
+	// Index is the index of the statement in the transaction.
+	Index int
+
+	// Attribute is the attribute with the violation, it is the zero
+	// Keyword if the whole statement is invalid.
+	Attribute edn.Keyword
+	Message   string
+}
+
+func (v Violation) String() string {
+	if v.Attribute == (edn.Keyword{}) {
+		return fmt.Sprintf("statement %d: %s", v.Index, v.Message)
+	}
+	return fmt.Sprintf("statement %d: %s: %s", v.Index, v.Attribute, v.Message)
+}
+
+// Validate checks the transaction data tx, a vector of entity maps and
+// of [:db/add e a v] and [:db/retract e a v] statements, and returns
+// the violations found.  Other statements, e.g. transaction functions,
+// are not checked.  Attributes in the db namespaces and reverse
+// references such as :person/_friends are checked as far as the schema
+// allows.
+func (s Schema) Validate(tx interface{}) []Violation {
+	stmts, ok := tx.([]interface{})
+	if !ok {
+		return []Violation{{Index: 0, Message: fmt.Sprintf("transaction must be a vector, but was %s", edn.String(tx))}}
+	}
+
+	var vs []Violation
+	for i, stmt := range stmts {
+		vs = append(vs, s.validateStatement(i, stmt)...)
+	}
+
+	return vs
+}
+
+func (s Schema) validateStatement(i int, stmt interface{}) []Violation {
+	switch stmt := stmt.(type) {
+	case map[interface{}]interface{}:
+		return s.validateEntity(i, stmt)
+	case []interface{}:
+		if len(stmt) == 0 || (stmt[0] != dbAdd && stmt[0] != dbRetract) {
+			return nil
+		}
+		if len(stmt) != 4 {
+			return []Violation{{Index: i, Attribute: stmt[0].(edn.Keyword), Message: fmt.Sprintf("expected [%s e a v], but was %s", stmt[0], edn.String(stmt))}}
+		}
+
+		attr, ok := stmt[2].(edn.Keyword)
+		if !ok {
+			return []Violation{{Index: i, Message: fmt.Sprintf("attribute must be a keyword, but was %s", edn.String(stmt[2]))}}
+		}
+		return s.validateValue(i, attr, stmt[3], true)
+	default:
+		return []Violation{{Index: i, Message: fmt.Sprintf("statement must be a map or a vector, but was %s", edn.String(stmt))}}
+	}
+}
+
+func (s Schema) validateEntity(i int, entity map[interface{}]interface{}) []Violation {
+	var vs []Violation
+	for key, val := range entity {
+		attr, ok := key.(edn.Keyword)
+		if !ok {
+			vs = append(vs, Violation{Index: i, Message: fmt.Sprintf("attribute must be a keyword, but was %s", edn.String(key))})
+			continue
+		}
+		if attr == dbID {
+			continue
+		}
+
+		vs = append(vs, s.validateValue(i, attr, val, false)...)
+	}
+
+	return vs
+}
+
+// validateValue checks the value of attr.  single is set for the value
+// of [:db/add e a v], which is a single value even for attributes of
+// cardinality many.
+func (s Schema) validateValue(i int, attr edn.Keyword, val interface{}, single bool) []Violation {
+	violation := func(format string, args ...interface{}) []Violation {
+		return []Violation{{Index: i, Attribute: attr, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	if len(attr.Name) > 1 && attr.Name[0] == '_' {
+		ref, ok := s[kw(attr.Namespace, attr.Name[1:])]
+		if !ok {
+			return violation("reverse reference to an undefined attribute")
+		}
+		if ref.ValueType != kw("db.type", "ref") {
+			return violation("reverse reference to an attribute of type %s", ref.ValueType)
+		}
+		return nil
+	}
+
+	a, ok := s[attr]
+	if !ok {
+		if attr.Namespace == "db" || strings.HasPrefix(attr.Namespace, "db.") {
+			return nil
+		}
+		return violation("undefined attribute")
+	}
+
+	vals := []interface{}{val}
+	coll, isColl := collection(val)
+	switch {
+	case isColl && a.Many && !single:
+		vals = coll
+	case isColl && a.ValueType != kw("db.type", "tuple") && !isLookupRef(a, val):
+		if a.Many {
+			return violation("statement takes a single value, but the value is the collection %s", edn.String(val))
+		}
+		return violation("attribute has cardinality one, but the value is the collection %s", edn.String(val))
+	}
+
+	var vs []Violation
+	for _, v := range vals {
+		if entity, ok := v.(map[interface{}]interface{}); ok && a.ValueType == kw("db.type", "ref") {
+			vs = append(vs, s.validateEntity(i, entity)...)
+			continue
+		}
+		if !hasType(a.ValueType, v) {
+			vs = append(vs, violation("value %s is not of type %s", edn.String(v), a.ValueType)...)
+		}
+	}
+
+	return vs
+}
+
+// collection returns the elements of the vector, list or set v.
+func collection(v interface{}) ([]interface{}, bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		return v, true
+	case map[interface{}]bool:
+		elems := make([]interface{}, 0, len(v))
+		for elem := range v {
+			if k, ok := elem.(edn.CompositeKey); ok {
+				val, err := k.Value()
+				if err == nil {
+					elem = val
+				}
+			}
+			elems = append(elems, elem)
+		}
+		return elems, true
+	default:
+		return nil, false
+	}
+}
+
+// isLookupRef reports whether val is a lookup ref [attr value] for an
+// attribute of type ref.
+func isLookupRef(a *Attribute, val interface{}) bool {
+	vec, ok := val.([]interface{})
+	if !ok || len(vec) != 2 || a.ValueType != kw("db.type", "ref") {
+		return false
+	}
+	_, ok = vec[0].(edn.Keyword)
+	return ok
+}
+
+// hasType reports whether v is a valid value for attributes of
+// valueType.  Values of unknown types are always valid.
+func hasType(valueType edn.Keyword, v interface{}) bool {
+	if valueType.Namespace != "db.type" {
+		return true
+	}
+
+	switch valueType.Name {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "long":
+		switch v.(type) {
+		case int64, int:
+			return true
+		}
+	case "bigint":
+		switch v.(type) {
+		case int64, int, *big.Int:
+			return true
+		}
+	case "float", "double", "bigdec":
+		switch v.(type) {
+		case float64, int64, int:
+			return true
+		}
+	case "instant":
+		_, ok := v.(time.Time)
+		return ok
+	case "uuid":
+		_, ok := v.(edn.UUID)
+		return ok
+	case "keyword":
+		_, ok := v.(edn.Keyword)
+		return ok
+	case "symbol":
+		_, ok := v.(edn.Symbol)
+		return ok
+	case "uri":
+		switch v := v.(type) {
+		case *url.URL, string:
+			return true
+		case edn.Tagged:
+			return v.Tag == edn.Symbol{Namespace: "", Name: "uri"}
+		}
+	case "bytes":
+		_, ok := v.([]byte)
+		return ok
+	case "tuple":
+		_, ok := v.([]interface{})
+		return ok
+	case "ref":
+		switch v := v.(type) {
+		case int64, int, string, edn.Keyword:
+			// entity ids, tempids and idents
+			return true
+		case []interface{}:
+			return len(v) == 2
+		case edn.Tagged:
+			return v.Tag == edn.Symbol{Namespace: "db", Name: "id"}
+		}
+	default:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,141 @@
+package datomic
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/heyLu/edn"
+)
+
+const schemaEDN = `[{:db/ident :person/name
+	  :db/valueType :db.type/string
+	  :db/cardinality :db.cardinality/one
+	  :db/unique :db.unique/identity}
+	 {:db/ident :person/age
+	  :db/valueType :db.type/long
+	  :db/cardinality :db.cardinality/one}
+	 {:db/ident :person/friends
+	  :db/valueType :db.type/ref
+	  :db/cardinality :db.cardinality/many}
+	 {:db/ident :person/address
+	  :db/valueType :db.type/ref
+	  :db/cardinality :db.cardinality/one
+	  :db/isComponent true}
+	 [{:db/ident :address/city
+	   :db/valueType :db.type/string
+	   :db/cardinality :db.cardinality/one}
+	  {:db/ident :color/red}]]`
+
+func parseSchema(t *testing.T) Schema {
+	val, err := edn.DecodeString(schemaEDN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := ParseSchema(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestParseSchema(t *testing.T) {
+	s := parseSchema(t)
+
+	if len(s) != 5 {
+		t.Fatalf("expected 5 attributes, got %d", len(s))
+	}
+	name := s[kw("person", "name")]
+	if name.ValueType != kw("db.type", "string") || name.Many || name.Unique == nil || *name.Unique != kw("db.unique", "identity") {
+		t.Errorf("unexpected attribute %#v", name)
+	}
+	if !s[kw("person", "friends")].Many {
+		t.Errorf(":person/friends should have cardinality many")
+	}
+	if !s[kw("person", "address")].IsComponent {
+		t.Errorf(":person/address should be a component")
+	}
+
+	for _, invalid := range []string{
+		`[{:db/ident "name" :db/valueType :db.type/string :db/cardinality :db.cardinality/one}]`,
+		`[{:db/ident :a :db/valueType :db.type/string}]`,
+		`[{:db/ident :a :db/valueType :db.type/string :db/cardinality :db.cardinality/one :db/isComponent "yes"}]`,
+		`[{:db/ident :a :db/valueType :db.type/string :db/cardinality :db.cardinality/one}
+		  {:db/ident :a :db/valueType :db.type/long :db/cardinality :db.cardinality/one}]`,
+		`[:a]`,
+	} {
+		val, err := edn.DecodeString(invalid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParseSchema(val); err == nil {
+			t.Errorf("expected error for %s", invalid)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := parseSchema(t)
+
+	tx, err := edn.DecodeString(`[{:db/id "jane" :person/name "Jane" :person/age 42
+	   :person/friends [[:person/name "Joe"] "ann" 17592186045418]
+	   :person/address {:address/city "Berlin"}}
+	  [:db/add "ann" :person/name "Ann"]
+	  [:db/retract 17592186045418 :person/friends [:person/name "Jane"]]
+	  {:db/ident :color/blue}
+	  {:person/_friends "jane" :person/name "Joe"}
+	  [:db/retractEntity 42]
+	  (my.ns/fn 1 2)]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if vs := s.Validate(tx); len(vs) != 0 {
+		t.Errorf("expected no violations, got %v", vs)
+	}
+}
+
+func TestValidateViolations(t *testing.T) {
+	s := parseSchema(t)
+
+	tx, err := edn.DecodeString(`[{:person/name 42 :person/age "old"}
+	  {:person/nickname "jj"}
+	  [:db/add "x" :person/age 1.5]
+	  {:person/name ["a" "b"]}
+	  {:person/address {:address/city :berlin}}
+	  [:db/add "x" :person/friends ["a" "b" "c"]]
+	  {:address/_city 1}
+	  [:db/add "x" :person/age]
+	  "not a statement"]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vs := s.Validate(tx)
+	var got []string
+	for _, v := range vs {
+		got = append(got, v.String())
+	}
+	sort.Strings(got)
+
+	expected := []string{
+		`statement 0: :person/age: value "old" is not of type :db.type/long`,
+		`statement 0: :person/name: value 42 is not of type :db.type/string`,
+		`statement 1: :person/nickname: undefined attribute`,
+		`statement 2: :person/age: value 1.5 is not of type :db.type/long`,
+		`statement 3: :person/name: attribute has cardinality one, but the value is the collection ["a" "b"]`,
+		`statement 4: :address/city: value :berlin is not of type :db.type/string`,
+		`statement 5: :person/friends: statement takes a single value, but the value is the collection ["a" "b" "c"]`,
+		`statement 6: :address/_city: reverse reference to an attribute of type :db.type/string`,
+		`statement 7: :db/add: expected [:db/add e a v], but was [:db/add "x" :person/age]`,
+		`statement 8: statement must be a map or a vector, but was "not a statement"`,
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d violations, got %d:\n%v", len(expected), len(got), got)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("expected\n%s\ngot\n%s", expected[i], got[i])
+		}
+	}
+}
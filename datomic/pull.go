@@ -0,0 +1,188 @@
+package datomic
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/heyLu/edn"
+)
+
+// Datom is an entity, attribute and value tuple, see Flatten.
+type Datom struct {
+	E interface{}
+	A edn.Keyword
+	V interface{}
+}
+
+// TempID identifies entities without a :db/id in Flatten.
+type TempID int64
+
+// Flatten returns the datoms of a pull result, an entity map or a
+// vector of them, e.g.
+//
+//	{:db/id 1 :person/name "Jane" :person/address {:address/city "Berlin"}}
+//
+// is flattened to
+//
+//	[1 :person/name "Jane"]
+//	[1 :person/address TempID(1)]
+//	[TempID(1) :address/city "Berlin"]
+//
+// Entities are identified by their :db/id if they have one, and by a
+// TempID otherwise.  Nested entities are referred to by their id, each
+// element of a vector is a datom of its own.  The attributes of an
+// entity are flattened in the order of their names.
+func Flatten(v interface{}) []Datom {
+	f := &flattener{}
+	switch v := v.(type) {
+	case []interface{}:
+		for _, elem := range v {
+			if entity, ok := elem.(map[interface{}]interface{}); ok {
+				f.entity(entity)
+			}
+		}
+	case map[interface{}]interface{}:
+		f.entity(v)
+	}
+
+	return f.datoms
+}
+
+type flattener struct {
+	datoms []Datom
+	tempID TempID
+}
+
+func (f *flattener) entity(m map[interface{}]interface{}) {
+	f.attributes(f.id(m), m)
+}
+
+func (f *flattener) id(m map[interface{}]interface{}) interface{} {
+	if id, ok := m[dbID]; ok {
+		return id
+	}
+
+	f.tempID++
+	return f.tempID
+}
+
+func (f *flattener) attributes(id interface{}, m map[interface{}]interface{}) {
+	attrs := make([]edn.Keyword, 0, len(m))
+	for key := range m {
+		if kw, ok := key.(edn.Keyword); ok && kw != dbID {
+			attrs = append(attrs, kw)
+		}
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].String() < attrs[j].String() })
+
+	for _, attr := range attrs {
+		if vals, ok := m[attr].([]interface{}); ok {
+			for _, val := range vals {
+				f.add(id, attr, val)
+			}
+			continue
+		}
+
+		f.add(id, attr, m[attr])
+	}
+}
+
+// add adds the datom for val, followed by those of val if it is a
+// nested entity.
+func (f *flattener) add(id interface{}, attr edn.Keyword, val interface{}) {
+	entity, ok := val.(map[interface{}]interface{})
+	if !ok {
+		f.datoms = append(f.datoms, Datom{E: id, A: attr, V: val})
+		return
+	}
+
+	ref := f.id(entity)
+	f.datoms = append(f.datoms, Datom{E: id, A: attr, V: ref})
+	f.attributes(ref, entity)
+}
+
+// Expand returns the entity map of root from the datoms, with the
+// values of refs expanded to nested entity maps, the inverse of
+// Flatten.  Entities identified by a TempID have no :db/id.
+//
+// Attributes of cardinality many in s have vectors of values, as do
+// all attributes with several datoms for an entity.  Without a schema,
+// s may be nil, the values of all attributes that are ids of entities
+// in datoms are expanded.  With a schema, only those of refs are.
+func Expand(datoms []Datom, root interface{}, s Schema) (map[interface{}]interface{}, error) {
+	e := &expander{entities: map[interface{}][]Datom{}, schema: s, expanding: map[interface{}]bool{}}
+	for _, d := range datoms {
+		e.entities[d.E] = append(e.entities[d.E], d)
+	}
+
+	if _, ok := e.entities[root]; !ok {
+		return nil, fmt.Errorf("no datoms for entity %s", edn.String(root))
+	}
+
+	return e.entity(root)
+}
+
+type expander struct {
+	entities  map[interface{}][]Datom
+	schema    Schema
+	expanding map[interface{}]bool
+}
+
+func (e *expander) entity(id interface{}) (map[interface{}]interface{}, error) {
+	if e.expanding[id] {
+		return nil, fmt.Errorf("entity %s refers to itself", edn.String(id))
+	}
+	e.expanding[id] = true
+	defer delete(e.expanding, id)
+
+	m := map[interface{}]interface{}{}
+	if _, ok := id.(TempID); !ok {
+		m[dbID] = id
+	}
+
+	counts := map[edn.Keyword]int{}
+	for _, d := range e.entities[id] {
+		counts[d.A]++
+	}
+
+	for _, d := range e.entities[id] {
+		val, err := e.value(d)
+		if err != nil {
+			return nil, err
+		}
+
+		attr := e.schema[d.A]
+		if counts[d.A] > 1 || (attr != nil && attr.Many) {
+			vals, _ := m[d.A].([]interface{})
+			m[d.A] = append(vals, val)
+		} else {
+			m[d.A] = val
+		}
+	}
+
+	return m, nil
+}
+
+func (e *expander) value(d Datom) (interface{}, error) {
+	if !hashable(d.V) {
+		return d.V, nil
+	}
+	if _, ok := e.entities[d.V]; !ok {
+		return d.V, nil
+	}
+	if attr := e.schema[d.A]; e.schema != nil && (attr == nil || attr.ValueType != kw("db.type", "ref")) {
+		return d.V, nil
+	}
+
+	return e.entity(d.V)
+}
+
+// hashable reports whether v can be used as a Go map key.
+func hashable(v interface{}) bool {
+	switch v.(type) {
+	case []interface{}, map[interface{}]interface{}, map[interface{}]bool:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,79 @@
+package datomic
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/heyLu/edn"
+)
+
+func TestFlatten(t *testing.T) {
+	pull, err := edn.DecodeString(`{:db/id 1 :person/name "Jane"
+	  :person/address {:address/city "Berlin"}
+	  :person/friends [{:db/id 2 :person/name "Joe"} {:person/name "Ann"}]
+	  :person/tags ["a" "b"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	datoms := Flatten(pull)
+	expected := []Datom{
+		{E: int64(1), A: kw("person", "address"), V: TempID(1)},
+		{E: TempID(1), A: kw("address", "city"), V: "Berlin"},
+		{E: int64(1), A: kw("person", "friends"), V: int64(2)},
+		{E: int64(2), A: kw("person", "name"), V: "Joe"},
+		{E: int64(1), A: kw("person", "friends"), V: TempID(2)},
+		{E: TempID(2), A: kw("person", "name"), V: "Ann"},
+		{E: int64(1), A: kw("person", "name"), V: "Jane"},
+		{E: int64(1), A: kw("person", "tags"), V: "a"},
+		{E: int64(1), A: kw("person", "tags"), V: "b"},
+	}
+	if !reflect.DeepEqual(datoms, expected) {
+		t.Errorf("expected\n%v\ngot\n%v", expected, datoms)
+	}
+
+	expanded, err := Expand(datoms, int64(1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !edn.Equal(expanded, pull) {
+		t.Errorf("expected %s, got %s", edn.String(pull), edn.String(expanded))
+	}
+}
+
+func TestExpandWithSchema(t *testing.T) {
+	s := parseSchema(t)
+
+	datoms := []Datom{
+		{E: int64(1), A: kw("person", "friends"), V: int64(2)},
+		{E: int64(1), A: kw("person", "age"), V: int64(2)},
+		{E: int64(2), A: kw("person", "name"), V: "Joe"},
+	}
+
+	expanded, err := Expand(datoms, int64(1), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := edn.DecodeString(`{:db/id 1 :person/age 2 :person/friends [{:db/id 2 :person/name "Joe"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !edn.Equal(expanded, expected) {
+		t.Errorf("expected %s, got %s", edn.String(expected), edn.String(expanded))
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	if _, err := Expand(nil, int64(1), nil); err == nil {
+		t.Errorf("expected error for missing entity")
+	}
+
+	cycle := []Datom{
+		{E: int64(1), A: kw("person", "friends"), V: int64(2)},
+		{E: int64(2), A: kw("person", "friends"), V: int64(1)},
+	}
+	if _, err := Expand(cycle, int64(1), nil); err == nil {
+		t.Errorf("expected error for cycle")
+	}
+}
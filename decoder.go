@@ -0,0 +1,314 @@
+package edn
+
+import (
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// Decoder reads EDN values from an input stream.
+//
+// In contrast to ReadValue, a Decoder can be configured before reading
+// and keeps its configuration for all values read from the stream.
+type Decoder struct {
+	r io.ByteScanner
+
+	metrics         Metrics
+	maxNumberLength int
+	namespaces      map[string]string
+	uuidFunc        func(UUID) interface{}
+	uuidVersions    []int
+	lenientUUIDs    bool
+	rawTags         bool
+	normalizer      func(string) string
+	discardFunc     func(offset int64, val interface{})
+	depthLimit      int
+	arena           *Arena
+	reuse           *reusable
+	keywordLimit    int
+	keywords        map[string]interface{}
+	internLimit     int
+	interned        map[string]string
+
+	offset   int64
+	depth    int
+	maxDepth int
+	ioErr    bool
+
+	// stack holds the collections of an incomplete value of a
+	// PushParser, mark the offset its input is read again from, and
+	// start the offset at which the value started
+	stack []*collection
+	mark  int64
+	start int64
+
+	// token is the buffer of readToken, text that of interned strings,
+	// buffers those of collections
+	token   []byte
+	text    []byte
+	buffers [][]interface{}
+}
+
+// NewDecoder returns a decoder reading from r.
+func NewDecoder(r io.ByteScanner) *Decoder {
+	return &Decoder{r: r}
+}
+
+// SetMaxNumberLength limits the length of number literals to n bytes,
+// including signs and suffixes.  This guards against input containing
+// huge big integers or ratios, which are expensive to parse and to
+// compute with.  A value of 0, the default, means no limit.
+func (d *Decoder) SetMaxNumberLength(n int) {
+	d.maxNumberLength = n
+}
+
+// SetMaxDepth limits the nesting of collections to n levels, e.g. 1
+// allows [1 2] but not [[1] 2].  Tagged elements and discarded forms
+// count as a level as well, so 1 also rejects [#inst "2020-01-01"].
+// Nested values are read without recursion, so the limit only guards
+// against the memory deeply nested input uses.  A value of 0, the
+// default, means no limit.
+func (d *Decoder) SetMaxDepth(n int) {
+	d.depthLimit = n
+}
+
+// RemapNamespace makes the decoder replace the namespace from with to
+// in all keywords and symbols it reads, including the tags of tagged
+// elements.  E.g. with RemapNamespace("old.ns", "new.ns") the input
+// :old.ns/name is read as :new.ns/name.
+//
+// Only exact namespace matches are replaced, "old.ns.sub" is kept as
+// it is.  Tag readers are looked up by the remapped tag.
+func (d *Decoder) RemapNamespace(from, to string) {
+	if d.namespaces == nil {
+		d.namespaces = make(map[string]string)
+	}
+
+	d.namespaces[from] = to
+	d.keywords = nil
+}
+
+// SetUUIDFunc makes the decoder return fn(u) for #uuid values instead
+// of the UUID u, e.g. to decode them as [16]byte with UUIDBytes, or as
+// the UUID type of another package:
+//
+//	d.SetUUIDFunc(func(u edn.UUID) interface{} {
+//		return uuid.UUID(u.Bytes())
+//	})
+func (d *Decoder) SetUUIDFunc(fn func(UUID) interface{}) {
+	d.uuidFunc = fn
+}
+
+// SetUUIDVersions makes the decoder reject #uuid values that don't have
+// the RFC 4122 variant and one of the given versions, e.g. 4 for
+// random UUIDs.  Without versions, all UUIDs are accepted.
+func (d *Decoder) SetUUIDVersions(versions ...int) {
+	d.uuidVersions = versions
+}
+
+// SetLenientUUIDs makes the decoder accept #uuid values in forms seen
+// in other systems besides the canonical one: with a "urn:uuid:"
+// prefix, in braces and without hyphens, e.g.
+// #uuid "urn:uuid:f81d4fae-7dec-11d0-a765-00a0c91e6bf6" or
+// #uuid "F81D4FAE7DEC11D0A76500A0C91E6BF6".  The values are the same
+// UUIDs as in canonical form, so String writes them canonically.
+func (d *Decoder) SetLenientUUIDs(lenient bool) {
+	d.lenientUUIDs = lenient
+}
+
+// SetRawTags makes the decoder read all tagged elements except #inst
+// and #uuid as Tagged values, without calling the tag readers
+// registered with RegisterTag, so that values are read the same way
+// whatever tag readers a program registers.
+func (d *Decoder) SetRawTags(raw bool) {
+	d.rawTags = raw
+}
+
+func (d *Decoder) convertUUID(u UUID) (interface{}, error) {
+	if d.uuidVersions != nil {
+		if !u.IsRFC4122() {
+			return nil, fmt.Errorf("uuid %s does not have the RFC 4122 variant", u)
+		}
+
+		ok := false
+		for _, version := range d.uuidVersions {
+			ok = ok || u.Version() == version
+		}
+		if !ok {
+			return nil, fmt.Errorf("uuid %s has version %d, but must have one of %v", u, u.Version(), d.uuidVersions)
+		}
+	}
+
+	if d.uuidFunc != nil {
+		return d.uuidFunc(u), nil
+	}
+
+	return u, nil
+}
+
+// UUIDBytes returns the bytes of u, see SetUUIDFunc.
+func UUIDBytes(u UUID) interface{} {
+	return u.Bytes()
+}
+
+// UUIDString returns the canonical string representation of u, see
+// SetUUIDFunc.
+func UUIDString(u UUID) interface{} {
+	return u.String()
+}
+
+func (d *Decoder) remapNamespace(val interface{}) interface{} {
+	return remapNamespace(d.namespaces, val)
+}
+
+// remapNamespace replaces the namespace of the keyword or symbol val by
+// the one it is mapped to in namespaces, if any.
+func remapNamespace(namespaces map[string]string, val interface{}) interface{} {
+	if namespaces == nil {
+		return val
+	}
+
+	switch val := val.(type) {
+	case Keyword:
+		if ns, ok := namespaces[val.Namespace]; ok && val.Namespace != "" {
+			return Keyword{ns, val.Name}
+		}
+	case Symbol:
+		if ns, ok := namespaces[val.Namespace]; ok && val.Namespace != "" {
+			return Symbol{ns, val.Name}
+		}
+	}
+
+	return val
+}
+
+// SetNormalizer makes the decoder pass the text of all strings,
+// keywords and symbols through fn, e.g. to normalize them to Unicode
+// NFC, so that keys written in different editors are equal:
+//
+//	d.SetNormalizer(norm.NFC.String)
+//
+// using golang.org/x/text/unicode/norm.  fn is only called for text
+// that contains non-ASCII characters.
+func (d *Decoder) SetNormalizer(fn func(string) string) {
+	d.normalizer = fn
+	d.keywords = nil
+	d.interned = nil
+}
+
+func (d *Decoder) normalize(s string) string {
+	return normalizeText(d.normalizer, s)
+}
+
+// normalizeText returns fn(s), or s if fn is nil or s is ASCII.
+func normalizeText(fn func(string) string, s string) string {
+	if fn == nil {
+		return s
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return fn(s)
+		}
+	}
+
+	return s
+}
+
+// SetDiscardFunc makes the decoder call fn with the forms discarded
+// with #_, instead of dropping them silently, e.g. for linters warning
+// about leftover discards in committed files.  offset is the offset of
+// the #_ in the input.  Discards within discarded forms are reported
+// before the forms containing them.
+func (d *Decoder) SetDiscardFunc(fn func(offset int64, val interface{})) {
+	d.discardFunc = fn
+}
+
+// Decode reads the next value.
+//
+// io.EOF is returned if the input ends before the start of a value.
+// Invalid input results in a *SyntaxError, which wraps
+// io.ErrUnexpectedEOF if the input ends within a value.
+//
+// After an error the decoder has consumed the input up to and
+// including the byte at which the error was detected, which is at
+// SyntaxError.Offset.  Decoding can continue from there, but the rest
+// of the invalid form is then read as further values.  Callers of
+// line-oriented streams can use InputOffset to skip to the next line
+// instead.
+func (d *Decoder) Decode() (interface{}, error) {
+	if len(d.stack) == 0 {
+		d.start = d.offset
+		d.maxDepth = 0
+	}
+	d.ioErr = false
+
+	val, err := d.readValue()
+	if err == ErrIncomplete {
+		return nil, err
+	}
+
+	if d.metrics != nil {
+		d.report(d.offset-d.start, err)
+	}
+
+	if err != nil && err != io.EOF && !d.ioErr {
+		return nil, &SyntaxError{Offset: d.offset, Err: err}
+	}
+
+	return val, err
+}
+
+// InputOffset returns the number of bytes read from the input so far.
+func (d *Decoder) InputOffset() int64 {
+	return d.offset
+}
+
+// SyntaxError describes invalid input.
+type SyntaxError struct {
+	// Offset is the number of bytes read when the error was detected.
+	Offset int64
+	Err    error
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+func (d *Decoder) readByte() (byte, error) {
+	ch, err := d.r.ReadByte()
+	if err == nil {
+		d.offset++
+	} else if err != io.EOF && err != ErrIncomplete {
+		d.ioErr = true
+	}
+
+	return ch, err
+}
+
+func (d *Decoder) unreadByte() error {
+	err := d.r.UnreadByte()
+	if err == nil {
+		d.offset--
+	} else {
+		d.ioErr = true
+	}
+
+	return err
+}
+
+// enter and leave track the nesting depth of collections.
+func (d *Decoder) enter() {
+	d.depth++
+	if d.depth > d.maxDepth {
+		d.maxDepth = d.depth
+	}
+}
+
+func (d *Decoder) leave() {
+	d.depth--
+}
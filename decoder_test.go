@@ -0,0 +1,399 @@
+package edn
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestMaxNumberLength(t *testing.T) {
+	d := NewDecoder(strings.NewReader("12345N 123456N " + strings.Repeat("9", 10000) + "N"))
+	d.SetMaxNumberLength(6)
+
+	if _, err := d.Decode(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := d.Decode()
+		if err == nil || !strings.Contains(err.Error(), "longer than 6 bytes") {
+			t.Errorf("expected length error, got %v", err)
+		}
+	}
+}
+
+func TestRemapNamespace(t *testing.T) {
+	d := NewDecoder(strings.NewReader("[:old.ns/a old.ns/b :old.ns.sub/c :d #old.ns/tag 1]"))
+	d.RemapNamespace("old.ns", "new.ns")
+
+	val, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []interface{}{
+		Keyword{"new.ns", "a"},
+		Symbol{"new.ns", "b"},
+		Keyword{"old.ns.sub", "c"},
+		Keyword{"", "d"},
+		Tagged{Symbol{"new.ns", "tag"}, int64(1)},
+	}
+	if !reflect.DeepEqual(val, expected) {
+		t.Errorf("unexpected value %#v", val)
+	}
+}
+
+func TestDecodeErrorOffset(t *testing.T) {
+	input := "{:a 1}\n[1 2 ) 3]\n:b\n"
+	d := NewDecoder(strings.NewReader(input))
+
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := d.Decode()
+	synErr, ok := err.(*SyntaxError)
+	if !ok {
+		t.Fatalf("expected *SyntaxError, got %#v", err)
+	}
+	if synErr.Offset != 13 || d.InputOffset() != 13 || input[synErr.Offset-1] != ')' {
+		t.Errorf("unexpected offsets %d and %d", synErr.Offset, d.InputOffset())
+	}
+
+	// resync at the next line
+	rest := input[d.InputOffset():]
+	d = NewDecoder(strings.NewReader(rest[strings.IndexByte(rest, '\n'):]))
+	val, err := d.Decode()
+	if err != nil || val != (Keyword{"", "b"}) {
+		t.Errorf("unexpected value %#v (%v)", val, err)
+	}
+}
+
+func TestByteScanners(t *testing.T) {
+	input := `{:a [1 -2 +3 sym/bol] :b #{"str"}} #tag 3.5 - +x #_ discarded 10/4 12N`
+	expected, err := ReadAllValues(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expected) != 6 {
+		t.Fatalf("unexpected values %#v", expected)
+	}
+
+	scanners := map[string]io.ByteScanner{
+		"bytes.Reader":     bytes.NewReader([]byte(input)),
+		"bufio.Reader":     bufio.NewReaderSize(strings.NewReader(input), 16),
+		"bufio.Reader(1)":  bufio.NewReaderSize(iotest.OneByteReader(strings.NewReader(input)), 16),
+		"strings.Reader":   strings.NewReader(input),
+		"PushParser chunk": &chunkScanner{buf: []byte(input), closed: true},
+	}
+
+	for name, r := range scanners {
+		vals, err := ReadAllValues(r)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		if String(vals) != String(expected) {
+			t.Errorf("%s: expected %s, got %s", name, String(expected), String(vals))
+		}
+	}
+}
+
+// noUnreadScanner fails on every UnreadByte.
+type noUnreadScanner struct {
+	io.ByteReader
+}
+
+func (noUnreadScanner) UnreadByte() error {
+	return bufio.ErrInvalidUnreadByte
+}
+
+func TestUnreadByteErrors(t *testing.T) {
+	for _, s := range []string{"123 ", "sym ", "[1 2]", "#tag 1", "-1"} {
+		_, err := ReadValue(noUnreadScanner{strings.NewReader(s)})
+		if !errors.Is(err, bufio.ErrInvalidUnreadByte) {
+			t.Errorf("%q: expected unread error, got %v", s, err)
+		}
+	}
+}
+
+// failingReader returns its data, followed by err instead of io.EOF.
+type failingReader struct {
+	data []byte
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestReadErrorsArePropagated(t *testing.T) {
+	input := `{:a [1 -2 sym/bol "str\n"] :b #{3.5 10/4} #tag 12N ; comment` + "\n" + `}`
+
+	for i := 0; i < len(input); i++ {
+		r := bufio.NewReader(&failingReader{[]byte(input[:i]), iotest.ErrTimeout})
+		val, err := ReadValue(r)
+		if !errors.Is(err, iotest.ErrTimeout) {
+			t.Errorf("%q: expected timeout error, got %#v, %v", input[:i], val, err)
+		}
+	}
+}
+
+func TestSetUUIDFunc(t *testing.T) {
+	input := `[#uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6" {:id #uuid "00000000-0000-0000-0000-000000000001"}]`
+	u := UUID{0xf81d4fae7dec11d0, 0xa76500a0c91e6bf6}
+
+	type myUUID [16]byte
+
+	tests := []struct {
+		fn       func(UUID) interface{}
+		expected interface{}
+	}{
+		{nil, u},
+		{UUIDBytes, u.Bytes()},
+		{UUIDString, "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"},
+		{func(u UUID) interface{} { return myUUID(u.Bytes()) }, myUUID{0xf8, 0x1d, 0x4f, 0xae, 0x7d, 0xec, 0x11, 0xd0, 0xa7, 0x65, 0x00, 0xa0, 0xc9, 0x1e, 0x6b, 0xf6}},
+	}
+
+	for _, test := range tests {
+		d := NewDecoder(strings.NewReader(input))
+		d.SetUUIDFunc(test.fn)
+		val, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		vec := val.([]interface{})
+		if !reflect.DeepEqual(vec[0], test.expected) {
+			t.Errorf("expected %#v, got %#v", test.expected, vec[0])
+		}
+
+		nested := vec[1].(map[interface{}]interface{})[Keyword{"", "id"}]
+		if reflect.TypeOf(nested) != reflect.TypeOf(test.expected) {
+			t.Errorf("expected nested uuid of type %T, got %#v", test.expected, nested)
+		}
+	}
+}
+
+func TestSetUUIDVersions(t *testing.T) {
+	tests := map[string]bool{
+		"f81d4fae-7dec-11d0-a765-00a0c91e6bf6": false,
+		"9c5b94b1-35ad-49bb-b118-8e8fc24abf80": true,
+		"9c5b94b1-35ad-49bb-c118-8e8fc24abf80": false,
+		"00000000-0000-0000-0000-000000000000": false,
+	}
+
+	for s, valid := range tests {
+		d := NewDecoder(strings.NewReader(`#uuid "` + s + `"`))
+		d.SetUUIDVersions(4)
+		_, err := d.Decode()
+		if valid && err != nil {
+			t.Errorf("%s: %v", s, err)
+		} else if !valid && err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+}
+
+func TestSetLenientUUIDs(t *testing.T) {
+	u := UUID{0xf81d4fae7dec11d0, 0xa76500a0c91e6bf6}
+	forms := []string{
+		"f81d4fae-7dec-11d0-a765-00a0c91e6bf6",
+		"urn:uuid:f81d4fae-7dec-11d0-a765-00a0c91e6bf6",
+		"URN:UUID:F81D4FAE-7DEC-11D0-A765-00A0C91E6BF6",
+		"{f81d4fae-7dec-11d0-a765-00a0c91e6bf6}",
+		"f81d4fae7dec11d0a76500a0c91e6bf6",
+		"urn:uuid:F81D4FAE7DEC11D0A76500A0C91E6BF6",
+	}
+
+	for _, s := range forms {
+		input := `#uuid "` + s + `"`
+
+		d := NewDecoder(strings.NewReader(input))
+		d.SetLenientUUIDs(true)
+		val, err := d.Decode()
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+		} else if val != u || String(val) != `#uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"` {
+			t.Errorf("%s: expected %s, got %s", s, u, String(val))
+		}
+
+		if _, err := DecodeString(input); err == nil && s != forms[0] {
+			t.Errorf("%s: expected error without SetLenientUUIDs", s)
+		}
+	}
+
+	d := NewDecoder(strings.NewReader(`#uuid "urn:uuid:f81d4fae"`))
+	d.SetLenientUUIDs(true)
+	if _, err := d.Decode(); err == nil {
+		t.Error("expected error for short uuid")
+	}
+}
+
+func TestSetRawTags(t *testing.T) {
+	input := `[#local-date "2020-01-01" #inst "2020-01-01T00:00:00Z" #uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6" #foo 1]`
+	d := NewDecoder(strings.NewReader(input))
+	d.SetRawTags(true)
+	val, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vec := val.([]interface{})
+	if tagged, ok := vec[0].(Tagged); !ok || tagged.Value != "2020-01-01" {
+		t.Errorf("expected a raw #local-date, got %#v", vec[0])
+	}
+	if _, ok := vec[1].(time.Time); !ok {
+		t.Errorf("expected #inst to be read as time.Time, got %#v", vec[1])
+	}
+	if _, ok := vec[2].(UUID); !ok {
+		t.Errorf("expected #uuid to be read as UUID, got %#v", vec[2])
+	}
+	if String(val) != input {
+		t.Errorf("expected %s, got %s", input, String(val))
+	}
+}
+
+func TestSetNormalizer(t *testing.T) {
+	// a stand-in for NFC, which needs golang.org/x/text
+	composeE := strings.NewReplacer("e\u0301", "é").Replace
+
+	input := "{:café \"café\" :cafe\u0301 \"cafe\u0301\" sym/cafe\u0301 :cafe}"
+	d := NewDecoder(strings.NewReader(input))
+	d.SetNormalizer(composeE)
+	val, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[interface{}]interface{}{
+		Keyword{"", "café"}:   "café",
+		Symbol{"sym", "café"}: Keyword{"", "cafe"},
+	}
+	if !reflect.DeepEqual(val, expected) {
+		t.Errorf("expected %#v, got %#v", expected, val)
+	}
+}
+
+func TestSetDiscardFunc(t *testing.T) {
+	type discard struct {
+		offset int64
+		val    string
+	}
+
+	var discards []discard
+	d := NewDecoder(strings.NewReader("{:a #_ (debug) 1}\n#_ #_ x [y] 2"))
+	d.SetDiscardFunc(func(offset int64, val interface{}) {
+		discards = append(discards, discard{offset, String(val)})
+	})
+
+	var vals []interface{}
+	for {
+		val, err := d.Decode()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		vals = append(vals, val)
+	}
+
+	if len(vals) != 2 || String(vals[0]) != "{:a 1}" || vals[1] != int64(2) {
+		t.Errorf("expected the values without discards, got %#v", vals)
+	}
+
+	expected := []discard{{4, "[debug]"}, {21, "x"}, {18, "[y]"}}
+	if !reflect.DeepEqual(discards, expected) {
+		t.Errorf("expected %v, got %v", expected, discards)
+	}
+}
+
+func TestDeepNesting(t *testing.T) {
+	const depth = 300000
+	input := strings.Repeat("[{:a (", depth/3) + "#{}" + strings.Repeat(")}]", depth/3)
+
+	val, err := DecodeString(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	levels := 0
+	for {
+		levels++
+		if vec, ok := val.([]interface{}); ok && len(vec) == 1 {
+			val = vec[0]
+		} else if m, ok := val.(map[interface{}]interface{}); ok && len(m) == 1 {
+			val = m[Keyword{"", "a"}]
+		} else {
+			break
+		}
+	}
+
+	if levels != depth/3*3+1 || !reflect.DeepEqual(val, map[interface{}]bool{}) {
+		t.Errorf("unexpected innermost value %#v at level %d", val, levels)
+	}
+
+	if _, err := ReadValue(strings.NewReader(strings.Repeat("[", depth))); err == nil || !strings.Contains(err.Error(), "eof while reading vector") {
+		t.Errorf("expected eof error, got %v", err)
+	}
+
+	val, err = DecodeString(strings.Repeat("#a ", depth) + "1")
+	for i := 0; i < depth && err == nil; i++ {
+		val = val.(Tagged).Value
+	}
+	if err != nil || val != int64(1) {
+		t.Errorf("expected nested tagged elements, got %v", err)
+	}
+
+	if val, err := DecodeString(strings.Repeat("#_ ", depth) + strings.Repeat("1 ", depth+1)); err != nil || val != int64(1) {
+		t.Errorf("expected the value after the discards, got %#v, %v", val, err)
+	}
+}
+
+func TestSetMaxDepth(t *testing.T) {
+	tests := map[string]bool{
+		"[1 2]":          true,
+		"{:a #{}}":       true,
+		"[[1] 2]":        true,
+		"[[[1]] 2]":      false,
+		"#tag [#{[1]}]":  false,
+		"#_ [[[]]] [[]]": false,
+		"#a [1]":         true,
+		"#a #b [1]":      false,
+		"#_ #_ 1 2 3":    true,
+		"[#_ #_ 1 2 3]":  false,
+	}
+
+	for s, valid := range tests {
+		d := NewDecoder(strings.NewReader(s))
+		d.SetMaxDepth(2)
+		_, err := d.Decode()
+		if valid && err != nil {
+			t.Errorf("%s: %v", s, err)
+		} else if !valid && (err == nil || !strings.Contains(err.Error(), "deeper than 2 levels")) {
+			t.Errorf("%s: expected depth error, got %v", s, err)
+		}
+	}
+
+	for _, prefix := range []string{"#a ", "#_ "} {
+		d := NewDecoder(strings.NewReader(strings.Repeat(prefix, 1000000)))
+		d.SetMaxDepth(100)
+		var serr *SyntaxError
+		if _, err := d.Decode(); !errors.As(err, &serr) {
+			t.Errorf("%s: expected a syntax error, got %v", prefix, err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package edn
+
+// Format is a data format recognized by DetectFormat.
+type Format int
+
+const (
+	// FormatUnknown means that the input could be either format, e.g.
+	// [1, 2, 3], or that it looks like neither.
+	FormatUnknown Format = iota
+	FormatEDN
+	FormatJSON
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatEDN:
+		return "edn"
+	case FormatJSON:
+		return "json"
+	default:
+		return "unknown"
+	}
+}
+
+// DetectFormat guesses whether prefix is the start of an EDN or a JSON
+// document, for routing payloads without a content type.
+//
+// It looks for syntax only one of the formats has: keywords, symbols,
+// nil, lists, sets, tags and comments are EDN, string keys followed by
+// a colon and null are JSON.  prefix may be cut off at any point.
+func DetectFormat(prefix []byte) Format {
+	for i := 0; i < len(prefix); i++ {
+		ch := prefix[i]
+
+		switch {
+		case isWhitespace(ch):
+			continue
+		case ch == '"':
+			end := i + 1
+			for end < len(prefix) && prefix[end] != '"' {
+				if prefix[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			i = end
+		case ch == ':':
+			if i > 0 && prefix[i-1] == '"' {
+				return FormatJSON
+			}
+			if i+1 == len(prefix) {
+				return FormatUnknown
+			}
+			if isNameStart(prefix[i+1]) {
+				return FormatEDN
+			}
+			return FormatJSON
+		case ch == '#' || ch == ';' || ch == '(' || ch == '\\':
+			return FormatEDN
+		case isNameStart(ch):
+			end := i
+			for end < len(prefix) && !isWhitespace(prefix[end]) && !isTerminatingMacro(prefix[end]) && prefix[end] != ':' {
+				end++
+			}
+			if end == len(prefix) {
+				// might be cut off, e.g. "tr" of "true"
+				return FormatUnknown
+			}
+
+			switch string(prefix[i:end]) {
+			case "null":
+				return FormatJSON
+			case "true", "false":
+			default:
+				return FormatEDN
+			}
+			i = end - 1
+		}
+	}
+
+	return FormatUnknown
+}
+
+// isNameStart reports whether ch can start a symbol or the name of a
+// keyword.  Numbers and signs are excluded, as JSON has them as well.
+func isNameStart(ch byte) bool {
+	return ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') ||
+		ch == '*' || ch == '!' || ch == '_' || ch == '?' || ch == '.' || ch == '<' || ch == '>' || ch == '=' || ch == '&' || ch == '%' || ch == '$'
+}
@@ -0,0 +1,35 @@
+package edn
+
+import (
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := map[string]Format{
+		`{:a 1}`:                         FormatEDN,
+		`{"a" :b}`:                       FormatEDN,
+		`[1 2 sym]`:                      FormatEDN,
+		`#{1 2}`:                         FormatEDN,
+		`#inst "2020-01-01T00:00:00Z"`:   FormatEDN,
+		`(1 2)`:                          FormatEDN,
+		`; comment`:                      FormatEDN,
+		`[true nil]`:                     FormatEDN,
+		`["a:b" \c]`:                     FormatEDN,
+		`{"a": 1}`:                       FormatJSON,
+		`{"a":true}`:                     FormatJSON,
+		`{"a" : 1}`:                      FormatJSON,
+		`[1, null]`:                      FormatJSON,
+		`  {"escaped \" quote": [1, 2]}`: FormatJSON,
+		`[1, 2, 3]`:                      FormatUnknown,
+		`"just a string"`:                FormatUnknown,
+		`[true, fal`:                     FormatUnknown,
+		`{"a" :`:                         FormatUnknown,
+		``:                               FormatUnknown,
+	}
+
+	for s, expected := range tests {
+		if f := DetectFormat([]byte(s)); f != expected {
+			t.Errorf("%s: expected %s, got %s", s, expected, f)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package edn
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Change is a difference between two values, see Diff.
+type Change struct {
+	// Op is "add", "remove" or "replace".
+	Op string
+
+	// Path leads to the changed value, see KeywordInfo.Paths for the
+	// format.  For set elements it ends with the element.
+	Path []interface{}
+
+	// Old is the removed or replaced value, New the added or replacing
+	// one.
+	Old, New interface{}
+
+	// SetElement is set for elements added to or removed from sets.
+	SetElement bool
+}
+
+func (c Change) String() string {
+	switch c.Op {
+	case "add":
+		return fmt.Sprintf("add %s %s", String(c.Path), String(c.New))
+	case "remove":
+		return fmt.Sprintf("remove %s %s", String(c.Path), String(c.Old))
+	default:
+		return fmt.Sprintf("replace %s %s with %s", String(c.Path), String(c.Old), String(c.New))
+	}
+}
+
+// Diff returns the changes that turn a into b, using EDN equality (see
+// Equal).  It descends into maps, sets, vectors and lists and the
+// values of tagged elements with the same tag, other values that
+// differ are replaced as a whole.
+//
+// The changes are ordered so that applying them one after the other
+// results in b: map entries and set elements are sorted by their
+// String representation, elements added to vectors come in ascending
+// and removed ones in descending order of their index.
+func Diff(a, b interface{}) []Change {
+	return diff(nil, a, b, []interface{}{})
+}
+
+func diff(changes []Change, a, b interface{}, path []interface{}) []Change {
+	if Equal(a, b) {
+		return changes
+	}
+
+	at := func(elem interface{}) []interface{} {
+		return append(append([]interface{}{}, path...), elem)
+	}
+
+	switch a := a.(type) {
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+
+		n := min(len(a), len(b))
+		for i := 0; i < n; i++ {
+			changes = diff(changes, a[i], b[i], at(i))
+		}
+		for i := n; i < len(b); i++ {
+			changes = append(changes, Change{Op: "add", Path: at(i), New: b[i]})
+		}
+		for i := len(a) - 1; i >= n; i-- {
+			changes = append(changes, Change{Op: "remove", Path: at(i), Old: a[i]})
+		}
+		return changes
+	case map[interface{}]interface{}:
+		b, ok := b.(map[interface{}]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]interface{}, 0, len(a)+len(b))
+		for key := range a {
+			keys = append(keys, key)
+		}
+		for key := range b {
+			if _, ok := MapGet(a, key); !ok {
+				keys = append(keys, key)
+			}
+		}
+		sortByString(keys)
+
+		for _, key := range keys {
+			old, inA := MapGet(a, key)
+			val, inB := MapGet(b, key)
+			switch {
+			case !inB:
+				changes = append(changes, Change{Op: "remove", Path: at(key), Old: old})
+			case !inA:
+				changes = append(changes, Change{Op: "add", Path: at(key), New: val})
+			default:
+				changes = diff(changes, old, val, at(key))
+			}
+		}
+		return changes
+	case map[interface{}]bool:
+		b, ok := b.(map[interface{}]bool)
+		if !ok {
+			break
+		}
+
+		elems := make([]interface{}, 0, len(a)+len(b))
+		for elem := range a {
+			elems = append(elems, elem)
+		}
+		for elem := range b {
+			if !SetContains(a, elem) {
+				elems = append(elems, elem)
+			}
+		}
+		sortByString(elems)
+
+		for _, elem := range elems {
+			switch {
+			case !SetContains(b, elem):
+				changes = append(changes, Change{Op: "remove", Path: at(elem), Old: elem, SetElement: true})
+			case !SetContains(a, elem):
+				changes = append(changes, Change{Op: "add", Path: at(elem), New: elem, SetElement: true})
+			}
+		}
+		return changes
+	case Tagged:
+		if b, ok := b.(Tagged); ok && a.Tag == b.Tag {
+			return diff(changes, a.Value, b.Value, path)
+		}
+	}
+
+	return append(changes, Change{Op: "replace", Path: path, Old: a, New: b})
+}
+
+// EDNPatch returns changes as an EDN value, a vector of maps of the
+// form
+//
+//	[{:op :replace :path [:port] :old 80 :value 8080}
+//	 {:op :add :path [:hosts 2] :value "c"}
+//	 {:op :remove :path [:debug] :old true}]
+//
+// modelled after JSON Patch.  The old values are included so that the
+// patch can be reviewed and reverted.
+func EDNPatch(changes []Change) interface{} {
+	patch := make([]interface{}, len(changes))
+	for i, c := range changes {
+		m := map[interface{}]interface{}{
+			Keyword{"", "op"}:   Keyword{"", c.Op},
+			Keyword{"", "path"}: decodePath(c.Path),
+		}
+		if c.Op != "add" {
+			m[Keyword{"", "old"}] = c.Old
+		}
+		if c.Op != "remove" {
+			m[Keyword{"", "value"}] = c.New
+		}
+		patch[i] = m
+	}
+
+	return patch
+}
+
+// decodePath returns a copy of path in which map keys and set elements
+// that are CompositeKeys are decoded again.
+func decodePath(path []interface{}) []interface{} {
+	vec := make([]interface{}, len(path))
+	for i, elem := range path {
+		if k, ok := elem.(CompositeKey); ok {
+			if val, err := k.Value(); err == nil {
+				elem = val
+			}
+		}
+		vec[i] = elem
+	}
+
+	return vec
+}
+
+// Unified returns a unified diff of a and b, laid out with Pretty in
+// width columns, with three lines of context around changed lines.
+// The result is empty if a and b are equal.
+func Unified(a, b interface{}, width int) string {
+	if Equal(a, b) {
+		return ""
+	}
+
+	return unifiedLines(strings.Split(Pretty(a, width), "\n"), strings.Split(Pretty(b, width), "\n"), 3)
+}
+
+// unifiedLines returns the unified diff of the lines of a and b.
+func unifiedLines(a, b []string, context int) string {
+	type line struct {
+		op     byte
+		text   string
+		ai, bi int
+	}
+
+	var lines []line
+	i, j := 0, 0
+	for _, op := range diffLines(nil, a, b) {
+		switch op {
+		case ' ':
+			lines = append(lines, line{op, a[i], i, j})
+			i, j = i+1, j+1
+		case '-':
+			lines = append(lines, line{op, a[i], i, j})
+			i++
+		default:
+			lines = append(lines, line{op, b[j], i, j})
+			j++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- a\n+++ b\n")
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			continue
+		}
+
+		// extend the hunk while changes are at most 2*context lines apart
+		end, unchanged := start, 0
+		for k := start; k < len(lines) && unchanged <= 2*context; k++ {
+			if lines[k].op == ' ' {
+				unchanged++
+			} else {
+				end, unchanged = k+1, 0
+			}
+		}
+
+		from, to := max(start-context, 0), min(end+context, len(lines))
+		hunk := lines[from:to]
+
+		var aLen, bLen int
+		for _, l := range hunk {
+			if l.op != '+' {
+				aLen++
+			}
+			if l.op != '-' {
+				bLen++
+			}
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(hunk[0].ai, aLen), hunkRange(hunk[0].bi, bLen))
+		for _, l := range hunk {
+			sb.WriteByte(l.op)
+			sb.WriteString(l.text)
+			sb.WriteByte('\n')
+		}
+
+		start = to
+	}
+
+	return sb.String()
+}
+
+// diffLines appends the edits turning a into b to ops, ' ' for lines
+// kept, '-' for lines removed and '+' for lines added, keeping a longest
+// common subsequence of the lines.  It uses Hirschberg's algorithm,
+// which needs linear space instead of a table of the lengths of the
+// common subsequences of all prefixes of a and b.
+func diffLines(ops []byte, a, b []string) []byte {
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		ops = append(ops, ' ')
+		a, b = a[1:], b[1:]
+	}
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0 || len(b) == 0:
+		ops = appendOps(ops, '-', len(a))
+		ops = appendOps(ops, '+', len(b))
+	case len(a) == 1:
+		j := slices.Index(b, a[0])
+		if j < 0 {
+			ops = append(ops, '-')
+			ops = appendOps(ops, '+', len(b))
+		} else {
+			ops = appendOps(ops, '+', j)
+			ops = append(ops, ' ')
+			ops = appendOps(ops, '+', len(b)-j-1)
+		}
+	default:
+		// split b where the common subsequences of the halves of a
+		// with the parts of b are longest
+		mid := len(a) / 2
+		head, tail := lcsLengths(a[:mid], b, false), lcsLengths(a[mid:], b, true)
+		split := 0
+		for j := range head {
+			if head[j]+tail[len(b)-j] > head[split]+tail[len(b)-split] {
+				split = j
+			}
+		}
+
+		ops = diffLines(ops, a[:mid], b[:split])
+		ops = diffLines(ops, a[mid:], b[split:])
+	}
+
+	return appendOps(ops, ' ', suffix)
+}
+
+// lcsLengths returns the lengths of the longest common subsequences of
+// a and b[:j] for each j, or of a and b[len(b)-j:] if reverse is true.
+func lcsLengths(a, b []string, reverse bool) []int {
+	if reverse {
+		a, b = slices.Clone(a), slices.Clone(b)
+		slices.Reverse(a)
+		slices.Reverse(b)
+	}
+
+	row := make([]int, len(b)+1)
+	for _, line := range a {
+		// diag is the length for the previous line of a and b[:j-1]
+		diag := 0
+		for j := 1; j <= len(b); j++ {
+			up := row[j]
+			if line == b[j-1] {
+				row[j] = diag + 1
+			} else if row[j-1] > row[j] {
+				row[j] = row[j-1]
+			}
+			diag = up
+		}
+	}
+
+	return row
+}
+
+func appendOps(ops []byte, op byte, n int) []byte {
+	for ; n > 0; n-- {
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// hunkRange formats the range of lines of a hunk, starting at the
+// 0-based line start.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
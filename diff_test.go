@@ -0,0 +1,136 @@
+package edn
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a, err := DecodeString(`{:port 80 :hosts ["a" "b" "c"] :debug true :tags #{:x :y} :when #my/t [1 2] :n 1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := DecodeString(`{:port 8080 :hosts ["a"] :tags #{:y :z} :when #my/t [1 3] :n 1N :name "b"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"remove [:debug] true",
+		"remove [:hosts 2] \"c\"",
+		"remove [:hosts 1] \"b\"",
+		"add [:name] \"b\"",
+		"replace [:port] 80 with 8080",
+		"remove [:tags :x] :x",
+		"add [:tags :z] :z",
+		"replace [:when 1] 2 with 3",
+	}
+
+	changes := Diff(a, b)
+	got := make([]string, len(changes))
+	for i, c := range changes {
+		got[i] = c.String()
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	if changes := Diff(a, a); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+
+	changes = Diff([]interface{}{int64(1)}, map[interface{}]interface{}{})
+	if len(changes) != 1 || changes[0].Op != "replace" || len(changes[0].Path) != 0 {
+		t.Errorf("expected the whole value to be replaced, got %v", changes)
+	}
+}
+
+func TestEDNPatch(t *testing.T) {
+	changes := Diff(
+		map[interface{}]interface{}{Keyword{"", "a"}: int64(1), KeyOf([]interface{}{int64(1)}): "x"},
+		map[interface{}]interface{}{Keyword{"", "b"}: int64(2), KeyOf([]interface{}{int64(1)}): "y"},
+	)
+
+	expected := `[{:old 1 :op :remove :path [:a]} {:op :add :path [:b] :value 2} {:old "x" :op :replace :path [[1]] :value "y"}]`
+	if s := String(EDNPatch(changes)); s != expected {
+		t.Errorf("expected %s, got %s", expected, s)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a, err := DecodeString(`{:a 1 :b 2 :c 3 :d 4 :e 5 :f 6 :g 7 :h 8 :i 9 :j 10}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := DecodeString(`{:a 1 :b 20 :c 3 :d 4 :e 5 :f 6 :g 7 :h 8 :i 9 :k 11}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `--- a
++++ b
+@@ -1,5 +1,5 @@
+ {:a 1
+- :b 2
++ :b 20
+  :c 3
+  :d 4
+  :e 5
+@@ -7,4 +7,4 @@
+  :g 7
+  :h 8
+  :i 9
+- :j 10}
++ :k 11}
+`
+	if s := Unified(a, b, 10); s != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, s)
+	}
+
+	if s := Unified(a, a, 10); s != "" {
+		t.Errorf("expected no diff for equal values, got\n%s", s)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+		ops  string
+	}{
+		{"a b c", "a b c", "   "},
+		{"a b c", "a x c", " -+ "},
+		{"a b c d", "b d e", "- - +"},
+		{"x a y b z", "a b", "- - -"},
+		{"", "a b", "++"},
+	}
+
+	for _, test := range tests {
+		a, b := strings.Fields(test.a), strings.Fields(test.b)
+		if ops := string(diffLines(nil, a, b)); ops != test.ops {
+			t.Errorf("%s -> %s: expected %q, got %q", test.a, test.b, test.ops, ops)
+		}
+	}
+
+	// every third line changed, the table of all pairs would take 72MB
+	var a, b []string
+	for i := 0; i < 3000; i++ {
+		a = append(a, strconv.Itoa(i))
+		if i%3 == 0 {
+			b = append(b, "changed")
+		} else {
+			b = append(b, strconv.Itoa(i))
+		}
+	}
+
+	kept := 0
+	for _, op := range diffLines(nil, a, b) {
+		if op == ' ' {
+			kept++
+		}
+	}
+	if kept != 2000 {
+		t.Errorf("expected 2000 lines kept, got %d", kept)
+	}
+}
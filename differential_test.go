@@ -0,0 +1,111 @@
+package edn
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clojureReader reads the lines from stdin with clojure.edn/read-string
+// and prints the results, one per line.  Unknown tags are kept as
+// tagged literals, which print as they were read.
+const clojureReader = `
+(require 'clojure.edn)
+(doseq [line (line-seq (java.io.BufferedReader. *in*))]
+  (println
+    (try
+      (pr-str (clojure.edn/read-string {:default tagged-literal} line))
+      (catch Exception e "!error"))))`
+
+// TestDifferential compares the values read from testdata/corpus.edn,
+// one value per line, with the values Clojure's EDN reader produces.
+//
+// It only runs if EDN_CLOJURE names a command accepting `-e <expr>`,
+// e.g. EDN_CLOJURE=bb or EDN_CLOJURE="clojure -M".
+func TestDifferential(t *testing.T) {
+	command := strings.Fields(os.Getenv("EDN_CLOJURE"))
+	if len(command) == 0 {
+		t.Skip("EDN_CLOJURE not set")
+	}
+
+	corpus, err := os.ReadFile("testdata/corpus.edn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(command[0], append(command[1:], "-e", clojureReader)...)
+	cmd.Stdin = bytes.NewReader(corpus)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("running %s: %v", command[0], err)
+	}
+
+	inputs := strings.Split(strings.TrimRight(string(corpus), "\n"), "\n")
+	outputs := bufio.NewScanner(bytes.NewReader(out))
+	for _, input := range inputs {
+		if !outputs.Scan() {
+			t.Fatalf("missing output for %s", input)
+		}
+		output := outputs.Text()
+
+		got, err := DecodeString(input)
+		if output == "!error" {
+			if err == nil {
+				t.Errorf("%s: clojure fails, but read %#v", input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: clojure reads %s, but failed with %v", input, output, err)
+			continue
+		}
+
+		expected, err := DecodeString(output)
+		if err != nil {
+			t.Errorf("%s: cannot read clojure output %s: %v", input, output, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(normalize(got), normalize(expected)) {
+			t.Errorf("%s: read as %s, but clojure reads %s", input, fmt.Sprint(got), output)
+		}
+	}
+}
+
+// normalize makes values comparable with reflect.DeepEqual, which
+// distinguishes instants in different locations.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC()
+	case []interface{}:
+		vec := make([]interface{}, len(v))
+		for i, elem := range v {
+			vec[i] = normalize(elem)
+		}
+		return vec
+	case map[interface{}]interface{}:
+		m := make(map[interface{}]interface{}, len(v))
+		for key, val := range v {
+			m[normalize(key)] = normalize(val)
+		}
+		return m
+	case map[interface{}]bool:
+		set := make(map[interface{}]bool, len(v))
+		for elem := range v {
+			set[normalize(elem)] = true
+		}
+		return set
+	case Tagged:
+		return Tagged{v.Tag, normalize(v.Value)}
+	default:
+		return v
+	}
+}
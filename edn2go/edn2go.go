@@ -0,0 +1,246 @@
+// Package edn2go generates Go struct definitions for EDN documents, as
+// a starting point for working with the data of Clojure APIs.
+//
+// The structs are generated from a schema, either inferred from sample
+// documents with ednschema.Infer or read with ednschema.Parse:
+//
+//	type User struct {
+//		Email *string     `edn:"email,omitempty"`
+//		Name  string      `edn:"name"`
+//		Role  edn.Keyword `edn:"role"` // one of :admin, :user
+//		Tags  []string    `edn:"tags"`
+//	}
+//
+// Maps with keyword keys become structs, nested ones named after their
+// parents, e.g. UserAddress for the :address of a User.  Other maps,
+// values of mixed types and unknown values are interface{}.  Optional
+// keys are omitempty, keys that may be nil have pointer types.
+package edn2go
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+
+	"github.com/heyLu/edn"
+	"github.com/heyLu/edn/ednkw"
+	"github.com/heyLu/edn/ednschema"
+)
+
+// Generate returns the formatted Go source of package pkg, declaring a
+// struct called name for the maps described by s.  s must describe
+// maps or vectors of maps, e.g. a file of records.
+func Generate(pkg, name string, s *ednschema.Schema) ([]byte, error) {
+	if !isStruct(s) && s.Elements != nil && isStruct(s.Elements) {
+		s = s.Elements
+	}
+	if !isStruct(s) {
+		return nil, fmt.Errorf("schema must describe maps with keyword keys, but was %s", s)
+	}
+
+	g := &generator{types: map[string]bool{}, imports: map[string]bool{}}
+	g.addStruct(name, s)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by edn2go. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for imp := range g.imports {
+			imports = append(imports, imp)
+		}
+		sort.Strings(imports)
+
+		fmt.Fprintf(&buf, "import (\n")
+		for _, imp := range imports {
+			fmt.Fprintf(&buf, "\t%q\n", imp)
+		}
+		fmt.Fprintf(&buf, ")\n\n")
+	}
+	buf.Write(g.buf.Bytes())
+
+	if g.err != nil {
+		return nil, g.err
+	}
+	return format.Source(buf.Bytes())
+}
+
+type generator struct {
+	buf     bytes.Buffer
+	types   map[string]bool
+	imports map[string]bool
+	err     error
+}
+
+// isStruct reports whether s describes maps that can be represented by
+// a struct, i.e. only maps with only keyword keys.
+func isStruct(s *ednschema.Schema) bool {
+	if len(nonNil(s.Types)) != 1 || nonNil(s.Types)[0] != ednschema.Map || len(s.Keys) == 0 {
+		return false
+	}
+
+	for key := range s.Keys {
+		if _, ok := key.(edn.Keyword); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func nonNil(types []ednschema.Type) []ednschema.Type {
+	res := make([]ednschema.Type, 0, len(types))
+	for _, t := range types {
+		if t != ednschema.Nil {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+type field struct {
+	name, typ, tag, comment string
+}
+
+func (g *generator) addStruct(name string, s *ednschema.Schema) {
+	if g.types[name] {
+		g.fail(fmt.Errorf("type %s is generated twice", name))
+		return
+	}
+	g.types[name] = true
+
+	kws := make([]edn.Keyword, 0, len(s.Keys))
+	for key := range s.Keys {
+		kws = append(kws, key.(edn.Keyword))
+	}
+	sort.Slice(kws, func(i, j int) bool { return kws[i].String() < kws[j].String() })
+
+	names := map[string]edn.Keyword{}
+	fields := make([]field, 0, len(kws))
+	for _, kw := range kws {
+		key := s.Keys[kw]
+
+		f := field{name: ednkw.Name(kw), tag: kw.FullName()}
+		if other, ok := names[f.name]; ok {
+			g.fail(fmt.Errorf("%s and %s are both named %s in %s", other, kw, f.name, name))
+			return
+		}
+		names[f.name] = kw
+
+		f.typ = g.typeOf(name+f.name, key.Schema)
+		if key.Optional {
+			f.tag += ",omitempty"
+		}
+		if len(key.Enum) > 0 {
+			enum := make([]string, len(key.Enum))
+			for i, kw := range key.Enum {
+				enum[i] = kw.String()
+			}
+			f.comment = " // one of " + strings.Join(enum, ", ")
+		}
+
+		fields = append(fields, f)
+	}
+
+	// nested structs are written before the struct using them, so they
+	// are generated first and the struct is written afterwards
+	fmt.Fprintf(&g.buf, "type %s struct {\n", name)
+	for _, f := range fields {
+		fmt.Fprintf(&g.buf, "\t%s %s `edn:%q`%s\n", f.name, f.typ, f.tag, f.comment)
+	}
+	fmt.Fprintf(&g.buf, "}\n\n")
+}
+
+// typeOf returns the Go type for values described by s, generating a
+// struct called name if they are maps.  Values that may be nil are
+// pointers, if their type can't be nil already.
+func (g *generator) typeOf(name string, s *ednschema.Schema) string {
+	types := nonNil(s.Types)
+	if len(types) != 1 {
+		return "interface{}"
+	}
+
+	typ := g.goType(name, types[0], s)
+	if len(types) < len(s.Types) && !nilable(typ) {
+		typ = "*" + typ
+	}
+
+	return typ
+}
+
+func (g *generator) goType(name string, t ednschema.Type, s *ednschema.Schema) string {
+	switch t {
+	case ednschema.Boolean:
+		return "bool"
+	case ednschema.Integer:
+		return "int64"
+	case ednschema.Float:
+		return "float64"
+	case ednschema.String:
+		return "string"
+	case ednschema.Keyword, ednschema.Symbol, ednschema.UUID, ednschema.Tagged:
+		g.imports["github.com/heyLu/edn"] = true
+		return "edn." + map[ednschema.Type]string{
+			ednschema.Keyword: "Keyword",
+			ednschema.Symbol:  "Symbol",
+			ednschema.UUID:    "UUID",
+			ednschema.Tagged:  "Tagged",
+		}[t]
+	case ednschema.Inst:
+		g.imports["time"] = true
+		return "time.Time"
+	case ednschema.BigInt:
+		g.imports["math/big"] = true
+		return "*big.Int"
+	case ednschema.Ratio:
+		g.imports["math/big"] = true
+		return "*big.Rat"
+	case ednschema.Vector:
+		if s.Elements == nil {
+			return "[]interface{}"
+		}
+		return "[]" + g.typeOf(name, s.Elements)
+	case ednschema.Set:
+		if s.Elements == nil {
+			return "map[interface{}]bool"
+		}
+		elem := g.typeOf(name, s.Elements)
+		if !comparable(elem) {
+			elem = "interface{}"
+		}
+		return "map[" + elem + "]bool"
+	case ednschema.Map:
+		if !isStruct(s) {
+			return "map[interface{}]interface{}"
+		}
+		g.addStruct(name, s)
+		return name
+	default:
+		return "interface{}"
+	}
+}
+
+// nilable reports whether values of the Go type typ can be nil.
+func nilable(typ string) bool {
+	return typ == "interface{}" || strings.HasPrefix(typ, "*") ||
+		strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[")
+}
+
+// comparable reports whether typ can be the key of a Go map, and
+// compares by value.
+func comparable(typ string) bool {
+	switch typ {
+	case "bool", "int64", "float64", "string", "edn.Keyword", "edn.Symbol", "edn.UUID":
+		return true
+	default:
+		return false
+	}
+}
+
+func (g *generator) fail(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
@@ -0,0 +1,88 @@
+package edn2go
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/heyLu/edn"
+	"github.com/heyLu/edn/ednschema"
+)
+
+func infer(t *testing.T, docs ...string) *ednschema.Schema {
+	samples := []interface{}{}
+	for _, doc := range docs {
+		val, err := edn.DecodeString(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		samples = append(samples, val)
+	}
+	return ednschema.Infer(samples...)
+}
+
+func TestGenerate(t *testing.T) {
+	s := infer(t,
+		`{:user/id #uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6" :name "jane" :role :admin
+		  :tags ["a"] :address {:city "Berlin" :zip 10115} :joined #inst "2020-01-01T00:00:00Z"}`,
+		`{:user/id #uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf7" :name "joe" :role :user :email nil
+		  :tags [] :address {:city "Paris"} :joined #inst "2021-01-01T00:00:00Z" :extra 1}`,
+		`{:user/id #uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf8" :name "ann" :role :user :email "ann@example.com"
+		  :tags ["b"] :address {:city "Rome" :zip nil} :joined #inst "2022-01-01T00:00:00Z" :extra "x"}`)
+
+	src, err := Generate("users", "User", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "// Code generated by edn2go. DO NOT EDIT.\n\n" +
+		"package users\n\n" +
+		"import (\n" +
+		"\t\"github.com/heyLu/edn\"\n" +
+		"\t\"time\"\n" +
+		")\n\n" +
+		"type UserAddress struct {\n" +
+		"\tCity string `edn:\"city\"`\n" +
+		"\tZip  *int64 `edn:\"zip,omitempty\"`\n" +
+		"}\n\n" +
+		"type User struct {\n" +
+		"\tAddress UserAddress `edn:\"address\"`\n" +
+		"\tEmail   *string     `edn:\"email,omitempty\"`\n" +
+		"\tExtra   interface{} `edn:\"extra,omitempty\"`\n" +
+		"\tJoined  time.Time   `edn:\"joined\"`\n" +
+		"\tName    string      `edn:\"name\"`\n" +
+		"\tRole    edn.Keyword `edn:\"role\"` // one of :admin, :user\n" +
+		"\tTags    []string    `edn:\"tags\"`\n" +
+		"\tUserID  edn.UUID    `edn:\"user/id\"`\n" +
+		"}\n"
+	if string(src) != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, src)
+	}
+}
+
+func TestGenerateRecords(t *testing.T) {
+	s := infer(t, `[{:id 1 :items [{:sku "a" :qty 2}] :labels #{:x :y}} {:id 2 :items [] :labels #{}}]`)
+
+	src, err := Generate("main", "Order", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, decl := range []string{
+		"type OrderItems struct {",
+		"\tQty int64  `edn:\"qty\"`",
+		"\tItems  []OrderItems",
+		"\tLabels map[edn.Keyword]bool",
+	} {
+		if !strings.Contains(string(src), decl) {
+			t.Errorf("expected %q in\n%s", decl, src)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, doc := range []string{`[1 2 3]`, `{"a" 1}`, `{:a-b 1 :a_b 2}`} {
+		if _, err := Generate("main", "T", infer(t, doc)); err == nil {
+			t.Errorf("expected error for %s", doc)
+		}
+	}
+}
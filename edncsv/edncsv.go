@@ -0,0 +1,224 @@
+// Package edncsv converts between vectors of EDN maps and CSV.
+//
+// Write writes records such as [{:id 1 :name "a"} {:id 2 :name "b"}]
+// as a CSV table with a header of the keys:
+//
+//	id,name
+//	1,a
+//	2,b
+//
+// Read reads such tables back.  CSV has no types, so the types of the
+// values are inferred unless they are given by a Schema.
+package edncsv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+// Write writes the records, a vector of maps with keyword or string
+// keys, to w.  The columns are sorted by key, see edn.Columns.
+//
+// Keywords are written with a leading colon, instants in RFC 3339
+// format, strings as they are, and missing values and nil as empty
+// cells.  Other values are written in EDN.
+func Write(w io.Writer, records interface{}) error {
+	columns, err := edn.Columns(records)
+	if err != nil {
+		return err
+	}
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		switch key := column.Key.(type) {
+		case edn.Keyword:
+			header[i] = key.FullName()
+		case string:
+			header[i] = key
+		default:
+			return fmt.Errorf("column key must be a keyword or string, but was %s", edn.String(key))
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	rows := 0
+	if len(columns) > 0 {
+		rows = len(columns[0].Values)
+	}
+
+	row := make([]string, len(columns))
+	for i := 0; i < rows; i++ {
+		for j, column := range columns {
+			row[j] = cell(column.Values[i])
+		}
+
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return edn.String(v)
+	}
+}
+
+// Schema maps column headers to the type of their values, one of
+// "string", "integer", "float", "boolean", "keyword", "inst", "uuid" or
+// "edn" for cells containing any EDN value.
+type Schema map[string]string
+
+// Read reads a CSV table with a header row written by Write, returning
+// a vector of maps keyed by keywords named like the columns.  Empty
+// cells are left out of the maps.
+//
+// The types of columns not in schema are inferred from each cell:
+// integers, floats, true and false, keywords and RFC 3339 instants are
+// read as such, everything else as strings.  schema may be nil.
+func Read(r io.Reader, schema Schema) ([]interface{}, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("missing header row")
+	} else if err != nil {
+		return nil, err
+	}
+
+	keys := make([]edn.Keyword, len(header))
+	for i, name := range header {
+		keys[i] = toKeyword(name)
+	}
+
+	records := []interface{}{}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		record := make(map[interface{}]interface{}, len(row))
+		for i, s := range row {
+			if s == "" {
+				continue
+			}
+
+			var val interface{}
+			if typ, ok := schema[header[i]]; ok {
+				val, err = parseCell(s, typ)
+			} else {
+				val = inferCell(s)
+			}
+			if err != nil {
+				line, _ := cr.FieldPos(i)
+				return nil, fmt.Errorf("line %d, column %q: %w", line, header[i], err)
+			}
+
+			record[keys[i]] = val
+		}
+		records = append(records, record)
+	}
+}
+
+func parseCell(s string, typ string) (interface{}, error) {
+	switch typ {
+	case "string":
+		return s, nil
+	case "inst":
+		return time.Parse(time.RFC3339Nano, s)
+	case "uuid":
+		return edn.ApplyTag(edn.Symbol{Namespace: "", Name: "uuid"}, s)
+	case "keyword":
+		s = strings.TrimPrefix(s, ":")
+		if !edn.IsValidKeyword(":" + s) {
+			return nil, fmt.Errorf("%q is not a keyword", s)
+		}
+		return toKeyword(s), nil
+	case "integer", "float", "boolean", "edn":
+		val, err := edn.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if typ == "edn" || typeOf(val) == typ {
+			return val, nil
+		}
+		if i, ok := val.(int64); ok && typ == "float" {
+			return float64(i), nil
+		}
+		return nil, fmt.Errorf("%q is not of type %s", s, typ)
+	default:
+		return nil, fmt.Errorf("unknown type %q", typ)
+	}
+}
+
+func inferCell(s string) interface{} {
+	if strings.HasPrefix(s, ":") {
+		if edn.IsValidKeyword(s) {
+			kw, _ := edn.DecodeString(s)
+			return kw
+		}
+		return s
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t
+	}
+
+	if s == "true" || s == "false" || strings.ContainsAny(s[:1], "0123456789+-") {
+		val, rest, err := edn.DecodeStringPrefix(s)
+		if err == nil && rest == "" {
+			switch val.(type) {
+			case bool, int64, float64, *big.Int, *big.Rat:
+				return val
+			}
+		}
+	}
+
+	return s
+}
+
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case int64:
+		return "integer"
+	case float64:
+		return "float"
+	case bool:
+		return "boolean"
+	default:
+		return ""
+	}
+}
+
+func toKeyword(s string) edn.Keyword {
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '/' {
+			return edn.Keyword{Namespace: s[:i], Name: s[i+1:]}
+		}
+	}
+
+	return edn.Keyword{Namespace: "", Name: s}
+}
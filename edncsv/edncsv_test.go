@@ -0,0 +1,81 @@
+package edncsv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/heyLu/edn"
+)
+
+func TestWriteRead(t *testing.T) {
+	records, err := edn.DecodeString(`[{:user/id 1 :name "Jane, \"JD\"" :score 1.5 :status :active :joined #inst "2020-01-02T03:04:05Z"}
+		{:user/id 2 :name "12" :tags #{:a} :status nil}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	if err := Write(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "joined,name,score,status,tags,user/id\n" +
+		"2020-01-02T03:04:05Z,\"Jane, \"\"JD\"\"\",1.5,:active,,1\n" +
+		",12,,,#{:a},2\n"
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+
+	back, err := Read(strings.NewReader(buf.String()), Schema{"name": "string", "tags": "edn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedBack, _ := edn.DecodeString(`[{:user/id 1 :name "Jane, \"JD\"" :score 1.5 :status :active :joined #inst "2020-01-02T03:04:05Z"}
+		{:user/id 2 :name "12" :tags #{:a}}]`)
+	if !edn.Equal(back, expectedBack) {
+		t.Errorf("expected %s, got %s", edn.String(expectedBack), edn.String(back))
+	}
+
+	inferred, err := Read(strings.NewReader(buf.String()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := inferred[1].(map[interface{}]interface{})
+	if row[edn.Keyword{Namespace: "", Name: "name"}] != int64(12) || row[edn.Keyword{Namespace: "", Name: "tags"}] != "#{:a}" {
+		t.Errorf("unexpected inferred values %s", edn.String(row))
+	}
+}
+
+func TestRead(t *testing.T) {
+	input := "a,b,c,d\n-1,+2.5,true,: \n12N,1/2,false,x:y\n"
+	records, err := Read(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, _ := edn.DecodeString(`[{:a -1 :b 2.5 :c true :d ": "} {:a 12N :b 1/2 :c false :d "x:y"}]`)
+	if !edn.Equal(records, expected) {
+		t.Errorf("expected %s, got %s", edn.String(expected), edn.String(records))
+	}
+
+	schemas := []Schema{{"a": "boolean"}, {"b": "integer"}, {"d": "keyword"}, {"a": "unknown"}, {"c": "inst"}}
+	for _, schema := range schemas {
+		if _, err := Read(strings.NewReader(input), schema); err == nil {
+			t.Errorf("%v: expected error", schema)
+		}
+	}
+
+	records, err = Read(strings.NewReader("n,k,u\n1,a,f81d4fae-7dec-11d0-a765-00a0c91e6bf6\n"), Schema{"n": "float", "k": "keyword", "u": "uuid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ = edn.DecodeString(`[{:n 1.0 :k :a :u #uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"}]`)
+	if !edn.Equal(records, expected) {
+		t.Errorf("expected %s, got %s", edn.String(expected), edn.String(records))
+	}
+
+	if _, err := Read(strings.NewReader(""), nil); err == nil {
+		t.Error("expected error for missing header")
+	}
+}
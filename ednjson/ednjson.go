@@ -0,0 +1,365 @@
+// Package ednjson converts between EDN values and JSON.
+//
+// ToJSON converts the values produced by the edn package to the values
+// encoding/json works with, FromJSON converts them back:
+//
+//   - nil, booleans, strings and floats are the same in both
+//   - integers become numbers, big integers json.Number
+//   - ratios become floats
+//   - keywords and symbols become strings, e.g. :user/id "user/id"
+//   - vectors, lists and sets become arrays
+//   - maps become objects, keyed by the names of keyword keys
+//   - instants and UUIDs become strings, other tagged values their
+//     value
+//
+// These conversions lose information, e.g. {:status :active} is read
+// back as {:status "active"}.  A Context describes the documents of a
+// known schema, so that they can be converted back and forth.  With
+// Context.Typed, values without a JSON representation are written as
+// JSON-LD style value objects instead, which FromJSON reads back:
+//
+//	{"@type": "keyword", "@value": "active"}
+//	{"@type": "inst", "@value": "1985-04-12T23:20:50.52Z"}
+package ednjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+// Context describes how the values of a schema are represented in
+// JSON.  The zero value converts as described in the package
+// documentation.
+type Context struct {
+	// Keys maps JSON object keys to the keywords they stand for, e.g.
+	// "userId" to :user/id.  Other object keys are read as keywords of
+	// the same name, "user/id" as :user/id.
+	Keys map[string]edn.Keyword
+
+	// Keywords contains the map keys whose values are keywords, which
+	// are written as strings, e.g. {:status :active} as
+	// {"status": "active"}.
+	Keywords map[edn.Keyword]bool
+
+	// Tags maps map keys to the tag of their values, which are written
+	// without the tag, e.g. with :created mapped to inst,
+	// {:created #inst "2020-01-01T00:00:00Z"} as
+	// {"created": "2020-01-01T00:00:00Z"}.  The values are read back
+	// using the tag readers of the edn package.
+	Tags map[edn.Keyword]edn.Symbol
+
+	// Typed makes ToJSON write keywords, symbols, sets, ratios and
+	// tagged values not described by Keywords or Tags as value objects
+	// with "@type" and "@value" keys.
+	Typed bool
+}
+
+// ToJSON converts v using the zero Context.
+func ToJSON(v interface{}) (interface{}, error) {
+	return (&Context{}).ToJSON(v)
+}
+
+// FromJSON converts v using the zero Context.
+func FromJSON(v interface{}) (interface{}, error) {
+	return (&Context{}).FromJSON(v)
+}
+
+// ToJSON converts the EDN value v to a value that can be written with
+// encoding/json.
+//
+// Map keys must be keywords or strings.  Infinite and NaN floats and
+// values of types the edn package does not produce result in an error.
+func (c *Context) ToJSON(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, bool, string, int64, int:
+		return v, nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("cannot convert %s to JSON", edn.String(v))
+		}
+		return v, nil
+	case *big.Int:
+		return json.Number(v.String()), nil
+	case *big.Rat:
+		if c.Typed {
+			return valueObject("ratio", v.String()), nil
+		}
+		f, _ := v.Float64()
+		return f, nil
+	case edn.Keyword:
+		if c.Typed {
+			return valueObject("keyword", v.FullName()), nil
+		}
+		return v.FullName(), nil
+	case edn.Symbol:
+		if c.Typed {
+			return valueObject("symbol", v.String()), nil
+		}
+		return v.String(), nil
+	case []interface{}:
+		return c.arrayToJSON(v)
+	case map[interface{}]bool:
+		elems := make([]interface{}, 0, len(v))
+		for elem := range v {
+			elems = append(elems, elem)
+		}
+		sort.Slice(elems, func(i, j int) bool { return edn.String(elems[i]) < edn.String(elems[j]) })
+
+		arr, err := c.arrayToJSON(elems)
+		if err != nil || !c.Typed {
+			return arr, err
+		}
+		return valueObject("set", arr), nil
+	case map[interface{}]interface{}:
+		return c.mapToJSON(v)
+	}
+
+	tag, val, ok := untag(v)
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %#v to JSON", v)
+	}
+
+	jval, err := c.ToJSON(val)
+	if err != nil || !c.Typed {
+		return jval, err
+	}
+
+	return valueObject(tag.String(), jval), nil
+}
+
+func (c *Context) arrayToJSON(v []interface{}) ([]interface{}, error) {
+	arr := make([]interface{}, len(v))
+	for i, elem := range v {
+		jval, err := c.ToJSON(elem)
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = jval
+	}
+
+	return arr, nil
+}
+
+func (c *Context) mapToJSON(m map[interface{}]interface{}) (map[string]interface{}, error) {
+	names := make(map[edn.Keyword]string, len(c.Keys))
+	for name, kw := range c.Keys {
+		names[kw] = name
+	}
+
+	obj := make(map[string]interface{}, len(m))
+	for key, val := range m {
+		var name string
+		switch key := key.(type) {
+		case edn.Keyword:
+			var ok bool
+			if name, ok = names[key]; !ok {
+				name = key.FullName()
+			}
+		case string:
+			name = key
+		default:
+			return nil, fmt.Errorf("object key must be a keyword or string, but was %s", edn.String(key))
+		}
+
+		if _, ok := obj[name]; ok {
+			return nil, fmt.Errorf("duplicate object key %q", name)
+		}
+
+		jval, err := c.entryToJSON(key, val)
+		if err != nil {
+			return nil, err
+		}
+		obj[name] = jval
+	}
+
+	return obj, nil
+}
+
+// entryToJSON converts the value of the map key key, as described by
+// Keywords and Tags.
+func (c *Context) entryToJSON(key, val interface{}) (interface{}, error) {
+	if kw, ok := key.(edn.Keyword); ok {
+		if c.Keywords[kw] {
+			if kwVal, ok := val.(edn.Keyword); ok {
+				return kwVal.FullName(), nil
+			}
+		}
+
+		if tag, ok := c.Tags[kw]; ok {
+			if valTag, raw, ok := untag(val); ok && valTag == tag {
+				val = raw
+			}
+		}
+	}
+
+	return c.ToJSON(val)
+}
+
+// untag returns the tag and the untagged value of the tagged values the
+// edn package produces.
+func untag(v interface{}) (edn.Symbol, interface{}, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return edn.Symbol{Namespace: "", Name: "inst"}, v.Format(time.RFC3339Nano), true
+	case edn.UUID:
+		return edn.Symbol{Namespace: "", Name: "uuid"}, v.String(), true
+	case edn.LocalDate:
+		return edn.Symbol{Namespace: "", Name: "local-date"}, v.String(), true
+	case edn.LocalTime:
+		return edn.Symbol{Namespace: "", Name: "local-time"}, v.String(), true
+	case edn.Tagged:
+		return v.Tag, v.Value, true
+	default:
+		return edn.Symbol{}, nil, false
+	}
+}
+
+func valueObject(typ string, val interface{}) map[string]interface{} {
+	return map[string]interface{}{"@type": typ, "@value": val}
+}
+
+// FromJSON converts a value read with encoding/json to an EDN value.
+//
+// Integral numbers are read as integers, also if they were decoded as
+// float64.  If the JSON was decoded with UseNumber, numbers without
+// fraction or exponent are read as integers and others as floats.
+// Value objects are read back regardless of Typed.
+func (c *Context) FromJSON(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, bool, string:
+		return v, nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			return int64(v), nil
+		}
+		return v, nil
+	case json.Number:
+		return fromNumber(v)
+	case []interface{}:
+		vec := make([]interface{}, len(v))
+		for i, elem := range v {
+			val, err := c.FromJSON(elem)
+			if err != nil {
+				return nil, err
+			}
+			vec[i] = val
+		}
+		return vec, nil
+	case map[string]interface{}:
+		if typ, ok := v["@type"].(string); ok && len(v) == 2 {
+			if val, ok := v["@value"]; ok {
+				return c.fromValueObject(typ, val)
+			}
+		}
+
+		m := make(map[interface{}]interface{}, len(v))
+		for name, jval := range v {
+			key, ok := c.Keys[name]
+			if !ok {
+				key = toKeyword(name)
+			}
+
+			val, err := c.fromJSONEntry(key, jval)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = val
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %#v from JSON", v)
+	}
+}
+
+func (c *Context) fromJSONEntry(key edn.Keyword, jval interface{}) (interface{}, error) {
+	if s, ok := jval.(string); ok && c.Keywords[key] {
+		return toKeyword(s), nil
+	}
+
+	val, err := c.FromJSON(jval)
+	if err != nil {
+		return nil, err
+	}
+
+	if tag, ok := c.Tags[key]; ok {
+		return edn.ApplyTag(tag, val)
+	}
+
+	return val, nil
+}
+
+func (c *Context) fromValueObject(typ string, jval interface{}) (interface{}, error) {
+	if s, ok := jval.(string); ok {
+		switch typ {
+		case "keyword":
+			return toKeyword(s), nil
+		case "symbol":
+			return toSymbol(s), nil
+		case "ratio":
+			r, ok := new(big.Rat).SetString(s)
+			if !ok {
+				return nil, fmt.Errorf("invalid ratio %q", s)
+			}
+			return r, nil
+		}
+	}
+
+	val, err := c.FromJSON(jval)
+	if err != nil {
+		return nil, err
+	}
+
+	if typ == "set" {
+		elems, ok := val.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("set value must be an array, but was %#v", jval)
+		}
+
+		set := make(map[interface{}]bool, len(elems))
+		for _, elem := range elems {
+			set[edn.KeyOf(elem)] = true
+		}
+		return set, nil
+	}
+
+	return edn.ApplyTag(toSymbol(typ), val)
+}
+
+func fromNumber(n json.Number) (interface{}, error) {
+	s := n.String()
+	if strings.ContainsAny(s, ".eE") {
+		return n.Float64()
+	}
+
+	if i, err := n.Int64(); err == nil {
+		return i, nil
+	}
+
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", s)
+	}
+	return i, nil
+}
+
+func toKeyword(s string) edn.Keyword {
+	sym := toSymbol(s)
+	return edn.Keyword{Namespace: sym.Namespace, Name: sym.Name}
+}
+
+func toSymbol(s string) edn.Symbol {
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '/' {
+			return edn.Symbol{Namespace: s[:i], Name: s[i+1:]}
+		}
+	}
+
+	return edn.Symbol{Namespace: "", Name: s}
+}
@@ -0,0 +1,131 @@
+package ednjson
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/heyLu/edn"
+)
+
+func TestToJSON(t *testing.T) {
+	val, err := edn.DecodeString(`{:user/id 12N :name "Jane" :status :active :roles #{:admin :dev}
+		:created #inst "2020-01-02T03:04:05Z" :ratio 1/4 :point #geo/point [1.5 2] "raw" nil}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jval, err := ToJSON(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := json.Marshal(jval)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"created":"2020-01-02T03:04:05Z","name":"Jane","point":[1.5,2],"ratio":0.25,"raw":null,"roles":["admin","dev"],"status":"active","user/id":12}`
+	if string(out) != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+
+	invalid := []interface{}{
+		math.NaN(),
+		map[interface{}]interface{}{int64(1): int64(2)},
+		map[interface{}]interface{}{edn.Keyword{Namespace: "", Name: "a"}: int64(1), "a": int64(2)},
+		struct{}{},
+	}
+	for _, v := range invalid {
+		if _, err := ToJSON(v); err == nil {
+			t.Errorf("%#v: expected error", v)
+		}
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	var jval interface{}
+	dec := json.NewDecoder(strings.NewReader(`{"a": [1, 2.5, 1e3, 123456789012345678901234567890], "b/c": {"d": null}}`))
+	dec.UseNumber()
+	if err := dec.Decode(&jval); err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := FromJSON(jval)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	if s := edn.String(val); s != `{:a [1 2.5 1000.0 `+n.String()+`N] :b/c {:d nil}}` {
+		t.Errorf("unexpected value %s", s)
+	}
+
+	if err := json.Unmarshal([]byte(`[1, 2.5, "s", true]`), &jval); err != nil {
+		t.Fatal(err)
+	}
+	val, err = FromJSON(jval)
+	if err != nil || !reflect.DeepEqual(val, []interface{}{int64(1), 2.5, "s", true}) {
+		t.Errorf("unexpected value %#v, %v", val, err)
+	}
+}
+
+func TestContext(t *testing.T) {
+	input := `{:user/id #uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6" :status :active
+		:created #inst "2020-01-02T03:04:05Z" :tags #{:a "b"} :sym my/sym :ratio 1/3
+		:point #geo/point [1.5 2] :nested [{:status :inactive}]}`
+	val, err := edn.DecodeString(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Context{
+		Keys:     map[string]edn.Keyword{"userId": {Namespace: "user", Name: "id"}},
+		Keywords: map[edn.Keyword]bool{{Namespace: "", Name: "status"}: true},
+		Tags:     map[edn.Keyword]edn.Symbol{{Namespace: "", Name: "created"}: {Namespace: "", Name: "inst"}},
+		Typed:    true,
+	}
+
+	jval, err := c.ToJSON(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := json.Marshal(jval)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{
+		`"userId":{"@type":"uuid","@value":"f81d4fae-7dec-11d0-a765-00a0c91e6bf6"}`,
+		`"status":"active"`,
+		`"created":"2020-01-02T03:04:05Z"`,
+		`"sym":{"@type":"symbol","@value":"my/sym"}`,
+		`"ratio":{"@type":"ratio","@value":"1/3"}`,
+		`"point":{"@type":"geo/point","@value":[1.5,2]}`,
+		`"tags":{"@type":"set","@value":["b",{"@type":"keyword","@value":"a"}]}`,
+	} {
+		if !strings.Contains(string(out), s) {
+			t.Errorf("expected %s in %s", s, out)
+		}
+	}
+
+	var read interface{}
+	dec := json.NewDecoder(strings.NewReader(string(out)))
+	dec.UseNumber()
+	if err := dec.Decode(&read); err != nil {
+		t.Fatal(err)
+	}
+
+	back, err := c.FromJSON(read)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !edn.Equal(back, val) {
+		t.Errorf("expected %s, got %s", edn.String(val), edn.String(back))
+	}
+}
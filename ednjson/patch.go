@@ -0,0 +1,87 @@
+package ednjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/heyLu/edn"
+)
+
+// Patch writes changes as a JSON Patch (RFC 6902) using the zero
+// Context.
+func Patch(changes []edn.Change) ([]byte, error) {
+	return (&Context{}).Patch(changes)
+}
+
+// Patch writes changes, as returned by edn.Diff, as a JSON Patch
+// (RFC 6902) for the JSON representation of the values:
+//
+//	[{"op":"replace","path":"/port","value":8080}]
+//
+// Elements added to sets are appended to the arrays they are written
+// as.  Removing set elements cannot be expressed, as the index of the
+// elements in the arrays is not known, and results in an error.
+func (c *Context) Patch(changes []edn.Change) ([]byte, error) {
+	names := make(map[edn.Keyword]string, len(c.Keys))
+	for name, kw := range c.Keys {
+		names[kw] = name
+	}
+
+	ops := make([]map[string]interface{}, 0, len(changes))
+	for _, change := range changes {
+		path := change.Path
+		if change.SetElement {
+			if change.Op == "remove" {
+				return nil, fmt.Errorf("cannot remove set element %s in a JSON Patch", edn.String(change.Old))
+			}
+			path = path[:len(path)-1]
+		}
+
+		var sb strings.Builder
+		for _, elem := range path {
+			sb.WriteByte('/')
+			switch elem := elem.(type) {
+			case edn.Keyword:
+				name, ok := names[elem]
+				if !ok {
+					name = elem.FullName()
+				}
+				sb.WriteString(escapePointer(name))
+			case string:
+				sb.WriteString(escapePointer(elem))
+			case int:
+				sb.WriteString(strconv.Itoa(elem))
+			default:
+				return nil, fmt.Errorf("cannot write path element %s in a JSON pointer", edn.String(elem))
+			}
+		}
+		if change.SetElement {
+			sb.WriteString("/-")
+		}
+
+		op := map[string]interface{}{"op": change.Op, "path": sb.String()}
+		if change.Op != "remove" {
+			var key interface{}
+			if len(path) > 0 && !change.SetElement {
+				key = path[len(path)-1]
+			}
+
+			val, err := c.entryToJSON(key, change.New)
+			if err != nil {
+				return nil, err
+			}
+			op["value"] = val
+		}
+		ops = append(ops, op)
+	}
+
+	return json.Marshal(ops)
+}
+
+// escapePointer escapes a reference token of a JSON pointer (RFC 6901).
+func escapePointer(s string) string {
+	s = strings.ReplaceAll(s, "~", "~0")
+	return strings.ReplaceAll(s, "/", "~1")
+}
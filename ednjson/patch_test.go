@@ -0,0 +1,43 @@
+package ednjson
+
+import (
+	"testing"
+
+	"github.com/heyLu/edn"
+)
+
+func TestPatch(t *testing.T) {
+	a, err := edn.DecodeString(`{:port 80 :hosts ["a" "b"] :status :active :tags #{:x} "a/b" 1 :debug true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := edn.DecodeString(`{:port 8080 :hosts ["a"] :status :inactive :tags #{:x :y} "a/b" 2}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Context{Keywords: map[edn.Keyword]bool{{Namespace: "", Name: "status"}: true}}
+	out, err := c.Patch(edn.Diff(a, b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[{"op":"replace","path":"/a~1b","value":2},` +
+		`{"op":"remove","path":"/debug"},` +
+		`{"op":"remove","path":"/hosts/1"},` +
+		`{"op":"replace","path":"/port","value":8080},` +
+		`{"op":"replace","path":"/status","value":"inactive"},` +
+		`{"op":"add","path":"/tags/-","value":"y"}]`
+	if string(out) != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+
+	if _, err := Patch(edn.Diff(b, a)); err == nil {
+		t.Error("expected error for removing a set element")
+	}
+
+	changes := edn.Diff(map[interface{}]interface{}{int64(1): "a"}, map[interface{}]interface{}{int64(1): "b"})
+	if _, err := Patch(changes); err == nil {
+		t.Error("expected error for an integer map key")
+	}
+}
@@ -0,0 +1,111 @@
+// Package ednkw generates Go variables for keywords, so that code
+// working with decoded documents can refer to UserID instead of
+// repeating edn.Keyword{Name: "user-id"} literals.
+//
+// The keywords can be collected from sample documents with Keywords,
+// Generate then writes the Go source for them:
+//
+//	var (
+//		UserID   = edn.Keyword{Namespace: "", Name: "user-id"}
+//		UserName = edn.Keyword{Namespace: "user", Name: "name"}
+//	)
+//
+// Keywords are structs, so they are variables instead of constants.
+// The ednkw command does the same for EDN files, e.g. in a
+// go:generate directive.
+package ednkw
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/heyLu/edn"
+)
+
+// Keywords returns the keywords used in the decoded samples, sorted by
+// their string representation.
+func Keywords(samples ...interface{}) []edn.Keyword {
+	seen := map[edn.Keyword]bool{}
+	for _, sample := range samples {
+		for kw := range edn.IndexKeywords(sample) {
+			seen[kw] = true
+		}
+	}
+
+	kws := make([]edn.Keyword, 0, len(seen))
+	for kw := range seen {
+		kws = append(kws, kw)
+	}
+	sort.Slice(kws, func(i, j int) bool {
+		return kws[i].String() < kws[j].String()
+	})
+
+	return kws
+}
+
+// Generate returns the formatted Go source of package pkg, declaring a
+// variable for each of the keywords.  The names are the camel-cased
+// namespace and name, e.g. UserName for :user/name.  It is an error if
+// two keywords result in the same name.
+func Generate(pkg string, kws []edn.Keyword) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by ednkw. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import \"github.com/heyLu/edn\"\n\n")
+
+	names := map[string]edn.Keyword{}
+	fmt.Fprintf(&buf, "var (\n")
+	for _, kw := range kws {
+		name := Name(kw)
+		if other, ok := names[name]; ok && other != kw {
+			return nil, fmt.Errorf("%s and %s are both named %s", other, kw, name)
+		} else if ok {
+			continue
+		}
+		names[name] = kw
+
+		fmt.Fprintf(&buf, "\t%s = edn.Keyword{Namespace: %q, Name: %q}\n", name, kw.Namespace, kw.Name)
+	}
+	fmt.Fprintf(&buf, ")\n")
+
+	return format.Source(buf.Bytes())
+}
+
+// initialisms are written in upper case in names, as is usual in Go.
+var initialisms = map[string]bool{
+	"API": true, "EDN": true, "HTML": true, "HTTP": true, "ID": true,
+	"JSON": true, "SQL": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// Name returns the Go name of kw, see Generate.  Characters that can't
+// appear in Go identifiers separate words, names starting with a digit
+// are prefixed with K.
+func Name(kw edn.Keyword) string {
+	words := strings.FieldsFunc(kw.Namespace+"/"+kw.Name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, word := range words {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+
+		r, n := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(word[n:])
+	}
+
+	name := b.String()
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(r) {
+		name = "K" + name
+	}
+
+	return name
+}
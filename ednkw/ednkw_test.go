@@ -0,0 +1,59 @@
+package ednkw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/heyLu/edn"
+)
+
+func TestName(t *testing.T) {
+	names := map[edn.Keyword]string{
+		{Name: "user-id"}:                  "UserID",
+		{Namespace: "user", Name: "name"}:  "UserName",
+		{Namespace: "my.app", Name: "url"}: "MyAppURL",
+		{Name: "valid?"}:                   "Valid",
+		{Name: "a_b*c"}:                    "ABC",
+		{Name: "2fa"}:                      "K2fa",
+		{Name: "über"}:                     "Über",
+	}
+
+	for kw, expected := range names {
+		if name := Name(kw); name != expected {
+			t.Errorf("%s: expected %s, got %s", kw, expected, name)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	doc, err := edn.DecodeString(`{:user/name "Jane" :user-id 1 :roles #{:admin} :user/name? true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kws := Keywords(doc, []interface{}{edn.Keyword{Name: "admin"}})
+	expected := []edn.Keyword{{Name: "admin"}, {Name: "roles"}, {Name: "user-id"}, {Namespace: "user", Name: "name"}, {Namespace: "user", Name: "name?"}}
+	if !reflect.DeepEqual(kws, expected) {
+		t.Fatalf("unexpected keywords %v", kws)
+	}
+
+	if _, err := Generate("kw", kws); err == nil || !strings.Contains(err.Error(), "UserName") {
+		t.Errorf("expected error for duplicate name, got %v", err)
+	}
+
+	src, err := Generate("kw", kws[:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"package kw\n",
+		"\tAdmin    = edn.Keyword{Namespace: \"\", Name: \"admin\"}\n",
+		"\tUserName = edn.Keyword{Namespace: \"user\", Name: \"name\"}\n",
+	} {
+		if !strings.Contains(string(src), line) {
+			t.Errorf("expected %q in:\n%s", line, src)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+// Package ednlint checks the keywords of EDN documents against rules,
+// e.g. to catch typos and outdated keys in configuration files.
+//
+// The rules are written in EDN themselves:
+//
+//	{:schema {:types #{:map}
+//	          :keys {:port {:types #{:integer}}
+//	                 :db/uri {:types #{:string}}}}
+//	 :deprecated {:db/url "use :db/uri"}
+//	 :namespaces "^(db|app)(\\.|$)"
+//	 :ranges {:port [1 65535]}}
+//
+// :schema reports map keys not described by an ednschema schema,
+// :deprecated reports keywords with the given explanations, :namespaces
+// reports keywords whose namespace does not match a regular expression,
+// and :ranges reports values of the given keys that are not numbers
+// within the bounds.  All rules are optional.  The ednlint command
+// checks EDN files with rules from a file.
+package ednlint
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+
+	"github.com/heyLu/edn"
+	"github.com/heyLu/edn/ednschema"
+)
+
+// Rules are the rules documents are checked against.
+type Rules struct {
+	// Schema describes the known map keys, if set.
+	Schema *ednschema.Schema
+
+	// Deprecated maps deprecated keywords to explanations.
+	Deprecated map[edn.Keyword]string
+
+	// Namespaces matches the allowed namespaces of qualified keywords,
+	// if set.  Keywords without a namespace are always allowed.
+	Namespaces *regexp.Regexp
+
+	// Ranges maps keys to the bounds of their values.
+	Ranges map[edn.Keyword]Range
+}
+
+// Range are the inclusive bounds of a number.
+type Range struct {
+	Min, Max float64
+}
+
+// Finding is a violation of a rule.
+type Finding struct {
+	// Path consists of the map keys, vector/list indices and set
+	// elements leading to the offending value, as in edn.KeywordIndex.
+	Path []interface{}
+
+	// Rule is the rule that was violated: "unknown-key", "deprecated",
+	// "namespace" or "range".
+	Rule    string
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s", edn.String(f.Path), f.Message)
+}
+
+// Parse returns the rules described by v, a map of the form shown in
+// the package documentation.
+func Parse(v interface{}) (*Rules, error) {
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("rules must be a map, but was %s", edn.String(v))
+	}
+
+	r := &Rules{}
+	for key, val := range m {
+		kw, ok := key.(edn.Keyword)
+		if !ok || kw.Namespace != "" {
+			return nil, fmt.Errorf("unknown rule %s", edn.String(key))
+		}
+
+		switch kw.Name {
+		case "schema":
+			s, err := ednschema.Parse(val)
+			if err != nil {
+				return nil, fmt.Errorf(":schema: %w", err)
+			}
+			r.Schema = s
+		case "deprecated":
+			deprecated, ok := val.(map[interface{}]interface{})
+			if !ok {
+				return nil, fmt.Errorf(":deprecated must be a map, but was %s", edn.String(val))
+			}
+			r.Deprecated = make(map[edn.Keyword]string, len(deprecated))
+			for key, reason := range deprecated {
+				kw, ok := key.(edn.Keyword)
+				if !ok {
+					return nil, fmt.Errorf(":deprecated must contain keywords, but contains %s", edn.String(key))
+				}
+				s, ok := reason.(string)
+				if !ok {
+					return nil, fmt.Errorf(":deprecated explanation of %s must be a string, but was %s", kw, edn.String(reason))
+				}
+				r.Deprecated[kw] = s
+			}
+		case "namespaces":
+			s, ok := val.(string)
+			if !ok {
+				return nil, fmt.Errorf(":namespaces must be a string, but was %s", edn.String(val))
+			}
+			re, err := regexp.Compile(s)
+			if err != nil {
+				return nil, fmt.Errorf(":namespaces: %w", err)
+			}
+			r.Namespaces = re
+		case "ranges":
+			ranges, ok := val.(map[interface{}]interface{})
+			if !ok {
+				return nil, fmt.Errorf(":ranges must be a map, but was %s", edn.String(val))
+			}
+			r.Ranges = make(map[edn.Keyword]Range, len(ranges))
+			for key, bounds := range ranges {
+				kw, ok := key.(edn.Keyword)
+				if !ok {
+					return nil, fmt.Errorf(":ranges must contain keywords, but contains %s", edn.String(key))
+				}
+				rng, err := parseRange(bounds)
+				if err != nil {
+					return nil, fmt.Errorf("range of %s: %w", kw, err)
+				}
+				r.Ranges[kw] = rng
+			}
+		default:
+			return nil, fmt.Errorf("unknown rule %s", edn.String(key))
+		}
+	}
+
+	return r, nil
+}
+
+func parseRange(v interface{}) (Range, error) {
+	bounds, ok := v.([]interface{})
+	if !ok || len(bounds) != 2 {
+		return Range{}, fmt.Errorf("must be [min max], but was %s", edn.String(v))
+	}
+
+	min, ok1 := number(bounds[0])
+	max, ok2 := number(bounds[1])
+	if !ok1 || !ok2 || min > max {
+		return Range{}, fmt.Errorf("must be [min max], but was %s", edn.String(v))
+	}
+
+	return Range{Min: min, Max: max}, nil
+}
+
+// number returns v as a float64, if it is a number.
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f, true
+	case *big.Rat:
+		f, _ := v.Float64()
+		return f, true
+	default:
+		return 0, false
+	}
+}
+
+// Lint returns the findings for the decoded document v, sorted by
+// path.
+func (r *Rules) Lint(v interface{}) []Finding {
+	l := &linter{rules: r}
+	l.walk(v, nil, r.Schema)
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		return edn.String(l.findings[i].Path) < edn.String(l.findings[j].Path)
+	})
+
+	return l.findings
+}
+
+type linter struct {
+	rules    *Rules
+	findings []Finding
+}
+
+func (l *linter) report(path []interface{}, rule, format string, args ...interface{}) {
+	l.findings = append(l.findings, Finding{
+		Path:    append([]interface{}{}, path...),
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// walk checks v at path, which is described by s if it is not nil.
+func (l *linter) walk(v interface{}, path []interface{}, s *ednschema.Schema) {
+	switch v := v.(type) {
+	case edn.Keyword:
+		l.checkKeyword(v, path)
+	case []interface{}:
+		for i, elem := range v {
+			l.walk(elem, append(path, i), elements(s))
+		}
+	case map[interface{}]bool:
+		for elem := range v {
+			l.walk(key(elem), append(path, elem), elements(s))
+		}
+	case map[interface{}]interface{}:
+		for k, val := range v {
+			entryPath := append(path, k)
+			l.walk(key(k), entryPath, nil)
+
+			var valSchema *ednschema.Schema
+			if s != nil && len(s.Keys) > 0 {
+				known, ok := s.Keys[k]
+				if !ok {
+					l.report(entryPath, "unknown-key", "unknown key %s", edn.String(k))
+				} else {
+					valSchema = known.Schema
+				}
+			}
+
+			if kw, ok := k.(edn.Keyword); ok {
+				l.checkRange(kw, val, entryPath)
+			}
+
+			l.walk(val, entryPath, valSchema)
+		}
+	case edn.Tagged:
+		l.walk(v.Value, path, nil)
+	}
+}
+
+func (l *linter) checkKeyword(kw edn.Keyword, path []interface{}) {
+	if reason, ok := l.rules.Deprecated[kw]; ok {
+		l.report(path, "deprecated", "%s is deprecated: %s", kw, reason)
+	}
+
+	if l.rules.Namespaces != nil && kw.Namespace != "" && !l.rules.Namespaces.MatchString(kw.Namespace) {
+		l.report(path, "namespace", "namespace of %s does not match %s", kw, l.rules.Namespaces)
+	}
+}
+
+func (l *linter) checkRange(kw edn.Keyword, val interface{}, path []interface{}) {
+	rng, ok := l.rules.Ranges[kw]
+	if !ok {
+		return
+	}
+
+	f, ok := number(val)
+	if !ok {
+		l.report(path, "range", "value %s of %s is not a number", edn.String(val), kw)
+	} else if f < rng.Min || f > rng.Max {
+		l.report(path, "range", "value %s of %s is not within [%g %g]", edn.String(val), kw, rng.Min, rng.Max)
+	}
+}
+
+// elements returns the schema of the elements of s, if any.
+func elements(s *ednschema.Schema) *ednschema.Schema {
+	if s == nil {
+		return nil
+	}
+	return s.Elements
+}
+
+// key returns the value of a map key or set element, which is decoded
+// if it is an edn.CompositeKey.
+func key(k interface{}) interface{} {
+	if ck, ok := k.(edn.CompositeKey); ok {
+		if val, err := ck.Value(); err == nil {
+			return val
+		}
+	}
+	return k
+}
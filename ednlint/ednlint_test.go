@@ -0,0 +1,90 @@
+package ednlint
+
+import (
+	"testing"
+
+	"github.com/heyLu/edn"
+)
+
+const rulesEDN = `{:schema {:types #{:map}
+                 :keys {:port {:types #{:integer}}
+                        :db/url {:types #{:string}}
+                        :servers {:types #{:vector}
+                                  :elements {:types #{:map}
+                                             :keys {:host {:types #{:string}}
+                                                    :port {:types #{:integer}}}}}}}
+       :deprecated {:db/url "use :db/uri"}
+       :namespaces "^(db|app)(\\.|$)"
+       :ranges {:port [1 65535]}}`
+
+func parseRules(t *testing.T) *Rules {
+	val, err := edn.DecodeString(rulesEDN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Parse(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestLint(t *testing.T) {
+	r := parseRules(t)
+
+	doc, err := edn.DecodeString(`{:port 8080
+	                               :db/url "postgres://localhost"
+	                               :prot 80
+	                               :servers [{:host "a" :port 70000}
+	                                         {:host "b" :port "80" :mode :other.ns/fast}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`[:db/url]: :db/url is deprecated: use :db/uri`,
+		`[:prot]: unknown key :prot`,
+		`[:servers 0 :port]: value 70000 of :port is not within [1 65535]`,
+		`[:servers 1 :mode]: unknown key :mode`,
+		`[:servers 1 :mode]: namespace of :other.ns/fast does not match ^(db|app)(\.|$)`,
+		`[:servers 1 :port]: value "80" of :port is not a number`,
+	}
+
+	findings := r.Lint(doc)
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %q, got %v", expected, findings)
+	}
+	for i, f := range findings {
+		if f.String() != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], f)
+		}
+	}
+
+	if findings := (&Rules{}).Lint(doc); len(findings) != 0 {
+		t.Errorf("expected no findings without rules, got %v", findings)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, invalid := range []string{
+		`[]`,
+		`{:unknown 1}`,
+		`{:schema {:types #{:nope}}}`,
+		`{:deprecated {"a" "b"}}`,
+		`{:deprecated {:a 1}}`,
+		`{:namespaces "("}`,
+		`{:ranges {:port [1]}}`,
+		`{:ranges {:port [10 1]}}`,
+		`{:ranges {:port ["1" 2]}}`,
+	} {
+		val, err := edn.DecodeString(invalid)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Parse(val); err == nil {
+			t.Errorf("%s: expected error", invalid)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+// Package ednlog implements an append-only log of EDN values, e.g. for
+// event sourcing.
+//
+// A log is a directory of segment files, which are written in order and
+// never modified afterwards.  A new segment is started when the current
+// one reaches Options.MaxSize.  Each value is a record of its own: by
+// default a line of EDN text, so that segments are plain EDN files, or,
+// with Options.Framed, the text preceded by its length and CRC-32, so
+// that corrupted records are detected.
+//
+// A Reader reads the values in the order they were appended, and can
+// continue reading a log while it is written, see Tail.  Follow does
+// the same for a single EDN file written by other programs.
+package ednlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+// SyncPolicy determines when appended records are written to stable
+// storage with fsync.
+type SyncPolicy int
+
+const (
+	// SyncNever leaves it to the operating system, records are only
+	// synced when a segment is closed.
+	SyncNever SyncPolicy = iota
+
+	// SyncAlways syncs after every record.
+	SyncAlways
+
+	// SyncInterval syncs when a record is appended at least
+	// Options.SyncInterval after the previous sync.
+	SyncInterval
+)
+
+// Options configures a log.  Writers and readers of a log must use the
+// same Framed option.
+type Options struct {
+	// Framed writes each record as a 4 byte length and a 4 byte IEEE
+	// CRC-32 of the EDN text, both big-endian, followed by the text.
+	Framed bool
+
+	// MaxSize is the size in bytes at which a new segment is started,
+	// no segments are started if it is 0.  Records are never split, so
+	// segments may be larger.
+	MaxSize int64
+
+	Sync         SyncPolicy
+	SyncInterval time.Duration
+}
+
+func (o Options) ext() string {
+	if o.Framed {
+		return ".ednlog"
+	}
+	return ".edn"
+}
+
+// segments returns the numbers of the segments in dir, in order.
+func (o Options) segments(dir string) ([]int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var nums []int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, o.ext()) {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSuffix(name, o.ext()), 10, 64)
+		if err != nil {
+			continue
+		}
+		nums = append(nums, n)
+	}
+	sort.Slice(nums, func(i, j int) bool { return nums[i] < nums[j] })
+
+	return nums, nil
+}
+
+func (o Options) path(dir string, n int64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", n, o.ext()))
+}
+
+func (o Options) record(v interface{}) []byte {
+	text := edn.String(v)
+	if !o.Framed {
+		return []byte(text + "\n")
+	}
+
+	rec := make([]byte, 8, 8+len(text))
+	binary.BigEndian.PutUint32(rec, uint32(len(text)))
+	binary.BigEndian.PutUint32(rec[4:], crc32.ChecksumIEEE([]byte(text)))
+	return append(rec, text...)
+}
+
+var errChecksum = errors.New("checksum mismatch")
+
+// readRecord reads the EDN text of the next record.  It returns
+// io.EOF if there is no complete record, io.ErrUnexpectedEOF if the
+// record is incomplete.
+func (o Options) readRecord(r *bufio.Reader) ([]byte, error) {
+	if !o.Framed {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF && len(line) > 0 {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
+		}
+		return line[:len(line)-1], nil
+	}
+
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	// the text is not allocated up front, a corrupted length could be
+	// arbitrarily large
+	var text bytes.Buffer
+	if _, err := io.CopyN(&text, r, int64(binary.BigEndian.Uint32(header[:]))); err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(text.Bytes()) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, errChecksum
+	}
+
+	return text.Bytes(), nil
+}
+
+// Writer appends values to a log.
+type Writer struct {
+	dir  string
+	opts Options
+
+	f        *os.File
+	segment  int64
+	size     int64
+	lastSync time.Time
+}
+
+// Open opens the log in dir for appending, creating dir if necessary.
+//
+// Writing continues in the last segment.  An incomplete or corrupted
+// record at its end, e.g. after a crash, is removed, as are all records
+// following it.  Only one Writer may write to a log at a time.
+func Open(dir string, opts Options) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	segments, err := opts.segments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{dir: dir, opts: opts, segment: 1, lastSync: time.Now()}
+	if len(segments) > 0 {
+		w.segment = segments[len(segments)-1]
+	}
+
+	f, err := os.OpenFile(opts.path(dir, w.segment), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := opts.validSize(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	w.f = f
+	w.size = size
+	return w, nil
+}
+
+// validSize returns the size of the complete and valid records at the
+// start of f.
+func (o Options) validSize(f *os.File) (int64, error) {
+	r := bufio.NewReader(f)
+
+	var size int64
+	for {
+		text, err := o.readRecord(r)
+		if err == io.EOF {
+			return size, nil
+		} else if err != nil {
+			if err == io.ErrUnexpectedEOF || err == errChecksum {
+				return size, nil
+			}
+			return 0, err
+		}
+
+		size += int64(len(text)) + 1
+		if o.Framed {
+			size += 7
+		}
+	}
+}
+
+// Append appends v to the log, syncing it according to the policy.
+func (w *Writer) Append(v interface{}) error {
+	rec := w.opts.record(v)
+
+	if w.opts.MaxSize > 0 && w.size > 0 && w.size+int64(len(rec)) > w.opts.MaxSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.f.Write(rec)
+	w.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	switch w.opts.Sync {
+	case SyncAlways:
+		return w.Sync()
+	case SyncInterval:
+		if time.Since(w.lastSync) >= w.opts.SyncInterval {
+			return w.Sync()
+		}
+	}
+
+	return nil
+}
+
+func (w *Writer) rotate() error {
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(w.opts.path(w.dir, w.segment+1), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	w.f = f
+	w.segment++
+	w.size = 0
+	return nil
+}
+
+// Sync writes the appended records to stable storage.
+func (w *Writer) Sync() error {
+	w.lastSync = time.Now()
+	return w.f.Sync()
+}
+
+// Close syncs and closes the current segment.
+func (w *Writer) Close() error {
+	err := w.f.Sync()
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// Reader reads the values of a log.
+type Reader struct {
+	dir  string
+	opts Options
+
+	f       *os.File
+	r       *bufio.Reader
+	segment int64
+	offset  int64
+}
+
+// NewReader returns a reader for the log in dir, starting at its first
+// value.
+func NewReader(dir string, opts Options) *Reader {
+	return &Reader{dir: dir, opts: opts}
+}
+
+// Next returns the next value of the log.  It returns io.EOF at the end
+// of the log, after which it may be called again to read the values
+// appended in the meantime.
+func (r *Reader) Next() (interface{}, error) {
+	for {
+		if r.f == nil {
+			ok, err := r.openNext()
+			if err != nil || !ok {
+				return nil, err
+			}
+		}
+
+		text, recErr := r.opts.readRecord(r.r)
+		if recErr == nil {
+			r.offset += int64(len(text)) + 1
+			if r.opts.Framed {
+				r.offset += 7
+			}
+
+			val, err := edn.DecodeString(string(text))
+			if err != nil {
+				return nil, fmt.Errorf("%s at offset %d: %w", r.f.Name(), r.offset, err)
+			}
+			return val, nil
+		} else if recErr != io.EOF && recErr != io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("%s at offset %d: %w", r.f.Name(), r.offset, recErr)
+		}
+
+		// The record may still be written, so the next call reads it
+		// again.  Only if there is a later segment, this one is done.
+		if _, err := r.f.Seek(r.offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		r.r.Reset(r.f)
+
+		later, err := r.laterSegment()
+		if err != nil {
+			return nil, err
+		}
+		if !later {
+			return nil, io.EOF
+		}
+		if recErr == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("%s at offset %d: incomplete record", r.f.Name(), r.offset)
+		}
+
+		r.f.Close()
+		r.f = nil
+	}
+}
+
+// openNext opens the segment following the current one.  It returns
+// io.EOF if there is none yet.
+func (r *Reader) openNext() (bool, error) {
+	segments, err := r.opts.segments(r.dir)
+	if os.IsNotExist(err) {
+		return false, io.EOF
+	} else if err != nil {
+		return false, err
+	}
+
+	for _, n := range segments {
+		if n <= r.segment {
+			continue
+		}
+
+		f, err := os.Open(r.opts.path(r.dir, n))
+		if err != nil {
+			return false, err
+		}
+
+		r.f = f
+		r.r = bufio.NewReader(f)
+		r.segment = n
+		r.offset = 0
+		return true, nil
+	}
+
+	return false, io.EOF
+}
+
+func (r *Reader) laterSegment() (bool, error) {
+	segments, err := r.opts.segments(r.dir)
+	if err != nil {
+		return false, err
+	}
+
+	return len(segments) > 0 && segments[len(segments)-1] > r.segment, nil
+}
+
+// Close closes the reader.
+func (r *Reader) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Close()
+}
+
+// Tail calls fn with the values of the log as they are appended,
+// checking for new values every interval.  It returns when fn returns
+// an error, reading fails or ctx is done.
+func (r *Reader) Tail(ctx context.Context, interval time.Duration, fn func(interface{}) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		val, err := r.Next()
+		if err == nil {
+			if err := fn(val); err != nil {
+				return err
+			}
+			continue
+		} else if err != io.EOF {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
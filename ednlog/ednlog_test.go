@@ -0,0 +1,220 @@
+package ednlog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+func readAll(t *testing.T, r *Reader) []interface{} {
+	t.Helper()
+	var vals []interface{}
+	for {
+		val, err := r.Next()
+		if err == io.EOF {
+			return vals
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		vals = append(vals, val)
+	}
+}
+
+func TestAppendRead(t *testing.T) {
+	for _, framed := range []bool{false, true} {
+		dir := t.TempDir()
+		opts := Options{Framed: framed, MaxSize: 40, Sync: SyncAlways}
+
+		w, err := Open(dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := NewReader(dir, opts)
+		defer r.Close()
+
+		var expected []interface{}
+		for i := 0; i < 10; i++ {
+			val := map[interface{}]interface{}{
+				edn.Keyword{Namespace: "event", Name: "id"}:   int64(i),
+				edn.Keyword{Namespace: "event", Name: "text"}: "line\nbreak",
+			}
+			if err := w.Append(val); err != nil {
+				t.Fatal(err)
+			}
+			expected = append(expected, val)
+
+			if i == 4 {
+				if vals := readAll(t, r); !edn.Equal(vals, expected) {
+					t.Errorf("framed=%v: expected %s, got %s", framed, edn.String(expected), edn.String(vals))
+				}
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if vals := readAll(t, r); !edn.Equal(vals, expected[5:]) {
+			t.Errorf("framed=%v: expected %s, got %s", framed, edn.String(expected[5:]), edn.String(vals))
+		}
+
+		segments, err := opts.segments(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(segments) != 10 {
+			t.Errorf("framed=%v: expected 10 segments, got %d", framed, len(segments))
+		}
+
+		w, err = Open(dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Append("more"); err != nil {
+			t.Fatal(err)
+		}
+		w.Close()
+
+		if vals := readAll(t, NewReader(dir, opts)); len(vals) != 11 || vals[10] != "more" {
+			t.Errorf("framed=%v: unexpected values after reopening %s", framed, edn.String(vals))
+		}
+	}
+}
+
+func TestSegmentsAreEDN(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Append([]interface{}{int64(1), "two"})
+	w.Append(edn.Keyword{Namespace: "", Name: "three"})
+	w.Close()
+
+	f, err := edn.OpenReader(filepath.Join(dir, "00000000000000000001.edn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	vals, err := edn.ReadAllValues(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edn.String(vals) != `[[1 "two"] :three]` {
+		t.Errorf("unexpected values %s", edn.String(vals))
+	}
+}
+
+func TestIncompleteRecords(t *testing.T) {
+	for _, framed := range []bool{false, true} {
+		dir := t.TempDir()
+		opts := Options{Framed: framed}
+
+		w, err := Open(dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Append("complete")
+		w.Close()
+
+		path := opts.path(dir, 1)
+		complete, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// a record being written is not read yet
+		partial := opts.record("partial")
+		if err := os.WriteFile(path, append(complete, partial[:len(partial)-2]...), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		r := NewReader(dir, opts)
+		if vals := readAll(t, r); len(vals) != 1 || vals[0] != "complete" {
+			t.Errorf("framed=%v: unexpected values %s", framed, edn.String(vals))
+		}
+
+		// it is removed when the log is opened again
+		w, err = Open(dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Append("next")
+		w.Close()
+
+		if vals := readAll(t, NewReader(dir, opts)); !edn.Equal(vals, []interface{}{"complete", "next"}) {
+			t.Errorf("framed=%v: unexpected values %s", framed, edn.String(vals))
+		}
+		r.Close()
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Framed: true}
+
+	w, err := Open(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Append("value")
+	w.Close()
+
+	path := opts.path(dir, 1)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-2] = 'X'
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewReader(dir, opts).Next(); err == nil || err == io.EOF {
+		t.Errorf("expected checksum error, got %v", err)
+	}
+}
+
+func TestTail(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{MaxSize: 10}
+
+	w, err := Open(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	go func() {
+		for i := 0; i < 5; i++ {
+			w.Append(int64(i))
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var vals []interface{}
+	done := errors.New("done")
+	err = NewReader(dir, opts).Tail(ctx, time.Millisecond, func(val interface{}) error {
+		vals = append(vals, val)
+		if len(vals) == 5 {
+			return done
+		}
+		return nil
+	})
+	if err != done {
+		t.Fatal(err)
+	}
+	if edn.String(vals) != "[0 1 2 3 4]" {
+		t.Errorf("unexpected values %s", edn.String(vals))
+	}
+}
@@ -0,0 +1,111 @@
+package ednlog
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+// Follow calls handler with the top-level values of the EDN file at
+// path, like tail -F: first with those already in the file, and then
+// with the values appended to it, checking for them every interval.
+//
+// A value is only handled once it is complete, values that are still
+// written are kept until the rest arrives.  If the file is truncated,
+// it is read again from the start, if it is replaced, e.g. when it is
+// rotated, the new file is read once the old one has been read
+// completely.
+//
+// Follow returns when handler returns an error, reading fails or ctx is
+// done.
+func Follow(ctx context.Context, path string, interval time.Duration, handler func(interface{}) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	p := edn.NewPushParser()
+	buf := make([]byte, 32*1024)
+	var offset int64
+	for {
+		n, err := f.Read(buf)
+		offset += int64(n)
+		p.Write(buf[:n])
+
+		for {
+			val, err := p.Next()
+			if err == edn.ErrIncomplete {
+				break
+			} else if err != nil {
+				return err
+			}
+
+			if err := handler(val); err != nil {
+				return err
+			}
+		}
+
+		if err == nil {
+			continue
+		} else if err != io.EOF {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if info.Size() < offset {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			p = edn.NewPushParser()
+			offset = 0
+			continue
+		}
+
+		// the current file is read completely, so a replacement can
+		// be read without losing values
+		if replaced, err := os.Stat(path); err == nil && !os.SameFile(info, replaced) && info.Size() == offset {
+			next, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+
+			// values at the very end, e.g. a number, are complete now
+			p.Close()
+			for {
+				val, err := p.Next()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					next.Close()
+					return err
+				}
+
+				if err := handler(val); err != nil {
+					next.Close()
+					return err
+				}
+			}
+
+			f.Close()
+			f = next
+			p = edn.NewPushParser()
+			offset = 0
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package ednlog
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+func TestFollow(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.edn")
+	if err := os.WriteFile(path, []byte(`{:id 1} [2 `), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file is changed once the values so far have been handled
+	writes := map[int]func() error{
+		1: func() error { return appendFile(path, `"two"] :three`) },
+		2: func() error { return appendFile(path, ` #{4}`) },
+		4: func() error {
+			// 5 is only complete at the end of the file, when it is
+			// rotated: renamed and replaced by a new one
+			if err := appendFile(path, ` 5`); err != nil {
+				return err
+			}
+			if err := os.Rename(path, path+".1"); err != nil {
+				return err
+			}
+			return os.WriteFile(path, []byte(`"six" `), 0644)
+		},
+		// truncated
+		6: func() error { return os.WriteFile(path, []byte(`:s `), 0644) },
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var vals []interface{}
+	done := errors.New("done")
+	err := Follow(ctx, path, time.Millisecond, func(val interface{}) error {
+		vals = append(vals, val)
+		if len(vals) == 7 {
+			return done
+		}
+		if write, ok := writes[len(vals)]; ok {
+			return write()
+		}
+		return nil
+	})
+	if err != done {
+		t.Fatal(err)
+	}
+
+	expected := `[{:id 1} [2 "two"] :three #{4} 5 "six" :s]`
+	if edn.String(vals) != expected {
+		t.Errorf("expected %s, got %s", expected, edn.String(vals))
+	}
+}
+
+func TestFollowMissingFile(t *testing.T) {
+	err := Follow(context.Background(), filepath.Join(t.TempDir(), "missing.edn"), time.Millisecond, func(interface{}) error { return nil })
+	if err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
+
+func appendFile(path, s string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(s); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
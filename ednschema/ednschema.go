@@ -0,0 +1,348 @@
+// Package ednschema describes the shape of EDN documents.
+//
+// A Schema records the types a value has, the keys of maps and the
+// elements of collections.  It can be inferred from sample documents
+// with Infer, e.g. to document an API only known by example, or read
+// from its EDN representation with Parse.
+package ednschema
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+// Type is the type of an EDN value.
+type Type string
+
+const (
+	Nil     Type = "nil"
+	Boolean Type = "boolean"
+	Integer Type = "integer"
+	Float   Type = "float"
+	String  Type = "string"
+	Keyword Type = "keyword"
+	Symbol  Type = "symbol"
+	UUID    Type = "uuid"
+	Inst    Type = "inst"
+	BigInt  Type = "bigint"
+	Ratio   Type = "ratio"
+	Vector  Type = "vector"
+	Map     Type = "map"
+	Set     Type = "set"
+	Tagged  Type = "tagged"
+	Unknown Type = "unknown"
+)
+
+// TypeOf returns the type of a decoded value.  Lists and vectors are
+// both of type Vector, as they are decoded to the same Go type.
+func TypeOf(v interface{}) Type {
+	switch v.(type) {
+	case nil:
+		return Nil
+	case bool:
+		return Boolean
+	case int64, int:
+		return Integer
+	case float64:
+		return Float
+	case string:
+		return String
+	case edn.Keyword:
+		return Keyword
+	case edn.Symbol:
+		return Symbol
+	case edn.UUID:
+		return UUID
+	case time.Time:
+		return Inst
+	case *big.Int:
+		return BigInt
+	case *big.Rat:
+		return Ratio
+	case []interface{}:
+		return Vector
+	case map[interface{}]interface{}:
+		return Map
+	case map[interface{}]bool:
+		return Set
+	case edn.Tagged:
+		return Tagged
+	default:
+		return Unknown
+	}
+}
+
+// MaxEnum is the maximum number of distinct keywords for which Infer
+// reports an enumeration.
+var MaxEnum = 8
+
+// Schema describes the values found at one position in a document.
+type Schema struct {
+	// Types are the types of the values, sorted by name.
+	Types []Type
+
+	// Keys describes the values of the map keys, if there were maps.
+	Keys map[interface{}]*Key
+
+	// Elements describes the elements, if there were vectors, lists or
+	// sets.
+	Elements *Schema
+
+	// Enum lists the values if all values were keywords, and there
+	// were at most MaxEnum distinct ones.
+	Enum []edn.Keyword
+
+	maps     int
+	keywords map[edn.Keyword]bool
+	other    bool
+}
+
+// Key describes the values of a map key.
+type Key struct {
+	*Schema
+
+	// Optional is set if the key was missing in some maps.
+	Optional bool
+
+	count int
+}
+
+// Infer returns a schema matching all the samples.
+func Infer(samples ...interface{}) *Schema {
+	s := &Schema{}
+	for _, sample := range samples {
+		s.add(sample)
+	}
+	s.finish()
+	return s
+}
+
+func (s *Schema) add(v interface{}) {
+	t := TypeOf(v)
+	s.addType(t)
+
+	if kw, ok := v.(edn.Keyword); ok {
+		if s.keywords == nil {
+			s.keywords = make(map[edn.Keyword]bool)
+		}
+		s.keywords[kw] = true
+	} else if t != Nil {
+		s.other = true
+	}
+
+	switch v := v.(type) {
+	case []interface{}:
+		for _, elem := range v {
+			s.elements().add(elem)
+		}
+	case map[interface{}]bool:
+		for elem := range v {
+			s.elements().add(elem)
+		}
+	case map[interface{}]interface{}:
+		s.maps++
+		if s.Keys == nil {
+			s.Keys = make(map[interface{}]*Key)
+		}
+		for key, val := range v {
+			k, ok := s.Keys[key]
+			if !ok {
+				k = &Key{Schema: &Schema{}}
+				s.Keys[key] = k
+			}
+			k.count++
+			k.add(val)
+		}
+	}
+}
+
+func (s *Schema) addType(t Type) {
+	for _, existing := range s.Types {
+		if existing == t {
+			return
+		}
+	}
+
+	s.Types = append(s.Types, t)
+	sort.Slice(s.Types, func(i, j int) bool { return s.Types[i] < s.Types[j] })
+}
+
+func (s *Schema) elements() *Schema {
+	if s.Elements == nil {
+		s.Elements = &Schema{}
+	}
+	return s.Elements
+}
+
+func (s *Schema) finish() {
+	if !s.other && len(s.keywords) > 0 && len(s.keywords) <= MaxEnum {
+		for kw := range s.keywords {
+			s.Enum = append(s.Enum, kw)
+		}
+		sort.Slice(s.Enum, func(i, j int) bool { return s.Enum[i].FullName() < s.Enum[j].FullName() })
+	}
+
+	if s.Elements != nil {
+		s.Elements.finish()
+	}
+	for _, key := range s.Keys {
+		key.Optional = key.count < s.maps
+		key.finish()
+	}
+}
+
+// EDN returns the schema as an EDN value, a map of the form
+//
+//  {:types #{:map}
+//   :keys {:name {:types #{:string}}
+//          :email {:types #{:string} :optional true}
+//          :role {:types #{:keyword} :enum #{:admin :user}}}}
+//
+// where :keys, :elements, :enum and :optional are only present if they
+// apply.
+func (s *Schema) EDN() interface{} {
+	types := make(map[interface{}]bool, len(s.Types))
+	for _, t := range s.Types {
+		types[edn.Keyword{Namespace: "", Name: string(t)}] = true
+	}
+
+	m := map[interface{}]interface{}{
+		edn.Keyword{Namespace: "", Name: "types"}: types,
+	}
+
+	if len(s.Keys) > 0 {
+		keys := make(map[interface{}]interface{}, len(s.Keys))
+		for key, k := range s.Keys {
+			val := k.EDN().(map[interface{}]interface{})
+			if k.Optional {
+				val[edn.Keyword{Namespace: "", Name: "optional"}] = true
+			}
+			keys[key] = val
+		}
+		m[edn.Keyword{Namespace: "", Name: "keys"}] = keys
+	}
+
+	if s.Elements != nil {
+		m[edn.Keyword{Namespace: "", Name: "elements"}] = s.Elements.EDN()
+	}
+
+	if len(s.Enum) > 0 {
+		enum := make(map[interface{}]bool, len(s.Enum))
+		for _, kw := range s.Enum {
+			enum[kw] = true
+		}
+		m[edn.Keyword{Namespace: "", Name: "enum"}] = enum
+	}
+
+	return m
+}
+
+// String returns the schema as EDN text.
+func (s *Schema) String() string {
+	return edn.String(s.EDN())
+}
+
+// Parse returns the schema described by v, a value of the form
+// returned by EDN.  It is the inverse of EDN, so schemas can be written
+// by hand or kept in files.
+func Parse(v interface{}) (*Schema, error) {
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema must be a map, but was %s", edn.String(v))
+	}
+
+	s := &Schema{}
+	for key, val := range m {
+		kw, ok := key.(edn.Keyword)
+		if !ok || kw.Namespace != "" {
+			return nil, fmt.Errorf("unknown schema key %s", edn.String(key))
+		}
+
+		switch kw.Name {
+		case "types":
+			types, ok := val.(map[interface{}]bool)
+			if !ok {
+				return nil, fmt.Errorf(":types must be a set, but was %s", edn.String(val))
+			}
+			for t := range types {
+				kw, ok := t.(edn.Keyword)
+				if !ok || kw.Namespace != "" || !knownType(Type(kw.Name)) {
+					return nil, fmt.Errorf("unknown type %s", edn.String(t))
+				}
+				s.addType(Type(kw.Name))
+			}
+		case "keys":
+			keys, ok := val.(map[interface{}]interface{})
+			if !ok {
+				return nil, fmt.Errorf(":keys must be a map, but was %s", edn.String(val))
+			}
+			s.Keys = make(map[interface{}]*Key, len(keys))
+			for key, val := range keys {
+				k, err := parseKey(val)
+				if err != nil {
+					return nil, fmt.Errorf("key %s: %w", edn.String(key), err)
+				}
+				s.Keys[key] = k
+			}
+		case "elements":
+			elems, err := Parse(val)
+			if err != nil {
+				return nil, fmt.Errorf(":elements: %w", err)
+			}
+			s.Elements = elems
+		case "enum":
+			enum, ok := val.(map[interface{}]bool)
+			if !ok {
+				return nil, fmt.Errorf(":enum must be a set, but was %s", edn.String(val))
+			}
+			for elem := range enum {
+				kw, ok := elem.(edn.Keyword)
+				if !ok {
+					return nil, fmt.Errorf(":enum must contain keywords, but contains %s", edn.String(elem))
+				}
+				s.Enum = append(s.Enum, kw)
+			}
+			sort.Slice(s.Enum, func(i, j int) bool { return s.Enum[i].FullName() < s.Enum[j].FullName() })
+		case "optional":
+			// handled by parseKey
+		default:
+			return nil, fmt.Errorf("unknown schema key %s", edn.String(key))
+		}
+	}
+
+	return s, nil
+}
+
+func parseKey(v interface{}) (*Key, error) {
+	s, err := Parse(v)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Key{Schema: s}
+	if m, ok := v.(map[interface{}]interface{}); ok {
+		if optional, ok := m[edn.Keyword{Namespace: "", Name: "optional"}]; ok {
+			b, ok := optional.(bool)
+			if !ok {
+				return nil, fmt.Errorf(":optional must be a boolean, but was %s", edn.String(optional))
+			}
+			k.Optional = b
+		}
+	}
+
+	return k, nil
+}
+
+func knownType(t Type) bool {
+	switch t {
+	case Nil, Boolean, Integer, Float, String, Keyword, Symbol, UUID, Inst,
+		BigInt, Ratio, Vector, Map, Set, Tagged, Unknown:
+		return true
+	default:
+		return false
+	}
+}
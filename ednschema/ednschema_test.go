@@ -0,0 +1,86 @@
+package ednschema
+
+import (
+	"testing"
+
+	"github.com/heyLu/edn"
+)
+
+func TestInfer(t *testing.T) {
+	samples := []interface{}{}
+	for _, s := range []string{
+		`{:name "jane" :role :admin :tags ["a"]}`,
+		`{:name "joe" :role :user :email "joe@example.com" :tags []}`,
+		`{:name "ann" :role :user :email nil :tags #{"b" 3}}`,
+	} {
+		val, err := edn.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		samples = append(samples, val)
+	}
+
+	s := Infer(samples...)
+
+	expected := `{:keys {:email {:optional true :types #{:nil :string}} ` +
+		`:name {:types #{:string}} ` +
+		`:role {:enum #{:admin :user} :types #{:keyword}} ` +
+		`:tags {:elements {:types #{:integer :string}} :types #{:set :vector}}} ` +
+		`:types #{:map}}`
+	if s.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, s.String())
+	}
+}
+
+func TestInferEnumLimit(t *testing.T) {
+	samples := []interface{}{}
+	for i := 0; i <= MaxEnum; i++ {
+		samples = append(samples, edn.Keyword{Namespace: "", Name: string(rune('a' + i))})
+	}
+
+	if s := Infer(samples[:MaxEnum]...); len(s.Enum) != MaxEnum {
+		t.Errorf("expected enum of %d keywords, got %v", MaxEnum, s.Enum)
+	}
+	if s := Infer(samples...); s.Enum != nil {
+		t.Errorf("expected no enum, got %v", s.Enum)
+	}
+}
+
+func TestParse(t *testing.T) {
+	src := `{:keys {:email {:optional true :types #{:nil :string}} ` +
+		`:role {:enum #{:admin :user} :types #{:keyword}} ` +
+		`:tags {:elements {:types #{:string}} :types #{:vector}}} ` +
+		`:types #{:map}}`
+	val, err := edn.DecodeString(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Parse(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.String() != src {
+		t.Errorf("expected\n%s\ngot\n%s", src, s.String())
+	}
+	if !s.Keys[edn.Keyword{Namespace: "", Name: "email"}].Optional {
+		t.Errorf(":email should be optional")
+	}
+
+	for _, invalid := range []string{
+		`[:map]`,
+		`{:types [:map]}`,
+		`{:types #{:thing}}`,
+		`{:type #{:map}}`,
+		`{:types #{:map} :keys {:a {:types #{:string} :optional "yes"}}}`,
+		`{:types #{:keyword} :enum #{"a"}}`,
+	} {
+		val, err := edn.DecodeString(invalid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Parse(val); err == nil {
+			t.Errorf("expected error for %s", invalid)
+		}
+	}
+}
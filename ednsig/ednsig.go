@@ -0,0 +1,151 @@
+// Package ednsig signs EDN values, e.g. to distribute trusted
+// configuration or manifests.
+//
+// A signed value is wrapped in an envelope map,
+//
+//	{:payload {:version "1.2.0" :url "https://example.com/app.tgz"}
+//	 :alg :ed25519
+//	 :sig "base64 encoded signature"}
+//
+// where the signature is computed over the canonical encoding of the
+// payload (see edn.Canonical), so that it stays valid if the envelope
+// is reformatted or read and written by other EDN implementations.
+// The payload is encoded with edn.Marshal and read back with
+// edn.Decoder.SetRawTags before, so that the signed text doesn't depend
+// on the tag readers registered by the signer or the verifier, as long
+// as the values of the tag readers are written as they were read.
+// Payloads edn.Marshal can't encode can't be signed.
+//
+// The supported algorithms are :ed25519 with ed25519 keys and
+// :hmac-sha256 with shared secret keys as []byte.
+package ednsig
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/heyLu/edn"
+)
+
+var (
+	payloadKey = edn.Keyword{Namespace: "", Name: "payload"}
+	algKey     = edn.Keyword{Namespace: "", Name: "alg"}
+	sigKey     = edn.Keyword{Namespace: "", Name: "sig"}
+
+	// Ed25519 and HMACSHA256 are the values of :alg.
+	Ed25519    = edn.Keyword{Namespace: "", Name: "ed25519"}
+	HMACSHA256 = edn.Keyword{Namespace: "", Name: "hmac-sha256"}
+)
+
+// Sign returns the envelope of payload signed with key, which is an
+// ed25519.PrivateKey or a []byte HMAC key.
+func Sign(payload interface{}, key interface{}) (map[interface{}]interface{}, error) {
+	msg, err := canonical(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var alg edn.Keyword
+	var sig []byte
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		alg = Ed25519
+		sig = ed25519.Sign(key, msg)
+	case []byte:
+		alg = HMACSHA256
+		sig = mac(key, msg)
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+
+	return map[interface{}]interface{}{
+		payloadKey: payload,
+		algKey:     alg,
+		sigKey:     base64.StdEncoding.EncodeToString(sig),
+	}, nil
+}
+
+// Verify checks the signature of envelope with key, an
+// ed25519.PublicKey or a []byte HMAC key, and returns the payload if
+// it is valid.  The :alg of the envelope must match the type of key,
+// so that a signature can't be checked with the wrong algorithm.
+func Verify(envelope interface{}, key interface{}) (interface{}, error) {
+	m, ok := envelope.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("envelope must be a map, but was %s", edn.String(envelope))
+	}
+
+	payload, ok := m[payloadKey]
+	if !ok {
+		return nil, fmt.Errorf("envelope has no :payload")
+	}
+	alg, ok := m[algKey].(edn.Keyword)
+	if !ok {
+		return nil, fmt.Errorf(":alg must be a keyword, but was %s", edn.String(m[algKey]))
+	}
+	encoded, ok := m[sigKey].(string)
+	if !ok {
+		return nil, fmt.Errorf(":sig must be a string, but was %s", edn.String(m[sigKey]))
+	}
+	sig, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid :sig: %w", err)
+	}
+
+	msg, err := canonical(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var valid bool
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		if alg != Ed25519 {
+			return nil, fmt.Errorf("envelope is signed with %s, but the key is for %s", alg, Ed25519)
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 public key of length %d", len(key))
+		}
+		valid = ed25519.Verify(key, msg, sig)
+	case []byte:
+		if alg != HMACSHA256 {
+			return nil, fmt.Errorf("envelope is signed with %s, but the key is for %s", alg, HMACSHA256)
+		}
+		valid = hmac.Equal(mac(key, msg), sig)
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+
+	if !valid {
+		return nil, fmt.Errorf("invalid signature")
+	}
+	return payload, nil
+}
+
+func mac(key, msg []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(msg)
+	return h.Sum(nil)
+}
+
+// canonical returns the signed text of payload, the canonical encoding
+// of payload as read without tag readers.
+func canonical(payload interface{}) ([]byte, error) {
+	data, err := edn.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	d := edn.NewDecoder(bytes.NewReader(data))
+	d.SetRawTags(true)
+	val, err := d.Decode()
+	if err != nil {
+		return nil, err
+	}
+
+	return edn.Canonical(val)
+}
@@ -0,0 +1,131 @@
+package ednsig
+
+import (
+	"crypto/ed25519"
+	"strings"
+	"testing"
+
+	"github.com/heyLu/edn"
+)
+
+func TestSignVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("secret")
+
+	payload, err := edn.DecodeString(`{:version "1.2.0" :files #{"a" "b"} :size 42}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, keys := range []struct{ sign, verify interface{} }{
+		{priv, pub},
+		{secret, secret},
+	} {
+		envelope, err := Sign(payload, keys.sign)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the envelope is still valid after writing and reading it
+		// with a different layout
+		text := strings.Replace(edn.String(envelope), `#{"a" "b"}`, `#{"b", "a"}`, 1)
+		read, err := edn.DecodeString(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		verified, err := Verify(read, keys.verify)
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		if !edn.Equal(verified, payload) {
+			t.Errorf("unexpected payload %s", edn.String(verified))
+		}
+
+		tampered := strings.Replace(text, ":size 42", ":size 43", 1)
+		read, err = edn.DecodeString(tampered)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Verify(read, keys.verify); err == nil {
+			t.Errorf("expected error for tampered envelope %s", tampered)
+		}
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope, err := Sign("payload", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(envelope, []byte(pub)); err == nil {
+		t.Errorf("expected error for hmac key with ed25519 envelope")
+	}
+	if _, err := Verify(envelope, pub[:10]); err == nil {
+		t.Errorf("expected error for short public key")
+	}
+	if _, err := Verify(envelope, "key"); err == nil {
+		t.Errorf("expected error for unsupported key")
+	}
+	if _, err := Sign("payload", "key"); err == nil {
+		t.Errorf("expected error for unsupported key")
+	}
+
+	for _, invalid := range []string{
+		`[:payload 1]`,
+		`{:alg :ed25519 :sig ""}`,
+		`{:payload 1 :alg "ed25519" :sig ""}`,
+		`{:payload 1 :alg :ed25519 :sig 42}`,
+		`{:payload 1 :alg :ed25519 :sig "not base64!"}`,
+		`{:payload 1 :alg :ed25519 :sig ""}`,
+	} {
+		val, err := edn.DecodeString(invalid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Verify(val, pub); err == nil {
+			t.Errorf("expected error for %s", invalid)
+		}
+	}
+}
+
+func TestSignTagged(t *testing.T) {
+	secret := []byte("secret")
+	tag := edn.Symbol{Namespace: "ednsig.test", Name: "point"}
+
+	// signed without a reader for the tag
+	payload, err := edn.DecodeString(`{:at #ednsig.test/point [1 2] :on #inst "2020-01-01T01:00:00+01:00"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := Sign(payload, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// and verified with one
+	edn.RegisterTag(tag, func(tag edn.Symbol, val interface{}) (interface{}, error) {
+		return edn.Tagged{Tag: tag, Value: val}, nil
+	})
+	defer edn.RegisterTag(tag, nil)
+
+	read, err := edn.DecodeString(edn.String(envelope))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(read, secret); err != nil {
+		t.Errorf("%s: %v", edn.String(envelope), err)
+	}
+
+	if _, err := Sign(map[interface{}]interface{}{edn.Keyword{Namespace: "", Name: "a"}: struct{}{}}, secret); err == nil {
+		t.Errorf("expected error for unencodable payload")
+	}
+}
@@ -0,0 +1,195 @@
+// Package ednsql generates SQL INSERT statements for vectors of EDN
+// maps, e.g. to load data exported from Clojure into a relational
+// database:
+//
+//	stmts, err := ednsql.Insert("users", records, nil)
+//	...
+//	for _, stmt := range stmts {
+//		_, err := db.Exec(stmt.Query, stmt.Args...)
+//		...
+//	}
+package ednsql
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+// Options configure the statements generated by Insert.  The zero
+// value, as well as nil, uses the defaults described for each field.
+type Options struct {
+	// Columns maps keys to column names.  By default, the column of a
+	// keyword is its name with hyphens replaced by underscores, e.g.
+	// first_name for :user/first-name, and the column of a string key
+	// is the string.
+	Columns map[edn.Keyword]string
+
+	// Placeholder returns the placeholder for the nth argument of a
+	// statement, starting at 1.  It defaults to "?", use
+	// DollarPlaceholder for PostgreSQL.
+	Placeholder func(n int) string
+
+	// Quote quotes table and column names.  By default names are not
+	// quoted and must consist of letters, digits and underscores.
+	Quote func(name string) string
+
+	// BatchSize is the maximum number of records inserted by a
+	// statement.  It defaults to 100.
+	BatchSize int
+}
+
+// DollarPlaceholder returns placeholders of the form $1, $2, ...
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// Statement is a query with its arguments, as passed to the Exec
+// method of database/sql.
+type Statement struct {
+	Query string
+	Args  []interface{}
+}
+
+// Insert returns statements inserting the records, a vector of maps,
+// into table.  Each record has a value for all columns, nil for keys it
+// does not contain.
+//
+// The values are converted to types supported by database/sql: nil,
+// booleans, integers, floats, strings and instants are kept, keywords,
+// symbols, UUIDs, big integers and ratios become strings, as do other
+// values, which are written in EDN.
+func Insert(table string, records interface{}, opts *Options) ([]Statement, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	columns, err := edn.Columns(records)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, nil
+	}
+
+	quote := opts.Quote
+	if quote == nil {
+		quote = func(name string) string { return name }
+		for _, part := range strings.Split(table, ".") {
+			if !isIdentifier(part) {
+				return nil, fmt.Errorf("invalid table name %q", table)
+			}
+		}
+	}
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		name, err := opts.column(column.Key)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Quote == nil && !isIdentifier(name) {
+			return nil, fmt.Errorf("invalid column name %q for %s", name, edn.String(column.Key))
+		}
+		names[i] = quote(name)
+	}
+
+	placeholder := opts.Placeholder
+	if placeholder == nil {
+		placeholder = func(int) string { return "?" }
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	prefix := "INSERT INTO " + quote(table) + " (" + strings.Join(names, ", ") + ") VALUES "
+	rows := len(columns[0].Values)
+
+	var stmts []Statement
+	for start := 0; start < rows; start += batchSize {
+		end := start + batchSize
+		if end > rows {
+			end = rows
+		}
+
+		var query strings.Builder
+		query.WriteString(prefix)
+		args := make([]interface{}, 0, (end-start)*len(columns))
+		for i := start; i < end; i++ {
+			if i > start {
+				query.WriteString(", ")
+			}
+
+			query.WriteByte('(')
+			for j, column := range columns {
+				if j > 0 {
+					query.WriteString(", ")
+				}
+
+				args = append(args, Value(column.Values[i]))
+				query.WriteString(placeholder(len(args)))
+			}
+			query.WriteByte(')')
+		}
+
+		stmts = append(stmts, Statement{Query: query.String(), Args: args})
+	}
+
+	return stmts, nil
+}
+
+func (opts *Options) column(key interface{}) (string, error) {
+	switch key := key.(type) {
+	case edn.Keyword:
+		if name, ok := opts.Columns[key]; ok {
+			return name, nil
+		}
+		return strings.ReplaceAll(key.Name, "-", "_"), nil
+	case string:
+		return key, nil
+	default:
+		return "", fmt.Errorf("column key must be a keyword or string, but was %s", edn.String(key))
+	}
+}
+
+func isIdentifier(name string) bool {
+	for i, ch := range name {
+		if !(ch == '_' || 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || i > 0 && '0' <= ch && ch <= '9') {
+			return false
+		}
+	}
+
+	return name != ""
+}
+
+// Value converts an EDN value to an argument for database/sql, as
+// described for Insert.
+func Value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, int64, float64, string, time.Time, []byte:
+		return v
+	case int:
+		return int64(v)
+	case edn.Keyword:
+		return v.FullName()
+	case edn.Symbol:
+		return v.String()
+	case edn.UUID:
+		return v.String()
+	case *big.Int:
+		if v != nil && v.IsInt64() {
+			return v.Int64()
+		}
+		return edn.String(v)
+	case *big.Rat:
+		return edn.String(v)
+	default:
+		return edn.String(v)
+	}
+}
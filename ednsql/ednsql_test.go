@@ -0,0 +1,68 @@
+package ednsql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+func TestInsert(t *testing.T) {
+	records, err := edn.DecodeString(`[{:user/id 1 :user/first-name "Jane" :status :active :joined #inst "2020-01-02T03:04:05Z"}
+		{:user/id 2 :user/first-name "John" :tags #{:a}}
+		{:user/id 3 :big 123456789012345678901N}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmts, err := Insert("app.users", records, &Options{BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joined := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	expected := []Statement{
+		{
+			"INSERT INTO app.users (big, joined, status, tags, first_name, id) VALUES (?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?)",
+			[]interface{}{nil, joined, "active", nil, "Jane", int64(1), nil, nil, nil, "#{:a}", "John", int64(2)},
+		},
+		{
+			"INSERT INTO app.users (big, joined, status, tags, first_name, id) VALUES (?, ?, ?, ?, ?, ?)",
+			[]interface{}{"123456789012345678901N", nil, nil, nil, nil, int64(3)},
+		},
+	}
+	if !reflect.DeepEqual(stmts, expected) {
+		t.Errorf("expected %#v, got %#v", expected, stmts)
+	}
+
+	stmts, err = Insert("users", records, &Options{
+		Columns:     map[edn.Keyword]string{{Namespace: "user", Name: "id"}: "user id"},
+		Placeholder: DollarPlaceholder,
+		Quote:       func(name string) string { return `"` + name + `"` },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 1 || !strings.HasPrefix(stmts[0].Query, `INSERT INTO "users" ("big", "joined", "status", "tags", "first_name", "user id") VALUES ($1, $2, $3, $4, $5, $6), ($7, `) || len(stmts[0].Args) != 18 {
+		t.Errorf("unexpected statements %#v", stmts)
+	}
+
+	invalid := map[string]string{
+		"users; drop":  `[{:a 1}]`,
+		"users":        `[{:a-b? 1}]`,
+		"other.users":  `[{[1] 1}]`,
+		"still.users.": `[{:a 1}]`,
+	}
+	for table, s := range invalid {
+		records, _ := edn.DecodeString(s)
+		if _, err := Insert(table, records, nil); err == nil {
+			t.Errorf("%s %s: expected error", table, s)
+		}
+	}
+
+	if stmts, err := Insert("users", []interface{}{}, nil); err != nil || stmts != nil {
+		t.Errorf("expected no statements, got %#v, %v", stmts, err)
+	}
+}
@@ -0,0 +1,109 @@
+// Package edntest provides utilities for testing code that handles EDN
+// values.
+package edntest
+
+import (
+	"math/rand"
+	"reflect"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+// RandomValue returns a random value of the types produced by the edn
+// package.
+//
+// size limits the length of strings, names and collections, as in
+// testing/quick.  Collections are nested at most 3 levels deep.  Map
+// keys and set elements are always scalar values, so that they are
+// usable as Go map keys.
+func RandomValue(r *rand.Rand, size int) interface{} {
+	return randomValue(r, size, 3)
+}
+
+func randomValue(r *rand.Rand, size, depth int) interface{} {
+	n := 6
+	if depth > 0 {
+		n = 9
+	}
+
+	switch r.Intn(n) {
+	case 0:
+		return nil
+	case 1:
+		return r.Intn(2) == 0
+	case 2:
+		return r.Int63() - r.Int63()
+	case 3:
+		return randomString(r, size)
+	case 4:
+		return RandomScalar(r, size)
+	case 5:
+		return r.NormFloat64() * 1e6
+	case 6:
+		vec := make([]interface{}, r.Intn(size+1))
+		for i := range vec {
+			vec[i] = randomValue(r, size, depth-1)
+		}
+		return vec
+	case 7:
+		m := make(map[interface{}]interface{})
+		for i := r.Intn(size + 1); i > 0; i-- {
+			m[RandomScalar(r, size)] = randomValue(r, size, depth-1)
+		}
+		return m
+	default:
+		set := make(map[interface{}]bool)
+		for i := r.Intn(size + 1); i > 0; i-- {
+			set[RandomScalar(r, size)] = true
+		}
+		return set
+	}
+}
+
+// RandomScalar returns a random keyword, symbol, uuid, instant, string
+// or integer.
+func RandomScalar(r *rand.Rand, size int) interface{} {
+	switch r.Intn(6) {
+	case 0:
+		return generate(edn.Keyword{}, r, size)
+	case 1:
+		return generate(edn.Symbol{}, r, size)
+	case 2:
+		return generate(edn.UUID{}, r, size)
+	case 3:
+		return time.Unix(r.Int63n(1<<33), 0).UTC()
+	case 4:
+		return randomString(r, size)
+	default:
+		return int64(r.Intn(1000))
+	}
+}
+
+func generate(gen interface {
+	Generate(*rand.Rand, int) reflect.Value
+}, r *rand.Rand, size int) interface{} {
+	return gen.Generate(r, size).Interface()
+}
+
+func randomString(r *rand.Rand, size int) string {
+	buf := make([]byte, r.Intn(size+1))
+	for i := range buf {
+		buf[i] = byte(' ' + r.Intn('~'-' '+1))
+	}
+	return string(buf)
+}
+
+// Value wraps a random EDN value for use with testing/quick:
+//
+//  quick.Check(func(v edntest.Value) bool {
+//      return handle(v.V) == nil
+//  }, nil)
+type Value struct {
+	V interface{}
+}
+
+// Generate implements quick.Generator.
+func (Value) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(Value{RandomValue(r, size)})
+}
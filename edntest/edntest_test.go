@@ -0,0 +1,90 @@
+package edntest
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+func TestGeneratedNamesAreReadable(t *testing.T) {
+	f := func(kw edn.Keyword, sym edn.Symbol) bool {
+		val, err := edn.DecodeString(kw.String())
+		if err != nil || val != kw {
+			t.Logf("%s read as %#v (%v)", kw, val, err)
+			return false
+		}
+
+		val, err = edn.DecodeString(sym.String())
+		if err != nil || val != sym {
+			t.Logf("%s read as %#v (%v)", sym, val, err)
+			return false
+		}
+
+		return true
+	}
+
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGeneratedUUIDsAreReadable(t *testing.T) {
+	f := func(u edn.UUID) bool {
+		val, err := edn.DecodeString(`#uuid "` + u.String() + `"`)
+		return err == nil && val == u
+	}
+
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRandomValue(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		if err := checkValue(RandomValue(r, 10), 0); err != "" {
+			t.Fatal(err)
+		}
+	}
+}
+
+func checkValue(v interface{}, depth int) string {
+	if depth > 3 {
+		return "nested too deeply"
+	}
+
+	switch v := v.(type) {
+	case nil, bool, int64, float64, string, edn.Keyword, edn.Symbol, edn.UUID, time.Time:
+		return ""
+	case []interface{}:
+		for _, elem := range v {
+			if err := checkValue(elem, depth+1); err != "" {
+				return err
+			}
+		}
+		return ""
+	case map[interface{}]interface{}:
+		for key, val := range v {
+			if err := checkValue(key, depth+1); err != "" {
+				return err
+			}
+			if err := checkValue(val, depth+1); err != "" {
+				return err
+			}
+		}
+		return ""
+	case map[interface{}]bool:
+		for elem := range v {
+			if err := checkValue(elem, depth+1); err != "" {
+				return err
+			}
+		}
+		return ""
+	default:
+		return "unexpected type"
+	}
+}
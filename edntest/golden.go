@@ -0,0 +1,51 @@
+package edntest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/heyLu/edn"
+)
+
+var update = flag.Bool("update", false, "write the golden files of edntest.AssertEqualEDN")
+
+// AssertEqualEDN compares got with the value in the golden file
+// wantFile, e.g. testdata/config.edn, using edn.Equal.  If they differ,
+// the test fails with a unified diff of them with each element on a
+// line of its own, see edn.Unified.
+//
+// With the -update flag, e.g. go test ./... -update, wantFile is
+// written with got instead, laid out with edn.Pretty, and the test
+// passes.  Test packages using AssertEqualEDN must not define an
+// -update flag of their own.
+func AssertEqualEDN(t testing.TB, wantFile string, got interface{}) {
+	t.Helper()
+
+	if *update {
+		if _, err := edn.Marshal(got); err != nil {
+			t.Fatalf("%s: %v", wantFile, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(wantFile), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(wantFile, []byte(edn.Pretty(got, 80)+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(wantFile)
+	if err != nil {
+		t.Fatalf("%v (run the test with -update to write it)", err)
+	}
+	want, err := edn.DecodeBytes(data)
+	if err != nil {
+		t.Fatalf("%s: %v", wantFile, err)
+	}
+
+	if !edn.Equal(want, got) {
+		t.Errorf("%s: values differ (-want +got):\n%s", wantFile, edn.Unified(want, got, 0))
+	}
+}
@@ -0,0 +1,79 @@
+package edntest
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/heyLu/edn"
+)
+
+// recorder records the failures of a test.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// assert returns the failures of AssertEqualEDN.
+func assert(t *testing.T, wantFile string, got interface{}) []string {
+	r := &recorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		AssertEqualEDN(r, wantFile, got)
+	}()
+	<-done
+
+	return r.failures
+}
+
+func TestAssertEqualEDN(t *testing.T) {
+	got, err := edn.DecodeString(`{:features #{:b :a} :db {:port 5432 :host "localhost"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if failures := assert(t, "testdata/config.edn", got); len(failures) > 0 {
+		t.Errorf("expected equal values to pass, got %q", failures)
+	}
+
+	changed, err := edn.DecodeString(`{:features #{:a :b} :db {:port 5433 :host "localhost"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failures := assert(t, "testdata/config.edn", changed)
+	if len(failures) != 1 || !strings.Contains(failures[0], "\n-      :port 5432}\n+      :port 5433}\n") {
+		t.Errorf("expected a diff, got %q", failures)
+	}
+
+	failures = assert(t, "testdata/missing.edn", got)
+	if len(failures) != 1 || !strings.Contains(failures[0], "-update") {
+		t.Errorf("expected a missing file to fail, got %q", failures)
+	}
+}
+
+func TestAssertEqualEDNUpdate(t *testing.T) {
+	defer func(old bool) { *update = old }(*update)
+	*update = true
+
+	got := map[interface{}]interface{}{edn.Keyword{Name: "a"}: int64(1)}
+	path := filepath.Join(t.TempDir(), "testdata", "a.edn")
+	AssertEqualEDN(t, path, got)
+
+	*update = false
+	AssertEqualEDN(t, path, got)
+}
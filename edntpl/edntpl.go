@@ -0,0 +1,200 @@
+// Package edntpl fills placeholders in EDN templates, e.g. to generate
+// Datomic transactions or the configuration of an environment.
+//
+// Placeholders are either tagged keywords, which are replaced by the
+// value of the keyword,
+//
+//	{:db/uri #tpl/var :db-uri
+//	 :port #tpl/var :port}
+//
+// or names in double braces within strings.  A string consisting of a
+// single placeholder is replaced by the value, otherwise the value is
+// inserted into the string:
+//
+//	{:port "{{port}}" :name "app-{{env}}"}
+//
+// The name in braces is the keyword without the colon, e.g. {{db/uri}}
+// for :db/uri.  The structure of the template is kept as is.
+package edntpl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/heyLu/edn"
+)
+
+// VarTag is the tag of placeholders, #tpl/var.
+var VarTag = edn.Symbol{Namespace: "tpl", Name: "var"}
+
+// Render returns tmpl with its placeholders replaced by their values.
+// It is an error if a placeholder has no value.
+func Render(tmpl interface{}, values map[edn.Keyword]interface{}) (interface{}, error) {
+	r := renderer{lookup: func(kw edn.Keyword) (interface{}, error) {
+		val, ok := values[kw]
+		if !ok {
+			return nil, fmt.Errorf("no value for %s", kw)
+		}
+		return val, nil
+	}}
+
+	return r.render(tmpl)
+}
+
+// Vars returns the keywords of the placeholders in tmpl, in the order
+// they first appear.  Map entries and set elements are visited in no
+// particular order.  The search ends at the first invalid placeholder,
+// which Render reports.
+func Vars(tmpl interface{}) []edn.Keyword {
+	var vars []edn.Keyword
+	seen := map[edn.Keyword]bool{}
+
+	r := renderer{lookup: func(kw edn.Keyword) (interface{}, error) {
+		if !seen[kw] {
+			seen[kw] = true
+			vars = append(vars, kw)
+		}
+		return nil, nil
+	}}
+	r.render(tmpl)
+
+	return vars
+}
+
+type renderer struct {
+	lookup func(edn.Keyword) (interface{}, error)
+}
+
+func (r renderer) render(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case edn.Tagged:
+		if v.Tag != VarTag {
+			val, err := r.render(v.Value)
+			if err != nil {
+				return nil, err
+			}
+			return edn.Tagged{Tag: v.Tag, Value: val}, nil
+		}
+
+		kw, ok := v.Value.(edn.Keyword)
+		if !ok {
+			return nil, fmt.Errorf("#%s must be followed by a keyword, but was %s", VarTag, edn.String(v.Value))
+		}
+		return r.lookup(kw)
+	case string:
+		return r.renderString(v)
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, elem := range v {
+			val, err := r.render(elem)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = val
+		}
+		return res, nil
+	case map[interface{}]interface{}:
+		res := make(map[interface{}]interface{}, len(v))
+		for key, val := range v {
+			k, err := r.renderKey(key)
+			if err != nil {
+				return nil, err
+			}
+			val, err := r.render(val)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", edn.String(key), err)
+			}
+			res[k] = val
+		}
+		return res, nil
+	case map[interface{}]bool:
+		res := make(map[interface{}]bool, len(v))
+		for elem := range v {
+			e, err := r.renderKey(elem)
+			if err != nil {
+				return nil, err
+			}
+			res[e] = true
+		}
+		return res, nil
+	default:
+		return v, nil
+	}
+}
+
+// renderKey renders a map key or set element, which may be a
+// CompositeKey containing placeholders.
+func (r renderer) renderKey(key interface{}) (interface{}, error) {
+	if k, ok := key.(edn.CompositeKey); ok {
+		val, err := k.Value()
+		if err != nil {
+			return nil, err
+		}
+		key = val
+	}
+
+	val, err := r.render(key)
+	if err != nil {
+		return nil, err
+	}
+	return edn.KeyOf(val), nil
+}
+
+func (r renderer) renderString(s string) (interface{}, error) {
+	start := strings.Index(s, "{{")
+	if start == -1 {
+		return s, nil
+	}
+
+	whole := start == 0 && strings.Index(s, "}}") == len(s)-2
+
+	var b strings.Builder
+	for start != -1 {
+		end := strings.Index(s[start:], "}}")
+		if end == -1 {
+			return nil, fmt.Errorf("unterminated placeholder in %q", s)
+		}
+		end += start
+
+		kw, err := placeholder(s[start+2 : end])
+		if err != nil {
+			return nil, err
+		}
+		val, err := r.lookup(kw)
+		if err != nil {
+			return nil, err
+		}
+
+		if whole {
+			return val, nil
+		}
+
+		b.WriteString(s[:start])
+		if str, ok := val.(string); ok {
+			b.WriteString(str)
+		} else if val != nil {
+			b.WriteString(edn.String(val))
+		}
+
+		s = s[end+2:]
+		start = strings.Index(s, "{{")
+	}
+	b.WriteString(s)
+
+	return b.String(), nil
+}
+
+// placeholder returns the keyword for the name in braces, e.g. :db/uri
+// for "db/uri".  Spaces around the name are ignored.
+func placeholder(name string) (edn.Keyword, error) {
+	name = strings.TrimSpace(name)
+	if !edn.IsValidKeyword(":" + name) {
+		return edn.Keyword{}, fmt.Errorf("invalid placeholder {{%s}}", name)
+	}
+
+	kw, err := edn.DecodeString(":" + name)
+	if err != nil {
+		return edn.Keyword{}, err
+	}
+	return kw.(edn.Keyword), nil
+}
@@ -0,0 +1,101 @@
+package edntpl
+
+import (
+	"testing"
+
+	"github.com/heyLu/edn"
+)
+
+func kw(name string) edn.Keyword {
+	return edn.Keyword{Namespace: "", Name: name}
+}
+
+func TestRender(t *testing.T) {
+	tmpl, err := edn.DecodeString(`[{:db/id "tempid" :db/uri #tpl/var :db/uri :port "{{port}}"
+	  :name "app-{{ env }}-{{port}}" :tags #{#tpl/var :env "static"}
+	  :nested #foo/bar {:size #tpl/var :size} :plain "{ not a {placeholder} }" :empty "{{none}}{{port}}"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[edn.Keyword]interface{}{
+		{Namespace: "db", Name: "uri"}: "datomic:mem://test",
+		kw("port"):                     int64(8080),
+		kw("env"):                      "prod",
+		kw("size"):                     []interface{}{int64(1), int64(2)},
+		kw("none"):                     "",
+	}
+
+	res, err := Render(tmpl, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := edn.DecodeString(`[{:db/id "tempid" :db/uri "datomic:mem://test" :port 8080
+	  :name "app-prod-8080" :tags #{"prod" "static"}
+	  :nested #foo/bar {:size [1 2]} :plain "{ not a {placeholder} }" :empty "8080"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !edn.Equal(res, expected) {
+		t.Errorf("expected %s, got %s", edn.String(expected), edn.String(res))
+	}
+}
+
+func TestRenderCollectionKeys(t *testing.T) {
+	tmpl, err := edn.DecodeString(`{[#tpl/var :a 1] "x" #{[#tpl/var :a]} "y"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Render(tmpl, map[edn.Keyword]interface{}{kw("a"): "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := edn.DecodeString(`{["b" 1] "x" #{["b"]} "y"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !edn.Equal(res, expected) {
+		t.Errorf("expected %s, got %s", edn.String(expected), edn.String(res))
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	values := map[edn.Keyword]interface{}{kw("a"): int64(1)}
+
+	for _, src := range []string{
+		`#tpl/var :missing`,
+		`{:a {:b "{{missing}}"}}`,
+		`#tpl/var "a"`,
+		`"{{a"`,
+		`"{{not valid}}"`,
+	} {
+		tmpl, err := edn.DecodeString(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Render(tmpl, values); err == nil {
+			t.Errorf("expected error for %s", src)
+		}
+	}
+}
+
+func TestVars(t *testing.T) {
+	tmpl, err := edn.DecodeString(`[#tpl/var :a "{{b}}-{{a}}" (#tpl/var :my/c)]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars := Vars(tmpl)
+	expected := []edn.Keyword{kw("a"), kw("b"), {Namespace: "my", Name: "c"}}
+	if len(vars) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, vars)
+	}
+	for i := range vars {
+		if vars[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, vars)
+		}
+	}
+}
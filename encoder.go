@@ -0,0 +1,404 @@
+package edn
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// Marshal returns the EDN representation of v, which must consist of
+// the types the reader produces:
+//
+//   - nil, bool, int64 (and int), float64 and string
+//   - Keyword, Symbol, UUID, time.Time, LocalDate and LocalTime
+//   - time.Duration as #duration "1h30m0s", see
+//     Encoder.SetDurationFormat
+//   - net.IP, *net.IPNet and *url.URL as #ip, #cidr and #uri, which
+//     read back as these types after RegisterNetTags
+//   - *big.Int and *big.Rat
+//   - []interface{} as vectors, map[interface{}]interface{} as maps,
+//     map[interface{}]bool as sets, and CompositeKey
+//   - other Go maps as maps, e.g. map[Keyword]interface{} or
+//     map[Symbol]int with their keys written as keywords and symbols,
+//     and map[string]T with string keys, see Encoder.SetKeywordKeys
+//   - Tagged, and the types of tag writers, see RegisterTagWriter
+//
+// The output is the same as that of String, so map entries and set
+// elements are sorted, and reads back as v.  Non-finite floats are
+// written as the symbolic values ##Inf, ##-Inf and ##NaN.  Values of
+// other types result in an error.
+func Marshal(v interface{}) ([]byte, error) {
+	return AppendValue(nil, v)
+}
+
+// Encoder writes EDN values to an output stream.
+type Encoder struct {
+	w           io.Writer
+	buf         []byte
+	separator   string
+	keywordKeys bool
+	printer     printer
+
+	entryFunc func(path []interface{}, key, val interface{}) (interface{}, bool)
+	zeroTime  ZeroTime
+
+	namespaces map[string]string
+	normalizer func(string) string
+}
+
+// NewEncoder returns an encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, separator: "\n"}
+}
+
+// Encode writes the EDN representation of v, see Marshal, followed by
+// the separator, a newline by default, so that the values of a stream
+// are on lines of their own and can be read with a Decoder.  Nothing is
+// written if v cannot be encoded.
+func (e *Encoder) Encode(v interface{}) error {
+	if e.keywordKeys {
+		var err error
+		if v, err = keywordKeys(v); err != nil {
+			return err
+		}
+	}
+
+	if e.changesValues() {
+		v = e.prepare(v, []interface{}{})
+	}
+
+	if err := e.printer.check(v); err != nil {
+		return err
+	}
+
+	buf := e.printer.appendValue(e.buf[:0], v)
+	buf = append(buf, e.separator...)
+	e.buf = buf
+
+	_, err := e.w.Write(buf)
+	return err
+}
+
+// SetSeparator sets the text written after each value, e.g. "\n\n" to
+// put blank lines between values, or " " to write them on one line.  It
+// must be whitespace, which may include commas, for the values to be
+// read back, see ReadAllValues.
+func (e *Encoder) SetSeparator(sep string) {
+	e.separator = sep
+}
+
+// Commas is a set of collection types whose elements an Encoder
+// separates with commas, see Encoder.SetCommas.
+type Commas uint8
+
+const (
+	CommasInMaps    Commas = 1 << iota // between map entries, {:a 1, :b 2}
+	CommasInVectors                    // [1, 2, 3]
+	CommasInSets                       // #{1, 2, 3}
+)
+
+// SetCommas makes the encoder write commas between the elements of the
+// collection types in commas, e.g. CommasInMaps to write maps like
+// Clojure prints them.  Commas are whitespace in EDN, so the output is
+// read back the same way.
+func (e *Encoder) SetCommas(commas Commas) {
+	e.printer.commas = commas
+}
+
+// Target is the EDN reader an Encoder writes for, see Encoder.SetTarget.
+type Target int
+
+const (
+	// TargetClojure111 is Clojure 1.11 and later, and this package.
+	// Clojure 1.11 reads the same literals as 1.9, but producers can
+	// name the version their consumers use.
+	TargetClojure111 Target = iota
+
+	// TargetClojure19 is Clojure 1.9 and 1.10, which read the symbolic
+	// values ##Inf, ##-Inf and ##NaN and namespaced maps.
+	TargetClojure19
+
+	// TargetEDN2012 is the EDN specification as published in 2012,
+	// which has neither of them.
+	TargetEDN2012
+)
+
+// SetTarget makes the encoder write only the literals target reads,
+// TargetClojure111 by default.  For TargetEDN2012, non-finite floats
+// result in an error and maps are not written as namespaced maps, see
+// SetNamespacedMaps.  None of the targets reads numbers with
+// underscores, such as 1_000, which the encoder never writes.
+func (e *Encoder) SetTarget(target Target) {
+	e.printer.target = target
+}
+
+// DurationFormat is the format an Encoder writes time.Duration values
+// in, see Encoder.SetDurationFormat.
+type DurationFormat int
+
+const (
+	// DurationGo writes them as formatted by time.Duration.String,
+	// e.g. #duration "1h30m0s".
+	DurationGo DurationFormat = iota
+
+	// DurationISO writes them as ISO-8601 durations, as Clojure prints
+	// java.time.Duration values, e.g. #java.time/duration "PT1H30M".
+	DurationISO
+)
+
+// SetDurationFormat sets the format of durations, DurationGo by default.
+// Both are read back as time.Duration.
+func (e *Encoder) SetDurationFormat(format DurationFormat) {
+	e.printer.durations = format
+}
+
+// SetNamespacedMaps makes the encoder write maps whose keys are all
+// keywords of the same namespace in the namespaced map syntax of
+// Clojure 1.9, e.g. {:user/id 1 :user/name "jane"} as
+// #:user{:id 1 :name "jane"}.  The reader reads them back as the same
+// maps, but other EDN readers need not support them, see SetTarget.
+func (e *Encoder) SetNamespacedMaps(namespaced bool) {
+	e.printer.namespacedMaps = namespaced
+}
+
+// ZeroTime is how an Encoder writes zero time.Time values, see
+// Encoder.SetZeroTime.
+type ZeroTime int
+
+const (
+	// ZeroTimeInst writes them as #inst "0001-01-01T00:00:00Z", which
+	// is read back as the zero time.
+	ZeroTimeInst ZeroTime = iota
+
+	// ZeroTimeNil writes them as nil, e.g. for consumers that treat
+	// the zero time as missing.
+	ZeroTimeNil
+
+	// ZeroTimeOmit leaves out map entries with zero times, and writes
+	// them as nil elsewhere.
+	ZeroTimeOmit
+)
+
+// SetZeroTime sets how the encoder writes zero times, ZeroTimeInst by
+// default.
+func (e *Encoder) SetZeroTime(zero ZeroTime) {
+	e.zeroTime = zero
+}
+
+// SetEntryFunc makes the encoder call fn for the entries of all maps in
+// the values it writes, e.g. to redact secrets when logging them:
+//
+//	e.SetEntryFunc(func(path []interface{}, key, val interface{}) (interface{}, bool) {
+//		if key == edn.Keyword{Namespace: "", Name: "password"} {
+//			return "****", true
+//		}
+//		return val, true
+//	})
+//
+// path is the path of the map, see KeywordInfo.Paths for the format,
+// and is only valid during the call.  The entry is written with the
+// value fn returns, or left out if fn returns false.  The maps within
+// the value are passed to fn as well, those within keys are not.
+func (e *Encoder) SetEntryFunc(fn func(path []interface{}, key, val interface{}) (interface{}, bool)) {
+	e.entryFunc = fn
+}
+
+// RemapNamespace makes the encoder replace the namespace from with to in
+// all keywords and symbols it writes, including the tags of tagged
+// elements, like Decoder.RemapNamespace does when reading.
+func (e *Encoder) RemapNamespace(from, to string) {
+	if e.namespaces == nil {
+		e.namespaces = make(map[string]string)
+	}
+
+	e.namespaces[from] = to
+}
+
+// SetNormalizer makes the encoder pass the text of all strings, keywords
+// and symbols it writes through fn, like Decoder.SetNormalizer does when
+// reading, e.g. to write keys in Unicode NFC:
+//
+//	e.SetNormalizer(norm.NFC.String)
+//
+// fn is only called for text that contains non-ASCII characters.
+func (e *Encoder) SetNormalizer(fn func(string) string) {
+	e.normalizer = fn
+}
+
+// changesValues reports whether options are set that change the values
+// written, which are applied by prepare.
+func (e *Encoder) changesValues() bool {
+	return e.entryFunc != nil || e.zeroTime != ZeroTimeInst || e.namespaces != nil || e.normalizer != nil
+}
+
+// prepare returns v at path with the options of the encoder that change
+// values applied, copying the collections it changes.  Within map keys
+// and set elements, path is nil, as their entries are not passed to the
+// entry func.
+func (e *Encoder) prepare(v interface{}, path []interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, elem := range v {
+			res[i] = e.prepare(elem, subPath(path, i))
+		}
+		return res
+	case map[interface{}]interface{}:
+		res := make(map[interface{}]interface{}, len(v))
+		for key, val := range v {
+			if key, val, ok := e.prepareEntry(path, key, val); ok {
+				res[key] = val
+			}
+		}
+		return res
+	case map[interface{}]bool:
+		res := make(map[interface{}]bool, len(v))
+		for elem := range v {
+			res[e.prepareKey(elem)] = true
+		}
+		return res
+	case string:
+		return normalizeText(e.normalizer, v)
+	case Keyword:
+		v = Keyword{normalizeText(e.normalizer, v.Namespace), normalizeText(e.normalizer, v.Name)}
+		return remapNamespace(e.namespaces, v)
+	case Symbol:
+		return e.prepareSymbol(v)
+	case CompositeKey:
+		return e.prepareKey(v)
+	case Tagged:
+		return Tagged{Tag: e.prepareSymbol(v.Tag), Value: e.prepare(v.Value, path)}
+	case time.Time:
+		if v.IsZero() && e.zeroTime != ZeroTimeInst {
+			return nil
+		}
+		return v
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map {
+		return v
+	}
+
+	// maps built in Go, which may not hold the values fn returns
+	res := make(map[interface{}]interface{}, rv.Len())
+	for iter := rv.MapRange(); iter.Next(); {
+		if key, val, ok := e.prepareEntry(path, iter.Key().Interface(), iter.Value().Interface()); ok {
+			res[key] = val
+		}
+	}
+	return res
+}
+
+func (e *Encoder) prepareEntry(path []interface{}, key, val interface{}) (interface{}, interface{}, bool) {
+	if e.entryFunc != nil && path != nil {
+		var ok bool
+		if val, ok = e.entryFunc(path, key, val); !ok {
+			return nil, nil, false
+		}
+	}
+
+	if t, ok := val.(time.Time); ok && t.IsZero() && e.zeroTime == ZeroTimeOmit {
+		return nil, nil, false
+	}
+
+	return e.prepareKey(key), e.prepare(val, subPath(path, key)), true
+}
+
+func (e *Encoder) prepareSymbol(sym Symbol) Symbol {
+	sym = Symbol{normalizeText(e.normalizer, sym.Namespace), normalizeText(e.normalizer, sym.Name)}
+	return remapNamespace(e.namespaces, sym).(Symbol)
+}
+
+// subPath returns the path of the element k of the collection at path.
+func subPath(path []interface{}, k interface{}) []interface{} {
+	if path == nil {
+		return nil
+	}
+	return append(path, k)
+}
+
+// prepareKey returns the map key or set element key prepared, with
+// composite keys decoded and prepared as well.
+func (e *Encoder) prepareKey(key interface{}) interface{} {
+	if k, ok := key.(CompositeKey); ok {
+		val, err := k.Value()
+		if err != nil {
+			// left to be reported by check
+			return key
+		}
+		return KeyOf(e.prepare(val, nil))
+	}
+
+	return KeyOf(e.prepare(key, nil))
+}
+
+// SetKeywordKeys makes the encoder write the keys of Go maps with
+// string keys, such as map[string]interface{}, as keywords, e.g. the
+// key "user/id" as :user/id.  Keys that can't be written as keywords
+// result in an error.  The string keys of decoded maps,
+// map[interface{}]interface{}, are kept as strings.
+func (e *Encoder) SetKeywordKeys(keywords bool) {
+	e.keywordKeys = keywords
+}
+
+// keywordKeys returns v with the Go maps in it converted to decoded
+// maps, with the keys of maps with string keys converted to keywords.
+func keywordKeys(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, elem := range v {
+			val, err := keywordKeys(elem)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = val
+		}
+		return res, nil
+	case map[interface{}]interface{}:
+		res := make(map[interface{}]interface{}, len(v))
+		for key, elem := range v {
+			val, err := keywordKeys(elem)
+			if err != nil {
+				return nil, err
+			}
+			res[key] = val
+		}
+		return res, nil
+	case map[interface{}]bool:
+		return v, nil
+	case Tagged:
+		val, err := keywordKeys(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		return Tagged{Tag: v.Tag, Value: val}, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map {
+		return v, nil
+	}
+
+	res := make(map[interface{}]interface{}, rv.Len())
+	for iter := rv.MapRange(); iter.Next(); {
+		key := iter.Key().Interface()
+		if rv.Type().Key().Kind() == reflect.String {
+			name := iter.Key().String()
+			kw, ok := readWholeToken(":" + name).(Keyword)
+			if !ok || kw.FullName() != name {
+				return nil, fmt.Errorf("cannot encode key %q as a keyword", name)
+			}
+			key = kw
+		}
+
+		val, err := keywordKeys(iter.Value().Interface())
+		if err != nil {
+			return nil, err
+		}
+		res[key] = val
+	}
+	return res, nil
+}
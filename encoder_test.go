@@ -0,0 +1,445 @@
+package edn
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshal(t *testing.T) {
+	u, err := parseUUID("f81d4fae-7dec-11d0-a765-00a0c91e6bf6")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val := map[interface{}]interface{}{
+		Keyword{"user", "id"}: u,
+		Keyword{"", "name"}:   "Jane \"J\"",
+		Keyword{"", "tags"}:   map[interface{}]bool{Keyword{"", "admin"}: true},
+		Keyword{"", "since"}:  time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Keyword{"", "nums"}:   []interface{}{int64(1), 2.5, big.NewInt(3), big.NewRat(1, 3), nil, true},
+		Symbol{"", "sym"}:     Tagged{Symbol{"my", "tag"}, []interface{}{}},
+	}
+
+	out, err := Marshal(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{:name "Jane \"J\"" :nums [1 2.5 3N 1/3 nil true] :since #inst "2020-01-02T03:04:05Z" :tags #{:admin} :user/id #uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6" sym #my/tag []}`
+	if string(out) != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+
+	read, err := DecodeBytes(out)
+	if err != nil || !Equal(read, val) {
+		t.Errorf("reads back as %#v (%v)", read, err)
+	}
+
+	if _, err := Marshal([]interface{}{struct{}{}}); err == nil {
+		t.Error("expected error for a struct")
+	}
+
+	nonFinite := []interface{}{math.Inf(1), math.Inf(-1), math.NaN()}
+	out, err = Marshal(nonFinite)
+	if err != nil || string(out) != "[##Inf ##-Inf ##NaN]" {
+		t.Fatalf("expected symbolic values, got %s, %v", out, err)
+	}
+
+	read, err = DecodeBytes(out)
+	if vec, ok := read.([]interface{}); err != nil || !ok || len(vec) != 3 ||
+		vec[0] != math.Inf(1) || vec[1] != math.Inf(-1) || !math.IsNaN(vec[2].(float64)) {
+		t.Errorf("reads back as %#v (%v)", read, err)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+
+	vals := []interface{}{int64(1), Keyword{"", "a"}, []interface{}{"x", Symbol{"", "y"}}}
+	for _, val := range vals {
+		if err := e.Encode(val); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := e.Encode(make(chan int)); err == nil {
+		t.Error("expected error for a channel")
+	}
+
+	if expected := "1\n:a\n[\"x\" y]\n"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	d := NewDecoder(&buf)
+	for _, val := range vals {
+		read, err := d.Decode()
+		if err != nil || !Equal(read, val) {
+			t.Errorf("expected %#v, got %#v, %v", val, read, err)
+		}
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestMarshalGoMaps(t *testing.T) {
+	tests := []struct {
+		val      interface{}
+		expected string
+	}{
+		{map[Keyword]interface{}{{"", "b"}: "x", {"user", "id"}: int64(1)}, `{:b "x" :user/id 1}`},
+		{map[Symbol]int{{"", "a"}: 1, {"", "b"}: 2}, `{a 1 b 2}`},
+		{map[string]int{"a": 1}, `{"a" 1}`},
+		{[]interface{}{map[Keyword]bool{{"", "a"}: true}}, `[{:a true}]`},
+	}
+
+	for _, test := range tests {
+		out, err := Marshal(test.val)
+		if err != nil {
+			t.Errorf("%#v: %v", test.val, err)
+		} else if string(out) != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, out)
+		}
+	}
+
+	if _, err := Marshal(map[Keyword]interface{}{{"", "a"}: struct{}{}}); err == nil {
+		t.Error("expected error for a struct value")
+	}
+
+	h1, err := ContentHash(map[Keyword]int{{"", "a"}: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := ContentHash(mustDecode(t, `{:a 1}`))
+	if err != nil || h1 != h2 {
+		t.Errorf("expected Go maps to hash like decoded maps (%v)", err)
+	}
+}
+
+func TestEncoderKeywordKeys(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetKeywordKeys(true)
+
+	val := []interface{}{
+		map[string]interface{}{"user/id": 1, "tags": map[string]bool{"a": true}},
+		map[interface{}]interface{}{"kept": "as string"},
+	}
+	if err := enc.Encode(val); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "[{:tags {:a true} :user/id 1} {\"kept\" \"as string\"}]\n"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	if err := enc.Encode(map[string]int{"not a keyword": 1}); err == nil {
+		t.Error("expected error for an invalid keyword")
+	}
+}
+
+func TestMarshalInvalidTokens(t *testing.T) {
+	vals := []interface{}{
+		Keyword{"", "a b"},
+		Keyword{},
+		Keyword{"", "a/b"},
+		Symbol{"", "nil"},
+		Symbol{"", "12"},
+		Symbol{},
+		Tagged{Tag: Symbol{}, Value: int64(1)},
+		Tagged{Tag: Symbol{"", "_x"}, Value: int64(1)},
+		CompositeKey("[1"),
+		CompositeKey("[1] 2"),
+		[]interface{}{map[interface{}]interface{}{Symbol{"", "true"}: int64(1)}},
+	}
+
+	for _, val := range vals {
+		if out, err := Marshal(val); err == nil {
+			t.Errorf("expected error for %#v, got %s", val, out)
+		}
+	}
+
+	valid := []interface{}{
+		Keyword{"my.ns", "a-b?"},
+		Symbol{"", "/"},
+		Symbol{"", "-x"},
+		Symbol{"a", "nil"},
+		Tagged{Tag: Symbol{"java.time", "duration"}, Value: "PT1S"},
+		CompositeKey("[1 2]"),
+	}
+	for _, val := range valid {
+		if _, err := Marshal(val); err != nil {
+			t.Errorf("%#v: %v", val, err)
+		}
+	}
+}
+
+func TestEncoderSeparator(t *testing.T) {
+	vals := []interface{}{int64(1), Keyword{"", "a"}, []interface{}{"x"}}
+
+	tests := []struct {
+		sep      string
+		expected string
+	}{
+		{" ", `1 :a ["x"] `},
+		{"\n\n", "1\n\n:a\n\n[\"x\"]\n\n"},
+		{", ", `1, :a, ["x"], `},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf)
+		e.SetSeparator(test.sep)
+		for _, val := range vals {
+			if err := e.Encode(val); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if buf.String() != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.sep, test.expected, buf.String())
+		}
+
+		read, err := ReadAllValues(&buf)
+		if err != nil || !Equal(read, vals) {
+			t.Errorf("%q: reads back as %#v (%v)", test.sep, read, err)
+		}
+	}
+}
+
+func TestEncoderCommas(t *testing.T) {
+	val := map[interface{}]interface{}{
+		Keyword{"", "a"}: []interface{}{int64(1), int64(2)},
+		Keyword{"", "b"}: map[interface{}]bool{int64(3): true, int64(4): true},
+	}
+
+	tests := []struct {
+		commas   Commas
+		expected string
+	}{
+		{0, "{:a [1 2] :b #{3 4}}\n"},
+		{CommasInMaps, "{:a [1 2], :b #{3 4}}\n"},
+		{CommasInVectors | CommasInSets, "{:a [1, 2] :b #{3, 4}}\n"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf)
+		e.SetCommas(test.commas)
+		if err := e.Encode(val); err != nil {
+			t.Fatal(err)
+		}
+
+		if buf.String() != test.expected {
+			t.Errorf("%d: expected %q, got %q", test.commas, test.expected, buf.String())
+		}
+
+		read, err := DecodeBytes(buf.Bytes())
+		if err != nil || !Equal(read, val) {
+			t.Errorf("%d: reads back as %#v (%v)", test.commas, read, err)
+		}
+	}
+}
+
+func TestEncoderEntryFunc(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+
+	var paths []string
+	e.SetEntryFunc(func(path []interface{}, key, val interface{}) (interface{}, bool) {
+		paths = append(paths, String(append(path, key)))
+		switch key {
+		case Keyword{"", "password"}:
+			return "****", true
+		case Keyword{"", "token"}:
+			return nil, false
+		}
+		return val, true
+	})
+
+	val := map[Keyword]interface{}{
+		{"", "users"}: []interface{}{
+			map[interface{}]interface{}{Keyword{"", "name"}: "jane", Keyword{"", "password"}: "secret"},
+		},
+		{"", "token"}: "abc",
+	}
+	if err := e.Encode(val); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "{:users [{:name \"jane\" :password \"****\"}]}\n"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	sort.Strings(paths)
+	expected := []string{"[:token]", "[:users 0 :name]", "[:users 0 :password]", "[:users]"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected paths %q, got %q", expected, paths)
+	}
+}
+
+func TestEncoderNamespacedMaps(t *testing.T) {
+	tests := []struct {
+		val      interface{}
+		expected string
+	}{
+		{mustDecode(t, `{:user/id 1 :user/name "jane" :user/roles #{:admin}}`), `#:user{:id 1 :name "jane" :roles #{:admin}}`},
+		{mustDecode(t, `{:user/id 1 :user/address {:address/city "x"}}`), `#:user{:address #:address{:city "x"} :id 1}`},
+		{mustDecode(t, `{:user/id 1 :account/id 2}`), `{:account/id 2 :user/id 1}`},
+		{mustDecode(t, `{:user/id 1 :id 2}`), `{:id 2 :user/id 1}`},
+		{mustDecode(t, `{:user/id 1 user/name 2}`), `{:user/id 1 user/name 2}`},
+		{mustDecode(t, `{}`), `{}`},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf)
+		e.SetNamespacedMaps(true)
+		if err := e.Encode(test.val); err != nil {
+			t.Fatal(err)
+		}
+
+		if buf.String() != test.expected+"\n" {
+			t.Errorf("expected %s, got %s", test.expected, buf.String())
+		}
+
+		read, err := DecodeBytes(buf.Bytes())
+		if err != nil || !Equal(read, test.val) {
+			t.Errorf("%s: reads back as %#v (%v)", test.expected, read, err)
+		}
+	}
+}
+
+func TestEncoderTarget(t *testing.T) {
+	tests := []struct {
+		target   Target
+		val      interface{}
+		expected string
+	}{
+		{TargetClojure111, []interface{}{math.Inf(1), mustDecode(t, `{:a/b 1}`)}, "[##Inf #:a{:b 1}]\n"},
+		{TargetClojure19, []interface{}{math.NaN(), mustDecode(t, `{:a/b 1}`)}, "[##NaN #:a{:b 1}]\n"},
+		{TargetEDN2012, []interface{}{1.5, mustDecode(t, `{:a/b 1}`)}, "[1.5 {:a/b 1}]\n"},
+		{TargetEDN2012, []interface{}{math.Inf(-1)}, ""},
+		{TargetEDN2012, map[interface{}]bool{KeyOf([]interface{}{math.NaN()}): true}, ""},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf)
+		e.SetTarget(test.target)
+		e.SetNamespacedMaps(true)
+
+		err := e.Encode(test.val)
+		if test.expected == "" && err == nil {
+			t.Errorf("%d: expected error for %s, got %s", test.target, String(test.val), buf.String())
+		} else if test.expected != "" && (err != nil || buf.String() != test.expected) {
+			t.Errorf("%d: expected %q, got %q (%v)", test.target, test.expected, buf.String(), err)
+		}
+	}
+}
+
+func TestEncoderZeroTime(t *testing.T) {
+	val := []interface{}{
+		time.Time{},
+		map[interface{}]interface{}{Keyword{"", "created"}: time.Time{}, Keyword{"", "id"}: int64(1)},
+		map[Keyword]time.Time{{"", "at"}: {}},
+		(*big.Int)(nil),
+		(*big.Rat)(nil),
+	}
+
+	tests := []struct {
+		zero     ZeroTime
+		expected string
+	}{
+		{ZeroTimeInst, `[#inst "0001-01-01T00:00:00Z" {:created #inst "0001-01-01T00:00:00Z" :id 1} {:at #inst "0001-01-01T00:00:00Z"} nil nil]`},
+		{ZeroTimeNil, `[nil {:created nil :id 1} {:at nil} nil nil]`},
+		{ZeroTimeOmit, `[nil {:id 1} {} nil nil]`},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf)
+		e.SetZeroTime(test.zero)
+		if err := e.Encode(val); err != nil {
+			t.Fatal(err)
+		}
+
+		if buf.String() != test.expected+"\n" {
+			t.Errorf("%d: expected %s, got %s", test.zero, test.expected, buf.String())
+		}
+		if _, err := DecodeBytes(buf.Bytes()); err != nil {
+			t.Errorf("%d: %v", test.zero, err)
+		}
+	}
+
+	read := mustDecode(t, `#inst "0001-01-01T00:00:00Z"`)
+	if tm, ok := read.(time.Time); !ok || !tm.IsZero() {
+		t.Errorf("expected the zero time, got %#v", read)
+	}
+}
+
+func TestEncoderRemapNamespace(t *testing.T) {
+	val := mustDecode(t, `{:new.ns/a [new.ns/b :new.ns.sub/c #new.ns/tag 1] #{:new.ns/d} {[:new.ns/e] 2}}`)
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.RemapNamespace("new.ns", "old.ns")
+	if err := e.Encode(val); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{#{:old.ns/d} {[:old.ns/e] 2} :old.ns/a [old.ns/b :new.ns.sub/c #old.ns/tag 1]}\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	d := NewDecoder(&buf)
+	d.RemapNamespace("old.ns", "new.ns")
+	read, err := d.Decode()
+	if err != nil || !Equal(read, val) {
+		t.Errorf("reads back as %s (%v)", String(read), err)
+	}
+}
+
+func TestEncoderNormalizer(t *testing.T) {
+	// a stand-in for NFC, as in TestSetNormalizer
+	composeE := strings.NewReplacer("e\u0301", "é").Replace
+
+	val := map[interface{}]interface{}{
+		Keyword{"", "cafe\u0301"}:          "cafe\u0301",
+		Symbol{"cafe\u0301", "x"}:          []interface{}{Tagged{Symbol{"", "cafe\u0301"}, "plain"}},
+		KeyOf([]interface{}{"cafe\u0301"}): true,
+	}
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.SetNormalizer(composeE)
+	if err := e.Encode(val); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "{:café \"café\" [\"café\"] true café/x [#café \"plain\"]}\n"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	read, err := DecodeBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[interface{}]interface{}{
+		Keyword{"", "café"}:          "café",
+		Symbol{"café", "x"}:          []interface{}{Tagged{Symbol{"", "café"}, "plain"}},
+		KeyOf([]interface{}{"café"}): true,
+	}
+	if !Equal(read, expected) {
+		t.Errorf("reads back as %s", String(read))
+	}
+}
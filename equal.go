@@ -0,0 +1,137 @@
+package edn
+
+import (
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// Equal reports whether the decoded values a and b are equal in EDN,
+// as the = of Clojure does:
+//
+//   - integers are equal to integers of the same value, whether they
+//     are int, int64 or *big.Int, and ratios to ratios.  Integers are
+//     never equal to floats.
+//   - vectors and lists are equal if their elements are, maps and sets
+//     if they have equal entries, regardless of their order.
+//   - instants are equal if they denote the same time.
+//
+// Other values are compared with reflect.DeepEqual.
+func Equal(a, b interface{}) bool {
+	if k, ok := a.(CompositeKey); ok {
+		if v, err := k.Value(); err == nil {
+			a = v
+		}
+	}
+	if k, ok := b.(CompositeKey); ok {
+		if v, err := k.Value(); err == nil {
+			b = v
+		}
+	}
+
+	if x, ok := bigInt(a); ok {
+		y, ok := bigInt(b)
+		return ok && x.Cmp(y) == 0
+	}
+
+	switch a := a.(type) {
+	case float64:
+		b, ok := b.(float64)
+		return ok && a == b
+	case *big.Rat:
+		b, ok := b.(*big.Rat)
+		return ok && a != nil && b != nil && a.Cmp(b) == 0
+	case time.Time:
+		b, ok := b.(time.Time)
+		return ok && a.Equal(b)
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for i := range a {
+			if !Equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[interface{}]interface{}:
+		b, ok := b.(map[interface{}]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for key, val := range a {
+			other, ok := MapGet(b, key)
+			if !ok || !Equal(val, other) {
+				return false
+			}
+		}
+		return true
+	case map[interface{}]bool:
+		b, ok := b.(map[interface{}]bool)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for elem := range a {
+			if !SetContains(b, elem) {
+				return false
+			}
+		}
+		return true
+	case Tagged:
+		b, ok := b.(Tagged)
+		return ok && a.Tag == b.Tag && Equal(a.Value, b.Value)
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// bigInt returns v as a big.Int if it is an integer.
+func bigInt(v interface{}) (*big.Int, bool) {
+	switch v := v.(type) {
+	case int:
+		return big.NewInt(int64(v)), true
+	case int64:
+		return big.NewInt(v), true
+	case *big.Int:
+		return v, v != nil
+	default:
+		return nil, false
+	}
+}
+
+// MapGet returns the value for key in the decoded map m, comparing
+// keys with Equal.  E.g. the key []interface{}{1, 2} finds the entry
+// for [1 2], and 1 the one for 1N.
+func MapGet(m map[interface{}]interface{}, key interface{}) (interface{}, bool) {
+	if val, ok := m[KeyOf(key)]; ok {
+		return val, true
+	}
+
+	for k, val := range m {
+		if Equal(k, key) {
+			return val, true
+		}
+	}
+
+	return nil, false
+}
+
+// SetContains reports whether the decoded set contains v, comparing
+// elements with Equal.
+func SetContains(set map[interface{}]bool, v interface{}) bool {
+	if set[KeyOf(v)] {
+		return true
+	}
+
+	for elem := range set {
+		if Equal(elem, v) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,88 @@
+package edn
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestEqual(t *testing.T) {
+	equal := [][2]interface{}{
+		{nil, nil},
+		{int64(1), 1},
+		{int64(1), big.NewInt(1)},
+		{big.NewRat(1, 2), big.NewRat(2, 4)},
+		{2.5, 2.5},
+		{"str", "str"},
+		{Keyword{"ns", "kw"}, Keyword{"ns", "kw"}},
+		{[]interface{}{int64(1), []interface{}{"a"}}, []interface{}{1, []interface{}{"a"}}},
+		{map[interface{}]interface{}{KeyOf([]interface{}{int64(1)}): big.NewInt(2)}, map[interface{}]interface{}{KeyOf([]interface{}{1}): int64(2)}},
+		{map[interface{}]bool{big.NewInt(3): true}, map[interface{}]bool{int64(3): true}},
+		{Tagged{Symbol{"", "tag"}, int64(1)}, Tagged{Symbol{"", "tag"}, big.NewInt(1)}},
+		{time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC), time.Date(2020, 1, 1, 2, 0, 0, 0, time.FixedZone("", 3600))},
+		{CompositeKey("[1 2]"), []interface{}{int64(1), int64(2)}},
+		{[]byte("raw"), []byte("raw")},
+	}
+
+	for _, test := range equal {
+		if !Equal(test[0], test[1]) || !Equal(test[1], test[0]) {
+			t.Errorf("%s and %s should be equal", String(test[0]), String(test[1]))
+		}
+	}
+
+	different := [][2]interface{}{
+		{nil, false},
+		{int64(1), 1.0},
+		{int64(1), big.NewRat(1, 1)},
+		{"1", int64(1)},
+		{Keyword{"", "a"}, Symbol{"", "a"}},
+		{[]interface{}{int64(1)}, []interface{}{int64(1), int64(2)}},
+		{map[interface{}]interface{}{Keyword{"", "a"}: int64(1)}, map[interface{}]interface{}{Keyword{"", "a"}: int64(2)}},
+		{map[interface{}]interface{}{Keyword{"", "a"}: nil}, map[interface{}]interface{}{Keyword{"", "b"}: nil}},
+		{map[interface{}]bool{int64(1): true}, []interface{}{int64(1)}},
+		{Tagged{Symbol{"", "a"}, int64(1)}, Tagged{Symbol{"", "b"}, int64(1)}},
+	}
+
+	for _, test := range different {
+		if Equal(test[0], test[1]) || Equal(test[1], test[0]) {
+			t.Errorf("%s and %s should be different", String(test[0]), String(test[1]))
+		}
+	}
+}
+
+func TestMapGetSetContains(t *testing.T) {
+	val, err := DecodeString(`{:set #{[1 2] 3N 1/2}, [1 {:a 2}] :vec, 12N :big, :kw nil}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := val.(map[interface{}]interface{})
+
+	found := []struct {
+		key, val interface{}
+	}{
+		{[]interface{}{1, map[interface{}]interface{}{Keyword{"", "a"}: 2}}, Keyword{"", "vec"}},
+		{12, Keyword{"", "big"}},
+		{big.NewInt(12), Keyword{"", "big"}},
+		{Keyword{"", "kw"}, nil},
+	}
+
+	for _, test := range found {
+		if val, ok := MapGet(m, test.key); !ok || val != test.val {
+			t.Errorf("%s: expected %s, got %s, %v", String(test.key), String(test.val), String(val), ok)
+		}
+	}
+
+	if _, ok := MapGet(m, 12.0); ok {
+		t.Error("12.0 should not find 12N")
+	}
+
+	set := m[Keyword{"", "set"}].(map[interface{}]bool)
+	for _, elem := range []interface{}{[]interface{}{1, 2}, int64(3), big.NewRat(2, 4)} {
+		if !SetContains(set, elem) {
+			t.Errorf("%s should be in the set", String(elem))
+		}
+	}
+	if SetContains(set, []interface{}{1}) {
+		t.Error("[1] should not be in the set")
+	}
+}
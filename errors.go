@@ -0,0 +1,55 @@
+package edn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// errorf is like fmt.Errorf, but formats the message only when Error is
+// called.  Many errors of the reader are never shown, e.g. when the
+// PushParser finds a value to be incomplete or IsValidKeyword checks a
+// string, so they should be cheap to create.
+//
+// The arguments must not change afterwards, which is why decoded values
+// are still formatted with fmt.Errorf.
+func errorf(format string, args ...interface{}) error {
+	return &lazyError{format: format, args: args}
+}
+
+type lazyError struct {
+	format string
+	args   []interface{}
+}
+
+func (e *lazyError) Error() string {
+	return fmt.Sprintf(strings.ReplaceAll(e.format, "%w", "%v"), e.args...)
+}
+
+func (e *lazyError) Unwrap() error {
+	if !strings.Contains(e.format, "%w") {
+		return nil
+	}
+
+	for _, arg := range e.args {
+		if err, ok := arg.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// eofError is the error for input that ends within a value, e.g.
+// "eof while reading string".  It wraps io.ErrUnexpectedEOF, so that
+// Metrics can tell it from other syntax errors.
+type eofError struct {
+	what string
+}
+
+func (e *eofError) Error() string {
+	return "eof while reading " + e.what
+}
+
+func (e *eofError) Unwrap() error {
+	return io.ErrUnexpectedEOF
+}
@@ -0,0 +1,90 @@
+package edn
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestErrorf(t *testing.T) {
+	err := errorf("macroRdr: '%c': %w", '[', io.ErrUnexpectedEOF)
+	expected := fmt.Errorf("macroRdr: '%c': %w", '[', io.ErrUnexpectedEOF)
+
+	if err.Error() != expected.Error() {
+		t.Errorf("expected %q, got %q", expected, err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected %v to wrap io.ErrUnexpectedEOF", err)
+	}
+	if errors.Unwrap(errorf("invalid token: '%s'", "x")) != nil {
+		t.Errorf("expected no wrapped error without %%w")
+	}
+}
+
+func TestBigNumbers(t *testing.T) {
+	tests := map[string]string{
+		"12345678901234567890N":  "12345678901234567890",
+		"-12345678901234567890N": "-12345678901234567890",
+		"0x1fN":                  "31",
+		"-2r101N":                "-5",
+		"017N":                   "15",
+	}
+
+	for s, expected := range tests {
+		i, ok := mustDecode(t, s).(*big.Int)
+		if !ok || i.String() != expected {
+			t.Errorf("%s: expected %s, got %v", s, expected, i)
+		}
+	}
+
+	r, ok := mustDecode(t, "-3/6").(*big.Rat)
+	if !ok || r.String() != "-1/2" {
+		t.Errorf("expected -1/2, got %v", r)
+	}
+	if _, err := DecodeString("1/0"); err == nil {
+		t.Errorf("expected an error for 1/0")
+	}
+}
+
+func BenchmarkInvalidInput(b *testing.B) {
+	b.Run("incomplete", func(b *testing.B) {
+		// the incomplete element of a value received in chunks is read
+		// again for every chunk
+		p := NewPushParser()
+		p.Write([]byte(`[1 2 3 {:a "b" :c [4 5 6`))
+		for i := 0; i < b.N; i++ {
+			if _, err := p.Next(); err != ErrIncomplete {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("keywords", func(b *testing.B) {
+		keys := []string{"with space", "@at", "", "a[b", "~tilde"}
+		for i := 0; i < b.N; i++ {
+			for _, key := range keys {
+				if IsValidKeyword(":" + key) {
+					b.Fatalf("%q is not a valid keyword", key)
+				}
+			}
+		}
+	})
+
+	b.Run("tokens", func(b *testing.B) {
+		input := strings.Repeat("a~b ", 100)
+		r := strings.NewReader(input)
+		for i := 0; i < b.N; i++ {
+			r.Reset(input)
+			d := NewDecoder(r)
+			for {
+				_, err := d.Decode()
+				if err == io.EOF {
+					break
+				}
+			}
+		}
+	})
+}
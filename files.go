@@ -0,0 +1,106 @@
+package edn
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// FileReader reads a possibly compressed EDN file, see OpenReader.
+type FileReader struct {
+	*bufio.Reader
+	closers []io.Closer
+}
+
+// OpenReader opens the file at path for reading with NewDecoder or
+// ReadValue.
+//
+// Files ending in .gz are decompressed transparently.  zstd compressed
+// files (.zst) are not supported, as the standard library has no zstd
+// implementation.
+func OpenReader(path string) (*FileReader, error) {
+	if strings.HasSuffix(path, ".zst") {
+		return nil, fmt.Errorf("zstd compression is not supported: %s", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &FileReader{closers: []io.Closer{f}}
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		r.Reader = bufio.NewReader(gz)
+		r.closers = append([]io.Closer{gz}, r.closers...)
+	} else {
+		r.Reader = bufio.NewReader(f)
+	}
+
+	return r, nil
+}
+
+// Close closes the file.
+func (r *FileReader) Close() error {
+	return closeAll(r.closers)
+}
+
+// FileWriter writes a possibly compressed EDN file, see CreateWriter.
+type FileWriter struct {
+	*bufio.Writer
+	closers []io.Closer
+}
+
+// CreateWriter creates the file at path for writing EDN text, e.g.
+// the output of String.  Files ending in .gz are compressed
+// transparently.  Close must be called to flush all data to the file.
+func CreateWriter(path string) (*FileWriter, error) {
+	if strings.HasSuffix(path, ".zst") {
+		return nil, fmt.Errorf("zstd compression is not supported: %s", path)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &FileWriter{closers: []io.Closer{f}}
+	if strings.HasSuffix(path, ".gz") {
+		gz := gzip.NewWriter(f)
+		w.Writer = bufio.NewWriter(gz)
+		w.closers = append([]io.Closer{gz}, w.closers...)
+	} else {
+		w.Writer = bufio.NewWriter(f)
+	}
+
+	return w, nil
+}
+
+// Close flushes all buffered data and closes the file.
+func (w *FileWriter) Close() error {
+	err := w.Flush()
+	if cerr := closeAll(w.closers); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+func closeAll(closers []io.Closer) error {
+	var err error
+	for _, c := range closers {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
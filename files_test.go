@@ -0,0 +1,58 @@
+package edn
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileReaderWriter(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"data.edn", "data.edn.gz"} {
+		path := filepath.Join(dir, name)
+
+		w, err := CreateWriter(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.WriteString(`{:a 1} [2 3] "four"`)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := OpenReader(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vals, err := ReadAllValues(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []interface{}{
+			map[interface{}]interface{}{Keyword{"", "a"}: int64(1)},
+			[]interface{}{int64(2), int64(3)},
+			"four",
+		}
+		if !reflect.DeepEqual(vals, expected) {
+			t.Errorf("%s: unexpected values %#v", name, vals)
+		}
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "data.edn.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) < 2 || raw[0] != 0x1f || raw[1] != 0x8b {
+		t.Errorf("data.edn.gz is not gzip compressed")
+	}
+
+	if _, err := OpenReader(filepath.Join(dir, "data.edn.zst")); err == nil {
+		t.Errorf("expected error for zstd file")
+	}
+}
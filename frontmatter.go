@@ -0,0 +1,99 @@
+package edn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReadFrontMatter splits a document into its EDN front matter and the
+// body following it.
+//
+// The front matter is either a map delimited by lines consisting of
+// "---", or a map that is the first form of the document:
+//
+//	---
+//	{:title "Hello"}
+//	---
+//	body
+//
+//	{:title "Hello"}
+//	body
+//
+// In both cases the line break after the front matter is not part of
+// the body.  Documents without front matter are returned as the body
+// with a nil map, as is the whole document on errors.
+func ReadFrontMatter(doc string) (map[interface{}]interface{}, string, error) {
+	if line, rest := cutLine(doc); line == "---" {
+		for end := 0; end < len(rest); {
+			line, body := cutLine(rest[end:])
+			if line == "---" {
+				meta, err := readFrontMatterBlock(rest[:end])
+				if err != nil {
+					return nil, doc, err
+				}
+
+				return meta, body, nil
+			}
+
+			end = len(rest) - len(body)
+		}
+
+		return nil, doc, fmt.Errorf("front matter is not terminated by ---")
+	}
+
+	start := strings.TrimLeft(doc, " \t\r\n,")
+	if !strings.HasPrefix(start, "{") {
+		return nil, doc, nil
+	}
+
+	val, rest, err := DecodeStringPrefix(start)
+	if err != nil {
+		return nil, doc, err
+	}
+
+	meta, ok := val.(map[interface{}]interface{})
+	if !ok {
+		return nil, doc, fmt.Errorf("front matter must be a map")
+	}
+
+	line, body := cutLine(rest)
+	if strings.TrimSpace(line) != "" {
+		body = rest
+	}
+
+	return meta, body, nil
+}
+
+// readFrontMatterBlock reads a delimited front matter block, which must
+// contain exactly one map.
+func readFrontMatterBlock(block string) (map[interface{}]interface{}, error) {
+	val, rest, err := DecodeStringPrefix(block)
+	if err == io.EOF {
+		return nil, fmt.Errorf("front matter is empty")
+	} else if err != nil {
+		return nil, err
+	}
+
+	meta, ok := val.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("front matter must be a map")
+	}
+
+	if _, err := DecodeString(rest); err != io.EOF {
+		return nil, fmt.Errorf("front matter must contain a single map")
+	}
+
+	return meta, nil
+}
+
+// cutLine returns the first line of s without its line break, and the
+// rest of s after it.
+func cutLine(s string) (line, rest string) {
+	i := strings.IndexByte(s, '\n')
+	if i < 0 {
+		return s, ""
+	}
+
+	return strings.TrimSuffix(s[:i], "\r"), s[i+1:]
+}
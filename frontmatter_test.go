@@ -0,0 +1,56 @@
+package edn
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadFrontMatter(t *testing.T) {
+	meta := map[interface{}]interface{}{
+		Keyword{"", "title"}: "Hello",
+		Keyword{"", "tags"}:  []interface{}{Keyword{"", "edn"}},
+	}
+
+	tests := []struct {
+		doc  string
+		meta map[interface{}]interface{}
+		body string
+	}{
+		{"---\n{:title \"Hello\"\n :tags [:edn]}\n---\n# Hello\n", meta, "# Hello\n"},
+		{"---\r\n{:title \"Hello\" :tags [:edn]} ; meta\r\n---\r\nbody", meta, "body"},
+		{"{:title \"Hello\" :tags [:edn]}\n# Hello\n", meta, "# Hello\n"},
+		{"\n{:title \"Hello\" :tags [:edn]}   \n\nbody", meta, "\nbody"},
+		{"{:title \"Hello\" :tags [:edn]} body", meta, " body"},
+		{"---\n{}\n---\n", map[interface{}]interface{}{}, ""},
+		{"# Hello\n", nil, "# Hello\n"},
+		{"", nil, ""},
+	}
+
+	for _, test := range tests {
+		m, body, err := ReadFrontMatter(test.doc)
+		if err != nil {
+			t.Errorf("%q: %v", test.doc, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(m, test.meta) || body != test.body {
+			t.Errorf("%q: expected %#v, %q, got %#v, %q", test.doc, test.meta, test.body, m, body)
+		}
+	}
+
+	invalid := []string{
+		"---\n{:title \"Hello\"}\n",
+		"---\n---\nbody",
+		"---\n[1 2]\n---\nbody",
+		"---\n{:a 1} {:b 2}\n---\nbody",
+		"{:title \"Hello\"\nbody",
+	}
+
+	for _, doc := range invalid {
+		if _, body, err := ReadFrontMatter(doc); err == nil {
+			t.Errorf("%q: expected error", doc)
+		} else if body != doc {
+			t.Errorf("%q: expected the whole document as body, got %q", doc, body)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package edn
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// Canonical returns the canonical encoding of v, in which values that
+// are Equal are encoded the same way.  It is the output of String for
+// a normalized copy of v: integers are written without the N suffix if
+// they fit into an int64, instants in UTC, and map entries and set
+// elements are sorted by their canonical encoding, and -0.0 is written
+// as 0.0.
+//
+// An error is returned if v contains values Marshal cannot encode, such
+// as values returned by tag readers of other packages without a tag
+// writer, see RegisterTagWriter, or keywords and symbols that are not
+// read back as themselves, such as the symbol nil, which would have the
+// encoding of another value.
+//
+// Values read by tag readers are encoded by their Go type, so the
+// encoding of a decoded document depends on the tag readers registered
+// when it was read: a tagged element without a reader is encoded with
+// its tag as it was read, one with a reader as the value the reader
+// returned, which need not have the same encoding.  Decode documents
+// with the same registry to compare their hashes.
+func Canonical(v interface{}) ([]byte, error) {
+	v = canonical(v)
+	if err := checkEncodable(v); err != nil {
+		return nil, err
+	}
+	return appendValue(nil, v), nil
+}
+
+// ContentHash returns the SHA-256 hash of the canonical encoding of v,
+// e.g. to detect duplicate documents.  Values that are Equal have the
+// same hash.  Errors are those of Canonical.
+func ContentHash(v interface{}) ([32]byte, error) {
+	data, err := Canonical(v)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+func canonical(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case *big.Int:
+		if v != nil && v.IsInt64() {
+			return v.Int64()
+		}
+		return v
+	case float64:
+		if v == 0 {
+			// -0.0 is Equal to 0.0
+			return 0.0
+		}
+		return v
+	case time.Time:
+		return v.UTC()
+	case CompositeKey:
+		val, err := v.Value()
+		if err != nil {
+			return v
+		}
+		return canonical(val)
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, elem := range v {
+			res[i] = canonical(elem)
+		}
+		return res
+	case map[interface{}]interface{}:
+		res := make(map[interface{}]interface{}, len(v))
+		for key, val := range v {
+			res[KeyOf(canonical(key))] = canonical(val)
+		}
+		return res
+	case map[interface{}]bool:
+		res := make(map[interface{}]bool, len(v))
+		for elem := range v {
+			res[KeyOf(canonical(elem))] = true
+		}
+		return res
+	case Tagged:
+		return Tagged{Tag: v.Tag, Value: canonical(v.Value)}
+	default:
+		if tagged, ok, err := writeTagged(v); ok && err == nil {
+			return canonical(tagged)
+		}
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Map {
+			res := make(map[interface{}]interface{}, rv.Len())
+			for iter := rv.MapRange(); iter.Next(); {
+				res[KeyOf(canonical(iter.Key().Interface()))] = canonical(iter.Value().Interface())
+			}
+			return res
+		}
+		return v
+	}
+}
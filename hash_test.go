@@ -0,0 +1,89 @@
+package edn
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestCanonical(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+
+	tests := []struct {
+		val      interface{}
+		expected string
+	}{
+		{big.NewInt(42), "42"},
+		{new(big.Int).Lsh(big.NewInt(1), 70), "1180591620717411303424N"},
+		{time.Date(2020, 1, 1, 1, 0, 0, 0, berlin), `#inst "2020-01-01T00:00:00Z"`},
+		{map[interface{}]interface{}{big.NewInt(2): 1, int64(1): []interface{}{big.NewInt(3)}}, "{1 [3] 2 1}"},
+		{map[interface{}]bool{KeyOf([]interface{}{big.NewInt(1)}): true}, "#{[1]}"},
+		{Tagged{Tag: Symbol{"", "foo"}, Value: big.NewInt(1)}, "#foo 1"},
+		{math.Copysign(0, -1), "0.0"},
+	}
+
+	for _, test := range tests {
+		if s := mustCanonical(t, test.val); s != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, s)
+		}
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	a := mustDecode(t, `{:a [1 2 3] :b #{:x :y} :c #inst "2020-01-01T01:00:00+01:00"}`)
+	b := mustDecode(t, `{:c #inst "2020-01-01T00:00:00Z", :b #{:y :x}, :a (1N 2 3)}`)
+	c := mustDecode(t, `{:a [1 2 3] :b #{:x :y} :c #inst "2020-01-01T00:00:01Z"}`)
+
+	if mustHash(t, a) != mustHash(t, b) {
+		t.Errorf("equal values should have the same hash")
+	}
+	if mustHash(t, a) == mustHash(t, c) {
+		t.Errorf("different values should have different hashes")
+	}
+	if mustHash(t, 0.0) != mustHash(t, math.Copysign(0, -1)) {
+		t.Errorf("0.0 and -0.0 should have the same hash")
+	}
+}
+
+func mustHash(t *testing.T, v interface{}) [32]byte {
+	t.Helper()
+	h, err := ContentHash(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestCanonicalUnencodable(t *testing.T) {
+	for _, v := range []interface{}{
+		struct{}{},
+		[]interface{}{struct{}{}},
+		Tagged{Tag: Symbol{"", "foo"}, Value: struct{}{}},
+		Symbol{"", "nil"},
+		[]interface{}{Symbol{"", "12"}},
+		Keyword{"", "a b"},
+		Tagged{Tag: Symbol{}, Value: int64(1)},
+	} {
+		if _, err := Canonical(v); err == nil {
+			t.Errorf("expected an error for %#v", v)
+		}
+		if _, err := ContentHash(v); err == nil {
+			t.Errorf("expected an error for %#v", v)
+		}
+	}
+}
+
+func TestContentHashInvalidTokens(t *testing.T) {
+	for _, pair := range [][2]interface{}{
+		{Symbol{"", "nil"}, nil},
+		{Symbol{"", "12"}, int64(12)},
+	} {
+		h, err := ContentHash(pair[0])
+		if err == nil && h == mustHash(t, pair[1]) {
+			t.Errorf("%#v should not have the hash of %#v", pair[0], pair[1])
+		} else if err == nil {
+			t.Errorf("expected an error for %#v", pair[0])
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package edn
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// HeaderTag is the tag of stream headers, edn/header.
+var HeaderTag = Symbol{Namespace: "edn", Name: "header"}
+
+// Header describes the values of a stream.  It is written as the first
+// value of the stream, as a map tagged with edn/header:
+//
+//	#edn/header {:version 2
+//	             :tags {myapp/point "a vector of x and y"}}
+//	{:name "first value"}
+//
+// Headers are optional, streams without one are read as before.
+type Header struct {
+	// Version is the schema version of the values, 0 if not given.
+	Version int64
+	// Tags maps the tags used in the stream to descriptions of them.
+	Tags map[Symbol]string
+	// Extra holds all other entries of the header map.
+	Extra map[interface{}]interface{}
+}
+
+var (
+	headerVersion = Keyword{Namespace: "", Name: "version"}
+	headerTags    = Keyword{Namespace: "", Name: "tags"}
+)
+
+// DecodeHeader reads the first value of the stream.  If it is a header,
+// it is returned with a nil val.  Otherwise h is nil and val is the
+// value, so that streams without a header can be read as usual.
+//
+// io.EOF is returned for empty streams, as by Decode.
+func (d *Decoder) DecodeHeader() (h *Header, val interface{}, err error) {
+	val, err = d.Decode()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tagged, ok := val.(Tagged)
+	if !ok || tagged.Tag != HeaderTag {
+		return nil, val, nil
+	}
+
+	h, err = parseHeader(tagged.Value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return h, nil, nil
+}
+
+func parseHeader(val interface{}) (*Header, error) {
+	m, ok := val.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("edn/header must be a map, but was %s", String(val))
+	}
+
+	h := &Header{}
+	for key, val := range m {
+		switch key {
+		case headerVersion:
+			version, ok := val.(int64)
+			if !ok || version < 0 {
+				return nil, fmt.Errorf("edn/header :version must be a non-negative integer, but was %s", String(val))
+			}
+			h.Version = version
+		case headerTags:
+			tags, ok := val.(map[interface{}]interface{})
+			if !ok {
+				return nil, fmt.Errorf("edn/header :tags must be a map, but was %s", String(val))
+			}
+
+			h.Tags = make(map[Symbol]string, len(tags))
+			for tag, desc := range tags {
+				sym, ok := tag.(Symbol)
+				if !ok {
+					return nil, fmt.Errorf("edn/header tag must be a symbol, but was %s", String(tag))
+				}
+
+				str, ok := desc.(string)
+				if !ok {
+					return nil, fmt.Errorf("edn/header description of %s must be a string, but was %s", sym, String(desc))
+				}
+
+				h.Tags[sym] = str
+			}
+		default:
+			if h.Extra == nil {
+				h.Extra = make(map[interface{}]interface{})
+			}
+			h.Extra[key] = val
+		}
+	}
+
+	return h, nil
+}
+
+// Value returns h as the tagged map it is written as.
+func (h *Header) Value() Tagged {
+	m := make(map[interface{}]interface{}, len(h.Extra)+2)
+	for key, val := range h.Extra {
+		m[key] = val
+	}
+
+	if h.Version != 0 {
+		m[headerVersion] = h.Version
+	}
+
+	if len(h.Tags) > 0 {
+		tags := make(map[interface{}]interface{}, len(h.Tags))
+		for tag, desc := range h.Tags {
+			tags[tag] = desc
+		}
+		m[headerTags] = tags
+	}
+
+	return Tagged{Tag: HeaderTag, Value: m}
+}
+
+// String returns the EDN representation of h, see String.
+func (h *Header) String() string {
+	return String(h.Value())
+}
+
+// WriteHeader writes h followed by a newline to w, to start a stream
+// of values.
+func WriteHeader(w io.Writer, h *Header) error {
+	_, err := io.WriteString(w, h.String()+"\n")
+	return err
+}
+
+// Check returns an error if a consumer supporting the schema versions
+// minVersion to maxVersion cannot read the stream described by h.
+//
+// The stream may also not use tags that the consumer cannot read,
+// which are those of the header without a registered tag reader (see
+// RegisterTag) that are not in known, e.g. tags the consumer handles as
+// Tagged values itself.
+func (h *Header) Check(minVersion, maxVersion int64, known ...Symbol) error {
+	if h.Version < minVersion || h.Version > maxVersion {
+		return fmt.Errorf("unsupported schema version %d, expected %d to %d", h.Version, minVersion, maxVersion)
+	}
+
+	var unknown []string
+	for tag := range h.Tags {
+		if _, ok := tagReader(tag); ok || containsSymbol(known, tag) {
+			continue
+		}
+		unknown = append(unknown, tag.String())
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown tags %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+func containsSymbol(syms []Symbol, sym Symbol) bool {
+	for _, s := range syms {
+		if s == sym {
+			return true
+		}
+	}
+
+	return false
+}
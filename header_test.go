@@ -0,0 +1,103 @@
+package edn
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeHeader(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`#edn/header {:version 2 :tags {my/point "x and y"} :author "me"} {:a 1}`))
+	h, val, err := d.DecodeHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Header{
+		Version: 2,
+		Tags:    map[Symbol]string{{"my", "point"}: "x and y"},
+		Extra:   map[interface{}]interface{}{Keyword{"", "author"}: "me"},
+	}
+	if !reflect.DeepEqual(h, expected) || val != nil {
+		t.Fatalf("expected %#v, got %#v, %#v", expected, h, val)
+	}
+
+	if val, err := d.Decode(); err != nil || String(val) != "{:a 1}" {
+		t.Errorf("expected the value after the header, got %#v, %v", val, err)
+	}
+
+	d = NewDecoder(strings.NewReader(`{:a 1}`))
+	h, val, err = d.DecodeHeader()
+	if err != nil || h != nil || String(val) != "{:a 1}" {
+		t.Errorf("expected the first value without a header, got %#v, %#v, %v", h, val, err)
+	}
+
+	d = NewDecoder(strings.NewReader(` `))
+	if _, _, err := d.DecodeHeader(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	invalid := []string{
+		`#edn/header [1]`,
+		`#edn/header {:version "1"}`,
+		`#edn/header {:version -1}`,
+		`#edn/header {:tags [my/point]}`,
+		`#edn/header {:tags {:my/point "x"}}`,
+		`#edn/header {:tags {my/point 1}}`,
+	}
+
+	for _, s := range invalid {
+		if _, _, err := NewDecoder(strings.NewReader(s)).DecodeHeader(); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+}
+
+func TestWriteHeader(t *testing.T) {
+	h := &Header{
+		Version: 3,
+		Tags:    map[Symbol]string{{"my", "point"}: "x and y"},
+		Extra:   map[interface{}]interface{}{Keyword{"", "author"}: "me"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHeader(&buf, h); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "#edn/header {:author \"me\" :tags {my/point \"x and y\"} :version 3}\n"
+	if buf.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, buf.String())
+	}
+
+	read, _, err := NewDecoder(&buf).DecodeHeader()
+	if err != nil || !reflect.DeepEqual(read, h) {
+		t.Errorf("expected %#v, got %#v, %v", h, read, err)
+	}
+
+	if s := (&Header{}).String(); s != "#edn/header {}" {
+		t.Errorf("expected an empty header map, got %s", s)
+	}
+}
+
+func TestHeaderCheck(t *testing.T) {
+	h := &Header{
+		Version: 2,
+		Tags:    map[Symbol]string{{"", "inst"}: "", {"my", "point"}: "", {"my", "line"}: ""},
+	}
+
+	if err := h.Check(1, 2, Symbol{"my", "point"}, Symbol{"my", "line"}); err != nil {
+		t.Errorf("expected header to be accepted, got %v", err)
+	}
+
+	if err := h.Check(3, 4, Symbol{"my", "point"}, Symbol{"my", "line"}); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+
+	err := h.Check(1, 2)
+	if err == nil || err.Error() != "unknown tags my/line, my/point" {
+		t.Errorf("expected error for unknown tags, got %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package edn
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IncludeTag is the tag of includes, include.
+var IncludeTag = Symbol{Namespace: "", Name: "include"}
+
+// Resolver reads configurations split over several files, which include
+// each other with the include tag:
+//
+//	{:server #include "server.edn"
+//	 :plugins #include "conf.d/*.edn"}
+//
+// An include is replaced by the value of the named file, with its own
+// includes resolved.  Paths are relative to the directory of the
+// including file.  A path containing glob patterns (see filepath.Match)
+// is replaced by a vector of the values of all matching files, in
+// lexical order of their names, which is empty if none match.
+//
+// Includes are resolved in vectors, lists, map values and the values of
+// tagged elements, but not in map keys and set elements.
+//
+// Each file must contain exactly one value.  Files are read once and
+// cached by their absolute path until their size or modification time,
+// or that of a file they include, changes, so that files included from
+// many places are read and resolved only once.  The cached values are
+// shared and must not be modified.
+//
+// A Resolver is safe for concurrent use.
+type Resolver struct {
+	mu    sync.Mutex
+	cache map[string]*resolved
+}
+
+// resolved is the cached value of a file, together with the files and
+// globs it depends on, including those of the files it includes.
+type resolved struct {
+	val   interface{}
+	files map[string]stamp
+	globs map[string][]string
+}
+
+type stamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func stampOf(info os.FileInfo) stamp {
+	return stamp{size: info.Size(), modTime: info.ModTime()}
+}
+
+// current returns whether none of the files of c has changed and all of
+// its globs still match the same files.
+func (c *resolved) current() bool {
+	for path, s := range c.files {
+		info, err := os.Stat(path)
+		if err != nil || stampOf(info) != s {
+			return false
+		}
+	}
+
+	for pattern, matches := range c.globs {
+		m, err := filepath.Glob(pattern)
+		if err != nil || strings.Join(m, "\x00") != strings.Join(matches, "\x00") {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *resolved) add(dep *resolved) {
+	for path, s := range dep.files {
+		c.files[path] = s
+	}
+	for pattern, matches := range dep.globs {
+		c.globs[pattern] = matches
+	}
+}
+
+// NewResolver returns a Resolver with an empty cache.
+func NewResolver() *Resolver {
+	return &Resolver{cache: make(map[string]*resolved)}
+}
+
+// IncludeError is returned for files that cannot be read or resolved.
+type IncludeError struct {
+	// Chain lists the files from the one passed to Resolve to the one
+	// that failed, each including the next.  It ends with the repeated
+	// file for include cycles.
+	Chain []string
+	Err   error
+}
+
+func (e *IncludeError) Error() string {
+	if len(e.Chain) == 1 {
+		return fmt.Sprintf("%s: %s", e.Chain[0], e.Err)
+	}
+	return fmt.Sprintf("%s: %s (included via %s)", e.Chain[len(e.Chain)-1], e.Err, strings.Join(e.Chain, " -> "))
+}
+
+func (e *IncludeError) Unwrap() error {
+	return e.Err
+}
+
+// Resolve returns the value of the file at path with all includes
+// resolved.  Errors are returned as *IncludeError, including include
+// cycles.
+func (r *Resolver) Resolve(path string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.resolve(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.val, nil
+}
+
+// Forget removes all files from the cache.
+func (r *Resolver) Forget() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cache = make(map[string]*resolved)
+}
+
+// resolve returns the value of the file at path, which is included via
+// the files in chain.
+func (r *Resolver) resolve(path string, chain []string) (*resolved, error) {
+	chain = append(chain[:len(chain):len(chain)], path)
+	fail := func(err error) error {
+		if _, ok := err.(*IncludeError); ok {
+			return err
+		}
+		return &IncludeError{Chain: chain, Err: err}
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	for _, including := range chain[:len(chain)-1] {
+		if a, err := filepath.Abs(including); err == nil && a == abs {
+			return nil, fail(fmt.Errorf("include cycle"))
+		}
+	}
+
+	if c, ok := r.cache[abs]; ok && c.current() {
+		return c, nil
+	}
+
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	vals, err := readFile(abs)
+	if err != nil {
+		return nil, fail(err)
+	}
+	if len(vals) != 1 {
+		return nil, fail(fmt.Errorf("must contain one value, but contains %d", len(vals)))
+	}
+
+	c := &resolved{
+		files: map[string]stamp{abs: stampOf(info)},
+		globs: map[string][]string{},
+	}
+	c.val, err = r.resolveIncludes(c, vals[0], filepath.Dir(path), chain)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	r.cache[abs] = c
+	return c, nil
+}
+
+// resolveIncludes returns a copy of v with its includes replaced, with
+// paths relative to dir.  The included files are added to c.
+func (r *Resolver) resolveIncludes(c *resolved, v interface{}, dir string, chain []string) (interface{}, error) {
+	switch v := v.(type) {
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, elem := range v {
+			val, err := r.resolveIncludes(c, elem, dir, chain)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = val
+		}
+		return res, nil
+	case map[interface{}]interface{}:
+		res := make(map[interface{}]interface{}, len(v))
+		for key, elem := range v {
+			val, err := r.resolveIncludes(c, elem, dir, chain)
+			if err != nil {
+				return nil, err
+			}
+			res[key] = val
+		}
+		return res, nil
+	case Tagged:
+		if v.Tag != IncludeTag {
+			val, err := r.resolveIncludes(c, v.Value, dir, chain)
+			if err != nil {
+				return nil, err
+			}
+			return Tagged{Tag: v.Tag, Value: val}, nil
+		}
+
+		include, ok := v.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("include must be a string, but was %s", String(v.Value))
+		}
+		name := include
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+
+		if !strings.ContainsAny(include, "*?[") {
+			dep, err := r.resolve(name, chain)
+			if err != nil {
+				return nil, err
+			}
+			c.add(dep)
+			return dep.val, nil
+		}
+
+		matches, err := filepath.Glob(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include %q: %w", include, err)
+		}
+		c.globs[name] = matches
+
+		vals := make([]interface{}, len(matches))
+		for i, match := range matches {
+			dep, err := r.resolve(match, chain)
+			if err != nil {
+				return nil, err
+			}
+			c.add(dep)
+			vals[i] = dep.val
+		}
+		return vals, nil
+	default:
+		return v, nil
+	}
+}
+
+func readFile(path string) ([]interface{}, error) {
+	r, err := OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ReadAllValues(r)
+}
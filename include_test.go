@@ -0,0 +1,156 @@
+package edn
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestResolver(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.edn":         `{:server #include "server.edn" :plugins #include "conf.d/*.edn" :tagged #my/tag [#include "conf.d/b.edn"]}`,
+		"server.edn":       `{:port 8080}`,
+		"conf.d/a.edn":     `{:name "a" :server #include "../server.edn"}`,
+		"conf.d/b.edn":     `{:name "b"}`,
+		"conf.d/notes.txt": `not edn`,
+	})
+
+	r := NewResolver()
+	val, err := r.Resolve(filepath.Join(dir, "main.edn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{:plugins [{:name "a" :server {:port 8080}} {:name "b"}] :server {:port 8080} :tagged #my/tag [{:name "b"}]}`
+	if s := mustCanonical(t, val); s != expected {
+		t.Fatalf("expected %s, got %s", expected, s)
+	}
+
+	// changes of included files are picked up
+	writeFiles(t, dir, map[string]string{
+		"server.edn":   `{:port 8081 :padding "changes the size"}`,
+		"conf.d/c.edn": `{:name "c"}`,
+	})
+	val, err = r.Resolve(filepath.Join(dir, "main.edn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `{:plugins [{:name "a" :server {:padding "changes the size" :port 8081}} {:name "b"} {:name "c"}] :server {:padding "changes the size" :port 8081} :tagged #my/tag [{:name "b"}]}`
+	if s := mustCanonical(t, val); s != expected {
+		t.Errorf("expected %s, got %s", expected, s)
+	}
+}
+
+func TestResolverCache(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.edn":   `[#include "shared.edn" #include "shared.edn"]`,
+		"shared.edn": `{:a 1}`,
+	})
+
+	r := NewResolver()
+	val, err := r.Resolve(filepath.Join(dir, "main.edn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vec := val.([]interface{})
+	if a, b := vec[0].(map[interface{}]interface{}), vec[1].(map[interface{}]interface{}); len(a) != 1 || !sameMap(a, b) {
+		t.Errorf("expected the cached value to be shared, got %s", String(val))
+	}
+
+	again, err := r.Resolve(filepath.Join(dir, "main.edn"))
+	if err != nil || !sameMap(again.([]interface{})[0].(map[interface{}]interface{}), vec[0].(map[interface{}]interface{})) {
+		t.Errorf("expected the cached value, got %s, %v", String(again), err)
+	}
+
+	r.Forget()
+	again, err = r.Resolve(filepath.Join(dir, "main.edn"))
+	if err != nil || sameMap(again.([]interface{})[0].(map[interface{}]interface{}), vec[0].(map[interface{}]interface{})) {
+		t.Errorf("expected the files to be read again, got %s, %v", String(again), err)
+	}
+}
+
+// sameMap returns whether a and b are the same map.
+func sameMap(a, b map[interface{}]interface{}) bool {
+	a[Keyword{"", "marker"}] = true
+	defer delete(a, Keyword{"", "marker"})
+	_, ok := b[Keyword{"", "marker"}]
+	return ok
+}
+
+func TestResolverErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.edn":       `{:b #include "b.edn"}`,
+		"b.edn":       `{:c #include "sub/c.edn"}`,
+		"sub/c.edn":   `{:a #include "../a.edn"}`,
+		"missing.edn": `{:x #include "nothing.edn"}`,
+		"two.edn":     `1 2`,
+		"number.edn":  `#include 1`,
+		"nested.edn":  `[#include "two.edn"]`,
+	})
+
+	r := NewResolver()
+	_, err := r.Resolve(filepath.Join(dir, "a.edn"))
+	var ierr *IncludeError
+	if !errors.As(err, &ierr) {
+		t.Fatalf("expected an *IncludeError, got %v", err)
+	}
+
+	chain := make([]string, len(ierr.Chain))
+	for i, path := range ierr.Chain {
+		chain[i], _ = filepath.Rel(dir, path)
+	}
+	if s := strings.Join(chain, " -> "); s != "a.edn -> b.edn -> sub/c.edn -> a.edn" {
+		t.Errorf("expected the include cycle, got %s", s)
+	}
+	if !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("expected include cycle error, got %v", err)
+	}
+
+	tests := map[string]string{
+		"missing.edn": "nothing.edn",
+		"two.edn":     "must contain one value, but contains 2",
+		"number.edn":  "include must be a string, but was 1",
+		"nested.edn":  "nested.edn -> ",
+	}
+	for name, msg := range tests {
+		_, err := r.Resolve(filepath.Join(dir, name))
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%s: expected error containing %q, got %v", name, msg, err)
+		}
+	}
+
+	// fixing a file makes the including files valid again
+	writeFiles(t, dir, map[string]string{"sub/c.edn": `{:a "no cycle"}`})
+	if val, err := r.Resolve(filepath.Join(dir, "a.edn")); err != nil || mustCanonical(t, val) != `{:b {:c {:a "no cycle"}}}` {
+		t.Errorf("expected the fixed value, got %s, %v", String(val), err)
+	}
+}
+
+func mustCanonical(t *testing.T, val interface{}) string {
+	t.Helper()
+	data, err := Canonical(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,88 @@
+package edn
+
+import (
+	"sort"
+	"strings"
+)
+
+// KeywordIndex maps the keywords in a document to their occurrences.
+type KeywordIndex map[Keyword]*KeywordInfo
+
+// KeywordInfo describes the occurrences of a keyword in a document.
+type KeywordInfo struct {
+	// Count is the number of occurrences, KeyCount the number of
+	// occurrences as map key.
+	Count    int
+	KeyCount int
+
+	// Paths contains the path of each occurrence, in the order the
+	// document was traversed.  A path consists of the map keys,
+	// vector/list indices (int) and set elements leading to the
+	// keyword.  For a map key, the path leads to the entry it names.
+	Paths [][]interface{}
+}
+
+// IndexKeywords returns an index of all keywords in the decoded value
+// v, including keywords in tagged values.
+func IndexKeywords(v interface{}) KeywordIndex {
+	idx := KeywordIndex{}
+	idx.add(v, nil, false)
+	return idx
+}
+
+func (idx KeywordIndex) add(v interface{}, path []interface{}, isKey bool) {
+	switch v := v.(type) {
+	case Keyword:
+		info, ok := idx[v]
+		if !ok {
+			info = &KeywordInfo{}
+			idx[v] = info
+		}
+
+		info.Count++
+		if isKey {
+			info.KeyCount++
+		}
+		info.Paths = append(info.Paths, append([]interface{}{}, path...))
+	case []interface{}:
+		for i, elem := range v {
+			idx.add(elem, append(path, i), false)
+		}
+	case map[interface{}]interface{}:
+		for key, val := range v {
+			entryPath := append(path, key)
+			idx.add(key, entryPath, true)
+			idx.add(val, entryPath, false)
+		}
+	case map[interface{}]bool:
+		for elem := range v {
+			idx.add(elem, append(path, elem), false)
+		}
+	case Tagged:
+		idx.add(v.Value, path, false)
+	}
+}
+
+// WithPrefix returns the keywords whose full name (without the leading
+// colon) starts with prefix, ordered by descending count and then by
+// name.  A prefix of "user/" thus completes all keywords in the "user"
+// namespace.
+func (idx KeywordIndex) WithPrefix(prefix string) []Keyword {
+	prefix = strings.TrimPrefix(prefix, ":")
+
+	kws := []Keyword{}
+	for kw := range idx {
+		if strings.HasPrefix(kw.FullName(), prefix) {
+			kws = append(kws, kw)
+		}
+	}
+
+	sort.Slice(kws, func(i, j int) bool {
+		ci, cj := idx[kws[i]].Count, idx[kws[j]].Count
+		if ci != cj {
+			return ci > cj
+		}
+		return kws[i].FullName() < kws[j].FullName()
+	})
+	return kws
+}
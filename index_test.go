@@ -0,0 +1,43 @@
+package edn
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIndexKeywords(t *testing.T) {
+	val, err := DecodeString(`{:user/name "jane" :user/roles [:admin :user/name] :status :ok :tags #{:ok}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx := IndexKeywords(val)
+
+	name := idx[Keyword{"user", "name"}]
+	if name == nil || name.Count != 2 || name.KeyCount != 1 {
+		t.Fatalf("unexpected info %#v", name)
+	}
+
+	paths := map[string]bool{}
+	for _, path := range name.Paths {
+		paths[String(path)] = true
+	}
+	if !reflect.DeepEqual(paths, map[string]bool{"[:user/name]": true, "[:user/roles 1]": true}) {
+		t.Errorf("unexpected paths %v", paths)
+	}
+
+	ok := idx[Keyword{"", "ok"}]
+	if ok == nil || ok.Count != 2 || ok.KeyCount != 0 {
+		t.Errorf("unexpected info %#v", ok)
+	}
+
+	completions := idx.WithPrefix(":user/")
+	expected := []Keyword{{"user", "name"}, {"user", "roles"}}
+	if !reflect.DeepEqual(completions, expected) {
+		t.Errorf("expected %v, got %v", expected, completions)
+	}
+
+	if len(idx.WithPrefix("")) != 6 {
+		t.Errorf("expected 6 keywords, got %v", idx.WithPrefix(""))
+	}
+}
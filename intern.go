@@ -0,0 +1,30 @@
+package edn
+
+// SetStringInterning makes the decoder return the same string for equal
+// strings it reads, so that values repeated many times, e.g. in logs,
+// share their memory and reading them again allocates nothing.
+//
+// At most n distinct strings are kept.  When that many have been read,
+// the decoder starts over with an empty table, so that strings seen
+// often are kept, but rare ones don't fill the table for good.  A value
+// of 0, the default, disables interning.  Interned strings are never
+// allocated from an arena, see SetArena.
+func (d *Decoder) SetStringInterning(n int) {
+	d.internLimit = n
+	d.interned = nil
+}
+
+// intern returns the interned string for the text of a string.
+func (d *Decoder) intern(text []byte) string {
+	if s, ok := d.interned[string(text)]; ok {
+		return s
+	}
+
+	if d.interned == nil || len(d.interned) >= d.internLimit {
+		d.interned = make(map[string]string)
+	}
+
+	s := d.normalize(string(text))
+	d.interned[string(text)] = s
+	return s
+}
@@ -0,0 +1,68 @@
+package edn
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestStringInterning(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`["info" "a \"quoted\" message" "info" "" "a \"quoted\" message"]`))
+	d.SetStringInterning(10)
+
+	val, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vec := val.([]interface{})
+	if expected := mustDecode(t, String(vec)); !Equal(vec, expected) {
+		t.Fatalf("expected %s, got %s", String(expected), String(vec))
+	}
+
+	same := func(a, b interface{}) bool {
+		return unsafe.StringData(a.(string)) == unsafe.StringData(b.(string))
+	}
+	if !same(vec[0], vec[2]) || !same(vec[1], vec[4]) {
+		t.Errorf("expected equal strings to be interned")
+	}
+}
+
+func TestStringInterningLimit(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`"a" "b" "c" "d"`))
+	d.SetStringInterning(2)
+
+	for _, expected := range []string{"a", "b", "c", "d"} {
+		val, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val != expected {
+			t.Errorf("expected %q, got %v", expected, val)
+		}
+		if len(d.interned) > 2 {
+			t.Errorf("expected at most 2 interned strings, got %d", len(d.interned))
+		}
+	}
+}
+
+func TestStringInterningAllocations(t *testing.T) {
+	input := `["GET" "/index.html" "200" "GET" "/index.html" "200"]`
+
+	count := func(n int) float64 {
+		r := strings.NewReader(input)
+		d := NewDecoder(r)
+		d.SetStringInterning(n)
+		return testing.AllocsPerRun(100, func() {
+			r.Reset(input)
+			if _, err := d.Decode(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	without, with := count(0), count(100)
+	if with >= without {
+		t.Errorf("expected fewer allocations with interning, got %v with and %v without", with, without)
+	}
+}
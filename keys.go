@@ -0,0 +1,43 @@
+package edn
+
+import (
+	"reflect"
+)
+
+// CompositeKey is used as map key and set element for vectors, lists,
+// maps and sets, which can't be keys of Go maps.  It contains their
+// String representation, so equal values have the same key.
+//
+// Use KeyOf to look up such values:
+//
+//	m[edn.KeyOf([]interface{}{int64(1), int64(2)})]
+type CompositeKey string
+
+// KeyOf returns the key v has in decoded maps and sets: v itself if it
+// can be a key of Go maps, and a CompositeKey otherwise.
+func KeyOf(v interface{}) interface{} {
+	if hashable(v) {
+		return v
+	}
+
+	return CompositeKey(String(v))
+}
+
+// Value decodes the value k was created from.
+func (k CompositeKey) Value() (interface{}, error) {
+	return DecodeString(string(k))
+}
+
+func hashable(v interface{}) bool {
+	switch v := v.(type) {
+	case []interface{}, map[interface{}]interface{}, map[interface{}]bool:
+		return false
+	case Keyword, string, int64, Symbol, float64, bool:
+		// the common keys, without reflection
+		return true
+	case Tagged:
+		return hashable(v.Value)
+	default:
+		return v == nil || reflect.TypeOf(v).Comparable()
+	}
+}
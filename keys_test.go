@@ -0,0 +1,48 @@
+package edn
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompositeKeys(t *testing.T) {
+	val, err := DecodeString(`{[1 2] :a, {:b #{3}} :b, #tag (4) :c, :d #{[5] [5]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := val.(map[interface{}]interface{})
+	keys := []struct {
+		key      interface{}
+		expected Keyword
+	}{
+		{[]interface{}{int64(1), int64(2)}, Keyword{"", "a"}},
+		{[]interface{}{1, 2}, Keyword{"", "a"}},
+		{map[interface{}]interface{}{Keyword{"", "b"}: map[interface{}]bool{3: true}}, Keyword{"", "b"}},
+		{Tagged{Symbol{"", "tag"}, []interface{}{int64(4)}}, Keyword{"", "c"}},
+	}
+
+	for _, test := range keys {
+		if got := m[KeyOf(test.key)]; got != test.expected {
+			t.Errorf("%s: expected %s, got %#v", String(test.key), test.expected, got)
+		}
+	}
+
+	set := m[Keyword{"", "d"}].(map[interface{}]bool)
+	if len(set) != 1 || !set[KeyOf([]interface{}{int64(5)})] {
+		t.Errorf("unexpected set %#v", set)
+	}
+
+	if s := String(val); s != `{#tag [4] :c :d #{[5]} [1 2] :a {:b #{3}} :b}` {
+		t.Errorf("unexpected representation %s", s)
+	}
+
+	key := KeyOf([]interface{}{int64(1), Keyword{"", "x"}})
+	if v, err := key.(CompositeKey).Value(); err != nil || !reflect.DeepEqual(v, []interface{}{int64(1), Keyword{"", "x"}}) {
+		t.Errorf("unexpected value %#v, %v", v, err)
+	}
+
+	if KeyOf(Keyword{"", "kw"}) != (Keyword{"", "kw"}) {
+		t.Error("hashable values should be their own keys")
+	}
+}
@@ -0,0 +1,127 @@
+package edn
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// LazyMap is a map whose values are only decoded when they are
+// accessed.
+//
+// Decoding a LazyMap only decodes its keys, the values are kept as the
+// raw bytes of their forms.  This is much cheaper than decoding the
+// whole map if only a few of the values are needed.
+type LazyMap struct {
+	keys    []interface{}
+	index   map[interface{}]int
+	raw     [][]byte
+	values  []interface{}
+	decoded []bool
+}
+
+// DecodeLazyMap reads the map at the start of data as a LazyMap.
+//
+// The value forms are only checked to be well-formed (balanced
+// delimiters, terminated strings), errors in them are reported by Get.
+// data must not be modified while the map is in use.
+func DecodeLazyMap(data []byte) (*LazyMap, error) {
+	pos, err := skipSpace(data, 0)
+	if err == io.EOF {
+		return nil, fmt.Errorf("eof while reading map")
+	} else if err != nil {
+		return nil, err
+	}
+
+	if data[pos] != '{' {
+		return nil, fmt.Errorf("expected map, but found '%c'", data[pos])
+	}
+	pos++
+
+	m := &LazyMap{index: make(map[interface{}]int)}
+	for {
+		next, err := skipSpace(data, pos)
+		if err == io.EOF {
+			return nil, fmt.Errorf("eof while reading map")
+		} else if err != nil {
+			return nil, err
+		}
+
+		if data[next] == '}' {
+			break
+		}
+
+		keyStart, keyEnd, err := scanForm(data, next)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ReadValue(bytes.NewReader(data[keyStart:keyEnd]))
+		if err != nil {
+			return nil, err
+		}
+		key = KeyOf(key)
+
+		valStart, valEnd, err := scanForm(data, keyEnd)
+		if err == io.EOF {
+			return nil, fmt.Errorf("map literal must contain an even number of forms")
+		} else if err != nil {
+			return nil, err
+		}
+
+		if i, ok := m.index[key]; ok {
+			m.raw[i] = data[valStart:valEnd]
+		} else {
+			m.index[key] = len(m.keys)
+			m.keys = append(m.keys, key)
+			m.raw = append(m.raw, data[valStart:valEnd])
+		}
+
+		pos = valEnd
+	}
+
+	m.values = make([]interface{}, len(m.keys))
+	m.decoded = make([]bool, len(m.keys))
+	return m, nil
+}
+
+// Len returns the number of entries.
+func (m *LazyMap) Len() int {
+	return len(m.keys)
+}
+
+// Keys returns the keys in the order they appear in the input.
+func (m *LazyMap) Keys() []interface{} {
+	return m.keys
+}
+
+// Raw returns the undecoded form of the value for key.
+func (m *LazyMap) Raw(key interface{}) ([]byte, bool) {
+	i, ok := m.index[KeyOf(key)]
+	if !ok {
+		return nil, false
+	}
+
+	return m.raw[i], true
+}
+
+// Get returns the value for key, decoding it on first access.  ok is
+// false if there is no entry for key.
+func (m *LazyMap) Get(key interface{}) (val interface{}, ok bool, err error) {
+	i, ok := m.index[KeyOf(key)]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if !m.decoded[i] {
+		val, err := ReadValue(bytes.NewReader(m.raw[i]))
+		if err != nil {
+			return nil, true, err
+		}
+
+		m.values[i] = val
+		m.decoded[i] = true
+	}
+
+	return m.values[i], true, nil
+}
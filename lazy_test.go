@@ -0,0 +1,68 @@
+package edn
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLazyMap(t *testing.T) {
+	data := []byte(`{:name "a \"quoted\" } string"
+	                 :nested {:deep [1 2 #{3}] ; comment with ]
+	                          :tagged #inst "1985-04-12T23:20:50.52Z"}
+	                 #_ :discarded #_ value
+	                 :broken #uuid "nope"
+	                 "str-key" \}
+	                 :last 42}`)
+
+	m, err := DecodeLazyMap(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedKeys := []interface{}{Keyword{"", "name"}, Keyword{"", "nested"}, Keyword{"", "broken"}, "str-key", Keyword{"", "last"}}
+	if !reflect.DeepEqual(m.Keys(), expectedKeys) {
+		t.Errorf("unexpected keys %#v", m.Keys())
+	}
+
+	raw, ok := m.Raw(Keyword{"", "last"})
+	if !ok || string(raw) != "42" {
+		t.Errorf("unexpected raw value %q", raw)
+	}
+
+	val, ok, err := m.Get(Keyword{"", "name"})
+	if err != nil || !ok || val != `a "quoted" } string` {
+		t.Errorf("unexpected value %#v (%v)", val, err)
+	}
+
+	val, ok, err = m.Get(Keyword{"", "nested"})
+	if err != nil || !ok {
+		t.Fatalf("unexpected value %#v (%v)", val, err)
+	}
+	if _, ok := val.(map[interface{}]interface{})[Keyword{"", "deep"}]; !ok {
+		t.Errorf("unexpected value %#v", val)
+	}
+
+	if _, ok, err := m.Get(Keyword{"", "broken"}); !ok || err == nil {
+		t.Errorf("expected error for broken value")
+	}
+
+	if _, ok, _ := m.Get(Keyword{"", "missing"}); ok {
+		t.Errorf("expected missing key")
+	}
+
+	m, err = DecodeLazyMap([]byte(`{[1 2] :pair}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val, ok, err := m.Get([]interface{}{int64(1), int64(2)}); !ok || err != nil || val != (Keyword{"", "pair"}) {
+		t.Errorf("unexpected value for composite key %#v, %v", val, err)
+	}
+}
+
+func TestLazyMapInvalid(t *testing.T) {
+	for _, s := range []string{`[1 2]`, `{:a 1`, `{:a}`, `{:a "unterminated}`, `{:a [1 2}`} {
+		if _, err := DecodeLazyMap([]byte(s)); err == nil {
+			t.Errorf("expected error for %s", s)
+		}
+	}
+}
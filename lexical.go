@@ -0,0 +1,120 @@
+package edn
+
+import (
+	"strings"
+)
+
+// IsValidSymbol reports whether s is read as the symbol s, e.g.
+// "foo", "my.ns/foo" or "/".  "nil", "true" and "false" are not
+// symbols, neither are numbers.
+func IsValidSymbol(s string) bool {
+	if isPlainToken(s) {
+		return s != "nil" && s != "true" && s != "false"
+	}
+
+	sym, ok := readWholeToken(s).(Symbol)
+	return ok && sym.String() == s
+}
+
+// IsValidKeyword reports whether s is read as the keyword s, e.g.
+// ":foo" or ":my.ns/foo".  s must include the leading colon.
+func IsValidKeyword(s string) bool {
+	if len(s) > 1 && s[0] == ':' && isPlainToken(s[1:]) {
+		return true
+	}
+
+	kw, ok := readWholeToken(s).(Keyword)
+	return ok && kw.String() == s
+}
+
+// isPlainName reports whether s starts with a letter and consists of
+// letters, digits and the characters .*+!-_?<>=.  The reader reads
+// these as the names and namespaces of symbols and keywords without
+// further checks, so that the writer can check the common keywords and
+// symbols without decoding them.
+func isPlainName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		letter := 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z'
+		if !letter && (i == 0 || !isDigit(ch) && strings.IndexByte(".*+!-_?<>=", ch) < 0) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isPlainToken reports whether s is a plain name with an optional plain
+// namespace, see isPlainName.
+func isPlainToken(s string) bool {
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		return isPlainName(s[:i]) && isPlainName(s[i+1:])
+	}
+	return isPlainName(s)
+}
+
+// validKeyword reports whether kw is read back as itself, unlike e.g.
+// Keyword{"", "a/b"}, which is read as Keyword{"a", "b"}.
+func validKeyword(kw Keyword) bool {
+	if (kw.Namespace == "" || isPlainName(kw.Namespace)) && isPlainName(kw.Name) {
+		return true
+	}
+	return readWholeToken(kw.String()) == kw
+}
+
+// validSymbol reports whether sym is read back as itself.
+func validSymbol(sym Symbol) bool {
+	if (sym.Namespace == "" || isPlainName(sym.Namespace)) && isPlainName(sym.Name) {
+		return sym.Namespace != "" || sym.Name != "nil" && sym.Name != "true" && sym.Name != "false"
+	}
+	return readWholeToken(sym.String()) == sym
+}
+
+// validTag reports whether the writer can write tag after a #, i.e.
+// whether it is read as the tag of a tagged element and not as another
+// dispatch, such as the discard #_.
+func validTag(tag Symbol) bool {
+	return validSymbol(tag) && dispatch[tag.String()[0]] == nil
+}
+
+// readWholeToken reads s with the reader, so that the checks above use
+// exactly the rules the reader does.  It returns nil if s is not read
+// as a single value in its entirety.
+func readWholeToken(s string) interface{} {
+	for i := 0; i < len(s); i++ {
+		if IsWhitespace(s[i]) {
+			return nil
+		}
+	}
+
+	d := NewDecoder(strings.NewReader(s))
+	val, err := d.Decode()
+	if err != nil || d.InputOffset() != int64(len(s)) {
+		return nil
+	}
+
+	return val
+}
+
+// IsWhitespace reports whether ch is whitespace.  Commas are whitespace
+// in EDN.
+func IsWhitespace(ch byte) bool {
+	return isWhitespace(ch)
+}
+
+// IsTerminator reports whether ch ends a symbol, keyword or number when
+// it follows one, i.e. whether ch is whitespace or a delimiting macro
+// character such as a bracket, a quote or the start of a comment.
+func IsTerminator(ch byte) bool {
+	return isWhitespace(ch) || isTerminatingMacro(ch)
+}
+
+// IsConstituent reports whether ch may appear within a symbol or
+// keyword after its first character.
+func IsConstituent(ch byte) bool {
+	return !IsTerminator(ch) && !nonConstituent(ch)
+}
+
+// IsDigit reports whether ch is a decimal digit.
+func IsDigit(ch byte) bool {
+	return isDigit(ch)
+}
@@ -0,0 +1,79 @@
+package edn
+
+import (
+	"testing"
+)
+
+func TestIsValidSymbolKeyword(t *testing.T) {
+	symbols := map[string]bool{
+		"foo":        true,
+		"my.ns/foo":  true,
+		"/":          true,
+		"+":          true,
+		"-foo":       true,
+		"a*b?!":      true,
+		"nil":        false,
+		"true":       false,
+		"42":         false,
+		"-1":         false,
+		":foo":       false,
+		"foo bar":    false,
+		"foo]":       false,
+		"foo@bar":    false,
+		"":           false,
+		"#foo":       false,
+		"\"str\"":    false,
+		"my.ns/":     false,
+		"my.ns/foo ": false,
+	}
+
+	for s, expected := range symbols {
+		if IsValidSymbol(s) != expected {
+			t.Errorf("IsValidSymbol(%q) should be %v", s, expected)
+		}
+	}
+
+	keywords := map[string]bool{
+		":foo":       true,
+		":my.ns/foo": true,
+		":a-b":       true,
+		"foo":        false,
+		"::foo":      false,
+		":":          false,
+		":foo bar":   false,
+		":foo/":      false,
+		"":           false,
+	}
+
+	for s, expected := range keywords {
+		if IsValidKeyword(s) != expected {
+			t.Errorf("IsValidKeyword(%q) should be %v", s, expected)
+		}
+	}
+}
+
+func TestCharacterPredicates(t *testing.T) {
+	for _, ch := range []byte(" \t\n\r,") {
+		if !IsWhitespace(ch) || !IsTerminator(ch) || IsConstituent(ch) {
+			t.Errorf("%q should be whitespace", ch)
+		}
+	}
+
+	for _, ch := range []byte("()[]{}\";") {
+		if IsWhitespace(ch) || !IsTerminator(ch) || IsConstituent(ch) {
+			t.Errorf("%q should be a terminator", ch)
+		}
+	}
+
+	for _, ch := range []byte("azAZ09*+!-_?./:#'") {
+		if IsWhitespace(ch) || IsTerminator(ch) || !IsConstituent(ch) {
+			t.Errorf("%q should be a constituent", ch)
+		}
+	}
+
+	for _, ch := range []byte("@`~") {
+		if IsConstituent(ch) {
+			t.Errorf("%q should not be a constituent", ch)
+		}
+	}
+}
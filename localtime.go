@@ -0,0 +1,87 @@
+package edn
+
+import (
+	"fmt"
+	"time"
+)
+
+// LocalDate is a date without a time or time zone, as read from
+// #local-date "2024-05-01".
+type LocalDate struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+func (d LocalDate) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// Time returns the start of the day in loc.
+func (d LocalDate) Time(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// LocalTime is a time of day without a date or time zone, as read from
+// #local-time "13:00".
+type LocalTime struct {
+	Hour, Minute, Second, Nanosecond int
+}
+
+// String formats the time as HH:MM, HH:MM:SS or HH:MM:SS.fffffffff,
+// omitting seconds and fractions of seconds if they are zero.
+func (t LocalTime) String() string {
+	switch {
+	case t.Nanosecond != 0:
+		s := fmt.Sprintf("%02d:%02d:%02d.%09d", t.Hour, t.Minute, t.Second, t.Nanosecond)
+		for s[len(s)-1] == '0' {
+			s = s[:len(s)-1]
+		}
+		return s
+	case t.Second != 0:
+		return fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Minute, t.Second)
+	default:
+		return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+	}
+}
+
+// RegisterLocalTimeTags enables reading of #local-date and #local-time
+// as LocalDate and LocalTime.  Without calling it these are read as
+// Tagged values.  It modifies the global tag registry, see RegisterTag.
+func RegisterLocalTimeTags() {
+	RegisterTag(Symbol{Namespace: "", Name: "local-date"}, readLocalDate)
+	RegisterTag(Symbol{Namespace: "", Name: "local-time"}, readLocalTime)
+}
+
+func readLocalDate(tag Symbol, val interface{}) (interface{}, error) {
+	str, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("local-date value must be a string, but was %#v", val)
+	}
+
+	t, err := time.Parse("2006-01-02", str)
+	if err != nil {
+		return nil, err
+	}
+
+	return LocalDate{t.Year(), t.Month(), t.Day()}, nil
+}
+
+func readLocalTime(tag Symbol, val interface{}) (interface{}, error) {
+	str, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("local-time value must be a string, but was %#v", val)
+	}
+
+	layout := "15:04"
+	if len(str) > len(layout) {
+		layout = "15:04:05.999999999"
+	}
+
+	t, err := time.Parse(layout, str)
+	if err != nil {
+		return nil, err
+	}
+
+	return LocalTime{t.Hour(), t.Minute(), t.Second(), t.Nanosecond()}, nil
+}
@@ -0,0 +1,42 @@
+package edn
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalTimeTags(t *testing.T) {
+	RegisterLocalTimeTags()
+
+	tests := []struct {
+		s        string
+		expected interface{}
+	}{
+		{`#local-date "2024-05-01"`, LocalDate{2024, time.May, 1}},
+		{`#local-time "13:00"`, LocalTime{13, 0, 0, 0}},
+		{`#local-time "13:05:09"`, LocalTime{13, 5, 9, 0}},
+		{`#local-time "23:59:59.25"`, LocalTime{23, 59, 59, 250000000}},
+	}
+
+	for _, test := range tests {
+		val, err := DecodeString(test.s)
+		if err != nil {
+			t.Errorf("%s: %v", test.s, err)
+			continue
+		}
+
+		if val != test.expected {
+			t.Errorf("%s: expected %#v, got %#v", test.s, test.expected, val)
+		}
+
+		if String(val) != test.s {
+			t.Errorf("%s: printed as %s", test.s, String(val))
+		}
+	}
+
+	for _, s := range []string{`#local-date "2024-02-30"`, `#local-date "2024-05-01T10:00"`, `#local-time "25:00"`, `#local-time 1300`} {
+		if _, err := DecodeString(s); err == nil {
+			t.Errorf("expected error for %s", s)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package edn
+
+import "math/big"
+
+// ConflictTag is the tag of the conflicts of Merge3, edn/conflict.
+var ConflictTag = Symbol{Namespace: "edn", Name: "conflict"}
+
+var (
+	conflictBase   = Keyword{Namespace: "", Name: "base"}
+	conflictOurs   = Keyword{Namespace: "", Name: "ours"}
+	conflictTheirs = Keyword{Namespace: "", Name: "theirs"}
+)
+
+// Merge3 merges the changes from base to ours and from base to theirs,
+// e.g. the versions of a file in a version control system:
+//
+//   - values changed on one side only take the changed value
+//   - map entries are merged by key, entries added on one side are
+//     added and entries removed on one side are removed
+//   - sets contain the elements that neither side removed
+//   - vectors and lists of the same length are merged by index
+//
+// Values that are changed differently on both sides are conflicts.
+// They are replaced by maps tagged with edn/conflict, holding the
+// values under :base, :ours and :theirs, without the keys of sides on
+// which a map entry is missing:
+//
+//	{:port #edn/conflict {:base 80 :ours 8080 :theirs 8081}}
+//
+// clean is false if there were conflicts.
+func Merge3(base, ours, theirs interface{}) (merged interface{}, clean bool) {
+	m := &merger{}
+	merged = m.merge(base, ours, theirs)
+	return merged, m.conflicts == 0
+}
+
+type merger struct {
+	conflicts int
+}
+
+func (m *merger) merge(base, ours, theirs interface{}) interface{} {
+	switch {
+	case Equal(ours, theirs), Equal(base, theirs):
+		return ours
+	case Equal(base, ours):
+		return theirs
+	}
+
+	switch base := base.(type) {
+	case map[interface{}]interface{}:
+		o, ok1 := ours.(map[interface{}]interface{})
+		t, ok2 := theirs.(map[interface{}]interface{})
+		if ok1 && ok2 {
+			return m.mergeMaps(base, o, t)
+		}
+	case map[interface{}]bool:
+		o, ok1 := ours.(map[interface{}]bool)
+		t, ok2 := theirs.(map[interface{}]bool)
+		if ok1 && ok2 {
+			return mergeSets(base, o, t)
+		}
+	case []interface{}:
+		o, ok1 := ours.([]interface{})
+		t, ok2 := theirs.([]interface{})
+		if ok1 && ok2 && len(o) == len(base) && len(t) == len(base) {
+			merged := make([]interface{}, len(base))
+			for i := range base {
+				merged[i] = m.merge(base[i], o[i], t[i])
+			}
+			return merged
+		}
+	case Tagged:
+		o, ok1 := ours.(Tagged)
+		t, ok2 := theirs.(Tagged)
+		if ok1 && ok2 && o.Tag == base.Tag && t.Tag == base.Tag {
+			return Tagged{Tag: base.Tag, Value: m.merge(base.Value, o.Value, t.Value)}
+		}
+	}
+
+	return m.conflict(base, true, ours, true, theirs, true)
+}
+
+func (m *merger) mergeMaps(base, ours, theirs map[interface{}]interface{}) map[interface{}]interface{} {
+	var keys keyIndex
+	var vals [][3]interface{}
+	var in [][3]bool
+	for side, entries := range []map[interface{}]interface{}{base, ours, theirs} {
+		for key, val := range entries {
+			i := keys.add(key)
+			if i == len(vals) {
+				vals, in = append(vals, [3]interface{}{}), append(in, [3]bool{})
+			}
+			vals[i][side], in[i][side] = val, true
+		}
+	}
+
+	merged := make(map[interface{}]interface{}, len(keys.keys))
+	for i, key := range keys.keys {
+		b, o, t := vals[i][0], vals[i][1], vals[i][2]
+		inBase, inOurs, inTheirs := in[i][0], in[i][1], in[i][2]
+
+		switch {
+		case inOurs && inTheirs && inBase:
+			merged[key] = m.merge(b, o, t)
+		case inOurs && inTheirs:
+			// added on both sides
+			if Equal(o, t) {
+				merged[key] = o
+			} else {
+				merged[key] = m.conflict(nil, false, o, true, t, true)
+			}
+		case !inBase && inOurs:
+			merged[key] = o
+		case !inBase && inTheirs:
+			merged[key] = t
+		case inOurs:
+			// removed by theirs
+			if !Equal(b, o) {
+				merged[key] = m.conflict(b, true, o, true, nil, false)
+			}
+		case inTheirs:
+			// removed by ours
+			if !Equal(b, t) {
+				merged[key] = m.conflict(b, true, nil, false, t, true)
+			}
+		}
+	}
+
+	return merged
+}
+
+func mergeSets(base, ours, theirs map[interface{}]bool) map[interface{}]bool {
+	var elems keyIndex
+	var in [][3]bool
+	for side, set := range []map[interface{}]bool{base, ours, theirs} {
+		for elem := range set {
+			i := elems.add(elem)
+			if i == len(in) {
+				in = append(in, [3]bool{})
+			}
+			in[i][side] = true
+		}
+	}
+
+	merged := make(map[interface{}]bool, len(ours)+len(theirs))
+	for i, elem := range elems.keys {
+		inBase, inOurs, inTheirs := in[i][0], in[i][1], in[i][2]
+		if (inOurs && inTheirs) || (!inBase && (inOurs || inTheirs)) {
+			merged[elem] = true
+		}
+	}
+
+	return merged
+}
+
+// keyIndex numbers map keys or set elements, giving keys that are Equal
+// the same number.  Comparing each pair of keys with Equal would take
+// quadratic time, so keys are looked up by their hashKey, and only the
+// others, e.g. CompositeKeys, are compared with Equal.
+type keyIndex struct {
+	keys     []interface{}
+	hashed   map[interface{}]int
+	unhashed []int
+}
+
+// add returns the number of key, which is len(keys) before the call if
+// it is new.
+func (x *keyIndex) add(key interface{}) int {
+	h, ok := hashKey(key)
+	if ok {
+		if i, found := x.hashed[h]; found {
+			return i
+		}
+		if x.hashed == nil {
+			x.hashed = make(map[interface{}]int)
+		}
+		x.hashed[h] = len(x.keys)
+	} else {
+		for _, i := range x.unhashed {
+			if Equal(x.keys[i], key) {
+				return i
+			}
+		}
+		x.unhashed = append(x.unhashed, len(x.keys))
+	}
+
+	x.keys = append(x.keys, key)
+	return len(x.keys) - 1
+}
+
+// hashKey returns a comparable value that is == for the keys that are
+// Equal to key, or false if key must be compared with Equal.
+func hashKey(key interface{}) (interface{}, bool) {
+	switch key := key.(type) {
+	case Keyword, string, int64, Symbol, float64, bool, nil:
+		return key, true
+	case int:
+		return int64(key), true
+	case *big.Int:
+		if key == nil {
+			return nil, false
+		} else if key.IsInt64() {
+			return key.Int64(), true
+		}
+		return bigIntKey(key.String()), true
+	default:
+		return nil, false
+	}
+}
+
+// bigIntKey is the hashKey of integers that don't fit in an int64.
+type bigIntKey string
+
+// conflict returns the conflict of the given values, omitting the
+// missing ones.
+func (m *merger) conflict(base interface{}, inBase bool, ours interface{}, inOurs bool, theirs interface{}, inTheirs bool) Tagged {
+	m.conflicts++
+
+	c := make(map[interface{}]interface{}, 3)
+	if inBase {
+		c[conflictBase] = base
+	}
+	if inOurs {
+		c[conflictOurs] = ours
+	}
+	if inTheirs {
+		c[conflictTheirs] = theirs
+	}
+
+	return Tagged{Tag: ConflictTag, Value: c}
+}
+
+// MergeStrategy is how Merge combines the values at a path.  Values
+// that aren't both maps, or for Append both vectors, lists or sets, are
+// always replaced by the later one.
+type MergeStrategy int
+
+const (
+	// DeepMerge merges maps entry by entry, merging the values of keys
+	// in both maps the same way.
+	DeepMerge MergeStrategy = iota
+
+	// LastWins merges maps entry by entry like clojure.core/merge, the
+	// values of keys in both maps are replaced by the later one.
+	LastWins
+
+	// Replace replaces values by the later one, including maps.
+	Replace
+
+	// Append concatenates vectors and lists and unites sets, and merges
+	// maps like DeepMerge, appending the values of keys in both maps.
+	Append
+)
+
+// MergeRule sets the strategy for the value at Path, e.g. [:servers]
+// or [:db :hosts].  It applies to the values nested in it as well,
+// unless other rules are set for them.
+type MergeRule struct {
+	Path     []interface{}
+	Strategy MergeStrategy
+}
+
+// MergeOptions configures Merge.
+type MergeOptions struct {
+	// Strategy is the strategy for the values without a rule.
+	Strategy MergeStrategy
+
+	Rules []MergeRule
+}
+
+// Merge merges the decoded values vals, e.g. configuration files and
+// the overlays for a deployment, with DeepMerge.  Later values take
+// precedence.  The values are not modified.
+func Merge(vals ...interface{}) interface{} {
+	return MergeOptions{}.Merge(vals...)
+}
+
+// Merge is like the package function, using the options.
+func (o MergeOptions) Merge(vals ...interface{}) interface{} {
+	if len(vals) == 0 {
+		return nil
+	}
+
+	merged := vals[0]
+	for _, val := range vals[1:] {
+		merged = o.merge(merged, val, nil, o.Strategy)
+	}
+	return merged
+}
+
+// merge merges the values a and b at path with the strategy of the
+// rule for path, or the strategy given.
+func (o MergeOptions) merge(a, b interface{}, path []interface{}, strategy MergeStrategy) interface{} {
+	for _, rule := range o.Rules {
+		if Equal(rule.Path, path) {
+			strategy = rule.Strategy
+		}
+	}
+
+	switch a := a.(type) {
+	case map[interface{}]interface{}:
+		b, ok := b.(map[interface{}]interface{})
+		if !ok || strategy == Replace {
+			break
+		}
+
+		var keys keyIndex
+		merged := make(map[interface{}]interface{}, len(a)+len(b))
+		for key, val := range a {
+			keys.add(key)
+			merged[key] = val
+		}
+
+		nested := strategy
+		if strategy == LastWins {
+			nested = Replace
+		}
+		for key, val := range b {
+			n := len(keys.keys)
+			if i := keys.add(key); i < n {
+				key = keys.keys[i]
+				val = o.merge(merged[key], val, append(path[:len(path):len(path)], key), nested)
+			}
+			merged[key] = val
+		}
+		return merged
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok && strategy == Append {
+			return append(a[:len(a):len(a)], b...)
+		}
+	case map[interface{}]bool:
+		if b, ok := b.(map[interface{}]bool); ok && strategy == Append {
+			return mergeSets(nil, a, b)
+		}
+	}
+
+	return b
+}
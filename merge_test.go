@@ -0,0 +1,105 @@
+package edn
+
+import (
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		base, ours, theirs string
+		merged             string
+		clean              bool
+	}{
+		{`{:a 1}`, `{:a 1}`, `{:a 2}`, `{:a 2}`, true},
+		{`{:a 1 :b 1}`, `{:a 2 :b 1}`, `{:a 1 :b 2}`, `{:a 2 :b 2}`, true},
+		{`{:a 1}`, `{:a 1 :b 1}`, `{:a 1 :c 1}`, `{:a 1 :b 1 :c 1}`, true},
+		{`{:a 1 :b 1}`, `{:b 1}`, `{:a 1 :b 2}`, `{:b 2}`, true},
+		{`{:db {:port 80 :host "a"}}`, `{:db {:port 8080 :host "a"}}`, `{:db {:port 80 :host "b"}}`, `{:db {:host "b" :port 8080}}`, true},
+		{`#{:a :b}`, `#{:a :b :c}`, `#{:b :d}`, `#{:b :c :d}`, true},
+		{`{1 :a [1 2] :b}`, `{1N :a [1 2] :c}`, `{1 :x [1 2] :b}`, `{1 :x [1 2] :c}`, true},
+		{`#{1 [1 2] :a}`, `#{1N [1 2]}`, `#{1 [1 2] :a :b}`, `#{1 :b [1 2]}`, true},
+		{`[1 2 3]`, `[1 20 3]`, `[1 2 30]`, `[1 20 30]`, true},
+		{`#my/t [1 2]`, `#my/t [10 2]`, `#my/t [1 20]`, `#my/t [10 20]`, true},
+		{`{:a 1}`, `{:a 2}`, `{:a 2}`, `{:a 2}`, true},
+		{`{:a 1}`, `{:a 2}`, `{:a 3}`, `{:a #edn/conflict {:base 1 :ours 2 :theirs 3}}`, false},
+		{`{}`, `{:a 2}`, `{:a 3}`, `{:a #edn/conflict {:ours 2 :theirs 3}}`, false},
+		{`{:a 1}`, `{}`, `{:a 3}`, `{:a #edn/conflict {:base 1 :theirs 3}}`, false},
+		{`{:a 1}`, `{:a 2}`, `{}`, `{:a #edn/conflict {:base 1 :ours 2}}`, false},
+		{`[1 2]`, `[1 2 3]`, `[1]`, `#edn/conflict {:base [1 2] :ours [1 2 3] :theirs [1]}`, false},
+		{`1`, `"a"`, `:b`, `#edn/conflict {:base 1 :ours "a" :theirs :b}`, false},
+	}
+
+	for _, test := range tests {
+		var vals [3]interface{}
+		for i, s := range []string{test.base, test.ours, test.theirs} {
+			val, err := DecodeString(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vals[i] = val
+		}
+
+		merged, clean := Merge3(vals[0], vals[1], vals[2])
+		if String(merged) != test.merged || clean != test.clean {
+			t.Errorf("%s %s %s: expected %s (clean %v), got %s (clean %v)",
+				test.base, test.ours, test.theirs, test.merged, test.clean, String(merged), clean)
+		}
+
+		if err := RoundTrip(merged); err != nil {
+			t.Errorf("%s: %v", test.merged, err)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := `{:db {:host "a" :port 80 :opts {:ssl true}} :hosts ["a"] :tags #{:x}}`
+	overlay := `{:db {:port 8080 :opts {:timeout 5}} :hosts ["b"] :tags #{:y} :debug true}`
+
+	tests := []struct {
+		options MergeOptions
+		merged  string
+	}{
+		{MergeOptions{}, `{:db {:host "a" :opts {:ssl true :timeout 5} :port 8080} :debug true :hosts ["b"] :tags #{:y}}`},
+		{MergeOptions{Strategy: LastWins}, `{:db {:opts {:timeout 5} :port 8080} :debug true :hosts ["b"] :tags #{:y}}`},
+		{MergeOptions{Strategy: Replace}, overlay},
+		{MergeOptions{Strategy: Append}, `{:db {:host "a" :opts {:ssl true :timeout 5} :port 8080} :debug true :hosts ["a" "b"] :tags #{:x :y}}`},
+		{MergeOptions{Rules: []MergeRule{
+			{[]interface{}{Keyword{"", "hosts"}}, Append},
+			{[]interface{}{Keyword{"", "db"}, Keyword{"", "opts"}}, Replace},
+		}}, `{:db {:host "a" :opts {:timeout 5} :port 8080} :debug true :hosts ["a" "b"] :tags #{:y}}`},
+		{MergeOptions{Strategy: LastWins, Rules: []MergeRule{
+			{[]interface{}{Keyword{"", "db"}}, DeepMerge},
+		}}, `{:db {:host "a" :opts {:ssl true :timeout 5} :port 8080} :debug true :hosts ["b"] :tags #{:y}}`},
+	}
+
+	for _, test := range tests {
+		a, err := DecodeString(base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := DecodeString(overlay)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		merged := test.options.Merge(a, b)
+		expected, err := DecodeString(test.merged)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(merged, expected) {
+			t.Errorf("%+v: expected %s, got %s", test.options, test.merged, String(merged))
+		}
+
+		if again, _ := DecodeString(base); !Equal(a, again) {
+			t.Errorf("%+v: modified the merged value to %s", test.options, String(a))
+		}
+	}
+
+	if merged := Merge(); merged != nil {
+		t.Errorf("expected nil without values, got %s", String(merged))
+	}
+	if merged := Merge(int64(1), nil, int64(3)); merged != int64(3) {
+		t.Errorf("expected the last value, got %s", String(merged))
+	}
+}
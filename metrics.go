@@ -0,0 +1,60 @@
+package edn
+
+import (
+	"errors"
+	"io"
+)
+
+// Metrics receives statistics about the values read by a Decoder.
+//
+// It is meant to be implemented by an adapter to a metrics library,
+// e.g. one incrementing Prometheus counters.  The methods are called
+// synchronously from Decode and should return quickly.
+type Metrics interface {
+	// ValueRead is called after a value has been read successfully.
+	// depth is the maximum nesting depth of collections in the value,
+	// 0 for scalar values.
+	ValueRead(depth int)
+
+	// ReadError is called if reading a value failed.
+	ReadError(kind ErrorKind)
+
+	// BytesRead is called with the number of bytes consumed by each
+	// call to Decode, including whitespace and comments.
+	BytesRead(n int64)
+}
+
+// ErrorKind classifies the errors reported to Metrics.
+type ErrorKind string
+
+const (
+	// KindSyntax is reported for input that is not valid EDN.
+	KindSyntax ErrorKind = "syntax"
+	// KindUnexpectedEOF is reported if the input ends within a value.
+	KindUnexpectedEOF ErrorKind = "eof"
+	// KindIO is reported if reading from the underlying reader failed.
+	KindIO ErrorKind = "io"
+)
+
+// SetMetrics makes the decoder report statistics to m.  If m is nil,
+// no statistics are reported.
+func (d *Decoder) SetMetrics(m Metrics) {
+	d.metrics = m
+}
+
+func (d *Decoder) report(n int64, err error) {
+	d.metrics.BytesRead(n)
+
+	switch {
+	case err == nil:
+		d.metrics.ValueRead(d.maxDepth)
+	case err == io.EOF:
+		// no more values, not an error
+	case d.ioErr:
+		d.metrics.ReadError(KindIO)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		d.metrics.ReadError(KindUnexpectedEOF)
+	default:
+		d.metrics.ReadError(KindSyntax)
+	}
+}
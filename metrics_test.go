@@ -0,0 +1,86 @@
+package edn
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type testMetrics struct {
+	values   int
+	maxDepth int
+	bytes    int64
+	errors   map[ErrorKind]int
+}
+
+func (m *testMetrics) ValueRead(depth int) {
+	m.values++
+	if depth > m.maxDepth {
+		m.maxDepth = depth
+	}
+}
+
+func (m *testMetrics) ReadError(kind ErrorKind) {
+	m.errors[kind]++
+}
+
+func (m *testMetrics) BytesRead(n int64) {
+	m.bytes += n
+}
+
+func TestMetrics(t *testing.T) {
+	m := &testMetrics{errors: map[ErrorKind]int{}}
+	input := "1 [2 [3 {:a #{4}}]] ) :kw [5"
+
+	d := NewDecoder(strings.NewReader(input))
+	d.SetMetrics(m)
+	for {
+		_, err := d.Decode()
+		if err == io.EOF || (err != nil && m.errors[KindUnexpectedEOF] > 0) {
+			break
+		}
+	}
+
+	if m.values != 3 {
+		t.Errorf("expected 3 values, got %d", m.values)
+	}
+	if m.maxDepth != 4 {
+		t.Errorf("expected max depth 4, got %d", m.maxDepth)
+	}
+	if m.bytes != int64(len(input)) {
+		t.Errorf("expected %d bytes, got %d", len(input), m.bytes)
+	}
+	if m.errors[KindSyntax] != 1 || m.errors[KindUnexpectedEOF] != 1 || m.errors[KindIO] != 0 {
+		t.Errorf("unexpected errors %v", m.errors)
+	}
+}
+
+func TestMetricsErrorKinds(t *testing.T) {
+	tests := map[string]ErrorKind{
+		`"abc`:  KindUnexpectedEOF,
+		`[1 2`:  KindUnexpectedEOF,
+		`#`:     KindUnexpectedEOF,
+		`#_`:    KindUnexpectedEOF,
+		`#foo`:  KindUnexpectedEOF,
+		`:a/`:   KindSyntax,
+		`[:a/`:  KindSyntax,
+		`1.2.3`: KindSyntax,
+		`##Foo`: KindSyntax,
+	}
+
+	for input, kind := range tests {
+		m := &testMetrics{errors: map[ErrorKind]int{}}
+		d := NewDecoder(strings.NewReader(input))
+		d.SetMetrics(m)
+
+		_, err := d.Decode()
+		if err == nil {
+			t.Errorf("%s: expected an error", input)
+		} else if m.errors[kind] != 1 || len(m.errors) != 1 {
+			t.Errorf("%s: expected a %s error, got %v (%v)", input, kind, m.errors, err)
+		} else if errors.Is(err, io.ErrUnexpectedEOF) != (kind == KindUnexpectedEOF) {
+			t.Errorf("%s: unexpected error %v", input, err)
+		}
+	}
+}
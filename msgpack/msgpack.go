@@ -0,0 +1,557 @@
+// Package msgpack converts between EDN values and MessagePack.
+//
+// Encode and Decode map the values produced by the edn package to
+// MessagePack as follows:
+//
+//   - nil, booleans, integers, floats and strings as the MessagePack
+//     types
+//   - vectors and lists as arrays, maps as maps
+//   - instants as timestamps (extension type -1)
+//
+// The other EDN types are written as application extension types:
+//
+//	1  keyword      the name as UTF-8, without colon, e.g. "user/id"
+//	2  symbol       the name as UTF-8, e.g. "my.ns/sym"
+//	3  set          the elements, encoded as array
+//	4  tagged       the tag and value, encoded as array ["my/tag" value]
+//	5  big integer  the decimal representation, e.g. "-12"
+//	6  ratio        the decimal representation, e.g. "1/3"
+//	7  UUID         the 16 bytes of the UUID
+//
+// Decode also reads binary data as []byte and unsigned integers beyond
+// the int64 range as big integers.
+//
+// References:
+//   - https://github.com/msgpack/msgpack/blob/master/spec.md
+package msgpack
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+const (
+	extTimestamp = -1
+	extKeyword   = 1
+	extSymbol    = 2
+	extSet       = 3
+	extTagged    = 4
+	extBigInt    = 5
+	extRatio     = 6
+	extUUID      = 7
+)
+
+// Encode writes v encoded as MessagePack to w.
+func Encode(w io.Writer, v interface{}) error {
+	buf, err := appendValue(nil, v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// Marshal returns v encoded as MessagePack.
+func Marshal(v interface{}) ([]byte, error) {
+	return appendValue(nil, v)
+}
+
+func appendValue(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, 0xc0), nil
+	case bool:
+		if v {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+	case int:
+		return appendInt(buf, int64(v)), nil
+	case int64:
+		return appendInt(buf, v), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(append(buf, 0xcb), math.Float64bits(v)), nil
+	case string:
+		return appendString(buf, v), nil
+	case []byte:
+		buf = appendLength(buf, len(v), 0, 0xc4, 0xc5, 0xc6)
+		return append(buf, v...), nil
+	case *big.Int:
+		if v == nil {
+			return append(buf, 0xc0), nil
+		}
+		return appendExt(buf, extBigInt, []byte(v.String())), nil
+	case *big.Rat:
+		if v == nil {
+			return append(buf, 0xc0), nil
+		}
+		return appendExt(buf, extRatio, []byte(v.String())), nil
+	case edn.Keyword:
+		return appendExt(buf, extKeyword, []byte(v.FullName())), nil
+	case edn.Symbol:
+		return appendExt(buf, extSymbol, []byte(v.String())), nil
+	case edn.UUID:
+		b := v.Bytes()
+		return appendExt(buf, extUUID, b[:]), nil
+	case time.Time:
+		return appendTimestamp(buf, v), nil
+	case edn.Tagged:
+		payload, err := appendValue(nil, []interface{}{v.Tag.String(), v.Value})
+		if err != nil {
+			return nil, err
+		}
+		return appendExt(buf, extTagged, payload), nil
+	case edn.CompositeKey:
+		val, err := v.Value()
+		if err != nil {
+			return nil, err
+		}
+		return appendValue(buf, val)
+	case []interface{}:
+		buf = appendLength(buf, len(v), 0x90, 0, 0xdc, 0xdd)
+		for _, elem := range v {
+			var err error
+			if buf, err = appendValue(buf, elem); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[interface{}]bool:
+		elems := make([][]byte, 0, len(v))
+		for elem := range v {
+			b, err := appendValue(nil, elem)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, b)
+		}
+		sortBytes(elems)
+
+		payload := appendLength(nil, len(elems), 0x90, 0, 0xdc, 0xdd)
+		for _, elem := range elems {
+			payload = append(payload, elem...)
+		}
+		return appendExt(buf, extSet, payload), nil
+	case map[interface{}]interface{}:
+		entries := make([][]byte, 0, len(v))
+		for key, val := range v {
+			b, err := appendValue(nil, key)
+			if err != nil {
+				return nil, err
+			}
+			if b, err = appendValue(b, val); err != nil {
+				return nil, err
+			}
+			entries = append(entries, b)
+		}
+		sortBytes(entries)
+
+		buf = appendLength(buf, len(entries), 0x80, 0, 0xde, 0xdf)
+		for _, entry := range entries {
+			buf = append(buf, entry...)
+		}
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("cannot encode %#v as MessagePack", v)
+	}
+}
+
+func appendInt(buf []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n <= math.MaxInt8:
+		return append(buf, byte(n))
+	case n < 0 && n >= -32:
+		return append(buf, byte(n))
+	case n >= math.MinInt8 && n <= math.MaxInt8:
+		return append(buf, 0xd0, byte(n))
+	case n >= math.MinInt16 && n <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(n))
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(n))
+	}
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = appendLength(buf, len(s), 0xa0, 0xd9, 0xda, 0xdb)
+	return append(buf, s...)
+}
+
+// appendLength writes the format byte and length of a string, binary,
+// array or map.  fix is the format for lengths below 32 for strings and
+// 16 for arrays and maps and 0 if there is none, len8 the format with a
+// one byte length or 0.
+func appendLength(buf []byte, n int, fix, len8, len16, len32 byte) []byte {
+	switch {
+	case fix == 0xa0 && n < 32, (fix == 0x90 || fix == 0x80) && n < 16:
+		return append(buf, fix|byte(n))
+	case len8 != 0 && n <= math.MaxUint8:
+		return append(buf, len8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, len16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, len32), uint32(n))
+	}
+}
+
+func appendExt(buf []byte, typ int8, data []byte) []byte {
+	switch len(data) {
+	case 1:
+		buf = append(buf, 0xd4)
+	case 2:
+		buf = append(buf, 0xd5)
+	case 4:
+		buf = append(buf, 0xd6)
+	case 8:
+		buf = append(buf, 0xd7)
+	case 16:
+		buf = append(buf, 0xd8)
+	default:
+		buf = appendLength(buf, len(data), 0, 0xc7, 0xc8, 0xc9)
+	}
+
+	buf = append(buf, byte(typ))
+	return append(buf, data...)
+}
+
+func appendTimestamp(buf []byte, t time.Time) []byte {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case sec >= 0 && sec <= math.MaxUint32 && nsec == 0:
+		return appendExt(buf, extTimestamp, binary.BigEndian.AppendUint32(nil, uint32(sec)))
+	case sec >= 0 && sec < 1<<34:
+		return appendExt(buf, extTimestamp, binary.BigEndian.AppendUint64(nil, nsec<<34|uint64(sec)))
+	default:
+		data := binary.BigEndian.AppendUint32(nil, uint32(nsec))
+		return appendExt(buf, extTimestamp, binary.BigEndian.AppendUint64(data, uint64(sec)))
+	}
+}
+
+// sortBytes sorts the encoded elements of sets and entries of maps, so
+// that the encoding of a value is always the same.
+func sortBytes(elems [][]byte) {
+	sort.Slice(elems, func(i, j int) bool { return bytes.Compare(elems[i], elems[j]) < 0 })
+}
+
+// MaxDepth is the maximum nesting of arrays, maps and extension types
+// Decode accepts.
+var MaxDepth = 10000
+
+// Decode reads the next MessagePack value from r.  io.EOF is returned
+// if r ends before the start of a value.  If r is not an io.ByteReader,
+// it is buffered and Decode may read beyond the value.
+func Decode(r io.Reader) (interface{}, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		b := bufio.NewReader(r)
+		r, br = b, b
+	}
+
+	d := &decoder{r: r, br: br}
+	return d.readValue()
+}
+
+// Unmarshal decodes the MessagePack value in data, which must contain
+// exactly one value.
+func Unmarshal(data []byte) (interface{}, error) {
+	return unmarshal(data, 0)
+}
+
+func unmarshal(data []byte, depth int) (interface{}, error) {
+	r := bytes.NewReader(data)
+	d := &decoder{r: r, br: r, depth: depth}
+	v, err := d.readValue()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("%d bytes of trailing data", r.Len())
+	}
+	return v, nil
+}
+
+type decoder struct {
+	r     io.Reader
+	br    io.ByteReader
+	depth int
+}
+
+func (d *decoder) readValue() (interface{}, error) {
+	b, err := d.br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b <= 0x8f:
+		return d.readMap(int(b & 0x0f))
+	case b <= 0x9f:
+		return d.readArray(int(b & 0x0f))
+	case b <= 0xbf:
+		return d.readString(int(b & 0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.readUint(1 << (b - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.readBytes(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.readUint(1 << (b - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.readExt(n)
+	case 0xca:
+		n, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := d.readUint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.readUint(1 << (b - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if n > math.MaxInt64 {
+			return new(big.Int).SetUint64(n), nil
+		}
+		return int64(n), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		n, err := d.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+		// sign extend
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.readExt(uint64(1) << (b - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readUint(1 << (b - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.readString(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.readUint(2 << (b - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.readArray(int(n))
+	case 0xde, 0xdf:
+		n, err := d.readUint(2 << (b - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.readMap(int(n))
+	default:
+		return nil, fmt.Errorf("invalid format 0x%02x", b)
+	}
+}
+
+// readElement reads an element of an array or map, for which the input
+// must not end.
+func (d *decoder) readElement() (interface{}, error) {
+	d.depth++
+	defer func() { d.depth-- }()
+	if d.depth > MaxDepth {
+		return nil, fmt.Errorf("items nested deeper than %d levels", MaxDepth)
+	}
+
+	v, err := d.readValue()
+	return v, unexpectedEOF(err)
+}
+
+func (d *decoder) readUint(size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(d.r, buf[8-size:]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+func (d *decoder) readBytes(n uint64) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, d.r, int64(n)); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (d *decoder) readString(n int) (interface{}, error) {
+	b, err := d.readBytes(uint64(n))
+	return string(b), err
+}
+
+func (d *decoder) readArray(n int) (interface{}, error) {
+	arr := []interface{}{}
+	for i := 0; i < n; i++ {
+		elem, err := d.readElement()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, elem)
+	}
+
+	return arr, nil
+}
+
+func (d *decoder) readMap(n int) (interface{}, error) {
+	m := map[interface{}]interface{}{}
+	for i := 0; i < n; i++ {
+		key, err := d.readElement()
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := d.readElement()
+		if err != nil {
+			return nil, err
+		}
+		m[edn.KeyOf(key)] = val
+	}
+
+	return m, nil
+}
+
+func (d *decoder) readExt(n uint64) (interface{}, error) {
+	typ, err := d.br.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	data, err := d.readBytes(n)
+	if err != nil {
+		return nil, err
+	}
+
+	switch int8(typ) {
+	case extTimestamp:
+		return readTimestamp(data)
+	case extKeyword:
+		sym := toSymbol(string(data))
+		if sym.Name == "" {
+			return nil, fmt.Errorf("keyword must not be empty")
+		}
+		return edn.Keyword{Namespace: sym.Namespace, Name: sym.Name}, nil
+	case extSymbol:
+		sym := toSymbol(string(data))
+		if sym.Name == "" {
+			return nil, fmt.Errorf("symbol must not be empty")
+		}
+		return sym, nil
+	case extBigInt:
+		i, ok := new(big.Int).SetString(string(data), 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid big integer %q", data)
+		}
+		return i, nil
+	case extRatio:
+		r, ok := new(big.Rat).SetString(string(data))
+		if !ok {
+			return nil, fmt.Errorf("invalid ratio %q", data)
+		}
+		return r, nil
+	case extUUID:
+		if len(data) != 16 {
+			return nil, fmt.Errorf("uuid must have 16 bytes, but has %d", len(data))
+		}
+		return edn.UUID{Msb: binary.BigEndian.Uint64(data[:8]), Lsb: binary.BigEndian.Uint64(data[8:])}, nil
+	case extSet, extTagged:
+		if d.depth >= MaxDepth {
+			return nil, fmt.Errorf("items nested deeper than %d levels", MaxDepth)
+		}
+
+		val, err := unmarshal(data, d.depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		arr, ok := val.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("extension type %d must contain an array, but was %#v", typ, val)
+		}
+
+		if int8(typ) == extSet {
+			set := make(map[interface{}]bool, len(arr))
+			for _, elem := range arr {
+				set[edn.KeyOf(elem)] = true
+			}
+			return set, nil
+		}
+
+		if len(arr) != 2 {
+			return nil, fmt.Errorf("tagged value must be an array of tag and value, but was %#v", val)
+		}
+		name, ok := arr[0].(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("tag must be a non-empty string, but was %#v", arr[0])
+		}
+		return edn.ApplyTag(toSymbol(name), arr[1])
+	default:
+		return nil, fmt.Errorf("unsupported extension type %d", int8(typ))
+	}
+}
+
+func readTimestamp(data []byte) (interface{}, error) {
+	switch len(data) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0).UTC(), nil
+	case 8:
+		n := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(n&(1<<34-1)), int64(n>>34)).UTC(), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(data[:4])
+		sec := int64(binary.BigEndian.Uint64(data[4:]))
+		return time.Unix(sec, int64(nsec)).UTC(), nil
+	default:
+		return nil, fmt.Errorf("invalid timestamp length %d", len(data))
+	}
+}
+
+func toSymbol(s string) edn.Symbol {
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '/' {
+			return edn.Symbol{Namespace: s[:i], Name: s[i+1:]}
+		}
+	}
+
+	return edn.Symbol{Namespace: "", Name: s}
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/heyLu/edn"
+)
+
+func TestRoundTrip(t *testing.T) {
+	input := `{:nil nil :bool [true false] :ints [0 127 128 -1 -32 -33 -128 -129 255 256 65536 -65536 4294967296 9223372036854775807 -9223372036854775807]
+		:big [12N -12N 18446744073709551616N] :float [1.5 -0.0 1e300] :ratio [1/3 -7/2]
+		:str ["" "héllo" "` + strings.Repeat("x", 40) + `" "` + strings.Repeat("y", 300) + `"] :ident [:kw :ns/kw sym ns/sym /]
+		:set #{1 [2] :three} :insts [#inst "1970-01-01T00:00:01Z" #inst "1985-04-12T23:20:50.52Z" #inst "1900-01-01T00:00:00.5Z" #inst "2600-01-01T00:00:00Z"]
+		:uuid #uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6" :tagged #my/tag {:a 1} [1 2] "composite key"
+		:vec [` + strings.Repeat("1 ", 20) + `]}`
+	val, err := edn.DecodeString(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := Marshal(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	back, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !edn.Equal(back, val) {
+		t.Errorf("expected %s, got %s", edn.String(val), edn.String(back))
+	}
+
+	again, _ := Marshal(back)
+	if !bytes.Equal(again, data) {
+		t.Errorf("encoding is not deterministic")
+	}
+
+	var buf bytes.Buffer
+	Encode(&buf, val)
+	Encode(&buf, edn.Keyword{Namespace: "", Name: "next"})
+	r := strings.NewReader(buf.String())
+	for _, expected := range []interface{}{val, edn.Keyword{Namespace: "", Name: "next"}} {
+		got, err := Decode(r)
+		if err != nil || !edn.Equal(got, expected) {
+			t.Errorf("expected %s, got %s, %v", edn.String(expected), edn.String(got), err)
+		}
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		val interface{}
+		hex string
+	}{
+		{int64(10), "0a"},
+		{int64(-5), "fb"},
+		{int64(-500), "d1fe0c"},
+		{"a", "a161"},
+		{edn.Keyword{Namespace: "", Name: "a"}, "d40161"},
+		{edn.Keyword{Namespace: "my", Name: "kw"}, "c70501" + hex.EncodeToString([]byte("my/kw"))},
+		{big.NewInt(12), "d5053132"},
+		{time.Unix(1, 0), "d6ff00000001"},
+		{[]interface{}{nil, true}, "92c0c3"},
+		{map[interface{}]bool{int64(1): true}, "d5039101"},
+		{map[interface{}]interface{}{"a": int64(1)}, "81a16101"},
+	}
+
+	for _, test := range tests {
+		data, err := Marshal(test.val)
+		if err != nil {
+			t.Errorf("%s: %v", edn.String(test.val), err)
+		} else if hex.EncodeToString(data) != test.hex {
+			t.Errorf("%s: expected %s, got %x", edn.String(test.val), test.hex, data)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := map[string]interface{}{
+		"ca3fc00000":         1.5,
+		"cc80":               int64(128),
+		"cfffffffffffffffff": new(big.Int).SetUint64(math.MaxUint64),
+		"d0ff":               int64(-1),
+		"d3ffffffffffffff00": int64(-256),
+		"c403010203":         []byte{1, 2, 3},
+		"d9026869":           "hi",
+		"dc0001c2":           []interface{}{false},
+		"de0001a16101":       map[interface{}]interface{}{"a": int64(1)},
+		"c70cff" + "000001f4" + "0000000000000001": time.Unix(1, 500),
+	}
+
+	for h, expected := range tests {
+		data, _ := hex.DecodeString(h)
+		val, err := Unmarshal(data)
+		if err != nil {
+			t.Errorf("%s: %v", h, err)
+		} else if !edn.Equal(val, expected) {
+			t.Errorf("%s: expected %#v, got %#v", h, expected, val)
+		}
+	}
+
+	invalid := []string{"", "c1", "a36161", "92c0", "d4", "d401", "d40161c0", "d4ff00", "d50700", "d5049100", "d404c0", strings.Repeat("91", 20000) + "01"}
+	for _, h := range invalid {
+		data, _ := hex.DecodeString(h)
+		if _, err := Unmarshal(data); err == nil {
+			t.Errorf("%s: expected error", h)
+		}
+	}
+}
@@ -0,0 +1,642 @@
+package edn
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pretty returns the EDN representation of v like String does, but
+// lays it out to fit into width columns where possible.
+//
+// Values that fit into the remaining width are written on one line.
+// Collections that don't are broken up with each element on a line of
+// its own, aligned after the opening delimiter; map entries keep the
+// value on the line of its key:
+//
+//  {:name "example"
+//   :deps [[org.clojure/clojure "1.11.1"]
+//          [org.clojure/core.async "1.6.681"]]}
+//
+// Scalar values longer than width are not broken up.  See Style for
+// other layouts.
+func Pretty(v interface{}, width int) string {
+	return Style{Width: width}.Pretty(v)
+}
+
+// Style configures the layout of Pretty output.  Teams can share a
+// style in a .ednfmt.edn file, see ReadStyle.
+type Style struct {
+	// Width is the number of columns to fit into.
+	Width int
+
+	// Indent is the number of columns the elements of broken up
+	// collections are indented by, relative to the line the collection
+	// starts on.  If it is not 0, the first element is put on a line of
+	// its own as well.  With 0, the elements are aligned after the
+	// opening delimiter.
+	Indent int
+
+	// AlignMaps aligns the values of broken up maps after the longest
+	// key.
+	AlignMaps bool
+
+	// Commas separates the entries of broken up maps with commas.
+	Commas bool
+
+	// SortKeys makes Format sort the entries of maps by their keys,
+	// see SortKeys.  Pretty always sorts them, as decoded maps have no
+	// order.
+	SortKeys bool
+
+	// MaxLength is the number of elements of collections Pretty
+	// writes, like *print-length* in Clojure.  The others are elided
+	// with ..., e.g. [1 2 ...] or {:a 1 ...} with 2 and 1.
+	MaxLength int
+
+	// MaxDepth is the number of levels of nested collections Pretty
+	// writes, like *print-level* in Clojure.  Collections nested
+	// deeper are elided with ..., e.g. {:a [1 ...]} for
+	// {:a [1 [2]]} with 2.
+	MaxDepth int
+
+	// MaxStringLength is the number of characters of strings Pretty
+	// writes, longer ones are truncated, e.g. "abc..." with 3.
+	MaxStringLength int
+
+	// Color writes keywords, strings, numbers and tags in distinct
+	// colors, and the delimiters of nested collections in the colors
+	// of the rainbow, using ANSI escape codes for terminals.
+	Color bool
+}
+
+// Pretty is like the package function, using the style.  Map entries
+// and set elements are always sorted, as decoded maps have no order.
+//
+// With MaxLength, MaxDepth or MaxStringLength set, such as in logs
+// which huge values would flood, the output is abbreviated and can't
+// be read back.  They are 0 and don't limit the output by default.
+func (s Style) Pretty(v interface{}) string {
+	l := s.layoutValue(v, 0)
+	return string(s.appendLayout(nil, l, 0, 0, 0))
+}
+
+// layout is a value or form to be laid out: text written as it is,
+// such as a scalar value or a token, a collection of elements between
+// delimiters, or a tagged element, which is the text of its tag
+// followed by the layout of its value.  The elements of maps are their
+// keys and values in turn.
+type layout struct {
+	text        string
+	open, close string
+	elems       []*layout
+	isMap       bool
+
+	// width is the number of columns of the layout on one line, which
+	// is computed once so that checking whether nested collections fit
+	// doesn't write them over and over
+	width int
+}
+
+func textLayout(text string) *layout {
+	return &layout{text: text, width: len(text)}
+}
+
+// taggedLayout returns the layout of a tagged element, whose prefix is
+// the tag followed by a space, or the namespace of a namespaced map.
+func taggedLayout(prefix string, val *layout) *layout {
+	return &layout{text: prefix, elems: []*layout{val}, width: len(prefix) + val.width}
+}
+
+func collectionLayout(open, close string, elems []*layout, isMap bool) *layout {
+	width := len(open) + len(close)
+	for i, elem := range elems {
+		if i > 0 {
+			width++
+		}
+		width += elem.width
+	}
+	return &layout{open: open, close: close, elems: elems, isMap: isMap, width: width}
+}
+
+// layoutValue returns the layout of v at depth, with map entries and
+// set elements sorted as String writes them, abbreviated as configured
+// by the style.
+func (s Style) layoutValue(v interface{}, depth int) *layout {
+	switch v := v.(type) {
+	case []interface{}:
+		if s.elideDepth(depth) {
+			return elided
+		}
+
+		n := s.length(len(v))
+		elems := make([]*layout, n, n+1)
+		for i, elem := range v[:n] {
+			elems[i] = s.layoutValue(elem, depth+1)
+		}
+		return collectionLayout("[", "]", s.elide(elems, len(v)), false)
+	case map[interface{}]bool:
+		if s.elideDepth(depth) {
+			return elided
+		}
+
+		elems := make([]*layout, 0, len(v))
+		for elem := range v {
+			elems = append(elems, s.layoutValue(elem, depth+1))
+		}
+		sortLayouts(elems, 1)
+		return collectionLayout("#{", "}", s.elide(elems[:s.length(len(v))], len(v)), false)
+	case map[interface{}]interface{}:
+		if s.elideDepth(depth) {
+			return elided
+		}
+
+		// sort the entries by key before laying out the values, so that
+		// only the values written are laid out
+		keys := make([]*layout, 0, len(v))
+		vals := make(map[*layout]interface{}, len(v))
+		for key, val := range v {
+			l := s.layoutValue(key, depth+1)
+			keys = append(keys, l)
+			vals[l] = val
+		}
+		sortLayouts(keys, 1)
+
+		n := s.length(len(v))
+		elems := make([]*layout, 0, 2*n+1)
+		for _, key := range keys[:n] {
+			elems = append(elems, key, s.layoutValue(vals[key], depth+1))
+		}
+		return collectionLayout("{", "}", s.elide(elems, len(v)), true)
+	case Tagged:
+		return taggedLayout("#"+v.Tag.String()+" ", s.layoutValue(v.Value, depth))
+	case string:
+		if s.MaxStringLength > 0 && utf8.RuneCountInString(v) > s.MaxStringLength {
+			return textLayout(String(string([]rune(v)[:s.MaxStringLength]) + "..."))
+		}
+		return textLayout(String(v))
+	default:
+		return textLayout(String(v))
+	}
+}
+
+// elided is the layout of elided elements and collections.
+var elided = textLayout("...")
+
+// elideDepth reports whether collections at depth are elided.
+func (s Style) elideDepth(depth int) bool {
+	return s.MaxDepth > 0 && depth >= s.MaxDepth
+}
+
+// length returns the number of the n elements of a collection that are
+// written.
+func (s Style) length(n int) int {
+	if s.MaxLength > 0 && n > s.MaxLength {
+		return s.MaxLength
+	}
+	return n
+}
+
+// elide appends ... to the elements written of a collection of n
+// elements if some of them are elided.
+func (s Style) elide(elems []*layout, n int) []*layout {
+	if s.length(n) < n {
+		return append(elems, elided)
+	}
+	return elems
+}
+
+// sortLayouts sorts the groups of n elements of elems by the text of
+// the first one, i.e. set elements or the entries of maps by their key.
+func sortLayouts(elems []*layout, n int) {
+	if len(elems) <= n {
+		return
+	}
+
+	groups := make([][]*layout, 0, len(elems)/n)
+	keys := make([]string, 0, len(elems)/n)
+	for i := 0; i < len(elems); i += n {
+		groups = append(groups, append([]*layout(nil), elems[i:i+n]...))
+		keys = append(keys, string(Style{}.appendFlat(nil, elems[i], 0)))
+	}
+
+	order := make([]int, len(groups))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return keys[order[i]] < keys[order[j]] })
+
+	for i, g := range order {
+		copy(elems[i*n:], groups[g])
+	}
+}
+
+// appendFlat appends l on one line, nested depth collections deep.
+func (s Style) appendFlat(buf []byte, l *layout, depth int) []byte {
+	if l.open == "" {
+		buf = s.appendText(buf, l.text)
+		if len(l.elems) == 1 {
+			buf = s.appendFlat(buf, l.elems[0], depth)
+		}
+		return buf
+	}
+
+	buf = s.appendDelimiter(buf, l.open, depth)
+	for i, elem := range l.elems {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = s.appendFlat(buf, elem, depth+1)
+	}
+	return s.appendDelimiter(buf, l.close, depth)
+}
+
+// appendLayout appends l starting at col on a line indented by indent,
+// nested depth collections deep.
+func (s Style) appendLayout(buf []byte, l *layout, col, indent, depth int) []byte {
+	if col+l.width <= s.Width || len(l.elems) == 0 {
+		return s.appendFlat(buf, l, depth)
+	}
+
+	if l.open == "" {
+		// a tagged element
+		buf = s.appendText(buf, l.text)
+		return s.appendLayout(buf, l.elems[0], col+len(l.text), indent, depth)
+	}
+
+	buf = s.appendDelimiter(buf, l.open, depth)
+	elemCol := s.elemCol(col, indent, len(l.open))
+	if !l.isMap {
+		for i, elem := range l.elems {
+			if i > 0 || s.Indent > 0 {
+				buf = appendIndent(buf, elemCol)
+			}
+			buf = s.appendLayout(buf, elem, elemCol, elemCol, depth+1)
+		}
+		return s.appendDelimiter(buf, l.close, depth)
+	}
+
+	keyWidth := 0
+	for i := 0; i < len(l.elems); i += 2 {
+		keyWidth = max(keyWidth, l.elems[i].width)
+	}
+
+	for i := 0; i < len(l.elems); i += 2 {
+		key := l.elems[i]
+		if i > 0 || s.Indent > 0 {
+			buf = appendIndent(buf, elemCol)
+		}
+		buf = s.appendFlat(buf, key, depth+1)
+		if i+1 == len(l.elems) {
+			// the ... of elided entries
+			break
+		}
+		buf = append(buf, ' ')
+
+		val := l.elems[i+1]
+		valCol := elemCol + key.width + 1
+		if s.AlignMaps {
+			buf = append(buf, strings.Repeat(" ", keyWidth-key.width)...)
+			valCol = elemCol + keyWidth + 1
+		}
+		buf = s.appendLayout(buf, val, valCol, elemCol, depth+1)
+
+		if s.Commas && i+2 < len(l.elems) {
+			buf = append(buf, ',')
+		}
+	}
+	return s.appendDelimiter(buf, l.close, depth)
+}
+
+// ANSI escape codes of the colors of Style.Color.
+const (
+	colorReset   = "\x1b[0m"
+	colorKeyword = "\x1b[35m" // magenta
+	colorString  = "\x1b[32m" // green
+	colorNumber  = "\x1b[36m" // cyan
+	colorTag     = "\x1b[33m" // yellow
+)
+
+// rainbow are the colors of the delimiters of collections by depth.
+var rainbow = []string{"\x1b[91m", "\x1b[93m", "\x1b[92m", "\x1b[96m", "\x1b[94m", "\x1b[95m"}
+
+// appendText appends the text of a token or the prefix of a tagged
+// element, colored by its kind.
+func (s Style) appendText(buf []byte, text string) []byte {
+	if !s.Color {
+		return append(buf, text...)
+	}
+
+	var color string
+	switch c := text[0]; {
+	case c == ':':
+		color = colorKeyword
+	case c == '"' || c == '\\':
+		color = colorString
+	case c >= '0' && c <= '9',
+		(c == '-' || c == '+') && len(text) > 1 && text[1] >= '0' && text[1] <= '9',
+		strings.HasPrefix(text, "##"):
+		color = colorNumber
+	case c == '#':
+		color = colorTag
+	default:
+		return append(buf, text...)
+	}
+
+	buf = append(buf, color...)
+	buf = append(buf, text...)
+	return append(buf, colorReset...)
+}
+
+// appendDelimiter appends a delimiter of a collection nested depth
+// collections deep.
+func (s Style) appendDelimiter(buf []byte, delim string, depth int) []byte {
+	if !s.Color {
+		return append(buf, delim...)
+	}
+
+	buf = append(buf, rainbow[depth%len(rainbow)]...)
+	buf = append(buf, delim...)
+	return append(buf, colorReset...)
+}
+
+// elemCol returns the column of the elements of a collection at col,
+// on a line indented by indent, with an opening delimiter of n bytes.
+func (s Style) elemCol(col, indent, n int) int {
+	if s.Indent > 0 {
+		return indent + s.Indent
+	}
+	return col + n
+}
+
+// Format lays out the top-level forms of doc with the style, changing
+// only the whitespace within them.  Text between the forms, such as
+// comments and discarded forms, is kept as it is.
+//
+// The tokens of the forms are kept as they are, so lists stay lists,
+// numbers, strings and tagged elements are not rewritten, and maps
+// keep the order of their entries unless SortKeys is set.  Forms
+// containing comments or discarded forms are kept as they are instead,
+// as their layout would drop them, with their maps sorted if SortKeys
+// is set.
+//
+// An error is returned if doc does not consist of well-formed forms,
+// see SplitTopLevel, or is nested deeper than 10000 levels.
+func (s Style) Format(doc []byte) ([]byte, error) {
+	var out []byte
+	for pos := 0; ; {
+		start, err := skipSpace(doc, pos)
+		if err == io.EOF {
+			return append(out, doc[pos:]...), nil
+		} else if err != nil {
+			return nil, &SyntaxError{Offset: int64(start), Err: err}
+		}
+
+		end, depth, err := scanNestedDepth(doc, start)
+		if err != nil {
+			return nil, &SyntaxError{Offset: int64(end), Err: err}
+		} else if depth > maxSortDepth {
+			return nil, fmt.Errorf("forms nested deeper than %d levels", maxSortDepth)
+		}
+
+		out = append(out, doc[pos:start]...)
+		col := start - (bytes.LastIndexByte(doc[:start], '\n') + 1)
+		out, err = s.formatForm(out, doc[start:end], col)
+		if serr := (*SyntaxError)(nil); errors.As(err, &serr) {
+			serr.Offset += int64(start)
+		}
+		if err != nil {
+			return nil, err
+		}
+		pos = end
+	}
+}
+
+// formatForm appends the top-level form starting at col to out.
+func (s Style) formatForm(out, form []byte, col int) ([]byte, error) {
+	// check that the form reads, without applying tag readers
+	d := NewDecoder(bytes.NewReader(form))
+	d.SetRawTags(true)
+	if _, err := d.Decode(); err != nil {
+		return nil, err
+	}
+
+	if s.SortKeys {
+		var err error
+		if form, err = SortKeys(form); err != nil {
+			return nil, err
+		}
+	}
+
+	l, _, ok := layoutForm(form, 0)
+	if !ok {
+		return append(out, form...), nil
+	}
+	return s.appendLayout(out, l, col, col, 0), nil
+}
+
+// layoutForm returns the layout of the well-formed form at data[pos],
+// with the text of its tokens as it is, and the end of the form.  ok is
+// false if the form contains comments or discarded forms, which the
+// layout would drop.
+func layoutForm(data []byte, pos int) (l *layout, end int, ok bool) {
+	switch ch := data[pos]; {
+	case ch == '[' || ch == '(' || ch == '{':
+		return layoutElems(data, pos+1, data[pos:pos+1], ch == '{')
+	case ch == '#' && data[pos+1] == '{':
+		return layoutElems(data, pos+2, data[pos:pos+2], false)
+	case ch == '#' && data[pos+1] != '#' && data[pos+1] != '_':
+		tagEnd := scanToken(data, pos+1)
+		start, ok := skipBlank(data, tagEnd)
+		if !ok {
+			return nil, start, false
+		}
+
+		val, end, ok := layoutForm(data, start)
+		if !ok {
+			return nil, end, false
+		}
+		prefix := string(data[pos:tagEnd])
+		if data[pos+1] != ':' {
+			// namespaced maps are kept as #:ns{...}
+			prefix += " "
+		}
+		return taggedLayout(prefix, val), end, true
+	case ch == '#' && data[pos+1] == '_':
+		return nil, pos, false
+	default:
+		end, err := scanNested(data, pos)
+		if err != nil {
+			return nil, end, false
+		}
+		return textLayout(string(data[pos:end])), end, true
+	}
+}
+
+// layoutElems returns the layout of the collection opened by open,
+// whose elements start at data[pos].
+func layoutElems(data []byte, pos int, open []byte, isMap bool) (*layout, int, bool) {
+	close := closingDelimiter(open[len(open)-1])
+
+	var elems []*layout
+	for {
+		start, ok := skipBlank(data, pos)
+		if !ok {
+			return nil, start, false
+		} else if data[start] == close {
+			return collectionLayout(string(open), string(close), elems, isMap), start + 1, true
+		}
+
+		elem, end, ok := layoutForm(data, start)
+		if !ok {
+			return nil, end, false
+		}
+		elems = append(elems, elem)
+		pos = end
+	}
+}
+
+// skipBlank skips whitespace.  ok is false if it is followed by a
+// comment or a discarded form, or the end of data.
+func skipBlank(data []byte, pos int) (int, bool) {
+	for pos < len(data) && isWhitespace(data[pos]) {
+		pos++
+	}
+
+	blank := pos < len(data) && data[pos] != ';' &&
+		!(data[pos] == '#' && pos+1 < len(data) && data[pos+1] == '_')
+	return pos, blank
+}
+
+func closingDelimiter(open byte) byte {
+	switch open {
+	case '[':
+		return ']'
+	case '(':
+		return ')'
+	default:
+		return '}'
+	}
+}
+
+// StyleFile is the name of style files, see FindStyle.
+const StyleFile = ".ednfmt.edn"
+
+// DefaultStyle is the style used without a style file.
+var DefaultStyle = Style{Width: 80}
+
+// ReadStyle reads the style in the file at path, a map of the form
+//
+//	{:width 100 :indent 2 :align-maps true :commas false :sort-keys true}
+//
+// in which all entries are optional.  Missing entries are those of
+// DefaultStyle.
+func ReadStyle(path string) (Style, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Style{}, err
+	}
+
+	val, err := DecodeBytes(data)
+	if err != nil {
+		return Style{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	m, ok := val.(map[interface{}]interface{})
+	if !ok {
+		return Style{}, fmt.Errorf("%s: style must be a map, but was %s", path, String(val))
+	}
+
+	style := DefaultStyle
+	for key, val := range m {
+		var ok bool
+		switch key {
+		case Keyword{"", "width"}:
+			style.Width, ok = styleInt(val)
+		case Keyword{"", "indent"}:
+			style.Indent, ok = styleInt(val)
+		case Keyword{"", "align-maps"}:
+			style.AlignMaps, ok = val.(bool)
+		case Keyword{"", "commas"}:
+			style.Commas, ok = val.(bool)
+		case Keyword{"", "sort-keys"}:
+			style.SortKeys, ok = val.(bool)
+		default:
+			return Style{}, fmt.Errorf("%s: unknown style option %s", path, String(key))
+		}
+
+		if !ok {
+			return Style{}, fmt.Errorf("%s: invalid value %s for %s", path, String(val), String(key))
+		}
+	}
+
+	return style, nil
+}
+
+func styleInt(v interface{}) (int, bool) {
+	i, ok := v.(int64)
+	if !ok || i < 0 || i > math.MaxInt32 {
+		return 0, false
+	}
+	return int(i), true
+}
+
+// FindStyle reads the style file in dir or the closest of its parent
+// directories, so that all files of a project share the style in its
+// root directory.  Without a style file, DefaultStyle is returned.
+func FindStyle(dir string) (Style, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return Style{}, err
+	}
+
+	for {
+		path := filepath.Join(dir, StyleFile)
+		if _, err := os.Stat(path); err == nil {
+			return ReadStyle(path)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return Style{}, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return DefaultStyle, nil
+		}
+		dir = parent
+	}
+}
+
+func appendIndent(buf []byte, col int) []byte {
+	buf = append(buf, '\n')
+	return append(buf, strings.Repeat(" ", col)...)
+}
+
+// sortByString sorts vals by their String representation, which
+// may be unhashable.
+func sortByString(vals []interface{}) {
+	strs := make([]string, len(vals))
+	for i, v := range vals {
+		strs[i] = String(v)
+	}
+	sort.Sort(byString{vals, strs})
+}
+
+type byString struct {
+	vals []interface{}
+	strs []string
+}
+
+func (b byString) Len() int           { return len(b.vals) }
+func (b byString) Less(i, j int) bool { return b.strs[i] < b.strs[j] }
+func (b byString) Swap(i, j int) {
+	b.vals[i], b.vals[j] = b.vals[j], b.vals[i]
+	b.strs[i], b.strs[j] = b.strs[j], b.strs[i]
+}
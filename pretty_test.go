@@ -0,0 +1,256 @@
+package edn
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestPretty(t *testing.T) {
+	val, err := DecodeString(`{:name "example" :deps [[org.clojure/clojure "1.11.1"] [org.clojure/core.async "1.6.681"]] :tags #{:a :b}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		width    int
+		expected string
+	}{
+		{200, String(val)},
+		{50, `{:deps [[org.clojure/clojure "1.11.1"]
+        [org.clojure/core.async "1.6.681"]]
+ :name "example"
+ :tags #{:a :b}}`},
+		{20, `{:deps [[org.clojure/clojure
+         "1.11.1"]
+        [org.clojure/core.async
+         "1.6.681"]]
+ :name "example"
+ :tags #{:a :b}}`},
+	}
+
+	for _, test := range tests {
+		s := Pretty(val, test.width)
+		if s != test.expected {
+			t.Errorf("width %d: expected\n%s\ngot\n%s", test.width, test.expected, s)
+		}
+
+		again, err := DecodeString(s)
+		if err != nil || !reflect.DeepEqual(again, val) {
+			t.Errorf("width %d: reads back as %#v (%v)", test.width, again, err)
+		}
+	}
+}
+
+func TestPrettyTagged(t *testing.T) {
+	val := Tagged{Symbol{"my", "tag"}, []interface{}{int64(1), int64(2), int64(3)}}
+
+	expected := "#my/tag [1\n         2\n         3]"
+	if s := Pretty(val, 10); s != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, s)
+	}
+}
+
+func TestStyle(t *testing.T) {
+	val, err := DecodeString(`{:name "example" :deps [[org.clojure/clojure "1.11.1"]] :tags #{:a :b}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		style    Style
+		expected string
+	}{
+		{Style{Width: 40, AlignMaps: true, Commas: true}, `{:deps [[org.clojure/clojure "1.11.1"]],
+ :name "example",
+ :tags #{:a :b}}`},
+		{Style{Width: 36, Indent: 2}, `{
+  :deps [
+    [org.clojure/clojure "1.11.1"]]
+  :name "example"
+  :tags #{:a :b}}`},
+	}
+
+	for _, test := range tests {
+		s := test.style.Pretty(val)
+		if s != test.expected {
+			t.Errorf("%+v: expected\n%s\ngot\n%s", test.style, test.expected, s)
+		}
+
+		again, err := DecodeString(s)
+		if err != nil || !reflect.DeepEqual(again, val) {
+			t.Errorf("%+v: reads back as %#v (%v)", test.style, again, err)
+		}
+	}
+
+	aligned := Style{Width: 20, AlignMaps: true}.Pretty(map[interface{}]interface{}{
+		Keyword{"", "a"}:      int64(1),
+		Keyword{"", "longer"}: "some text",
+	})
+	if expected := "{:a      1\n :longer \"some text\"}"; aligned != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, aligned)
+	}
+}
+
+func TestStyleElision(t *testing.T) {
+	val, err := DecodeString(`{:name "a very long name" :ids [1 2 3 4] :nested {:a {:b {:c 1}}} :tags #{:x :y :z}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		style    Style
+		expected string
+	}{
+		{Style{Width: 200, MaxLength: 2}, `{:ids [1 2 ...] :name "a very long name" ...}`},
+		{Style{Width: 200, MaxDepth: 2}, `{:ids [1 2 3 4] :name "a very long name" :nested {:a ...} :tags #{:x :y :z}}`},
+		{Style{Width: 200, MaxDepth: 1}, `{:ids ... :name "a very long name" :nested ... :tags ...}`},
+		{Style{Width: 200, MaxStringLength: 6}, `{:ids [1 2 3 4] :name "a very..." :nested {:a {:b {:c 1}}} :tags #{:x :y :z}}`},
+		{Style{Width: 20, MaxLength: 3, MaxDepth: 2}, `{:ids [1 2 3 ...]
+ :name "a very long name"
+ :nested {:a ...}
+ ...}`},
+	}
+
+	for _, test := range tests {
+		if s := test.style.Pretty(val); s != test.expected {
+			t.Errorf("%+v: expected\n%s\ngot\n%s", test.style, test.expected, s)
+		}
+	}
+
+	if s := (Style{Width: 80, MaxStringLength: 2}).Pretty("äöü"); s != `"äö..."` {
+		t.Errorf("expected strings to be truncated by characters, got %s", s)
+	}
+}
+
+func TestStyleColor(t *testing.T) {
+	val, err := DecodeString(`{:a [1 "s" #my/tag x ##Inf]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "\x1b[91m{\x1b[0m\x1b[35m:a\x1b[0m \x1b[93m[\x1b[0m" +
+		"\x1b[36m1\x1b[0m \x1b[32m\"s\"\x1b[0m \x1b[33m#my/tag \x1b[0mx \x1b[36m##Inf\x1b[0m" +
+		"\x1b[93m]\x1b[0m\x1b[91m}\x1b[0m"
+	if s := (Style{Width: 80, Color: true}).Pretty(val); s != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, s)
+	}
+
+	// the escape codes don't count for the layout
+	plain := Style{Width: 10}.Pretty(val)
+	colored := Style{Width: 10, Color: true}.Pretty(val)
+	if stripped := regexp.MustCompile("\x1b\\[[0-9]+m").ReplaceAllString(colored, ""); stripped != plain {
+		t.Errorf("expected\n%s\ngot\n%s", plain, stripped)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	doc := `;; the config
+{:name "example" :deps [[org.clojure/clojure "1.11.1"] [org.clojure/core.async "1.6.681"]]}
+
+#_ {:old true}
+{:b 1 ; comment
+ :a #my/tag 2}
+[3,   4]
+(a   (b c)
+   #inst "2020-01-01T01:00:00+01:00" 1.50)
+#:user {:id 1}
+`
+
+	tests := []struct {
+		style    Style
+		expected string
+	}{
+		{Style{Width: 50}, `;; the config
+{:name "example"
+ :deps [[org.clojure/clojure "1.11.1"]
+        [org.clojure/core.async "1.6.681"]]}
+
+#_ {:old true}
+{:b 1 ; comment
+ :a #my/tag 2}
+[3 4]
+(a (b c) #inst "2020-01-01T01:00:00+01:00" 1.50)
+#:user{:id 1}
+`},
+		{Style{Width: 50, SortKeys: true}, `;; the config
+{:deps [[org.clojure/clojure "1.11.1"]
+        [org.clojure/core.async "1.6.681"]]
+ :name "example"}
+
+#_ {:old true}
+{:a #my/tag 2
+ :b 1 ; comment
+}
+[3 4]
+(a (b c) #inst "2020-01-01T01:00:00+01:00" 1.50)
+#:user{:id 1}
+`},
+		{Style{Width: 40, Indent: 2}, `;; the config
+{
+  :name "example"
+  :deps [
+    [org.clojure/clojure "1.11.1"]
+    [org.clojure/core.async "1.6.681"]]}
+
+#_ {:old true}
+{:b 1 ; comment
+ :a #my/tag 2}
+[3 4]
+(
+  a
+  (b c)
+  #inst "2020-01-01T01:00:00+01:00"
+  1.50)
+#:user{:id 1}
+`},
+	}
+
+	for _, test := range tests {
+		formatted, err := test.style.Format([]byte(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(formatted) != test.expected {
+			t.Errorf("%+v: expected\n%s\ngot\n%s", test.style, test.expected, formatted)
+		}
+	}
+
+	if _, err := DefaultStyle.Format([]byte("{:a 1} [1 2")); err == nil {
+		t.Errorf("expected error for unbalanced form")
+	}
+}
+
+func TestFindStyle(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "config", "env")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if style, err := FindStyle(sub); err != nil || style != DefaultStyle {
+		t.Errorf("expected the default style, got %+v, %v", style, err)
+	}
+
+	err := os.WriteFile(filepath.Join(root, StyleFile), []byte(`{:width 100 :indent 2 :commas true :sort-keys true}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Style{Width: 100, Indent: 2, Commas: true, SortKeys: true}
+	if style, err := FindStyle(sub); err != nil || style != expected {
+		t.Errorf("expected %+v, got %+v, %v", expected, style, err)
+	}
+
+	for _, invalid := range []string{`[]`, `{:width "80"}`, `{:indent -1}`, `{:commas 1}`, `{:sort-keys "yes"}`, `{:sort true}`} {
+		if err := os.WriteFile(filepath.Join(root, StyleFile), []byte(invalid), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := FindStyle(sub); err == nil {
+			t.Errorf("%s: expected error", invalid)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package edn
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// String returns the EDN representation of v, which should be one of
+// the values produced by the reader.
+//
+// It is meant for debugging output and error messages, so it never
+// fails: values of other types are written as strings containing their
+// fmt.Sprint representation.  Nil *big.Int, *big.Rat, net.IP,
+// *net.IPNet and *url.URL values are written as nil, zero times as
+// #inst "0001-01-01T00:00:00Z".  The entries of maps and sets are
+// sorted by their EDN representation, so the output for a value is
+// always the same.
+func String(v interface{}) string {
+	return string(appendValue(nil, v))
+}
+
+func appendValue(buf []byte, v interface{}) []byte {
+	return plainPrinter.appendValue(buf, v)
+}
+
+// printer holds the options of an Encoder that change how values are
+// written.  The zero value writes them as String does.
+type printer struct {
+	commas         Commas
+	namespacedMaps bool
+	target         Target
+	durations      DurationFormat
+}
+
+var plainPrinter printer
+
+// string returns v as written by p.
+func (p *printer) string(v interface{}) string {
+	return string(p.appendValue(nil, v))
+}
+
+func (p *printer) appendValue(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, "nil"...)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case int64:
+		return AppendInt(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case float64:
+		return appendFloat(buf, v)
+	case string:
+		return appendString(buf, v)
+	case Keyword:
+		return AppendKeyword(buf, v)
+	case Symbol:
+		return AppendSymbol(buf, v)
+	case UUID:
+		buf = append(buf, "#uuid "...)
+		return appendString(buf, v.String())
+	case time.Time:
+		buf = append(buf, "#inst "...)
+		return appendString(buf, v.Format(time.RFC3339Nano))
+	case LocalDate:
+		buf = append(buf, "#local-date "...)
+		return appendString(buf, v.String())
+	case LocalTime:
+		buf = append(buf, "#local-time "...)
+		return appendString(buf, v.String())
+	case time.Duration:
+		if p.durations == DurationISO {
+			buf = append(buf, "#java.time/duration "...)
+			return appendString(buf, formatISODuration(v))
+		}
+		buf = append(buf, "#duration "...)
+		return appendString(buf, v.String())
+	case net.IP:
+		if v == nil {
+			return append(buf, "nil"...)
+		}
+		buf = append(buf, "#ip "...)
+		return appendString(buf, v.String())
+	case *net.IPNet:
+		if v == nil {
+			return append(buf, "nil"...)
+		}
+		buf = append(buf, "#cidr "...)
+		return appendString(buf, v.String())
+	case *url.URL:
+		if v == nil {
+			return append(buf, "nil"...)
+		}
+		buf = append(buf, "#uri "...)
+		return appendString(buf, v.String())
+	case *big.Int:
+		if v == nil {
+			return append(buf, "nil"...)
+		}
+		buf = append(buf, v.String()...)
+		return append(buf, 'N')
+	case *big.Rat:
+		if v == nil {
+			return append(buf, "nil"...)
+		}
+		return append(buf, v.String()...)
+	case []interface{}:
+		buf = append(buf, '[')
+		for i, elem := range v {
+			if i > 0 {
+				buf = p.appendSeparator(buf, CommasInVectors)
+			}
+			buf = p.appendValue(buf, elem)
+		}
+		return append(buf, ']')
+	case map[interface{}]interface{}:
+		keys := make([]interface{}, 0, len(v))
+		vals := make([]interface{}, 0, len(v))
+		for key, val := range v {
+			keys, vals = append(keys, key), append(vals, val)
+		}
+		return p.appendMap(buf, keys, vals)
+	case map[interface{}]bool:
+		elems := make([]string, 0, len(v))
+		for elem := range v {
+			elems = append(elems, p.string(elem))
+		}
+		return p.appendSorted(buf, "#{", elems, '}', CommasInSets)
+	case CompositeKey:
+		return append(buf, v...)
+	case Tagged:
+		buf = append(buf, '#')
+		buf = AppendSymbol(buf, v.Tag)
+		buf = append(buf, ' ')
+		return p.appendValue(buf, v.Value)
+	default:
+		if tagged, ok, err := writeTagged(v); ok && err == nil {
+			return p.appendValue(buf, tagged)
+		}
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Map {
+			// maps built in Go, e.g. map[Keyword]interface{}
+			keys := make([]interface{}, 0, rv.Len())
+			vals := make([]interface{}, 0, rv.Len())
+			for iter := rv.MapRange(); iter.Next(); {
+				keys, vals = append(keys, iter.Key().Interface()), append(vals, iter.Value().Interface())
+			}
+			return p.appendMap(buf, keys, vals)
+		}
+		return appendString(buf, fmt.Sprint(v))
+	}
+}
+
+// appendMap appends the map with the entries keys[i] vals[i], as a
+// namespaced map if enabled and all keys are keywords of a namespace.
+func (p *printer) appendMap(buf []byte, keys, vals []interface{}) []byte {
+	open, ns := "{", ""
+	if p.namespacedMaps && p.target != TargetEDN2012 {
+		ns = sharedNamespace(keys)
+	}
+	if ns != "" {
+		open = "#:" + ns + "{"
+	}
+
+	entries := make([]string, len(keys))
+	for i, key := range keys {
+		if ns != "" {
+			key = Keyword{"", key.(Keyword).Name}
+		}
+		entries[i] = p.string(key) + " " + p.string(vals[i])
+	}
+	return p.appendSorted(buf, open, entries, '}', CommasInMaps)
+}
+
+// sharedNamespace returns the namespace of keys if they are all
+// keywords of the same namespace, and "" otherwise.
+func sharedNamespace(keys []interface{}) string {
+	ns := ""
+	for _, key := range keys {
+		kw, ok := key.(Keyword)
+		if !ok || kw.Namespace == "" || (ns != "" && kw.Namespace != ns) {
+			return ""
+		}
+		ns = kw.Namespace
+	}
+	return ns
+}
+
+func (p *printer) appendSorted(buf []byte, open string, elems []string, close byte, commas Commas) []byte {
+	sort.Strings(elems)
+
+	buf = append(buf, open...)
+	for i, elem := range elems {
+		if i > 0 {
+			buf = p.appendSeparator(buf, commas)
+		}
+		buf = append(buf, elem...)
+	}
+	return append(buf, close)
+}
+
+// appendSeparator appends the separator of the elements of collections
+// of the type given by commas.
+func (p *printer) appendSeparator(buf []byte, commas Commas) []byte {
+	if p.commas&commas != 0 {
+		buf = append(buf, ',')
+	}
+	return append(buf, ' ')
+}
+
+func appendFloat(buf []byte, f float64) []byte {
+	switch {
+	case math.IsInf(f, 1):
+		return append(buf, "##Inf"...)
+	case math.IsInf(f, -1):
+		return append(buf, "##-Inf"...)
+	case math.IsNaN(f):
+		return append(buf, "##NaN"...)
+	}
+
+	start := len(buf)
+	buf = strconv.AppendFloat(buf, f, 'g', -1, 64)
+	for _, ch := range buf[start:] {
+		if ch == '.' || ch == 'e' {
+			return buf
+		}
+	}
+
+	// without a decimal point or exponent it would be read as an integer
+	return append(buf, ".0"...)
+}
+
+// QuoteString returns s as an EDN string literal, with quotes,
+// backslashes and control characters escaped, e.g. for building EDN
+// text from untrusted input.  Reading the literal results in s again.
+func QuoteString(s string) string {
+	return string(appendString(nil, s))
+}
+
+// AppendQuoted appends s as an EDN string literal to dst, see
+// QuoteString.
+func AppendQuoted(dst []byte, s string) []byte {
+	return appendString(dst, s)
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch ch {
+		case '"':
+			buf = append(buf, '\\', '"')
+		case '\\':
+			buf = append(buf, '\\', '\\')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		default:
+			if ch < ' ' || ch == 0x7f {
+				buf = append(buf, fmt.Sprintf("\\u%04x", ch)...)
+			} else {
+				buf = append(buf, ch)
+			}
+		}
+	}
+	return append(buf, '"')
+}
@@ -0,0 +1,105 @@
+package edn
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		val      interface{}
+		expected string
+	}{
+		{nil, "nil"},
+		{true, "true"},
+		{int64(-42), "-42"},
+		{3.5, "3.5"},
+		{float64(3), "3.0"},
+		{1e21, "1e+21"},
+		{math.Inf(-1), "##-Inf"},
+		{"say \"hi\"\n\x01", `"say \"hi\"\n\u0001"`},
+		{Keyword{"ns", "kw"}, ":ns/kw"},
+		{Symbol{"", "sym"}, "sym"},
+		{UUID{0xf81d4fae7dec11d0, 0xa76500a0c91e6bf6}, `#uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"`},
+		{time.Date(1985, 4, 12, 23, 20, 50, 520000000, time.UTC), `#inst "1985-04-12T23:20:50.52Z"`},
+		{90 * time.Minute, `#duration "1h30m0s"`},
+		{big.NewInt(12), "12N"},
+		{big.NewRat(3, 45), "1/15"},
+		{(*big.Int)(nil), "nil"},
+		{(*big.Rat)(nil), "nil"},
+		{time.Time{}, `#inst "0001-01-01T00:00:00Z"`},
+		{[]interface{}{int64(1), "two", []interface{}{}}, `[1 "two" []]`},
+		{map[interface{}]interface{}{Keyword{"", "b"}: int64(2), Keyword{"", "a"}: int64(1)}, "{:a 1 :b 2}"},
+		{map[interface{}]bool{int64(3): true, int64(1): true}, "#{1 3}"},
+		{Tagged{Symbol{"my", "tag"}, []interface{}{Symbol{"", "x"}}}, "#my/tag [x]"},
+		{struct{ A int }{1}, `"{1}"`},
+	}
+
+	for _, test := range tests {
+		s := String(test.val)
+		if s != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, s)
+		}
+	}
+}
+
+func TestStringReadable(t *testing.T) {
+	for _, s := range []string{
+		`[1 2 3 :hey "ho"]`,
+		`{:a "b" :c d}`,
+		`#{1 3 -7 100 :oops}`,
+		`(4 5 6 yay/nay)`,
+		`#inst "1985-04-12T23:20:50.52Z"`,
+		`#uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"`,
+		`[0.23532e10 -253/9 2r1111N]`,
+		`#fun/maybe [who knows]`,
+		`"tab\tnewline\n"`,
+		`[##Inf ##-Inf 1.5]`,
+	} {
+		val, err := DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		again, err := DecodeString(String(val))
+		if err != nil {
+			t.Errorf("cannot read %s: %v", String(val), err)
+			continue
+		}
+
+		if !reflect.DeepEqual(normalize(val), normalize(again)) {
+			t.Errorf("%s printed as %s, which reads as %#v", s, String(val), again)
+		}
+	}
+}
+
+func TestQuoteString(t *testing.T) {
+	for _, s := range []string{
+		"",
+		`plain`,
+		`" :injected "`,
+		`back\slash\`,
+		"tab\tnewline\ncr\r",
+		"control \x00\x01\x1f\x7f",
+		"unicode ünïcødé 😀",
+	} {
+		quoted := QuoteString(s)
+		if string(AppendQuoted([]byte("x"), s)) != "x"+quoted {
+			t.Errorf("AppendQuoted differs from QuoteString for %q", s)
+		}
+
+		d := NewDecoder(strings.NewReader(quoted))
+		val, err := d.Decode()
+		if err != nil {
+			t.Errorf("cannot read %s: %v", quoted, err)
+			continue
+		}
+		if val != s || d.InputOffset() != int64(len(quoted)) {
+			t.Errorf("%q quoted as %s, which reads as %q", s, quoted, val)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package edn
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report summarizes a decoded value, see Profile.
+type Report struct {
+	// Values is the number of values, including collections and their
+	// elements, map keys and tagged values.
+	Values int
+
+	// Types counts the values per type, e.g. Types["keyword"].
+	Types map[string]int
+
+	// MaxDepth is the maximum nesting depth of collections.
+	MaxDepth int
+
+	// Largest are the largest collections, largest first.
+	Largest []Collection
+
+	// Keywords is an index of all keywords.
+	Keywords KeywordIndex
+}
+
+// Collection describes a collection found by Profile.
+type Collection struct {
+	Path []interface{}
+	Type string
+	Size int
+}
+
+// MaxLargest is the number of collections reported in Report.Largest.
+const MaxLargest = 5
+
+// Profile summarizes the decoded value v, which helps with
+// understanding unfamiliar data.
+func Profile(v interface{}) Report {
+	r := Report{
+		Types:    map[string]int{},
+		Keywords: IndexKeywords(v),
+	}
+	r.add(v, nil, 0)
+
+	sort.SliceStable(r.Largest, func(i, j int) bool { return r.Largest[i].Size > r.Largest[j].Size })
+	return r
+}
+
+func (r *Report) add(v interface{}, path []interface{}, depth int) {
+	r.Values++
+	r.Types[typeName(v)]++
+
+	switch v := v.(type) {
+	case []interface{}:
+		r.addCollection(path, "vector", len(v), depth+1)
+		for i, elem := range v {
+			r.add(elem, append(path, i), depth+1)
+		}
+	case map[interface{}]interface{}:
+		r.addCollection(path, "map", len(v), depth+1)
+		for key, val := range v {
+			r.add(key, append(path, key), depth+1)
+			r.add(val, append(path, key), depth+1)
+		}
+	case map[interface{}]bool:
+		r.addCollection(path, "set", len(v), depth+1)
+		for elem := range v {
+			r.add(elem, append(path, elem), depth+1)
+		}
+	case Tagged:
+		r.add(v.Value, path, depth)
+	}
+}
+
+func (r *Report) addCollection(path []interface{}, typ string, size int, depth int) {
+	if depth > r.MaxDepth {
+		r.MaxDepth = depth
+	}
+
+	if len(r.Largest) == MaxLargest {
+		smallest := len(r.Largest) - 1
+		for i, c := range r.Largest {
+			if c.Size < r.Largest[smallest].Size {
+				smallest = i
+			}
+		}
+		if r.Largest[smallest].Size >= size {
+			return
+		}
+		r.Largest = append(r.Largest[:smallest], r.Largest[smallest+1:]...)
+	}
+
+	r.Largest = append(r.Largest, Collection{append([]interface{}{}, path...), typ, size})
+}
+
+// String formats the report for humans, one statistic per line.
+func (r Report) String() string {
+	buf := new(strings.Builder)
+
+	fmt.Fprintf(buf, "values: %d\n", r.Values)
+	fmt.Fprintf(buf, "max depth: %d\n", r.MaxDepth)
+	fmt.Fprintf(buf, "unique keywords: %d\n", len(r.Keywords))
+
+	types := make([]string, 0, len(r.Types))
+	for t := range r.Types {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(buf, "%s: %d\n", t, r.Types[t])
+	}
+
+	for _, c := range r.Largest {
+		fmt.Fprintf(buf, "%s of %d at %s\n", c.Type, c.Size, String(c.Path))
+	}
+
+	return buf.String()
+}
+
+// typeName returns the name of the EDN type of v, as used in Report.
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case int64, int:
+		return "integer"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case Keyword:
+		return "keyword"
+	case Symbol:
+		return "symbol"
+	case UUID:
+		return "uuid"
+	case time.Time:
+		return "inst"
+	case *big.Int:
+		return "bigint"
+	case *big.Rat:
+		return "ratio"
+	case []interface{}:
+		return "vector"
+	case map[interface{}]interface{}:
+		return "map"
+	case map[interface{}]bool:
+		return "set"
+	case Tagged:
+		return "tagged"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
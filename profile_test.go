@@ -0,0 +1,41 @@
+package edn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	val, err := DecodeString(`{:users [{:name "a" :roles #{:admin :dev}} {:name "b" :roles #{}}] :count 2}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := Profile(val)
+
+	if r.Values != 17 {
+		t.Errorf("expected 17 values, got %d", r.Values)
+	}
+	if r.Types["keyword"] != 8 || r.Types["map"] != 3 || r.Types["set"] != 2 || r.Types["string"] != 2 {
+		t.Errorf("unexpected types %v", r.Types)
+	}
+	if r.MaxDepth != 4 {
+		t.Errorf("expected max depth 4, got %d", r.MaxDepth)
+	}
+	if len(r.Keywords) != 6 {
+		t.Errorf("expected 6 unique keywords, got %d", len(r.Keywords))
+	}
+
+	if len(r.Largest) != MaxLargest {
+		t.Fatalf("unexpected largest collections %v", r.Largest)
+	}
+	for _, c := range r.Largest {
+		if c.Size != 2 {
+			t.Errorf("unexpected largest collections %v", r.Largest)
+		}
+	}
+
+	if !strings.Contains(r.String(), "set of 2 at [:users 0 :roles]\n") {
+		t.Errorf("unexpected report\n%s", r)
+	}
+}
@@ -0,0 +1,131 @@
+package edn
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrIncomplete is returned by PushParser.Next if the buffered input
+// does not contain a complete value yet.
+var ErrIncomplete = errors.New("incomplete input")
+
+// PushParser reads values from input that arrives in chunks.
+//
+// Input is handed to the parser with Write as it becomes available,
+// complete values are taken out with Next.  Input that does not form
+// a complete value yet is retained until more input arrives, so the
+// parser can be driven from an event loop without blocking on a
+// reader.
+//
+//  p := edn.NewPushParser()
+//  p.Write(chunk)
+//  for {
+//      val, err := p.Next()
+//      if err == edn.ErrIncomplete {
+//          break // wait for the next chunk
+//      }
+//      ...
+//  }
+//
+// The parser keeps the state of an incomplete value between chunks:
+// the collections read so far are kept, only the element that was cut
+// off by the end of a chunk, e.g. a string, is read again.  Feeding n
+// bytes therefore takes time linear in n unless single elements span
+// many chunks.
+type PushParser struct {
+	r chunkScanner
+	d *Decoder
+}
+
+// NewPushParser returns a parser without any buffered input.
+func NewPushParser() *PushParser {
+	p := &PushParser{}
+	p.d = NewDecoder(&p.r)
+	return p
+}
+
+// Decoder returns the decoder reading the values, which can be
+// configured as usual, e.g. with SetMaxDepth, SetUUIDFunc or
+// SetMetrics.  Its Decode method must not be called, values must be
+// read with Next.
+func (p *PushParser) Decoder() *Decoder {
+	return p.d
+}
+
+// Write appends chunk to the buffered input.  It never fails.
+func (p *PushParser) Write(chunk []byte) (int, error) {
+	p.r.buf = append(p.r.buf, chunk...)
+	return len(chunk), nil
+}
+
+// Close signals that no more input follows.  Afterwards Next reads
+// values that are terminated by the end of the input (e.g. a trailing
+// number) and returns io.EOF once the buffered input is exhausted.
+func (p *PushParser) Close() error {
+	p.r.closed = true
+	return nil
+}
+
+// Buffered returns the number of bytes that have been written but not
+// read by Next yet.  The input of the collections of an incomplete
+// value has been read already.
+func (p *PushParser) Buffered() int {
+	return len(p.r.buf) - p.r.pos
+}
+
+// Next reads the next value from the buffered input.
+//
+// If the input does not contain a complete value, ErrIncomplete is
+// returned and reading continues when more input is written.  A value
+// that reaches the end of the buffered input (such as `12` in `[1 12`)
+// is only returned after Close has been called, because more input
+// might still change it.
+//
+// If the input is invalid, the input up to the error is discarded and
+// a *SyntaxError is returned, as by Decoder.Decode.
+func (p *PushParser) Next() (interface{}, error) {
+	val, err := p.d.Decode()
+	if err == ErrIncomplete {
+		// read the cut off element again with the next chunk
+		p.r.pos -= int(p.d.offset - p.d.mark)
+		p.d.offset = p.d.mark
+	}
+
+	p.r.buf = p.r.buf[p.r.pos:]
+	p.r.pos = 0
+	if len(p.r.buf) == 0 {
+		p.r.buf = nil
+	}
+
+	return val, err
+}
+
+// chunkScanner reads the buffered input of a PushParser.  Its end is
+// the end of the input once the parser is closed.
+type chunkScanner struct {
+	buf    []byte
+	pos    int
+	closed bool
+}
+
+func (s *chunkScanner) ReadByte() (byte, error) {
+	if s.pos >= len(s.buf) {
+		if s.closed {
+			return 0, io.EOF
+		}
+		return 0, ErrIncomplete
+	}
+
+	ch := s.buf[s.pos]
+	s.pos++
+	return ch, nil
+}
+
+func (s *chunkScanner) UnreadByte() error {
+	if s.pos <= 0 {
+		return errors.New("chunkScanner.UnreadByte: at beginning of input")
+	}
+
+	s.pos--
+	return nil
+}
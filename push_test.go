@@ -0,0 +1,147 @@
+package edn
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPushParser(t *testing.T) {
+	p := NewPushParser()
+
+	p.Write([]byte("[1 2"))
+	if _, err := p.Next(); err != ErrIncomplete {
+		t.Fatalf("expected ErrIncomplete, got %v", err)
+	}
+
+	p.Write([]byte(" 3] :foo 4"))
+	val, err := p.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(val, []interface{}{int64(1), int64(2), int64(3)}) {
+		t.Errorf("unexpected value %#v", val)
+	}
+
+	val, err = p.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != (Keyword{"", "foo"}) {
+		t.Errorf("unexpected value %#v", val)
+	}
+
+	// 4 might be continued by the next chunk
+	if _, err := p.Next(); err != ErrIncomplete {
+		t.Fatalf("expected ErrIncomplete, got %v", err)
+	}
+
+	p.Write([]byte("2 "))
+	p.Close()
+	val, err = p.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != int64(42) {
+		t.Errorf("unexpected value %#v", val)
+	}
+
+	if _, err := p.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestPushParserInvalid(t *testing.T) {
+	p := NewPushParser()
+	p.Write([]byte("] 1 "))
+
+	if _, err := p.Next(); err == nil || err == ErrIncomplete {
+		t.Fatalf("expected syntax error, got %v", err)
+	}
+
+	val, err := p.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != int64(1) {
+		t.Errorf("unexpected value %#v", val)
+	}
+}
+
+func TestPushParserChunks(t *testing.T) {
+	const n = 200000
+	var sb strings.Builder
+	sb.WriteString(`{:at #inst "2020-01-02T03:04:05Z" :xs [`)
+	for i := 0; i < n; i++ {
+		sb.WriteString(strconv.Itoa(i))
+		sb.WriteString(` #_ "skipped" `)
+	}
+	sb.WriteString(`]} :end`)
+	input := []byte(sb.String())
+
+	// the parser keeps its state between chunks, so feeding the input in
+	// small chunks takes linear time
+	p := NewPushParser()
+	var vals []interface{}
+	for len(input) > 0 {
+		chunk := input[:min(7, len(input))]
+		input = input[len(chunk):]
+		p.Write(chunk)
+
+		for {
+			val, err := p.Next()
+			if err == ErrIncomplete {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			vals = append(vals, val)
+		}
+	}
+	p.Close()
+	val, err := p.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals = append(vals, val)
+
+	if len(vals) != 2 || vals[1] != (Keyword{"", "end"}) {
+		t.Fatalf("expected a map and :end, got %d values", len(vals))
+	}
+
+	m := vals[0].(map[interface{}]interface{})
+	xs := m[Keyword{"", "xs"}].([]interface{})
+	if len(xs) != n || xs[n-1] != int64(n-1) || m[Keyword{"", "at"}] != time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) {
+		t.Errorf("unexpected value with %d elements", len(xs))
+	}
+}
+
+func TestPushParserDecoder(t *testing.T) {
+	p := NewPushParser()
+	p.Decoder().SetMaxDepth(2)
+
+	p.Write([]byte("[[1] "))
+	if _, err := p.Next(); err != ErrIncomplete {
+		t.Fatalf("expected ErrIncomplete, got %v", err)
+	}
+
+	p.Write([]byte("[[2]]] 3 "))
+	var serr *SyntaxError
+	if _, err := p.Next(); !errors.As(err, &serr) || !strings.Contains(err.Error(), "deeper than 2 levels") {
+		t.Fatalf("expected depth error, got %v", err)
+	}
+
+	// reading continues after the error, as with Decode
+	for i := 0; ; i++ {
+		val, err := p.Next()
+		if val == int64(3) {
+			break
+		} else if err == ErrIncomplete || i > 5 {
+			t.Fatalf("expected 3, got %v", err)
+		}
+	}
+}
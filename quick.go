@@ -0,0 +1,57 @@
+package edn
+
+import (
+	"math/rand"
+	"reflect"
+)
+
+// Generate implements quick.Generator.  The generated keywords are
+// valid EDN, about half of them have a namespace.
+func (Keyword) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(Keyword{randomNamespace(r, size), randomName(r, size)})
+}
+
+// Generate implements quick.Generator.  The generated symbols are valid
+// EDN, about half of them have a namespace.
+func (Symbol) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(Symbol{randomNamespace(r, size), randomName(r, size)})
+}
+
+// Generate implements quick.Generator.
+func (UUID) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(UUID{r.Uint64(), r.Uint64()})
+}
+
+const (
+	nameStart = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	nameChars = nameStart + "0123456789-_*+!?"
+)
+
+func randomNamespace(r *rand.Rand, size int) string {
+	if r.Intn(2) == 0 {
+		return ""
+	}
+
+	name := randomName(r, size)
+	for i := r.Intn(3); i > 0; i-- {
+		name += "." + randomName(r, size)
+	}
+	return name
+}
+
+func randomName(r *rand.Rand, size int) string {
+	if size < 1 {
+		size = 1
+	}
+
+	buf := []byte{nameStart[r.Intn(len(nameStart))]}
+	for i := r.Intn(size); i > 0; i-- {
+		buf = append(buf, nameChars[r.Intn(len(nameChars))])
+	}
+
+	name := string(buf)
+	if name == "nil" || name == "true" || name == "false" {
+		return name + "_"
+	}
+	return name
+}
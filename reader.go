@@ -1,4 +1,4 @@
-// Package edn implements reading EDN values.
+// Package edn implements reading and writing EDN values.
 //
 // It reads EDN values into plain Go values.
 //
@@ -8,12 +8,21 @@
 //  - lists and vectors are read as []interface{}
 //  - maps are read as map[interface{}]interface{}
 //  - sets are read as map[interface{}]bool
+//  - vectors, lists, maps and sets used as map keys or set elements
+//    are read as CompositeKey
 //  - instants are read as time.Time
 //  - uuids are read as UUID
+//  - durations, #duration "1h30m" and #java.time/duration "PT1H30M",
+//    are read as time.Duration
 //  - comments (;) and discards (#_) are supported
+//  - the symbolic values ##Inf, ##-Inf and ##NaN are read as float64
+//  - namespaced maps such as #:user{:id 1} are read as maps with the
+//    namespace applied to their keys, {:user/id 1}
 //
-// Support for arbitrary precision floats and custom tagged
-// elements is not implemented yet.
+// Other tagged elements are read as Tagged, unless a reader is
+// registered for their tag with RegisterTag, see also RegisterTagWriter
+// for writing them.  Arbitrary precision floats such as 1.5M are not
+// supported yet.
 //
 // References:
 //  - http://edn-format.org
@@ -21,30 +30,45 @@
 package edn
 
 import (
-	"bytes"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"math"
 	"math/big"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // DecodeString reads the first value from a string.
 func DecodeString(s string) (interface{}, error) {
-	buf := bytes.NewBuffer([]byte(s))
-	return ReadValue(buf)
+	return NewDecoder(newStringReader(s)).Decode()
+}
+
+// DecodeStringPrefix reads the first value from a string and returns
+// the input following it, e.g. for formats that embed a value in a
+// header.  rest starts right after the value, including any whitespace.
+func DecodeStringPrefix(s string) (val interface{}, rest string, err error) {
+	d := NewDecoder(newStringReader(s))
+	val, err = d.Decode()
+	if err != nil {
+		return nil, s, err
+	}
+
+	return val, s[d.InputOffset():], nil
 }
 
 // ReadAllValues reads values until io.EOF is reached
 func ReadAllValues(r io.ByteScanner) ([]interface{}, error) {
+	d := NewDecoder(r)
 	vals := []interface{}{}
 
 	for {
-		val, err := ReadValue(r)
+		val, err := d.Decode()
 		if err != nil {
 			if err == io.EOF {
 				return vals, nil
@@ -59,73 +83,331 @@ func ReadAllValues(r io.ByteScanner) ([]interface{}, error) {
 
 // ReadValue reads the next value.
 func ReadValue(r io.ByteScanner) (interface{}, error) {
+	return NewDecoder(r).Decode()
+}
+
+// readValue reads the next value, skipping comments and discarded
+// forms.  Collections, tagged elements and discarded forms are read
+// using an explicit stack instead of recursively, so that deeply nested
+// input doesn't exhaust the stack of the goroutine.
+//
+// If the input of a PushParser ends within a value, ErrIncomplete is
+// returned and the stack is kept, so that reading continues where it
+// stopped when more input arrives.  The input of the incomplete element
+// is read again from d.mark.
+func (d *Decoder) readValue() (val interface{}, err error) {
+	stack := d.stack
+	d.stack = nil
+	defer func() {
+		if err == ErrIncomplete {
+			d.stack = stack
+			return
+		}
+
+		// leave the collections left open by errors
+		for _, coll := range stack {
+			if coll.delim != 0 {
+				d.leave()
+			}
+		}
+	}()
+
 	for {
-		ch, err := r.ReadByte()
-		if err != nil {
-			return nil, err
+		d.mark = d.offset
+		d.skipWhitespace()
+		ch, err := d.readByte()
+		for err == nil && isWhitespace(ch) {
+			ch, err = d.readByte()
 		}
 
-		for isWhitespace(ch) {
-			ch, err = r.ReadByte()
-			if err != nil {
-				return nil, fmt.Errorf("whitespace: %v", err)
+		if err == ErrIncomplete {
+			return nil, err
+		} else if err != nil && len(stack) > 0 {
+			if err == io.EOF {
+				err = &eofError{stack[len(stack)-1].name()}
 			}
+			return nil, errorf("macroRdr: '%c': %w", stack[0].open, err)
+		} else if err != nil {
+			return nil, err
 		}
 
-		if isDigit(ch) {
-			n, err := readNumber(r, ch)
+		var val interface{}
+		if top := len(stack) - 1; top >= 0 && stack[top].delim != 0 && ch == stack[top].delim {
+			coll := stack[top]
+			stack = stack[:top]
+			d.leave()
+
+			val, err = coll.value(d)
 			if err != nil {
+				return nil, errorf("macroRdr: '%c': %w", coll.open, err)
+			}
+		} else {
+			val, err = d.readForm(ch)
+			if err == ErrIncomplete {
 				return nil, err
+			} else if err == nil && d.depthLimit > 0 && len(stack) >= d.depthLimit {
+				if _, ok := val.(*collection); ok {
+					err = errorf("values nested deeper than %d levels", d.depthLimit)
+				}
 			}
 
-			return n, nil
-		}
+			if err != nil && len(stack) > 0 {
+				return nil, errorf("macroRdr: '%c': %w", stack[0].open, err)
+			} else if err != nil && isMacro(ch) {
+				return nil, errorf("macroRdr: '%c': %w", ch, err)
+			} else if err != nil {
+				return nil, err
+			}
 
-		macroRdr, ok := macros[ch]
-		if ok {
-			val, err := macroRdr(r, ch)
-			if err != nil {
-				return nil, fmt.Errorf("macroRdr: '%c': %v", ch, err)
+			if val == (noValue{}) {
+				continue
 			}
 
-			if val == r {
+			if coll, ok := val.(*collection); ok {
+				if top := len(stack) - 1; top >= 0 {
+					coll.inTag = stack[top].tagged || stack[top].inTag
+				}
+				if coll.delim != 0 {
+					if coll.copied(d) {
+						coll.elems = d.elemBuffer()
+					} else if d.reuse != nil && !coll.inTag {
+						coll.elems = d.reuse.slice()
+					}
+					d.enter()
+				}
+				stack = append(stack, coll)
 				continue
 			}
+		}
+
+		// add val to the enclosing collection, which completes tagged
+		// elements and discarded forms, whose values are added in turn
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if top.tagged && len(top.elems) == 0 {
+				if _, ok := val.(Symbol); !ok {
+					return nil, errorf("macroRdr: '%c': %w", stack[0].open, errorf("reader tag must be a symbol"))
+				}
+			}
+
+			top.elems = append(top.elems, val)
+			if !top.complete() {
+				break
+			}
+
+			stack = stack[:len(stack)-1]
+			val, err = top.value(d)
+			if err != nil && len(stack) > 0 {
+				return nil, errorf("macroRdr: '%c': %w", stack[0].open, err)
+			} else if err != nil {
+				return nil, errorf("macroRdr: '%c': %w", top.open, err)
+			}
+
+			if val == (noValue{}) {
+				break
+			}
+		}
 
+		if len(stack) == 0 && val != (noValue{}) {
 			return val, nil
 		}
+	}
+}
 
-		if ch == '+' || ch == '-' {
-			ch2, err := r.ReadByte()
-			if err != nil {
+// readForm reads the form starting with ch.  Macros for collections
+// return the *collection to be read by readValue.
+func (d *Decoder) readForm(ch byte) (interface{}, error) {
+	if isDigit(ch) {
+		return readNumber(d, ch)
+	}
+
+	macroRdr, ok := macros[ch]
+	if ok {
+		return macroRdr(d, ch)
+	}
+
+	if ch == '+' || ch == '-' {
+		ch2, err := d.readByte()
+		if err == nil {
+			if err := d.unreadByte(); err != nil {
 				return nil, err
 			}
 
 			if isDigit(ch2) {
-				r.UnreadByte()
-				n, err := readNumber(r, ch)
-				if err != nil {
-					return nil, err
-				}
+				return readNumber(d, ch)
+			}
+		} else if err != io.EOF {
+			return nil, err
+		}
+	}
+
+	buf, err := readToken(d, ch)
+	if err != nil {
+		return nil, err
+	}
 
-				return n, err
+	if val, ok := d.cachedKeyword(buf); ok {
+		return val, nil
+	}
+
+	token := string(buf)
+	val, err := interpretToken(d.normalize(token))
+	if err != nil {
+		return nil, err
+	}
+
+	val = d.remapNamespace(val)
+	if d.keywordLimit > 0 {
+		d.cacheKeyword(token, val)
+	}
+	return val, nil
+}
+
+// collection is a vector, list, map or set being read by readValue.
+//
+// Tagged elements and discarded forms are read as collections without
+// a closing delimiter, which are complete once the tag and the value,
+// or the discarded value, have been read.
+type collection struct {
+	// open is '[', '(' or '{', or '#' for sets, tagged elements and
+	// discarded forms, delim is 0 for the latter two
+	open, delim byte
+	elems       []interface{}
+
+	tagged, discard bool
+	// offset is the offset of the #_ of discarded forms
+	offset int64
+
+	// inTag is set for collections within tagged elements, which
+	// DecodeInto doesn't reuse, as tag readers may keep them
+	inTag bool
+
+	// ns is the namespace of namespaced maps, #:ns{...}
+	ns string
+}
+
+func (c *collection) name() string {
+	switch {
+	case c.tagged && len(c.elems) == 0:
+		return "reader tag"
+	case c.tagged:
+		return "tagged value"
+	case c.discard:
+		return "discarded form"
+	}
+
+	switch c.open {
+	case '[':
+		return "vector"
+	case '(':
+		return "list"
+	case '{':
+		return "map"
+	default:
+		return "set"
+	}
+}
+
+// complete reports whether all elements of a tagged element or
+// discarded form have been read.
+func (c *collection) complete() bool {
+	return (c.tagged && len(c.elems) == 2) || (c.discard && len(c.elems) == 1)
+}
+
+// copied reports whether the value of c is built from a copy of its
+// elements, so that they can be read into a buffer of the decoder.
+// This is the case for maps and sets, and for vectors and lists with
+// an arena.
+func (c *collection) copied(d *Decoder) bool {
+	return c.open == '{' || c.open == '#' || d.arena != nil
+}
+
+// value returns the decoded collection.  With an arena, vectors and
+// lists are copied into it.  With DecodeInto, maps and sets are reused.
+func (c *collection) value(d *Decoder) (interface{}, error) {
+	if c.tagged {
+		return d.applyTag(c.elems[0].(Symbol), c.elems[1])
+	} else if c.discard {
+		if d.discardFunc != nil {
+			d.discardFunc(c.offset, c.elems[0])
+		}
+		return noValue{}, nil
+	}
+
+	if c.copied(d) {
+		defer d.release(c.elems)
+	}
+
+	switch c.open {
+	case '{':
+		if len(c.elems)%2 != 0 {
+			return nil, errorf("map literal must contain an even number of forms")
+		}
+
+		var m map[interface{}]interface{}
+		if d.reuse != nil && !c.inTag {
+			m = d.reuse.makeMap(len(c.elems) / 2)
+		} else {
+			m = make(map[interface{}]interface{}, len(c.elems)/2)
+		}
+		for i := 0; i < len(c.elems); i += 2 {
+			key := c.elems[i]
+			if c.ns != "" {
+				key = applyNamespace(c.ns, key)
 			}
+			m[KeyOf(key)] = c.elems[i+1]
+		}
 
-			r.UnreadByte()
+		return m, nil
+	case '#':
+		var set map[interface{}]bool
+		if d.reuse != nil && !c.inTag {
+			set = d.reuse.makeSet(len(c.elems))
+		} else {
+			set = make(map[interface{}]bool, len(c.elems))
+		}
+		for _, elem := range c.elems {
+			set[KeyOf(elem)] = true
 		}
 
-		token, err := readToken(r, ch)
-		if err != nil {
-			return nil, err
+		return set, nil
+	default:
+		if d.arena != nil {
+			return d.arena.vector(c.elems), nil
+		}
+		if c.elems == nil {
+			return []interface{}{}, nil
 		}
 
-		return interpretToken(token)
+		return c.elems, nil
 	}
 }
 
-var macros = map[byte]func(r io.ByteScanner, ch byte) (interface{}, error){}
-var dispatch = map[byte]func(r io.ByteScanner, ch byte) (interface{}, error){}
-var tagged = map[Symbol]func(tag Symbol, val interface{}) (interface{}, error){}
+// elemBuffer returns a buffer for the elements of a collection, to be
+// returned with release once its value is built.  The buffers of large
+// maps are kept as well, so that reading a stream of them doesn't grow
+// a new one for each.
+func (d *Decoder) elemBuffer() []interface{} {
+	if len(d.buffers) == 0 {
+		return nil
+	}
+
+	buf := d.buffers[len(d.buffers)-1]
+	d.buffers = d.buffers[:len(d.buffers)-1]
+	return buf
+}
+
+func (d *Decoder) release(buf []interface{}) {
+	if cap(buf) == 0 {
+		return
+	}
+
+	clear(buf)
+	d.buffers = append(d.buffers, buf[:0])
+}
+
+var macros = map[byte]func(d *Decoder, ch byte) (interface{}, error){}
+var dispatch = map[byte]func(d *Decoder, ch byte) (interface{}, error){}
 
 func init() {
 	macros['['] = readVector
@@ -144,29 +426,36 @@ func init() {
 	dispatch['<'] = notImplemented
 	dispatch['{'] = readSet
 	dispatch['_'] = readDiscard
+	dispatch['#'] = readSymbolic
+	dispatch[':'] = readNamespacedMap
 
-	tagged[Symbol{Namespace: "", Name: "inst"}] = readTime
-	tagged[Symbol{Namespace: "", Name: "uuid"}] = readUUID
+	RegisterTag(Symbol{Namespace: "", Name: "inst"}, readTime)
+	RegisterTag(Symbol{Namespace: "", Name: "uuid"}, readUUID)
+	RegisterTag(Symbol{Namespace: "", Name: "duration"}, readDuration)
+	RegisterTag(Symbol{Namespace: "java.time", Name: "duration"}, readDuration)
 }
 
-func notImplemented(r io.ByteScanner, ch byte) (interface{}, error) {
-	return nil, fmt.Errorf("macro or dispatch reader for '%c' not implemented", ch)
+func notImplemented(d *Decoder, ch byte) (interface{}, error) {
+	return nil, errorf("macro or dispatch reader for '%c' not implemented", ch)
 }
 
-func readDispatch(r io.ByteScanner, ch byte) (interface{}, error) {
-	ch, err := r.ReadByte()
+func readDispatch(d *Decoder, ch byte) (interface{}, error) {
+	ch, err := d.readByte()
 	if err == io.EOF {
-		return nil, fmt.Errorf("eof while reading dispatch character")
+		return nil, &eofError{"dispatch character"}
 	} else if err != nil {
 		return nil, err
 	}
 
 	dispatchRdr, ok := dispatch[ch]
 	if ok {
-		return dispatchRdr(r, ch)
+		return dispatchRdr(d, ch)
 	} else {
-		r.UnreadByte()
-		return readTagged(r, ch)
+		if err := d.unreadByte(); err != nil {
+			return nil, err
+		}
+
+		return readTagged(d, ch)
 	}
 }
 
@@ -175,32 +464,51 @@ type Tagged struct {
 	Value interface{}
 }
 
-func readTagged(r io.ByteScanner, ch byte) (interface{}, error) {
-	sym, err := ReadValue(r)
-	if err == io.EOF {
-		return nil, fmt.Errorf("eof while reading reader tag")
-	} else if err != nil {
-		return nil, err
+// ApplyTag returns the value the reader produces for the tagged element
+// with tag and the decoded value val, using the registered tag readers.
+// E.g. ApplyTag(Symbol{"", "inst"}, "2020-01-01T00:00:00Z") returns a
+// time.Time.  Without a reader for tag, the result is a Tagged.
+func ApplyTag(tag Symbol, val interface{}) (interface{}, error) {
+	readerFn, ok := tagReader(tag)
+	if !ok {
+		return Tagged{Tag: tag, Value: val}, nil
 	}
 
-	tag, ok := sym.(Symbol)
+	return readerFn(tag, val)
+}
+
+func readTagged(d *Decoder, ch byte) (interface{}, error) {
+	return &collection{open: '#', tagged: true}, nil
+}
+
+// applyTag returns the value of the element tagged with tag, using the
+// registered tag readers.
+func (d *Decoder) applyTag(tag Symbol, obj interface{}) (interface{}, error) {
+	readerFn, ok := tagReader(tag)
+	if d.rawTags {
+		switch tag {
+		case Symbol{Namespace: "", Name: "inst"}:
+			readerFn, ok = readTime, true
+		case Symbol{Namespace: "", Name: "uuid"}:
+			readerFn, ok = readUUID, true
+		default:
+			ok = false
+		}
+	}
 	if !ok {
-		return nil, fmt.Errorf("reader tag must be a symbol")
+		return Tagged{Tag: tag, Value: obj}, nil
 	}
 
-	obj, err := ReadValue(r)
-	if err == io.EOF {
-		return nil, fmt.Errorf("eof while reading tagged value")
-	} else if err != nil {
-		return nil, err
+	if str, ok := obj.(string); ok && d.lenientUUIDs && tag == (Symbol{Namespace: "", Name: "uuid"}) {
+		obj = normalizeUUID(str)
 	}
 
-	readerFn, ok := tagged[tag]
-	if !ok {
-		return Tagged{Tag: tag, Value: obj}, nil
+	val, err := readerFn(tag, obj)
+	if u, ok := val.(UUID); ok && err == nil {
+		return d.convertUUID(u)
 	}
 
-	return readerFn(tag, obj)
+	return val, err
 }
 
 func readTime(tag Symbol, val interface{}) (interface{}, error) {
@@ -221,11 +529,40 @@ type UUID struct {
 	Msb, Lsb uint64
 }
 
-func (u UUID) String() string {
-	buf := make([]byte, 16)
+// Bytes returns the 16 bytes of u, most significant first.
+func (u UUID) Bytes() [16]byte {
+	var buf [16]byte
 	binary.BigEndian.PutUint64(buf[0:8], u.Msb)
 	binary.BigEndian.PutUint64(buf[8:], u.Lsb)
-	return fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:])
+	return buf
+}
+
+// Version returns the version of u, which is only meaningful if u has
+// the RFC 4122 variant.
+func (u UUID) Version() int {
+	return int(u.Msb >> 12 & 0xf)
+}
+
+// IsRFC4122 reports whether u has the variant specified in RFC 4122,
+// which most UUIDs use.
+func (u UUID) IsRFC4122() bool {
+	return u.Lsb>>62 == 0x2
+}
+
+func (u UUID) String() string {
+	buf := u.Bytes()
+
+	var text [36]byte
+	hex.Encode(text[0:8], buf[0:4])
+	text[8] = '-'
+	hex.Encode(text[9:13], buf[4:6])
+	text[13] = '-'
+	hex.Encode(text[14:18], buf[6:8])
+	text[18] = '-'
+	hex.Encode(text[19:23], buf[8:10])
+	text[23] = '-'
+	hex.Encode(text[24:], buf[10:])
+	return string(text[:])
 }
 
 func readUUID(tag Symbol, val interface{}) (interface{}, error) {
@@ -234,13 +571,34 @@ func readUUID(tag Symbol, val interface{}) (interface{}, error) {
 		return nil, fmt.Errorf("uuid value must be a string, but was %#v", val)
 	}
 
+	return parseUUID(str)
+}
+
+// parseUUID parses the canonical form of a UUID, e.g.
+// "f81d4fae-7dec-11d0-a765-00a0c91e6bf6".  Upper case hex digits are
+// accepted as well.
+func parseUUID(str string) (UUID, error) {
 	if len(str) != 36 {
-		return nil, fmt.Errorf("uuid value must be a string of length 36")
+		return UUID{}, errorf("uuid value must be a string of length 36, but was %q", str)
 	}
 
-	buf, err := hex.DecodeString(str[0:8] + str[9:13] + str[14:18] + str[19:23] + str[24:])
-	if err != nil {
-		return nil, err
+	var buf [16]byte
+	n := 0
+	for i := 0; i < len(str); i++ {
+		if i == 8 || i == 13 || i == 18 || i == 23 {
+			if str[i] != '-' {
+				return UUID{}, errorf("uuid value must have a hyphen at position %d, but was %q", i, str)
+			}
+			continue
+		}
+
+		digit, ok := hexDigit(str[i])
+		if !ok {
+			return UUID{}, errorf("uuid value has invalid character '%c' at position %d: %q", str[i], i, str)
+		}
+
+		buf[n/2] = buf[n/2]<<4 | digit
+		n++
 	}
 
 	msb := binary.BigEndian.Uint64(buf[0:8])
@@ -249,76 +607,179 @@ func readUUID(tag Symbol, val interface{}) (interface{}, error) {
 	return UUID{msb, lsb}, nil
 }
 
-func readSet(r io.ByteScanner, ch byte) (interface{}, error) {
-	elems, err := readDelimitedList(r, '}')
+// normalizeUUID converts the UUID forms accepted by SetLenientUUIDs to
+// the canonical one.  Other strings are returned unchanged.
+func normalizeUUID(str string) string {
+	if len(str) > 9 && strings.EqualFold(str[:9], "urn:uuid:") {
+		str = str[9:]
+	} else if len(str) > 2 && str[0] == '{' && str[len(str)-1] == '}' {
+		str = str[1 : len(str)-1]
+	}
+
+	if len(str) == 32 {
+		str = str[0:8] + "-" + str[8:12] + "-" + str[12:16] + "-" + str[16:20] + "-" + str[20:]
+	}
+
+	return str
+}
+
+func hexDigit(ch byte) (byte, bool) {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return ch - '0', true
+	case 'a' <= ch && ch <= 'f':
+		return ch - 'a' + 10, true
+	case 'A' <= ch && ch <= 'F':
+		return ch - 'A' + 10, true
+	default:
+		return 0, false
+	}
+}
+
+func readSet(d *Decoder, ch byte) (interface{}, error) {
+	return &collection{open: '#', delim: '}'}, nil
+}
+
+// noValue is returned by the readers of comments and discarded forms,
+// which don't produce a value.
+type noValue struct{}
+
+func readDiscard(d *Decoder, ch byte) (interface{}, error) {
+	return &collection{open: '#', discard: true, offset: d.offset - 2}, nil
+}
+
+// readSymbolic reads the symbolic values ##Inf, ##-Inf and ##NaN.
+func readSymbolic(d *Decoder, ch byte) (interface{}, error) {
+	ch, err := d.readByte()
 	if err == io.EOF {
-		return nil, fmt.Errorf("eof while reading comment")
+		return nil, &eofError{"symbolic value"}
 	} else if err != nil {
 		return nil, err
 	}
 
-	set := make(map[interface{}]bool, len(elems))
-	for _, elem := range elems {
-		set[elem] = true
+	buf, err := readToken(d, ch)
+	if err != nil {
+		return nil, err
 	}
 
-	return set, nil
+	switch string(buf) {
+	case "Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	default:
+		return nil, errorf("unknown symbolic value: ##%s", string(buf))
+	}
 }
 
-func readDiscard(r io.ByteScanner, ch byte) (interface{}, error) {
-	_, err := ReadValue(r)
-	return r, err
+func readMap(d *Decoder, ch byte) (interface{}, error) {
+	return &collection{open: '{', delim: '}'}, nil
 }
 
-func readMap(r io.ByteScanner, ch byte) (interface{}, error) {
-	elems, err := readDelimitedList(r, '}')
+// readNamespacedMap reads the namespace of a namespaced map, #:ns{...},
+// which may be followed by whitespace before the map.
+func readNamespacedMap(d *Decoder, ch byte) (interface{}, error) {
+	ch, err := d.readByte()
 	if err == io.EOF {
-		return nil, fmt.Errorf("eof while reading comment")
+		return nil, &eofError{"namespaced map"}
 	} else if err != nil {
 		return nil, err
+	} else if ch == ':' {
+		return nil, errorf("auto-resolved namespaced maps are not supported")
 	}
 
-	if len(elems)%2 != 0 {
-		return nil, fmt.Errorf("map literal must contain an even number of forms")
+	buf, err := readToken(d, ch)
+	if err != nil {
+		return nil, err
 	}
 
-	m := make(map[interface{}]interface{}, len(elems)/2)
-	for i := 0; i < len(elems); i += 2 {
-		m[elems[i]] = elems[i+1]
+	ns := d.normalize(string(buf))
+	if sym, ok := matchSymbol(ns).(Symbol); !ok || sym.Namespace != "" || ns == "nil" || ns == "true" || ns == "false" {
+		return nil, errorf("invalid namespace of namespaced map: '%s'", ns)
+	}
+	if to, ok := d.namespaces[ns]; ok {
+		ns = to
 	}
 
-	return m, nil
+	for {
+		ch, err = d.readByte()
+		if err == io.EOF {
+			return nil, &eofError{"namespaced map"}
+		} else if err != nil {
+			return nil, err
+		} else if !isWhitespace(ch) {
+			break
+		}
+	}
+
+	if ch != '{' {
+		return nil, errorf("namespaced map must specify a map")
+	}
+	return &collection{open: '{', delim: '}', ns: ns}, nil
 }
 
-func readComment(r io.ByteScanner, ch byte) (interface{}, error) {
+// applyNamespace returns the key of a namespaced map with namespace ns:
+// keywords and symbols without a namespace get ns, those with the
+// namespace _ get none, other keys are kept.
+func applyNamespace(ns string, key interface{}) interface{} {
+	switch key := key.(type) {
+	case Keyword:
+		if key.Namespace == "" {
+			return Keyword{ns, key.Name}
+		} else if key.Namespace == "_" {
+			return Keyword{"", key.Name}
+		}
+	case Symbol:
+		if key.Namespace == "" {
+			return Symbol{ns, key.Name}
+		} else if key.Namespace == "_" {
+			return Symbol{"", key.Name}
+		}
+	}
+	return key
+}
+
+func readComment(d *Decoder, ch byte) (interface{}, error) {
 	for {
-		ch, err := r.ReadByte()
+		ch, err := d.readByte()
 		if err == io.EOF {
-			return nil, fmt.Errorf("eof while reading comment")
+			// a comment at the end of the input ends there
+			return noValue{}, nil
 		} else if err != nil {
 			return nil, err
 		}
 
 		if ch == '\n' || ch == '\r' {
-			return r, nil
+			return noValue{}, nil
 		}
 	}
 }
 
-func readString(r io.ByteScanner, ch byte) (interface{}, error) {
+func readString(d *Decoder, ch byte) (interface{}, error) {
 	buf := []byte{}
+	if d.internLimit > 0 {
+		buf = d.text[:0]
+		defer func() { d.text = buf[:0] }()
+	} else if d.arena != nil {
+		buf = d.arena.buffer()
+	}
 
-	for ch, err := r.ReadByte(); ch != '"'; ch, err = r.ReadByte() {
+	// high is the first half of a surrogate pair read from an escape
+	var high rune
+	buf = d.appendStringChunk(buf)
+	for ch, err := d.readByte(); ch != '"'; ch, err = d.readByte() {
 		if err == io.EOF {
-			return nil, fmt.Errorf("eof while reading string")
+			return nil, &eofError{"string"}
 		} else if err != nil {
 			return nil, err
 		}
 
 		if ch == '\\' {
-			ch, err = r.ReadByte()
+			ch, err = d.readByte()
 			if err == io.EOF {
-				return nil, fmt.Errorf("eof while reading string")
+				return nil, &eofError{"string"}
 			} else if err != nil {
 				return nil, err
 			}
@@ -332,93 +793,91 @@ func readString(r io.ByteScanner, ch byte) (interface{}, error) {
 				ch = '\n'
 			case '\\':
 			case '"':
-			case '\b':
+			case 'b':
 				ch = '\b'
 			case 'f':
 				ch = '\f'
 			case 'u':
-				ch, err = r.ReadByte()
-				if err == io.EOF {
-					return nil, fmt.Errorf("eof while reading string")
-				} else if err != nil {
+				r, err := readUnicodeEscape(d)
+				if err != nil {
 					return nil, err
 				}
 
-				return nil, fmt.Errorf("unicode escapes not implemented")
+				// characters outside of the basic multilingual plane are
+				// escaped as surrogate pairs, as in Java and JSON
+				if high != 0 && 0xdc00 <= r && r <= 0xdfff {
+					r = utf16.DecodeRune(high, r)
+				} else if high != 0 {
+					buf = utf8.AppendRune(buf, utf8.RuneError)
+				}
+				high = 0
+				if 0xd800 <= r && r <= 0xdbff {
+					high = r
+					continue
+				}
+
+				buf = utf8.AppendRune(buf, r)
+				continue
 			default:
 				if isDigit(ch) {
-					return nil, fmt.Errorf("octal escapes not implemented")
+					return nil, errorf("octal escapes not implemented")
 				} else {
-					return nil, fmt.Errorf("unsupported escape character: '%c'", ch)
+					return nil, errorf("unsupported escape character: '%c'", ch)
 				}
 			}
 		}
 
+		if high != 0 {
+			buf = utf8.AppendRune(buf, utf8.RuneError)
+			high = 0
+		}
 		buf = append(buf, ch)
+		buf = d.appendStringChunk(buf)
 	}
 
-	return string(buf), nil
-}
-
-func readVector(r io.ByteScanner, ch byte) (interface{}, error) {
-	return readDelimitedList(r, ']')
-}
+	if high != 0 {
+		buf = utf8.AppendRune(buf, utf8.RuneError)
+	}
 
-func readList(r io.ByteScanner, ch byte) (interface{}, error) {
-	return readDelimitedList(r, ')')
+	if d.internLimit > 0 {
+		return d.intern(buf), nil
+	} else if d.arena != nil {
+		return d.normalize(d.arena.string(buf)), nil
+	}
+	return d.normalize(string(buf)), nil
 }
 
-func readDelimitedList(r io.ByteScanner, delim byte) ([]interface{}, error) {
-	vec := []interface{}{}
-
-	for {
-		ch, err := r.ReadByte()
+// readUnicodeEscape reads the four hex digits of a \u escape.
+func readUnicodeEscape(d *Decoder) (rune, error) {
+	var r rune
+	for i := 0; i < 4; i++ {
+		ch, err := d.readByte()
 		if err == io.EOF {
-			return nil, fmt.Errorf("eof while reading vector")
+			return 0, &eofError{"string"}
 		} else if err != nil {
-			return nil, fmt.Errorf("readVector: %v", err)
-		}
-
-		for isWhitespace(ch) {
-			ch, err = r.ReadByte()
-			if err != nil {
-				return nil, fmt.Errorf("readVector: whitespace: %v", err)
-			}
+			return 0, err
 		}
 
-		if ch == delim {
-			break
+		n, ok := hexDigit(ch)
+		if !ok {
+			return 0, errorf("invalid character '%c' in unicode escape", ch)
 		}
+		r = r<<4 | rune(n)
+	}
 
-		macroRdr, ok := macros[ch]
-		if ok {
-			val, err := macroRdr(r, ch)
-			if err != nil {
-				return nil, err
-			}
-
-			if val != r {
-				vec = append(vec, val)
-			}
-		} else {
-			r.UnreadByte()
-
-			val, err := ReadValue(r)
-			if err != nil {
-				return nil, err
-			}
+	return r, nil
+}
 
-			if val != r {
-				vec = append(vec, val)
-			}
-		}
-	}
+func readVector(d *Decoder, ch byte) (interface{}, error) {
+	return &collection{open: '[', delim: ']'}, nil
+}
 
-	return vec, nil
+func readList(d *Decoder, ch byte) (interface{}, error) {
+	return &collection{open: '(', delim: ')'}, nil
 }
 
-func unmatchedDelimiter(r io.ByteScanner, ch byte) (interface{}, error) {
-	return nil, fmt.Errorf("unmatched delimiter: '%c'", ch)
+func unmatchedDelimiter(d *Decoder, ch byte) (interface{}, error) {
+	return nil, errorf("unmatched delimiter: '%c'", ch)
 }
 
 func interpretToken(token string) (interface{}, error) {
@@ -436,7 +895,7 @@ func interpretToken(token string) (interface{}, error) {
 		return val, nil
 	}
 
-	return nil, fmt.Errorf("invalid token: '%s'", token)
+	return nil, errorf("invalid token: '%s'", token)
 }
 
 var symbolPattern = regexp.MustCompile("[:]?([^/].*/)?(/|[^/]*)")
@@ -454,6 +913,24 @@ func (kw Keyword) String() string {
 	}
 }
 
+// FullName returns the keyword without the leading colon, i.e. "ns/name"
+// or "name" if it has no namespace.
+func (kw Keyword) FullName() string {
+	if kw.Namespace == "" {
+		return kw.Name
+	} else {
+		return kw.Namespace + "/" + kw.Name
+	}
+}
+
+// Equal reports whether kw and other have the same namespace and name.
+//
+// Keywords are comparable, so this is the same as kw == other, but it
+// can be used where a method is expected.
+func (kw Keyword) Equal(other Keyword) bool {
+	return kw == other
+}
+
 type Symbol struct {
 	Namespace string
 	Name      string
@@ -467,6 +944,11 @@ func (sym Symbol) String() string {
 	}
 }
 
+// Equal reports whether sym and other have the same namespace and name.
+func (sym Symbol) Equal(other Symbol) bool {
+	return sym == other
+}
+
 func matchSymbol(s string) interface{} {
 	m := symbolPattern.FindStringSubmatch(s)
 	if m != nil {
@@ -480,6 +962,9 @@ func matchSymbol(s string) interface{} {
 		if strings.HasPrefix(s, "::") {
 			return nil
 		}
+		if name == "" {
+			return nil
+		}
 
 		if len(ns) != 0 {
 			ns = ns[:len(ns)-1]
@@ -494,28 +979,33 @@ func matchSymbol(s string) interface{} {
 	}
 }
 
-func readToken(r io.ByteScanner, ch byte) (string, error) {
-	buf := []byte{ch}
+// readToken reads the token starting with ch.  The result is only valid
+// until the next token is read, it is the decoder's buffer.
+func readToken(d *Decoder, ch byte) ([]byte, error) {
+	buf := append(d.token[:0], ch)
+	defer func() { d.token = buf }()
 	// FIXME: if leadContituent && nonConstituent(ch) { ... }
 
 	for {
-		ch, err := r.ReadByte()
+		ch, err := d.readByte()
 		if err == io.EOF {
-			return string(buf), nil
-		} else if isWhitespace(ch) || isTerminatingMacro(ch) {
-			r.UnreadByte()
-			return string(buf), nil
+			return buf, nil
 		} else if err != nil {
-			return "", err
+			return nil, err
+		} else if isWhitespace(ch) || isTerminatingMacro(ch) {
+			if err := d.unreadByte(); err != nil {
+				return nil, err
+			}
+
+			return buf, nil
 		}
 
 		if nonConstituent(ch) {
-			return "", fmt.Errorf("invalid constituent character: '%c'", ch)
+			return nil, errorf("invalid constituent character: '%c'", ch)
 		}
 
 		buf = append(buf, ch)
 	}
-	return "", nil
 }
 
 func nonConstituent(ch byte) bool {
@@ -526,20 +1016,28 @@ func isTerminatingMacro(ch byte) bool {
 	return ch != '#' && ch != '\'' && isMacro(ch)
 }
 
-func readNumber(r io.ByteScanner, ch byte) (interface{}, error) {
+func readNumber(d *Decoder, ch byte) (interface{}, error) {
 	buf := []byte{ch}
 
 	for {
-		ch, err := r.ReadByte()
+		ch, err := d.readByte()
 
 		if err == io.EOF {
 			break
+		} else if err != nil {
+			return nil, err
 		} else if isWhitespace(ch) || isMacro(ch) {
-			r.UnreadByte()
+			if err := d.unreadByte(); err != nil {
+				return nil, err
+			}
+
 			break
 		}
 
 		buf = append(buf, ch)
+		if d.maxNumberLength > 0 && len(buf) > d.maxNumberLength {
+			return nil, errorf("number literal longer than %d bytes", d.maxNumberLength)
+		}
 	}
 
 	return matchNumber(string(buf))
@@ -557,7 +1055,7 @@ func matchNumber(s string) (interface{}, error) {
 	if match != nil {
 		if match[7] != "" {
 			if match[8] == "" {
-				return 0, nil
+				return boxInt(0), nil
 			} else {
 				return &big.Int{}, nil
 			}
@@ -584,7 +1082,7 @@ func matchNumber(s string) (interface{}, error) {
 			}
 		}
 		if n == "" {
-			return nil, fmt.Errorf("invalid number")
+			return nil, errorf("invalid number")
 		}
 
 		if match[8] == "" {
@@ -594,29 +1092,23 @@ func matchNumber(s string) (interface{}, error) {
 			}
 
 			if negate {
-				return -i, nil
+				return boxInt(-i), nil
 			} else {
-				return i, nil
+				return boxInt(i), nil
 			}
 		} else {
-			var base string
 			switch radix {
-			case 16:
-				base = "0x"
-			case 10:
-				base = ""
-			case 8:
-				base = "0"
-			case 2:
-				base = "0b"
+			case 2, 8, 10, 16:
 			default:
-				return nil, fmt.Errorf("big integer can only have base 2, 8, 10 or 16")
+				return nil, errorf("big integer can only have base 2, 8, 10 or 16")
 			}
 
-			i := new(big.Int)
-			_, err := fmt.Sscan(base+n, i)
-			if err != nil {
-				return nil, err
+			i, ok := new(big.Int).SetString(n, radix)
+			if !ok {
+				return nil, errorf("invalid number")
+			}
+			if negate {
+				i.Neg(i)
 			}
 
 			return i, nil
@@ -626,7 +1118,7 @@ func matchNumber(s string) (interface{}, error) {
 	match = floatPattern.FindStringSubmatch(s)
 	if match != nil {
 		if match[4] != "" {
-			return nil, fmt.Errorf("arbitrary precision floats not implemented")
+			return nil, errorf("arbitrary precision floats not implemented")
 		}
 
 		d, err := strconv.ParseFloat(s, 64)
@@ -639,20 +1131,19 @@ func matchNumber(s string) (interface{}, error) {
 
 	match = ratioPattern.FindStringSubmatch(s)
 	if match != nil {
-		r := new(big.Rat)
-		_, err := fmt.Sscan(s, r)
-		if err != nil {
-			return nil, err
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return nil, errorf("invalid ratio: %s", s)
 		}
 
 		return r, nil
 	}
 
-	return nil, fmt.Errorf("invalid number")
+	return nil, errorf("invalid number")
 }
 
 func isWhitespace(ch byte) bool {
-	return ch == ' ' || ch == '\t' || ch == '\n' || ch == ','
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == ','
 }
 
 func isMacro(ch byte) bool {
@@ -3,7 +3,11 @@ package edn
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"math"
+	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -50,3 +54,344 @@ func readAndPrint(s string) {
 
 	fmt.Printf("%#-50v %-35v %v\n", s, reflect.TypeOf(val), val)
 }
+
+func TestKeywordSymbol(t *testing.T) {
+	kw := Keyword{"user", "name"}
+	if kw.FullName() != "user/name" || kw.String() != ":user/name" {
+		t.Errorf("unexpected names %q and %q", kw.FullName(), kw.String())
+	}
+	if (Keyword{"", "name"}).FullName() != "name" {
+		t.Errorf("unexpected name %q", Keyword{"", "name"}.FullName())
+	}
+
+	val, _ := DecodeString(":user/name")
+	if !kw.Equal(val.(Keyword)) || kw.Equal(Keyword{"", "name"}) {
+		t.Errorf("Keyword.Equal is broken")
+	}
+
+	val, _ = DecodeString("user/name")
+	if !(Symbol{"user", "name"}).Equal(val.(Symbol)) || (Symbol{"user", "name"}).Equal(Symbol{"", "name"}) {
+		t.Errorf("Symbol.Equal is broken")
+	}
+
+	m := map[interface{}]int{Keyword{"a", "b"}: 1}
+	if m[val] != 0 || m[Keyword{"a", "b"}] != 1 {
+		t.Errorf("keywords are not usable as map keys")
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected interface{}
+	}{
+		{"#_ #_ a b c", Symbol{"", "c"}},
+		{"#_ [1 2] 3", int64(3)},
+		{"[1 #_ 2 3]", []interface{}{int64(1), int64(3)}},
+		{"[#_ 1]", []interface{}{}},
+		{"(#_ #_ 1 2 3)", []interface{}{int64(3)}},
+		{"#{#_ 1 2}", map[interface{}]bool{int64(2): true}},
+		{"{:a #_ :b 1}", map[interface{}]interface{}{Keyword{"", "a"}: int64(1)}},
+		{"{:a #_ #_ :b :c 1}", map[interface{}]interface{}{Keyword{"", "a"}: int64(1)}},
+		{"{#_ :a :b 1}", map[interface{}]interface{}{Keyword{"", "b"}: int64(1)}},
+		{"#foo #_ 1 2", Tagged{Symbol{"", "foo"}, int64(2)}},
+		{"#_ #foo 1 2", int64(2)},
+		{"#_ ; comment\n 1 2", int64(2)},
+	}
+
+	for _, test := range tests {
+		val, err := DecodeString(test.s)
+		if err != nil {
+			t.Errorf("%q: %v", test.s, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%q: expected %#v, got %#v", test.s, test.expected, val)
+		}
+	}
+
+	for _, s := range []string{"#_", "[1 #_]", "{:a #_ :b}", "#foo #_ 1"} {
+		if val, err := DecodeString(s); err == nil {
+			t.Errorf("%q: expected error, got %#v", s, val)
+		}
+	}
+
+	if _, err := DecodeString("#_ 1"); err != io.EOF {
+		t.Errorf("expected io.EOF for discarded value only, got %v", err)
+	}
+}
+
+func TestSkippedFormsProduceNoValues(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected interface{}
+	}{
+		{"[1 ;comment\n]", []interface{}{int64(1)}},
+		{"[;comment\n]", []interface{}{}},
+		{"[1 #_ 2]", []interface{}{int64(1)}},
+		{"{:a 1 ;comment\n}", map[interface{}]interface{}{Keyword{"", "a"}: int64(1)}},
+		{"#{;comment\n #_ 1}", map[interface{}]bool{}},
+		{"[#foo ;comment\n 1]", []interface{}{Tagged{Symbol{"", "foo"}, int64(1)}}},
+		{";comment\n#_ 1\n[2]", []interface{}{int64(2)}},
+	}
+
+	for _, test := range tests {
+		val, err := DecodeString(test.s)
+		if err != nil {
+			t.Errorf("%q: %v", test.s, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%q: expected %#v, got %#v", test.s, test.expected, val)
+		}
+	}
+}
+
+func TestTrailingComments(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected []interface{}
+	}{
+		{"[1 2] ; trailing", []interface{}{[]interface{}{int64(1), int64(2)}}},
+		{"[1 2] ; trailing\n", []interface{}{[]interface{}{int64(1), int64(2)}}},
+		{"1 ;", []interface{}{int64(1)}},
+		{"; only a comment", []interface{}{}},
+		{"; one\n; two\n\n", []interface{}{}},
+		{"", []interface{}{}},
+		{"  \n\t", []interface{}{}},
+		{":a\r\n:b\r\n", []interface{}{Keyword{"", "a"}, Keyword{"", "b"}}},
+	}
+
+	for _, test := range tests {
+		vals, err := ReadAllValues(bytes.NewReader([]byte(test.s)))
+		if err != nil {
+			t.Errorf("%q: %v", test.s, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(vals, test.expected) {
+			t.Errorf("%q: expected %#v, got %#v", test.s, test.expected, vals)
+		}
+	}
+
+	if _, err := DecodeString("[1 2 ; unterminated"); err == nil {
+		t.Error("expected error for unterminated vector")
+	}
+}
+
+func TestDecodeStringPrefix(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected interface{}
+		rest     string
+	}{
+		{"{:title \"Hello\"}\n---\nbody", map[interface{}]interface{}{Keyword{"", "title"}: "Hello"}, "\n---\nbody"},
+		{"42]rest", int64(42), "]rest"},
+		{":kw(rest)", Keyword{"", "kw"}, "(rest)"},
+		{"  ; comment\n[1] tail", []interface{}{int64(1)}, " tail"},
+		{"#_ skipped sym", Symbol{"", "sym"}, ""},
+		{"\"str\"more", "str", "more"},
+	}
+
+	for _, test := range tests {
+		val, rest, err := DecodeStringPrefix(test.s)
+		if err != nil {
+			t.Errorf("%q: %v", test.s, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(val, test.expected) || rest != test.rest {
+			t.Errorf("%q: expected %#v, %q, got %#v, %q", test.s, test.expected, test.rest, val, rest)
+		}
+	}
+
+	if _, rest, err := DecodeStringPrefix("  "); err != io.EOF || rest != "  " {
+		t.Errorf("expected io.EOF for empty input, got %v, %q", err, rest)
+	}
+	if _, _, err := DecodeStringPrefix("[1 2"); err == nil {
+		t.Error("expected error for unterminated vector")
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	tests := map[string]string{
+		`"a\tb\rc\nd"`:  "a\tb\rc\nd",
+		`"\\ \""`:       "\\ \"",
+		`"back\bspace"`: "back\bspace",
+		`"form\ffeed"`:  "form\ffeed",
+	}
+
+	for s, expected := range tests {
+		val, err := DecodeString(s)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+		} else if val != expected {
+			t.Errorf("%s should be read as %q, but was %q", s, expected, val)
+		}
+	}
+
+	if _, err := DecodeString("\"\\\b\""); err == nil {
+		t.Errorf("expected error for a backslash followed by a backspace")
+	}
+}
+
+func TestUnicodeEscapes(t *testing.T) {
+	tests := map[string]string{
+		`"\u0041\u00fc"`:         "Aü",
+		`"\u20AC"`:               "€",
+		`"\ud83d\ude00!"`:        "😀!",
+		`"lone \ud83d"`:          "lone \ufffd",
+		`"lone \ud83d and more"`: "lone \ufffd and more",
+		`"lone \ude00"`:          "lone \ufffd",
+		`"\ud83d\u0041"`:         "\ufffdA",
+	}
+
+	for s, expected := range tests {
+		val, err := DecodeString(s)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+			continue
+		}
+		if val != expected {
+			t.Errorf("%s should be read as %q, but was %q", s, expected, val)
+		}
+	}
+
+	for _, invalid := range []string{`"\u12"`, `"\u12g4"`, `"\u`} {
+		if _, err := DecodeString(invalid); err == nil {
+			t.Errorf("expected error for %s", invalid)
+		}
+	}
+}
+
+func TestUUID(t *testing.T) {
+	u := UUID{0xf81d4fae7dec11d0, 0xa76500a0c91e6bf6}
+	for _, s := range []string{"f81d4fae-7dec-11d0-a765-00a0c91e6bf6", "F81D4FAE-7DEC-11D0-A765-00A0C91E6BF6"} {
+		val, err := DecodeString(`#uuid "` + s + `"`)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+		} else if val != u {
+			t.Errorf("%s: expected %s, got %#v", s, u, val)
+		}
+	}
+
+	if u.Version() != 1 || !u.IsRFC4122() {
+		t.Errorf("expected RFC 4122 version 1 uuid, got version %d", u.Version())
+	}
+
+	invalid := map[string]string{
+		"f81d4fae7-dec-11d0-a765-00a0c91e6bf6":    "hyphen at position 8",
+		"f81d4fae-7dec-11d0-a76500a0c91e6bf6-":    "hyphen at position 23",
+		"f81d4fae-7dec-11d0-a765-00a0c91e6bfg":    "invalid character 'g' at position 35",
+		"f81d4fae-7dec-11d0-a765-00a0c91e6bf":     "length 36",
+		"f81d4fae-7dec-11d0-a765-00a0c91e6bf6-00": "length 36",
+	}
+
+	for s, msg := range invalid {
+		_, err := DecodeString(`#uuid "` + s + `"`)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%s: expected error containing %q, got %v", s, msg, err)
+		}
+	}
+}
+
+func TestReadAllValuesSeparators(t *testing.T) {
+	expected := []interface{}{
+		map[interface{}]interface{}{Keyword{"", "a"}: int64(1)},
+		[]interface{}{int64(2)},
+		Symbol{"", "three"},
+		"four",
+	}
+
+	for _, sep := range []string{"\n", " ", "\n\n", "\r\n", ",", "\t", ""} {
+		input := `{:a 1}` + sep + `[2]` + sep + "three" + sep + `"four"` + sep
+
+		vals, err := ReadAllValues(strings.NewReader(input))
+		if err != nil {
+			t.Errorf("%q: %v", sep, err)
+		} else if !reflect.DeepEqual(vals, expected) {
+			t.Errorf("%q: expected %#v, got %#v", sep, expected, vals)
+		}
+	}
+}
+
+func TestZero(t *testing.T) {
+	for _, s := range []string{"0", "-0", "+0"} {
+		if val := mustDecode(t, s); val != int64(0) {
+			t.Errorf("%s: expected int64(0), got %#v", s, val)
+		}
+	}
+
+	if val, ok := mustDecode(t, "0N").(*big.Int); !ok || val.Sign() != 0 {
+		t.Errorf("expected 0N to be a big integer, got %#v", val)
+	}
+}
+
+func TestApplyTag(t *testing.T) {
+	val, err := ApplyTag(Symbol{"", "uuid"}, "f81d4fae-7dec-11d0-a765-00a0c91e6bf6")
+	if err != nil || val != (UUID{0xf81d4fae7dec11d0, 0xa76500a0c91e6bf6}) {
+		t.Errorf("unexpected value %#v, %v", val, err)
+	}
+
+	val, err = ApplyTag(Symbol{"my", "tag"}, int64(1))
+	if err != nil || val != (Tagged{Symbol{"my", "tag"}, int64(1)}) {
+		t.Errorf("unexpected value %#v, %v", val, err)
+	}
+
+	if _, err := ApplyTag(Symbol{"", "inst"}, int64(1)); err == nil {
+		t.Error("expected error for invalid inst")
+	}
+}
+
+func TestSymbolicValues(t *testing.T) {
+	for _, f := range []float64{math.Inf(1), math.Inf(-1), math.NaN()} {
+		val, err := DecodeString(String(f))
+		if err != nil {
+			t.Errorf("%s: %v", String(f), err)
+			continue
+		}
+
+		if g, ok := val.(float64); !ok || (g != f && !(math.IsNaN(f) && math.IsNaN(g))) {
+			t.Errorf("%s: expected %v, got %#v", String(f), f, val)
+		}
+	}
+
+	for _, s := range []string{"##", "##inf", "##Infinity", "[##NaN"} {
+		if val, err := DecodeString(s); err == nil {
+			t.Errorf("%q: expected error, got %#v", s, val)
+		}
+	}
+}
+
+func TestNamespacedMap(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected interface{}
+	}{
+		{`#:user{:id 1 :_/kind 2 :other/x 3 name 4 "s" 5}`, map[interface{}]interface{}{
+			Keyword{"user", "id"}: int64(1), Keyword{"", "kind"}: int64(2), Keyword{"other", "x"}: int64(3),
+			Symbol{"user", "name"}: int64(4), "s": int64(5),
+		}},
+		{"#:a.b {:c #:d{:e 1}}", map[interface{}]interface{}{
+			Keyword{"a.b", "c"}: map[interface{}]interface{}{Keyword{"d", "e"}: int64(1)},
+		}},
+		{"[#:a{}]", []interface{}{map[interface{}]interface{}{}}},
+	}
+
+	for _, test := range tests {
+		val, err := DecodeString(test.s)
+		if err != nil {
+			t.Errorf("%q: %v", test.s, err)
+		} else if !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("%q: expected %#v, got %#v", test.s, test.expected, val)
+		}
+	}
+
+	for _, s := range []string{"#::{:a 1}", "#:a/b{:c 1}", "#:a [1]", "#:nil{}", "#:a", "#:a{:b}"} {
+		if val, err := DecodeString(s); err == nil {
+			t.Errorf("%q: expected error, got %#v", s, val)
+		}
+	}
+}
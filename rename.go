@@ -0,0 +1,62 @@
+package edn
+
+import (
+	"fmt"
+)
+
+// RenameKeyword returns doc with all occurrences of the keyword from
+// replaced by to, and the number of replacements.  Only the keywords
+// themselves are replaced, so the formatting and comments of doc are
+// kept, e.g. for renaming attributes in schema migrations.  Strings,
+// comments and symbols with the same name are not changed, keywords
+// in discarded forms are.
+//
+// An error is returned if doc does not consist of well-formed forms,
+// see SplitTopLevel, or if to is not a valid keyword.
+func RenameKeyword(doc []byte, from, to Keyword) ([]byte, int, error) {
+	if !IsValidKeyword(to.String()) {
+		return nil, 0, fmt.Errorf("invalid keyword %s", to)
+	}
+
+	if _, err := SplitTopLevel(doc); err != nil {
+		return nil, 0, err
+	}
+
+	old := from.String()
+
+	var out []byte
+	n, last := 0, 0
+	for pos := 0; pos < len(doc); {
+		switch ch := doc[pos]; {
+		case ch == '"':
+			pos, _ = scanString(doc, pos+1)
+		case ch == ';':
+			for pos < len(doc) && doc[pos] != '\n' && doc[pos] != '\r' {
+				pos++
+			}
+		case ch == '#' && pos+1 < len(doc) && doc[pos+1] == '_':
+			// the discarded form may follow without a space, as in #_:old
+			pos += 2
+		case ch == '\\':
+			// character literals such as \: or \newline
+			pos = scanToken(doc, min(pos+2, len(doc)))
+		case isWhitespace(ch) || isMacro(ch):
+			pos++
+		default:
+			end := scanToken(doc, pos)
+			if string(doc[pos:end]) == old {
+				out = append(out, doc[last:pos]...)
+				out = AppendKeyword(out, to)
+				last = end
+				n++
+			}
+			pos = end
+		}
+	}
+
+	if n == 0 {
+		return doc, 0, nil
+	}
+
+	return append(out, doc[last:]...), n, nil
+}
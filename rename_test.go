@@ -0,0 +1,46 @@
+package edn
+
+import (
+	"testing"
+)
+
+func TestRenameKeyword(t *testing.T) {
+	doc := `;; :user/name is renamed
+{:user/name "Jane" ; the :user/name
+ :user/names [:user/name user/name ":user/name"]
+ :other #_ :user/name :user/name-2
+ \: :user/name}`
+
+	expected := `;; :user/name is renamed
+{:person/full-name "Jane" ; the :user/name
+ :user/names [:person/full-name user/name ":user/name"]
+ :other #_ :person/full-name :user/name-2
+ \: :person/full-name}`
+
+	out, n, err := RenameKeyword([]byte(doc), Keyword{"user", "name"}, Keyword{"person", "full-name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(out) != expected || n != 4 {
+		t.Errorf("expected\n%s\n(4 replacements), got\n%s\n(%d replacements)", expected, out, n)
+	}
+
+	out, n, err = RenameKeyword([]byte(doc), Keyword{"", "missing"}, Keyword{"", "b"})
+	if err != nil || n != 0 || string(out) != doc {
+		t.Errorf("expected doc to be unchanged, got %s, %d, %v", out, n, err)
+	}
+
+	out, n, err = RenameKeyword([]byte(`{:old 1 #_:old 2 #_#_:old 3 [#_:old]}`), Keyword{"", "old"}, Keyword{"", "new"})
+	if expected := `{:new 1 #_:new 2 #_#_:new 3 [#_:new]}`; err != nil || n != 4 || string(out) != expected {
+		t.Errorf("expected %s (4 replacements), got %s (%d replacements), %v", expected, out, n, err)
+	}
+
+	if _, _, err := RenameKeyword([]byte("[:a"), Keyword{"", "a"}, Keyword{"", "b"}); err == nil {
+		t.Error("expected error for malformed input")
+	}
+
+	if _, _, err := RenameKeyword([]byte(":a"), Keyword{"", "a"}, Keyword{"", "b c"}); err == nil {
+		t.Error("expected error for an invalid keyword")
+	}
+}
@@ -0,0 +1,108 @@
+package edn
+
+import (
+	"io"
+)
+
+// DecodeInto reads the next value from r into *v, see Decoder.DecodeInto.
+func DecodeInto(r io.ByteScanner, v *interface{}) error {
+	return NewDecoder(r).DecodeInto(v)
+}
+
+// DecodeInto reads the next value into *v, reusing the maps, sets and
+// vectors of the value *v holds for those of the new one, e.g. for
+// consumers that decode many messages of a similar shape:
+//
+//	var msg interface{}
+//	for {
+//		if err := d.DecodeInto(&msg); err != nil {
+//			...
+//		}
+//		handle(msg)
+//	}
+//
+// The collections of the old value are cleared before decoding, so
+// neither they nor values within them may be used elsewhere.  Maps
+// and sets are reused in the order they are read, vectors and lists
+// in the order they start, so the more similar the values are, the
+// fewer collections are allocated.  Collections within tagged values
+// are not reused, so tag readers can keep the values passed to them,
+// unless they return them as part of their results, which are cleared
+// like the rest of the value.
+//
+// If decoding fails, *v is set to nil.
+func (d *Decoder) DecodeInto(v *interface{}) error {
+	r := &reusable{}
+	r.collect(*v)
+	*v = nil
+
+	d.reuse = r
+	defer func() { d.reuse = nil }()
+
+	val, err := d.Decode()
+	if err != nil {
+		return err
+	}
+
+	*v = val
+	return nil
+}
+
+// reusable are the cleared collections of a value for DecodeInto.
+type reusable struct {
+	maps   []map[interface{}]interface{}
+	sets   []map[interface{}]bool
+	slices [][]interface{}
+}
+
+// collect clears and collects the collections in v, the vectors before
+// their elements and the maps and sets after them.
+func (r *reusable) collect(v interface{}) {
+	switch v := v.(type) {
+	case []interface{}:
+		r.slices = append(r.slices, v)
+		for _, elem := range v {
+			r.collect(elem)
+		}
+		clear(v)
+	case map[interface{}]interface{}:
+		for _, val := range v {
+			r.collect(val)
+		}
+		clear(v)
+		r.maps = append(r.maps, v)
+	case map[interface{}]bool:
+		clear(v)
+		r.sets = append(r.sets, v)
+	}
+}
+
+func (r *reusable) slice() []interface{} {
+	if len(r.slices) == 0 {
+		return nil
+	}
+
+	s := r.slices[0]
+	r.slices = r.slices[1:]
+	return s[:0]
+}
+
+func (r *reusable) makeMap(n int) map[interface{}]interface{} {
+	if len(r.maps) == 0 {
+		return make(map[interface{}]interface{}, n)
+	}
+
+	m := r.maps[0]
+	r.maps = r.maps[1:]
+	return m
+}
+
+func (r *reusable) makeSet(n int) map[interface{}]bool {
+	if len(r.sets) == 0 {
+		return make(map[interface{}]bool, n)
+	}
+
+	s := r.sets[0]
+	r.sets = r.sets[1:]
+	return s
+}
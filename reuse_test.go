@@ -0,0 +1,115 @@
+package edn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeInto(t *testing.T) {
+	inputs := []string{
+		`{:id 1 :tags ["a" "b"] :seen #{1 2}}`,
+		`{:id 2 :tags ["c"] :seen #{3}}`,
+		`[1 2 3]`,
+		`{:id 3 :tags ["d" "e" "f" "g"] :seen #{} :extra {:nested true}}`,
+		`"just a string"`,
+		`{:id 4}`,
+	}
+
+	var v interface{}
+	for _, input := range inputs {
+		if err := DecodeInto(strings.NewReader(input), &v); err != nil {
+			t.Fatalf("decoding %s: %s", input, err)
+		}
+
+		if expected := mustDecode(t, input); !Equal(v, expected) {
+			t.Errorf("expected %s, got %s", String(expected), String(v))
+		}
+	}
+}
+
+func TestDecodeIntoReuses(t *testing.T) {
+	var v interface{}
+	if err := DecodeInto(strings.NewReader(`{:tags ["a" "b"]}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	m := v.(map[interface{}]interface{})
+	tags := m[Keyword{Name: "tags"}].([]interface{})
+
+	if err := DecodeInto(strings.NewReader(`{:tags ["c" "d"] :id 1}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	m2 := v.(map[interface{}]interface{})
+	tags2 := m2[Keyword{Name: "tags"}].([]interface{})
+
+	m2[Keyword{Name: "marker"}] = true
+	if _, ok := m[Keyword{Name: "marker"}]; !ok {
+		t.Errorf("expected map to be reused")
+	}
+	if &tags[0] != &tags2[0] {
+		t.Errorf("expected vector to be reused")
+	}
+}
+
+func TestDecodeIntoTagged(t *testing.T) {
+	tag := Symbol{"test", "keep"}
+	var kept []interface{}
+	RegisterTag(tag, func(tag Symbol, val interface{}) (interface{}, error) {
+		kept = append(kept, val)
+		return int64(len(kept)), nil
+	})
+	defer RegisterTag(tag, nil)
+
+	var v interface{}
+	if err := DecodeInto(strings.NewReader(`[{:c 3} [1 2] [3 4]]`), &v); err != nil {
+		t.Fatal(err)
+	}
+	old := v.([]interface{})
+	m, vec := old[0].(map[interface{}]interface{}), old[2].([]interface{})
+
+	if err := DecodeInto(strings.NewReader(`[#test/keep {:x [4 5]} #test/keep [6 7]]`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if String(v) != "[1 2]" || String(kept) != "[{:x [4 5]} [6 7]]" {
+		t.Fatalf("unexpected values %s, %s", String(v), String(kept))
+	}
+
+	// the old collections are only reused outside of the tagged values
+	if sameMap(m, kept[0].(map[interface{}]interface{})) {
+		t.Errorf("expected the map passed to the tag reader not to be reused")
+	}
+	if &vec[0] == &kept[1].([]interface{})[0] {
+		t.Errorf("expected the vector passed to the tag reader not to be reused")
+	}
+}
+
+func TestDecodeIntoError(t *testing.T) {
+	v := interface{}(map[interface{}]interface{}{"a": 1})
+	if err := DecodeInto(strings.NewReader(`{:a`), &v); err == nil {
+		t.Fatal("expected an error")
+	}
+	if v != nil {
+		t.Errorf("expected nil after error, got %s", String(v))
+	}
+}
+
+func TestDecodeIntoAllocations(t *testing.T) {
+	input := `{:id 1 :tags ["a" "b" "c"] :items [{:n 1} {:n 2} {:n 3}]}`
+
+	r := strings.NewReader(input)
+	var v interface{}
+	decode := func() {
+		r.Reset(input)
+		if err := NewDecoder(r).DecodeInto(&v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reused := testing.AllocsPerRun(100, decode)
+	fresh := testing.AllocsPerRun(100, func() {
+		v = nil
+		decode()
+	})
+	if reused >= fresh {
+		t.Errorf("expected fewer allocations when reusing, got %v and %v without", reused, fresh)
+	}
+}
@@ -0,0 +1,78 @@
+package edn
+
+import (
+	"fmt"
+)
+
+// RoundTrip writes v with Marshal, reads the output back and reports
+// an error if v can't be written, or if the result is not Equal to v.
+// The latter error names the path of the first value that differs, see
+// KeywordInfo.Paths for the format.
+//
+// It is meant as a check in tests that values survive being written and
+// read, e.g. that all types written by tag writers have tag readers
+// registered.
+func RoundTrip(v interface{}) error {
+	data, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	read, err := DecodeBytes(data)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", data, err)
+	}
+
+	if Equal(v, read) {
+		return nil
+	}
+
+	path, expected, got := firstDifference(v, read, nil)
+	return fmt.Errorf("value at path %s is read back as %s instead of %s", String(path), String(got), String(expected))
+}
+
+// firstDifference returns the path to the first values of a and b that
+// are not Equal, descending into vectors and maps that differ.
+func firstDifference(a, b interface{}, path []interface{}) ([]interface{}, interface{}, interface{}) {
+	if path == nil {
+		path = []interface{}{}
+	}
+
+	switch a := a.(type) {
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			break
+		}
+
+		for i := range a {
+			if !Equal(a[i], b[i]) {
+				return firstDifference(a[i], b[i], append(path, i))
+			}
+		}
+	case map[interface{}]interface{}:
+		b, ok := b.(map[interface{}]interface{})
+		if !ok || len(a) != len(b) {
+			break
+		}
+
+		keys := make([]interface{}, 0, len(a))
+		for key := range a {
+			keys = append(keys, key)
+		}
+		sortByString(keys)
+
+		for _, key := range keys {
+			other, ok := MapGet(b, key)
+			if !ok {
+				break
+			}
+
+			if !Equal(a[key], other) {
+				return firstDifference(a[key], other, append(path, key))
+			}
+		}
+	}
+
+	return path, a, b
+}
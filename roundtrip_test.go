@@ -0,0 +1,52 @@
+package edn
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	valid := []interface{}{
+		nil,
+		[]interface{}{int64(1), 2.5, "three\n", Keyword{"", "four"}, Symbol{"ns", "five"}},
+		map[interface{}]interface{}{KeyOf([]interface{}{int64(1)}): big.NewInt(2), Keyword{"", "r"}: big.NewRat(1, 3)},
+		map[interface{}]bool{UUID{1, 2}: true, time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC): true},
+		Tagged{Symbol{"my", "tag"}, []interface{}{}},
+	}
+
+	for _, v := range valid {
+		if err := RoundTrip(v); err != nil {
+			t.Errorf("%s: %v", String(v), err)
+		}
+	}
+
+	invalid := map[string]interface{}{
+		`cannot encode value of type struct {}`:   map[interface{}]interface{}{Keyword{"", "a"}: []interface{}{int64(1), struct{}{}}},
+		`cannot encode value of type complex128`:  complex(1, 2),
+		`cannot encode invalid symbol "nil"`:      map[interface{}]interface{}{Keyword{"", "b"}: Symbol{"", "nil"}},
+		`cannot encode invalid keyword ":bad kw"`: Keyword{"", "bad kw"},
+	}
+
+	for msg, v := range invalid {
+		err := RoundTrip(v)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%#v: expected error containing %q, got %v", v, msg, err)
+		}
+	}
+
+	// written by a tag writer, but read back as a Tagged without a reader
+	type version struct{ major, minor int64 }
+	RegisterTagWriter(reflect.TypeOf(version{}), func(v interface{}) (Symbol, interface{}, error) {
+		return Symbol{"test", "version"}, []interface{}{v.(version).major, v.(version).minor}, nil
+	})
+	defer RegisterTagWriter(reflect.TypeOf(version{}), nil)
+
+	v := map[interface{}]interface{}{Keyword{"", "a"}: int64(1), Keyword{"", "v"}: []interface{}{version{1, 2}}}
+	msg := `value at path [:v 0] is read back as #test/version [1 2]`
+	if err := RoundTrip(v); err == nil || !strings.Contains(err.Error(), msg) {
+		t.Errorf("expected error containing %q, got %v", msg, err)
+	}
+}
@@ -0,0 +1,200 @@
+package edn
+
+import (
+	"fmt"
+	"io"
+)
+
+// scanForm finds the next form in data, starting at pos, without
+// decoding it.  It returns the offsets of the first byte of the form
+// and the byte after it.  Whitespace, comments and discarded forms
+// before the form are skipped.  If there is no form before the end of
+// data, io.EOF is returned.
+//
+// Nested forms are scanned with an explicit stack instead of
+// recursively, as by the Decoder, so that deeply nested input doesn't
+// exhaust the stack of the goroutine.
+func scanForm(data []byte, pos int) (start, end int, err error) {
+	start, err = skipSpace(data, pos)
+	if err != nil {
+		return start, start, err
+	}
+
+	end, err = scanNested(data, start)
+	return start, end, err
+}
+
+// skipSpace skips whitespace, comments and discarded forms.
+func skipSpace(data []byte, pos int) (int, error) {
+	// discards is the number of #_ whose forms have not been skipped yet
+	discards := 0
+	for {
+		pos = skipComments(data, pos)
+		switch {
+		case pos >= len(data) && discards > 0:
+			return pos, fmt.Errorf("eof while reading discarded form")
+		case pos >= len(data):
+			return pos, io.EOF
+		case data[pos] == '#' && pos+1 < len(data) && data[pos+1] == '_':
+			discards++
+			pos += 2
+		case discards > 0:
+			end, err := scanNested(data, pos)
+			if err != nil {
+				return end, err
+			}
+			discards--
+			pos = end
+		default:
+			return pos, nil
+		}
+	}
+}
+
+// skipComments skips whitespace and comments.
+func skipComments(data []byte, pos int) int {
+	for pos < len(data) {
+		switch ch := data[pos]; {
+		case isWhitespace(ch):
+			pos++
+		case ch == ';':
+			for pos < len(data) && data[pos] != '\n' && data[pos] != '\r' {
+				pos++
+			}
+		default:
+			return pos
+		}
+	}
+
+	return pos
+}
+
+// Markers of tagged elements and discarded forms on the stack of
+// scanNested, which contains the closing delimiters of collections.
+const (
+	scanTagged  = 't'
+	scanDiscard = '_'
+)
+
+// scanNested returns the end of the form starting at data[pos], which
+// is not whitespace.
+func scanNested(data []byte, pos int) (int, error) {
+	end, _, err := scanNestedDepth(data, pos)
+	return end, err
+}
+
+// scanNestedDepth is like scanNested, and also returns the maximum
+// nesting depth of the collections, tagged elements and discarded forms
+// in the form.
+func scanNestedDepth(data []byte, pos int) (end, depth int, err error) {
+	var stack []byte
+	for {
+		pos = skipComments(data, pos)
+		if pos >= len(data) {
+			if len(stack) == 0 {
+				return pos, 0, io.EOF
+			}
+
+			switch stack[len(stack)-1] {
+			case scanTagged:
+				return pos, 0, fmt.Errorf("eof while reading tagged value")
+			case scanDiscard:
+				return pos, 0, fmt.Errorf("eof while reading discarded form")
+			default:
+				return pos, 0, fmt.Errorf("eof while reading collection")
+			}
+		}
+
+		// complete is set if a form ended at pos
+		complete := false
+		switch ch := data[pos]; ch {
+		case '"':
+			end, err := scanString(data, pos+1)
+			if err != nil {
+				return end, 0, err
+			}
+			pos, complete = end, true
+		case '[':
+			stack, pos = append(stack, ']'), pos+1
+		case '(':
+			stack, pos = append(stack, ')'), pos+1
+		case '{':
+			stack, pos = append(stack, '}'), pos+1
+		case ']', ')', '}':
+			if len(stack) == 0 || stack[len(stack)-1] != ch {
+				return pos + 1, 0, fmt.Errorf("unmatched delimiter: '%c'", ch)
+			}
+			stack, pos, complete = stack[:len(stack)-1], pos+1, true
+		case '\\':
+			if pos+1 >= len(data) {
+				return pos, 0, fmt.Errorf("eof while reading character")
+			}
+			pos, complete = scanToken(data, pos+2), true
+		case '#':
+			if pos+1 >= len(data) {
+				return pos + 1, 0, fmt.Errorf("eof while reading dispatch character")
+			}
+
+			switch data[pos+1] {
+			case '{':
+				stack, pos = append(stack, '}'), pos+2
+			case '_':
+				stack, pos = append(stack, scanDiscard), pos+2
+			case '#':
+				// symbolic values such as ##Inf
+				pos, complete = scanToken(data, pos+2), true
+			default:
+				// tagged element, the tag is followed by the tagged form
+				end := scanToken(data, pos+1)
+				if end == pos+1 {
+					return end, 0, fmt.Errorf("reader tag must be a symbol")
+				}
+				stack, pos = append(stack, scanTagged), end
+			}
+		default:
+			pos, complete = scanToken(data, pos+1), true
+		}
+
+		depth = max(depth, len(stack))
+
+		// a complete form completes the tagged elements it is the value
+		// of, and the innermost discarded form
+		for complete && len(stack) > 0 {
+			switch stack[len(stack)-1] {
+			case scanTagged:
+				stack = stack[:len(stack)-1]
+			case scanDiscard:
+				stack, complete = stack[:len(stack)-1], false
+			default:
+				complete = false
+			}
+		}
+
+		if complete && len(stack) == 0 {
+			return pos, depth, nil
+		}
+	}
+}
+
+func scanString(data []byte, pos int) (int, error) {
+	for pos < len(data) {
+		switch data[pos] {
+		case '"':
+			return pos + 1, nil
+		case '\\':
+			pos += 2
+		default:
+			pos++
+		}
+	}
+
+	return len(data), fmt.Errorf("eof while reading string")
+}
+
+func scanToken(data []byte, pos int) int {
+	for pos < len(data) && !isWhitespace(data[pos]) && !isTerminatingMacro(data[pos]) {
+		pos++
+	}
+
+	return pos
+}
@@ -0,0 +1,116 @@
+package edn
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// SecretKeys returns the AES key with the given id, a key of 16, 24 or
+// 32 bytes.  It is provided by the caller, e.g. to read keys from the
+// environment or a key management service.
+type SecretKeys func(id string) ([]byte, error)
+
+var (
+	secretTag     = Symbol{Namespace: "", Name: "secret"}
+	secretKeyID   = Keyword{Namespace: "", Name: "key"}
+	secretDataKey = Keyword{Namespace: "", Name: "data"}
+)
+
+// RegisterSecretTag enables reading of encrypted values, e.g. for
+// credentials in configuration files:
+//
+//	{:db/password #secret {:key "2024-01" :data "base64..."}}
+//
+// The value of the tag is the id of the key and the AES-GCM encrypted
+// EDN text of the value, written by EncryptSecret.  It is decrypted
+// with the key from keys and read as the value itself, so the code
+// using the configuration does not need to know about encryption.
+//
+// Without calling it these are read as Tagged values.  It modifies the
+// global tag registry, see RegisterTag.
+func RegisterSecretTag(keys SecretKeys) {
+	RegisterTag(secretTag, func(tag Symbol, val interface{}) (interface{}, error) {
+		return readSecret(keys, val)
+	})
+}
+
+// EncryptSecret returns v encrypted with key as a #secret value, for
+// writing with String.  id identifies the key when the value is read.
+// v is encoded with Marshal, whose error is returned for values it
+// can't encode.
+func EncryptSecret(v interface{}, id string, key []byte) (Tagged, error) {
+	text, err := Marshal(v)
+	if err != nil {
+		return Tagged{}, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return Tagged{}, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return Tagged{}, err
+	}
+
+	// the key id is authenticated, so that the data can't be moved to
+	// a different key
+	data := gcm.Seal(nonce, nonce, text, []byte(id))
+
+	return Tagged{Tag: secretTag, Value: map[interface{}]interface{}{
+		secretKeyID:   id,
+		secretDataKey: base64.StdEncoding.EncodeToString(data),
+	}}, nil
+}
+
+func readSecret(keys SecretKeys, val interface{}) (interface{}, error) {
+	m, ok := val.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("secret value must be a map, but was %#v", val)
+	}
+	id, ok := m[secretKeyID].(string)
+	if !ok {
+		return nil, fmt.Errorf("secret :key must be a string, but was %#v", m[secretKeyID])
+	}
+	encoded, ok := m[secretDataKey].(string)
+	if !ok {
+		return nil, fmt.Errorf("secret :data must be a string, but was %#v", m[secretDataKey])
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret data: %w", err)
+	}
+
+	key, err := keys(id)
+	if err != nil {
+		return nil, fmt.Errorf("secret key %q: %w", id, err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, fmt.Errorf("secret key %q: %w", id, err)
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid secret data: too short")
+	}
+	text, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("decrypting secret with key %q: %w", id, err)
+	}
+
+	return DecodeString(string(text))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
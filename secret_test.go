@@ -0,0 +1,63 @@
+package edn
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSecretTag(t *testing.T) {
+	keys := map[string][]byte{
+		"k1": []byte("0123456789abcdef"),
+		"k2": []byte("0123456789abcdef0123456789abcdef"),
+	}
+	RegisterSecretTag(func(id string) ([]byte, error) {
+		key, ok := keys[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown key")
+		}
+		return key, nil
+	})
+	defer RegisterTag(secretTag, nil)
+
+	secret := map[interface{}]interface{}{Keyword{"", "user"}: "admin", Keyword{"", "password"}: "hunter2"}
+	encrypted, err := EncryptSecret(secret, "k1", keys["k1"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := String(map[interface{}]interface{}{Keyword{"db", "credentials"}: encrypted})
+	if strings.Contains(config, "hunter2") {
+		t.Fatalf("secret is not encrypted: %s", config)
+	}
+
+	val, err := DecodeString(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(val, map[interface{}]interface{}{Keyword{"db", "credentials"}: secret}) {
+		t.Errorf("unexpected value %s", String(val))
+	}
+
+	// the data is bound to its key
+	data := encrypted.Value.(map[interface{}]interface{})[secretDataKey]
+	for _, invalid := range []string{
+		fmt.Sprintf(`#secret {:key "k2" :data %q}`, data),
+		fmt.Sprintf(`#secret {:key "unknown" :data %q}`, data),
+		`#secret {:key "k1" :data "AAAA"}`,
+		`#secret {:key "k1" :data "not base64"}`,
+		`#secret {:key "k1"}`,
+		`#secret "data"`,
+	} {
+		if _, err := DecodeString(invalid); err == nil {
+			t.Errorf("expected error for %s", invalid)
+		}
+	}
+
+	if _, err := EncryptSecret("value", "short", []byte("short")); err == nil {
+		t.Errorf("expected error for invalid key")
+	}
+	if _, err := EncryptSecret(struct{}{}, "k1", keys["k1"]); err == nil {
+		t.Errorf("expected error for unencodable value")
+	}
+}
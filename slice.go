@@ -0,0 +1,109 @@
+package edn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unsafe"
+)
+
+// DecodeBytes reads the first value from b.  It is faster than reading
+// from an io.ByteScanner, as whitespace and the text of strings are
+// scanned in bulk.  The value does not reference b.
+func DecodeBytes(b []byte) (interface{}, error) {
+	return NewDecoder(&sliceReader{b: b}).Decode()
+}
+
+// sliceReader reads from a byte slice, which lets the decoder scan it
+// directly instead of a byte at a time, see skipWhitespace and
+// stringChunk.  It only reads the slice, so that it may be the bytes
+// of a string.
+type sliceReader struct {
+	b   []byte
+	pos int
+}
+
+func newStringReader(s string) *sliceReader {
+	return &sliceReader{b: unsafe.Slice(unsafe.StringData(s), len(s))}
+}
+
+func (r *sliceReader) ReadByte() (byte, error) {
+	if r.pos >= len(r.b) {
+		return 0, io.EOF
+	}
+
+	ch := r.b[r.pos]
+	r.pos++
+	return ch, nil
+}
+
+func (r *sliceReader) UnreadByte() error {
+	if r.pos <= 0 {
+		return io.EOF
+	}
+
+	r.pos--
+	return nil
+}
+
+// eightSpaces are eight spaces read as a little-endian uint64.
+const eightSpaces = 0x2020202020202020
+
+// skipWhitespace skips the whitespace at the current position and
+// returns its length.  Runs of spaces, e.g. the indentation of pretty
+// printed documents, are skipped eight bytes at a time.
+func (r *sliceReader) skipWhitespace() int {
+	i := r.pos
+	for i < len(r.b) {
+		if i+8 <= len(r.b) && binary.LittleEndian.Uint64(r.b[i:]) == eightSpaces {
+			i += 8
+			continue
+		}
+		if !isWhitespace(r.b[i]) {
+			break
+		}
+		i++
+	}
+
+	n := i - r.pos
+	r.pos = i
+	return n
+}
+
+// stringChunk reads the text of a string up to its end or the next
+// escape, leaving the '"' or '\\' to be read.
+func (r *sliceReader) stringChunk() []byte {
+	rest := r.b[r.pos:]
+
+	i := bytes.IndexByte(rest, '"')
+	if i < 0 {
+		i = len(rest)
+	}
+	if j := bytes.IndexByte(rest[:i], '\\'); j >= 0 {
+		i = j
+	}
+
+	r.pos += i
+	return rest[:i]
+}
+
+// skipWhitespace skips whitespace in bulk if the decoder reads from a
+// slice, the remaining whitespace is skipped by the caller.
+func (d *Decoder) skipWhitespace() {
+	if r, ok := d.r.(*sliceReader); ok {
+		d.offset += int64(r.skipWhitespace())
+	}
+}
+
+// appendStringChunk appends the text of a string up to its end or the
+// next escape to buf if the decoder reads from a slice.
+func (d *Decoder) appendStringChunk(buf []byte) []byte {
+	r, ok := d.r.(*sliceReader)
+	if !ok {
+		return buf
+	}
+
+	chunk := r.stringChunk()
+	d.offset += int64(len(chunk))
+	return append(buf, chunk...)
+}
@@ -0,0 +1,168 @@
+package edn
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBytes(t *testing.T) {
+	tests := []string{
+		`"plain"`,
+		`"with \"escapes\"\n and é and 😀"`,
+		`"\\"`,
+		`""`,
+		`           [1        2,,,,,,,,3]`,
+		"{\n        :a         \"b\"\n\n\t\t:c [\"d\"]}",
+		`[1 "two" #{:three} {"four" 5}]`,
+	}
+
+	for _, s := range tests {
+		val, err := DecodeBytes([]byte(s))
+		if err != nil {
+			t.Errorf("%s: %s", s, err)
+			continue
+		}
+
+		if expected, _ := ReadValue(bufio.NewReader(strings.NewReader(s))); !Equal(val, expected) {
+			t.Errorf("%s: expected %s, got %s", s, String(expected), String(val))
+		}
+	}
+}
+
+func TestDecodeBytesErrors(t *testing.T) {
+	tests := []string{
+		`"unterminated`,
+		`"unterminated \n`,
+		`[1          2`,
+		`"\x"`,
+		"           ",
+	}
+
+	for _, s := range tests {
+		_, err := DecodeBytes([]byte(s))
+		_, expected := NewDecoder(bufio.NewReader(strings.NewReader(s))).Decode()
+		if err == nil || err.Error() != expected.Error() {
+			t.Errorf("%s: expected error %v, got %v", s, expected, err)
+		}
+
+		var got, want *SyntaxError
+		if errors.As(expected, &want) && (!errors.As(err, &got) || got.Offset != want.Offset) {
+			t.Errorf("%s: expected error at offset %d, got %v", s, want.Offset, err)
+		}
+	}
+}
+
+func TestDecodeBytesOffset(t *testing.T) {
+	s := `          "first"          "second"`
+	d := NewDecoder(&sliceReader{b: []byte(s)})
+
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	if d.InputOffset() != int64(len(`          "first"`)) {
+		t.Errorf("expected offset after first value, got %d", d.InputOffset())
+	}
+
+	val, err := d.Decode()
+	if err != nil || val != "second" || d.InputOffset() != int64(len(s)) {
+		t.Errorf("expected \"second\" at the end, got %v (%v) at %d", val, err, d.InputOffset())
+	}
+}
+
+// prettyDocument returns a pretty printed document with n entries.
+func prettyDocument(n int) []byte {
+	var entries []interface{}
+	for i := 0; i < n; i++ {
+		entries = append(entries, map[interface{}]interface{}{
+			Keyword{Name: "id"}:          int64(i),
+			Keyword{Name: "name"}:        fmt.Sprintf("entry number %d", i),
+			Keyword{Name: "description"}: strings.Repeat("some longer text ", 5),
+			Keyword{Name: "tags"}:        []interface{}{"a", "b", "c"},
+		})
+	}
+
+	return []byte(Pretty(map[interface{}]interface{}{Keyword{Name: "entries"}: entries}, 20))
+}
+
+func BenchmarkDecodePretty(b *testing.B) {
+	doc := prettyDocument(1000)
+
+	b.Run("bytes", func(b *testing.B) {
+		b.SetBytes(int64(len(doc)))
+		for i := 0; i < b.N; i++ {
+			if _, err := DecodeBytes(doc); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("reader", func(b *testing.B) {
+		b.SetBytes(int64(len(doc)))
+		for i := 0; i < b.N; i++ {
+			if _, err := ReadValue(bufio.NewReader(bytes.NewReader(doc))); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkDecodeLongStrings(b *testing.B) {
+	var elems []interface{}
+	for i := 0; i < 100; i++ {
+		elems = append(elems, strings.Repeat("lorem ipsum dolor sit amet ", 40))
+	}
+	doc := []byte(String(elems))
+
+	b.Run("bytes", func(b *testing.B) {
+		b.SetBytes(int64(len(doc)))
+		for i := 0; i < b.N; i++ {
+			if _, err := DecodeBytes(doc); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("reader", func(b *testing.B) {
+		b.SetBytes(int64(len(doc)))
+		for i := 0; i < b.N; i++ {
+			if _, err := ReadValue(bufio.NewReader(bytes.NewReader(doc))); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkDecodeLargeMap(b *testing.B) {
+	m := make(map[interface{}]interface{}, 100000)
+	for i := 0; i < 100000; i++ {
+		m[Keyword{Namespace: "entry", Name: fmt.Sprintf("k%d", i)}] = int64(i)
+	}
+	doc := []byte(String(m))
+
+	b.Run("bytes", func(b *testing.B) {
+		b.SetBytes(int64(len(doc)))
+		for i := 0; i < b.N; i++ {
+			if _, err := DecodeBytes(doc); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("stream", func(b *testing.B) {
+		// a decoder reading one map after the other reuses its buffer
+		b.SetBytes(int64(len(doc)))
+		r := bytes.NewReader(doc)
+		d := NewDecoder(bufio.NewReader(r))
+		for i := 0; i < b.N; i++ {
+			r.Reset(doc)
+			d.r = bufio.NewReader(r)
+			if _, err := d.Decode(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
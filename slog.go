@@ -0,0 +1,100 @@
+package edn
+
+import (
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+)
+
+// LogValue implements slog.LogValuer.
+func (kw Keyword) LogValue() slog.Value {
+	return slog.StringValue(kw.String())
+}
+
+// LogValue implements slog.LogValuer.
+func (sym Symbol) LogValue() slog.Value {
+	return slog.StringValue(sym.String())
+}
+
+// LogValue implements slog.LogValuer.
+func (u UUID) LogValue() slog.Value {
+	return slog.StringValue(u.String())
+}
+
+// LogValue implements slog.LogValuer.  The tag and the value are logged
+// as a group with the keys "tag" and "value".
+func (t Tagged) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("tag", "#"+t.Tag.String()),
+		SlogAttr("value", t.Value))
+}
+
+// SlogAttr returns an slog.Attr for the decoded value v.
+//
+// Maps are logged as groups, with keywords, symbols and other keys
+// converted to strings.  Vectors, lists and sets are logged as slices,
+// with keywords and symbols in them converted to strings, so that they
+// show up as in EDN instead of as Go structs.
+func SlogAttr(key string, v interface{}) slog.Attr {
+	return slog.Attr{Key: key, Value: slogValue(v)}
+}
+
+func slogValue(v interface{}) slog.Value {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		attrs := make([]slog.Attr, 0, len(v))
+		for key, val := range v {
+			attrs = append(attrs, SlogAttr(slogKey(key), val))
+		}
+		return slog.GroupValue(attrs...)
+	case []interface{}, map[interface{}]bool:
+		return slog.AnyValue(plainValue(v))
+	case time.Time:
+		return slog.TimeValue(v)
+	default:
+		return slog.AnyValue(v)
+	}
+}
+
+func slogKey(key interface{}) string {
+	switch key := key.(type) {
+	case string:
+		return key
+	case fmt.Stringer:
+		return key.String()
+	default:
+		return fmt.Sprint(key)
+	}
+}
+
+// plainValue converts v to a value that log handlers render sensibly
+// without knowing about this package, e.g. as JSON.
+func plainValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case Keyword, Symbol, UUID, *big.Int, *big.Rat:
+		return v.(fmt.Stringer).String()
+	case Tagged:
+		return map[string]interface{}{"tag": "#" + v.Tag.String(), "value": plainValue(v.Value)}
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, elem := range v {
+			list[i] = plainValue(elem)
+		}
+		return list
+	case map[interface{}]bool:
+		list := make([]interface{}, 0, len(v))
+		for elem := range v {
+			list = append(list, plainValue(elem))
+		}
+		return list
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[slogKey(key)] = plainValue(val)
+		}
+		return m
+	default:
+		return v
+	}
+}
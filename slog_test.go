@@ -0,0 +1,48 @@
+package edn
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogAttr(t *testing.T) {
+	val, err := DecodeString(`{:user/name "jane" :roles [:admin :dev] :id #uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	logger.Info("request", SlogAttr("body", val), slog.Any("kw", Keyword{"", "status"}))
+
+	out := buf.String()
+	for _, s := range []string{
+		`"body":{`,
+		`":user/name":"jane"`,
+		`":roles":[":admin",":dev"]`,
+		`":id":"f81d4fae-7dec-11d0-a765-00a0c91e6bf6"`,
+		`"kw":":status"`,
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("expected %s in %s", s, out)
+		}
+	}
+}
+
+func TestSlogTagged(t *testing.T) {
+	val, err := DecodeString(`#my/tag [1 sym]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewTextHandler(buf, nil))
+	logger.Info("tagged", slog.Any("v", val))
+
+	out := buf.String()
+	if !strings.Contains(out, `v.tag=#my/tag`) || !strings.Contains(out, `v.value="[1 sym]"`) {
+		t.Errorf("unexpected output %s", out)
+	}
+}
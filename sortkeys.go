@@ -0,0 +1,208 @@
+package edn
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// SortKeys returns doc with the entries of all its maps, including
+// nested ones, sorted by their keys, in the order String writes them.
+//
+// Only the entries are moved, the rest of doc is kept as it is.
+// Comments on the lines before an entry and at the end of its last
+// line move with the entry, as do discarded forms before it:
+//
+//	{;; the port
+//	 :port 8080 ; default
+//	 :host "localhost"}
+//
+// becomes
+//
+//	{:host "localhost"
+//	 ;; the port
+//	 :port 8080 ; default
+//	 }
+//
+// where the line break before the closing brace keeps it out of the
+// comment.  An error is returned if doc does not consist of well-formed
+// forms, see SplitTopLevel, is nested deeper than 10000 levels, or a
+// map has an odd number of forms.
+func SortKeys(doc []byte) ([]byte, error) {
+	// check the forms before sorting them recursively
+	for pos := 0; ; {
+		start, err := skipSpace(doc, pos)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, &SyntaxError{Offset: int64(start), Err: err}
+		}
+
+		end, depth, err := scanNestedDepth(doc, start)
+		if err != nil {
+			return nil, &SyntaxError{Offset: int64(end), Err: err}
+		} else if depth > maxSortDepth {
+			return nil, fmt.Errorf("forms nested deeper than %d levels", maxSortDepth)
+		}
+		pos = end
+	}
+
+	return sortKeysIn(nil, doc, 0, len(doc))
+}
+
+// sortKeysIn appends data[pos:end] with the maps of the forms in it
+// sorted to out.
+func sortKeysIn(out, data []byte, pos, end int) ([]byte, error) {
+	for {
+		start, err := skipSpace(data[:end], pos)
+		if err == io.EOF || start >= end || isClosingDelimiter(data[start]) {
+			return append(out, data[pos:end]...), nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		out = append(out, data[pos:start]...)
+		_, formEnd, err := scanForm(data, start)
+		if err != nil {
+			return nil, err
+		}
+
+		out, err = sortKeysForm(out, data, start, formEnd)
+		if err != nil {
+			return nil, err
+		}
+		pos = formEnd
+	}
+}
+
+// maxSortDepth limits the nesting of the forms passed to SortKeys,
+// which are sorted recursively.
+const maxSortDepth = 10000
+
+// sortKeysForm appends the form data[start:end] with its maps sorted
+// to out.
+func sortKeysForm(out, data []byte, start, end int) ([]byte, error) {
+	switch {
+	case data[start] == '{':
+		return sortMap(out, data, start, end)
+	case data[start] == '[' || data[start] == '(':
+		out = append(out, data[start])
+		out, err := sortKeysIn(out, data, start+1, end-1)
+		return append(out, data[end-1]), err
+	case data[start] == '#' && start+1 < end && data[start+1] == '{':
+		out = append(out, "#{"...)
+		out, err := sortKeysIn(out, data, start+2, end-1)
+		return append(out, '}'), err
+	case data[start] == '#' && start+1 < end && data[start+1] != '#':
+		// the tagged form after the tag
+		tagEnd := scanToken(data, start+1)
+		return sortKeysIn(append(out, data[start:tagEnd]...), data, tagEnd, end)
+	default:
+		return append(out, data[start:end]...), nil
+	}
+}
+
+// sortMap appends the map data[start:end] with its entries sorted.
+func sortMap(out, data []byte, start, end int) ([]byte, error) {
+	type entry struct {
+		start, end int
+		text       []byte
+		order      string
+		comment    bool
+	}
+
+	var entries []entry
+	pos := start + 1
+	for {
+		keyStart, err := skipSpace(data[:end-1], pos)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		_, keyEnd, err := scanForm(data, keyStart)
+		if err != nil {
+			return nil, err
+		}
+
+		valStart, err := skipSpace(data[:end-1], keyEnd)
+		if err == io.EOF {
+			return nil, fmt.Errorf("map literal must contain an even number of forms")
+		} else if err != nil {
+			return nil, err
+		}
+
+		_, valEnd, err := scanForm(data, valStart)
+		if err != nil {
+			return nil, err
+		}
+
+		// a comment on the line of the value belongs to the entry
+		entryEnd, comment := valEnd, false
+		for entryEnd < end-1 && (data[entryEnd] == ' ' || data[entryEnd] == '\t' || data[entryEnd] == ',') {
+			entryEnd++
+		}
+		if entryEnd < end-1 && data[entryEnd] == ';' {
+			comment = true
+			for entryEnd < end-1 && data[entryEnd] != '\n' && data[entryEnd] != '\r' {
+				entryEnd++
+			}
+		} else {
+			entryEnd = valEnd
+		}
+
+		// so do comments and discarded forms on the lines before it
+		entryStart := pos
+		for isWhitespace(data[entryStart]) {
+			entryStart++
+		}
+
+		var text []byte
+		text, err = sortKeysForm(append(text, data[entryStart:keyStart]...), data, keyStart, keyEnd)
+		if err != nil {
+			return nil, err
+		}
+		text = append(text, data[keyEnd:valStart]...)
+		text, err = sortKeysForm(text, data, valStart, valEnd)
+		if err != nil {
+			return nil, err
+		}
+		text = append(text, data[valEnd:entryEnd]...)
+
+		order := string(data[keyStart:keyEnd])
+		if key, err := DecodeBytes(data[keyStart:keyEnd]); err == nil {
+			order = String(key)
+		}
+
+		entries = append(entries, entry{entryStart, entryEnd, text, order, comment})
+		pos = entryEnd
+	}
+
+	if len(entries) == 0 {
+		return append(out, data[start:end]...), nil
+	}
+
+	sorted := make([]entry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].order < sorted[j].order })
+
+	// the entries take the places of each other, the text between them
+	// stays where it is
+	gapStart := start
+	for i, e := range entries {
+		gap := data[gapStart:e.start]
+		if i > 0 && sorted[i-1].comment && bytes.IndexByte(gap, '\n') < 0 {
+			out = append(out, '\n')
+		}
+		out = append(out, gap...)
+		out = append(out, sorted[i].text...)
+		gapStart = e.end
+	}
+
+	if sorted[len(sorted)-1].comment && bytes.IndexByte(data[gapStart:end], '\n') < 0 {
+		out = append(out, '\n')
+	}
+	return append(out, data[gapStart:end]...), nil
+}
@@ -0,0 +1,54 @@
+package edn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSortKeys(t *testing.T) {
+	tests := []struct {
+		doc, sorted string
+	}{
+		{`{:b 1 :a 2}`, `{:a 2 :b 1}`},
+		{"{;; the port\n :port 8080 ; default\n :host \"localhost\"}",
+			"{:host \"localhost\"\n ;; the port\n :port 8080 ; default\n}"},
+		{"{:c 3\n :b {:z 1 :y 2} ; nested\n\n ;; a\n :a [{:d 4 :c 3}]}",
+			"{;; a\n :a [{:c 3 :d 4}]\n :b {:y 2 :z 1} ; nested\n\n :c 3}"},
+		{"; top\n[{:b 1, :a 2} #my/tag {:y 1 :x 2} #{{:b 1 :a 2}} (f {:b 1 :a 2})]",
+			"; top\n[{:a 2, :b 1} #my/tag {:x 2 :y 1} #{{:a 2 :b 1}} (f {:a 2 :b 1})]"},
+		{"{:b 1 #_ :x :a 2}", "{#_ :x :a 2 :b 1}"},
+		{`{"b" 1 :a 2 1 3}`, `{"b" 1 1 3 :a 2}`},
+		{`{} "{:b 1 :a 2}" ; {:b 1 :a 2}`, `{} "{:b 1 :a 2}" ; {:b 1 :a 2}`},
+	}
+
+	for _, test := range tests {
+		sorted, err := SortKeys([]byte(test.doc))
+		if err != nil {
+			t.Errorf("%q: %v", test.doc, err)
+			continue
+		}
+
+		if string(sorted) != test.sorted {
+			t.Errorf("%q: expected\n%s\ngot\n%s", test.doc, test.sorted, sorted)
+		}
+	}
+
+	for _, invalid := range []string{`{:a}`, `{:a 1`, `[1 2))`} {
+		if _, err := SortKeys([]byte(invalid)); err == nil {
+			t.Errorf("%q: expected error", invalid)
+		}
+	}
+}
+
+func TestSortKeysDeep(t *testing.T) {
+	doc := strings.Repeat("[", 1000) + "{:b 1 :a 2}" + strings.Repeat("]", 1000)
+	sorted, err := SortKeys([]byte(doc))
+	if err != nil || !strings.Contains(string(sorted), "{:a 2 :b 1}") {
+		t.Errorf("expected the nested map to be sorted, got %v", err)
+	}
+
+	doc = strings.Repeat("[", 1000000) + strings.Repeat("]", 1000000)
+	if _, err := SortKeys([]byte(doc)); err == nil || !strings.Contains(err.Error(), "nested deeper") {
+		t.Errorf("expected depth error, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package edn
+
+import (
+	"io"
+)
+
+// SplitTopLevel returns the raw bytes of each top-level form of data,
+// without decoding them.  The forms are only checked to be well-formed
+// (balanced delimiters, terminated strings), so that they can be handed
+// to DecodeBytes in parallel or routed by a cheap look at their start.
+//
+// Whitespace, comments and discarded forms between the forms are not
+// part of the result.  The slices share the memory of data.  Malformed
+// input results in a *SyntaxError with the offset at which the
+// error was detected.
+func SplitTopLevel(data []byte) ([][]byte, error) {
+	var forms [][]byte
+	for pos := 0; ; {
+		start, end, err := scanForm(data, pos)
+		if err == io.EOF {
+			return forms, nil
+		} else if err != nil {
+			return nil, &SyntaxError{Offset: int64(end), Err: err}
+		}
+
+		forms = append(forms, data[start:end:end])
+		pos = end
+	}
+}
@@ -0,0 +1,82 @@
+package edn
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSplitTopLevel(t *testing.T) {
+	tests := []struct {
+		data  string
+		forms []string
+	}{
+		{"", nil},
+		{" ; only a comment\n", nil},
+		{"1 :a \"b c\"", []string{"1", ":a", `"b c"`}},
+		{"{:a [1 2]}\n(f \"]\" ; )\n x)", []string{"{:a [1 2]}", "(f \"]\" ; )\n x)"}},
+		{"#inst \"2020-01-01T00:00:00Z\" #{1} ##Inf", []string{`#inst "2020-01-01T00:00:00Z"`, "#{1}", "##Inf"}},
+		{"#_ [1 2] 3 #_4", []string{"3"}},
+		{"a,b\r\nc[d]", []string{"a", "b", "c", "[d]"}},
+		{"\"\\\"}\" x", []string{`"\"}"`, "x"}},
+	}
+
+	for _, test := range tests {
+		forms, err := SplitTopLevel([]byte(test.data))
+		if err != nil {
+			t.Errorf("%q: %v", test.data, err)
+			continue
+		}
+
+		if len(forms) != len(test.forms) {
+			t.Errorf("%q: expected %q, got %q", test.data, test.forms, forms)
+			continue
+		}
+
+		for i, form := range forms {
+			if string(form) != test.forms[i] {
+				t.Errorf("%q: expected %q, got %q", test.data, test.forms, forms)
+				break
+			}
+		}
+	}
+}
+
+func TestSplitTopLevelInvalid(t *testing.T) {
+	tests := []struct {
+		data   string
+		offset int64
+	}{
+		{"[1 2", 4},
+		{"1 ]", 3},
+		{"{:a \"b}", 7},
+		{"#_", 2},
+		{"#tag", 4},
+	}
+
+	for _, test := range tests {
+		forms, err := SplitTopLevel([]byte(test.data))
+		var serr *SyntaxError
+		if !errors.As(err, &serr) {
+			t.Errorf("%q: expected *SyntaxError, got %q, %v", test.data, forms, err)
+		} else if serr.Offset != test.offset {
+			t.Errorf("%q: expected error at %d, got %d (%v)", test.data, test.offset, serr.Offset, err)
+		}
+	}
+}
+
+func TestSplitTopLevelDeep(t *testing.T) {
+	const depth = 1000000
+
+	forms, err := SplitTopLevel([]byte(strings.Repeat("[#a #_ x ", depth) + "0" + strings.Repeat("]", depth) + " 1"))
+	if err != nil || len(forms) != 2 || string(forms[1]) != "1" {
+		t.Errorf("expected two forms, got %d, %v", len(forms), err)
+	}
+
+	for _, prefix := range []string{"[", "#_ ", "#a "} {
+		var serr *SyntaxError
+		if _, err := SplitTopLevel([]byte(strings.Repeat(prefix, depth))); !errors.As(err, &serr) {
+			t.Errorf("%q: expected *SyntaxError, got %v", prefix, err)
+		}
+	}
+}
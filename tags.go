@@ -0,0 +1,108 @@
+package edn
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// tagReaders is the global tag registry.  It is copied on every change,
+// so that the decoder looks up tags without locking, while tags may be
+// registered at any time.
+var (
+	tagReaders atomic.Pointer[map[Symbol]func(tag Symbol, val interface{}) (interface{}, error)]
+	tagWriters atomic.Pointer[map[reflect.Type]func(v interface{}) (Symbol, interface{}, error)]
+	registerMu sync.Mutex
+)
+
+// RegisterTag makes the reader read elements tagged with tag as the
+// result of fn, which is called with the tag and the decoded value.
+// A nil fn removes the reader for tag, so that its elements are read
+// as Tagged values again.
+//
+// The registry is global and used by all decoders.  It is safe to call
+// RegisterTag while values are read, decoders reading a value at the
+// time may or may not use fn for it.
+func RegisterTag(tag Symbol, fn func(tag Symbol, val interface{}) (interface{}, error)) {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	readers := make(map[Symbol]func(tag Symbol, val interface{}) (interface{}, error))
+	if old := tagReaders.Load(); old != nil {
+		for t, f := range *old {
+			readers[t] = f
+		}
+	}
+
+	if fn == nil {
+		delete(readers, tag)
+	} else {
+		readers[tag] = fn
+	}
+
+	tagReaders.Store(&readers)
+}
+
+// tagReader returns the registered reader for tag.
+func tagReader(tag Symbol) (func(tag Symbol, val interface{}) (interface{}, error), bool) {
+	readers := tagReaders.Load()
+	if readers == nil {
+		return nil, false
+	}
+
+	fn, ok := (*readers)[tag]
+	return fn, ok
+}
+
+// RegisterTagWriter makes Marshal and String write values of type t as
+// tagged elements, with the tag and value returned by fn, e.g.
+//
+//	edn.RegisterTagWriter(reflect.TypeOf(decimal.Decimal{}), func(v interface{}) (edn.Symbol, interface{}, error) {
+//		return edn.Symbol{Namespace: "", Name: "decimal"}, v.(decimal.Decimal).String(), nil
+//	})
+//
+// which is the counterpart of a tag reader for the tag.  The value must
+// be encodable itself.  Marshal returns the errors of fn, String writes
+// values fn fails for as strings, as other unknown values.  fn may be
+// called more than once for a value.  A nil fn removes the writer for
+// t.
+//
+// Writers are only used for types String doesn't write itself.  Like
+// that of RegisterTag, the registry is global and safe to change at any
+// time.
+func RegisterTagWriter(t reflect.Type, fn func(v interface{}) (Symbol, interface{}, error)) {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	writers := make(map[reflect.Type]func(v interface{}) (Symbol, interface{}, error))
+	if old := tagWriters.Load(); old != nil {
+		for t, f := range *old {
+			writers[t] = f
+		}
+	}
+
+	if fn == nil {
+		delete(writers, t)
+	} else {
+		writers[t] = fn
+	}
+
+	tagWriters.Store(&writers)
+}
+
+// writeTagged returns v as written by its registered tag writer, ok is
+// false if there is none.
+func writeTagged(v interface{}) (tagged Tagged, ok bool, err error) {
+	writers := tagWriters.Load()
+	if writers == nil || v == nil {
+		return Tagged{}, false, nil
+	}
+
+	fn, ok := (*writers)[reflect.TypeOf(v)]
+	if !ok {
+		return Tagged{}, false, nil
+	}
+
+	tag, val, err := fn(v)
+	return Tagged{Tag: tag, Value: val}, true, err
+}
@@ -0,0 +1,214 @@
+package edn
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RegisterNetTags enables reading of the following tagged elements:
+//
+//  - #ip "10.0.0.1" as net.IP
+//  - #cidr "10.0.0.0/8" as *net.IPNet
+//  - #uri "https://example.com" as *url.URL
+//
+// Without calling it these are read as Tagged values.  It modifies the
+// global tag registry, see RegisterTag.  String and Marshal write
+// values of these types with the same tags.
+//
+// Durations, #duration "1h30m" and #java.time/duration "PT1H30M", are
+// always read as time.Duration, so that the durations Marshal writes
+// are read back without registering tags.
+func RegisterNetTags() {
+	RegisterTag(Symbol{Namespace: "", Name: "ip"}, readIP)
+	RegisterTag(Symbol{Namespace: "", Name: "cidr"}, readCIDR)
+	RegisterTag(Symbol{Namespace: "", Name: "uri"}, readURI)
+}
+
+func readIP(tag Symbol, val interface{}) (interface{}, error) {
+	str, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("ip value must be a string, but was %#v", val)
+	}
+
+	ip := net.ParseIP(str)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid ip address: %q", str)
+	}
+
+	return ip, nil
+}
+
+func readCIDR(tag Symbol, val interface{}) (interface{}, error) {
+	str, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("cidr value must be a string, but was %#v", val)
+	}
+
+	_, ipNet, err := net.ParseCIDR(str)
+	if err != nil {
+		return nil, err
+	}
+
+	return ipNet, nil
+}
+
+func readURI(tag Symbol, val interface{}) (interface{}, error) {
+	str, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("uri value must be a string, but was %#v", val)
+	}
+
+	u, err := url.Parse(str)
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func readDuration(tag Symbol, val interface{}) (interface{}, error) {
+	str, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("duration value must be a string, but was %#v", val)
+	}
+
+	if strings.HasPrefix(str, "P") || strings.HasPrefix(str, "-P") || strings.HasPrefix(str, "+P") {
+		return parseISODuration(str)
+	}
+
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// isoDurationPattern matches the ISO-8601 durations produced by
+// java.time.Duration, e.g. PT1H30M, P2DT3H, PT-0.5S.
+var isoDurationPattern = regexp.MustCompile(`^([-+]?)P(?:([-+]?[0-9]+)D)?(?:T(?:([-+]?[0-9]+)H)?(?:([-+]?[0-9]+)M)?(?:([-+]?[0-9]+(?:[.,][0-9]{0,9})?)S)?)?$`)
+
+// parseISODuration parses an ISO-8601 duration.  Years and months are
+// not supported, as their length varies.
+func parseISODuration(str string) (time.Duration, error) {
+	match := isoDurationPattern.FindStringSubmatch(str)
+	if match == nil || strings.HasSuffix(str, "P") || strings.HasSuffix(str, "T") {
+		return 0, fmt.Errorf("invalid duration: %q", str)
+	}
+
+	// the sign of the whole duration applies to each part, so that the
+	// parts of the minimum duration don't overflow before negating it
+	sign := int64(1)
+	if match[1] == "-" {
+		sign = -1
+	}
+
+	var d time.Duration
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute}
+	for i, unit := range units {
+		if match[i+2] == "" {
+			continue
+		}
+
+		n, err := strconv.ParseInt(match[i+2], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %q", str)
+		}
+
+		part, ok := mulDuration(n, time.Duration(sign)*unit)
+		if d, ok = addDuration(d, part, ok); !ok {
+			return 0, fmt.Errorf("duration out of range: %q", str)
+		}
+	}
+
+	if match[5] != "" {
+		secs, frac := match[5], ""
+		if i := strings.IndexAny(secs, ".,"); i >= 0 {
+			secs, frac = secs[:i], secs[i+1:]
+		}
+
+		n, err := strconv.ParseInt(secs, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %q", str)
+		}
+
+		nanos := int64(0)
+		if frac != "" {
+			nanos, _ = strconv.ParseInt((frac + "000000000")[:9], 10, 64)
+		}
+		if strings.HasPrefix(secs, "-") {
+			nanos = -nanos
+		}
+
+		part, ok := mulDuration(n, time.Duration(sign)*time.Second)
+		part, ok = addDuration(part, time.Duration(sign*nanos), ok)
+		if d, ok = addDuration(d, part, ok); !ok {
+			return 0, fmt.Errorf("duration out of range: %q", str)
+		}
+	}
+
+	return d, nil
+}
+
+// mulDuration returns n units, with ok false if it overflows.
+func mulDuration(n int64, unit time.Duration) (d time.Duration, ok bool) {
+	if unit < 0 {
+		if n == math.MinInt64 {
+			return 0, false
+		}
+		n, unit = -n, -unit
+	}
+	if n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// addDuration returns a + b if ok, with ok false if it overflows.
+func addDuration(a, b time.Duration, ok bool) (time.Duration, bool) {
+	sum := a + b
+	if !ok || (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, false
+	}
+	return sum, true
+}
+
+// formatISODuration formats d as an ISO-8601 duration in hours, minutes
+// and seconds, as java.time.Duration does, e.g. PT1H30M.  Negative
+// durations have a leading sign, e.g. -PT0.5S.
+func formatISODuration(d time.Duration) string {
+	buf := make([]byte, 0, 24)
+	u := uint64(d)
+	if d < 0 {
+		buf = append(buf, '-')
+		u = -u
+	}
+	buf = append(buf, "PT"...)
+
+	secs, nanos := u/1e9, u%1e9
+	if h := secs / 3600; h > 0 {
+		buf = strconv.AppendUint(buf, h, 10)
+		buf = append(buf, 'H')
+	}
+	if m := secs / 60 % 60; m > 0 {
+		buf = strconv.AppendUint(buf, m, 10)
+		buf = append(buf, 'M')
+	}
+	if s := secs % 60; s > 0 || nanos > 0 || u == 0 {
+		buf = strconv.AppendUint(buf, s, 10)
+		if nanos > 0 {
+			frac := strconv.AppendUint(nil, 1e9+nanos, 10)[1:]
+			buf = append(buf, '.')
+			buf = append(buf, strings.TrimRight(string(frac), "0")...)
+		}
+		buf = append(buf, 'S')
+	}
+
+	return string(buf)
+}
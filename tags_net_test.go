@@ -0,0 +1,147 @@
+package edn
+
+import (
+	"bytes"
+	"math"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestNetTags(t *testing.T) {
+	RegisterNetTags()
+
+	val, err := DecodeString(`#ip "10.0.0.1"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip, ok := val.(net.IP); !ok || !ip.Equal(net.IPv4(10, 0, 0, 1)) {
+		t.Errorf("unexpected value %#v", val)
+	}
+
+	val, err = DecodeString(`#cidr "10.0.0.0/8"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ipNet, ok := val.(*net.IPNet); !ok || ipNet.String() != "10.0.0.0/8" {
+		t.Errorf("unexpected value %#v", val)
+	}
+
+	val, err = DecodeString(`#uri "https://example.com/path?q=1"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, ok := val.(*url.URL); !ok || u.Host != "example.com" || u.Path != "/path" {
+		t.Errorf("unexpected value %#v", val)
+	}
+
+	val, err = DecodeString(`#duration "1h30m"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != 90*time.Minute {
+		t.Errorf("unexpected value %#v", val)
+	}
+
+	for s, expected := range map[string]time.Duration{
+		`#java.time/duration "PT1H30M"`: 90 * time.Minute,
+		`#duration "P2DT3H"`:            51 * time.Hour,
+		`#duration "PT0.5S"`:            500 * time.Millisecond,
+		`#duration "PT-0.5S"`:           -500 * time.Millisecond,
+		`#duration "-PT1M1.25S"`:        -(time.Minute + 1250*time.Millisecond),
+		`#duration "PT0S"`:              0,
+	} {
+		val, err := DecodeString(s)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+		} else if val != expected {
+			t.Errorf("%s: expected %v, got %v", s, expected, val)
+		}
+	}
+
+	for _, s := range []string{
+		`#ip "not-an-ip"`, `#cidr "10.0.0.1"`, `#duration 3`, `#duration "P"`, `#duration "PT"`, `#duration "P1Y"`,
+		`#duration "PT9999999999999H"`, `#duration "P999999999999999D"`, `#duration "PT9223372037S"`,
+		`#duration "PT2562047H47M16.854775808S"`, `#duration "-PT2562047H47M16.854775809S"`, `#duration "P106751DT24H"`,
+	} {
+		if _, err := DecodeString(s); err == nil {
+			t.Errorf("expected error for %s", s)
+		}
+	}
+}
+
+func TestNetTagsRoundTrip(t *testing.T) {
+	RegisterNetTags()
+
+	for _, s := range []string{
+		`#ip "10.0.0.1"`,
+		`#ip "2001:db8::1"`,
+		`#cidr "10.0.0.0/8"`,
+		`#uri "https://example.com/path?q=1"`,
+		`#duration "1h30m0s"`,
+	} {
+		val, err := DecodeString(s)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+
+		data, err := Marshal(val)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if string(data) != s {
+			t.Errorf("expected %s, got %s", s, data)
+		}
+
+		again, err := DecodeBytes(data)
+		if err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if String(again) != String(val) {
+			t.Errorf("%s: read back as %s", s, String(again))
+		}
+	}
+
+	for _, v := range []interface{}{net.IP(nil), (*net.IPNet)(nil), (*url.URL)(nil)} {
+		if s := String(v); s != "nil" {
+			t.Errorf("expected nil for %#v, got %s", v, s)
+		}
+	}
+}
+
+func TestDurationFormats(t *testing.T) {
+	durations := []time.Duration{
+		0, 90 * time.Minute, 51 * time.Hour, 1500 * time.Millisecond, -500 * time.Millisecond,
+		time.Nanosecond, math.MaxInt64, math.MinInt64,
+	}
+	iso := []string{
+		"PT0S", "PT1H30M", "PT51H", "PT1.5S", "-PT0.5S",
+		"PT0.000000001S", "PT2562047H47M16.854775807S", "-PT2562047H47M16.854775808S",
+	}
+
+	for i, d := range durations {
+		for _, format := range []DurationFormat{DurationGo, DurationISO} {
+			var buf bytes.Buffer
+			e := NewEncoder(&buf)
+			e.SetDurationFormat(format)
+			if err := e.Encode(d); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := `#duration "` + d.String() + "\"\n"
+			if format == DurationISO {
+				expected = `#java.time/duration "` + iso[i] + "\"\n"
+			}
+			if buf.String() != expected {
+				t.Errorf("expected %q, got %q", expected, buf.String())
+			}
+
+			// durations are read without RegisterNetTags
+			read, err := DecodeBytes(buf.Bytes())
+			if err != nil || read != d {
+				t.Errorf("%s: read back as %#v (%v)", buf.String(), read, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package edn
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRegisterTag(t *testing.T) {
+	tag := Symbol{Namespace: "test", Name: "upper"}
+	RegisterTag(tag, func(tag Symbol, val interface{}) (interface{}, error) {
+		return strings.ToUpper(val.(string)), nil
+	})
+
+	if val := mustDecode(t, `#test/upper "hi"`); val != "HI" {
+		t.Errorf("expected \"HI\", got %v", val)
+	}
+
+	RegisterTag(tag, nil)
+	expected := Tagged{Tag: tag, Value: "hi"}
+	if val := mustDecode(t, `#test/upper "hi"`); val != expected {
+		t.Errorf("expected %v, got %v", expected, val)
+	}
+
+	if val := mustDecode(t, `#inst "2020-01-01T00:00:00Z"`); String(val) != `#inst "2020-01-01T00:00:00Z"` {
+		t.Errorf("expected the builtin tags to be kept, got %v", val)
+	}
+}
+
+type testPoint struct{ x, y int64 }
+
+func TestRegisterTagWriter(t *testing.T) {
+	tag := Symbol{Namespace: "test", Name: "point"}
+	typ := reflect.TypeOf(testPoint{})
+	RegisterTagWriter(typ, func(v interface{}) (Symbol, interface{}, error) {
+		p := v.(testPoint)
+		if p.x < 0 {
+			return Symbol{}, nil, fmt.Errorf("negative point")
+		}
+		return tag, []interface{}{p.x, p.y}, nil
+	})
+	RegisterTag(tag, func(tag Symbol, val interface{}) (interface{}, error) {
+		vec := val.([]interface{})
+		return testPoint{vec[0].(int64), vec[1].(int64)}, nil
+	})
+	defer RegisterTag(tag, nil)
+
+	val := []interface{}{testPoint{1, 2}, Keyword{"", "a"}}
+	data, err := Marshal(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `[#test/point [1 2] :a]`; string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+	if again := mustDecode(t, string(data)); !reflect.DeepEqual(again, val) {
+		t.Errorf("expected %#v to read back, got %#v", val, again)
+	}
+
+	if _, err := Marshal(testPoint{-1, 0}); err == nil || !strings.Contains(err.Error(), "negative point") {
+		t.Errorf("expected the error of the writer, got %v", err)
+	}
+	if s := String(testPoint{-1, 0}); s != `"{-1 0}"` {
+		t.Errorf("expected the fallback of String, got %s", s)
+	}
+
+	RegisterTagWriter(typ, nil)
+	if _, err := Marshal(testPoint{1, 2}); err == nil {
+		t.Errorf("expected error after removing the writer")
+	}
+}
+
+func TestRegisterTagConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		tag := Symbol{Namespace: "test", Name: string(rune('a' + i))}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				RegisterTag(tag, func(tag Symbol, val interface{}) (interface{}, error) {
+					return val, nil
+				})
+				RegisterTag(tag, nil)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := DecodeString(`[#test/a 1 #inst "2020-01-01T00:00:00Z"]`); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkDecodeTagged(b *testing.B) {
+	doc := []byte(`[#inst "2020-01-01T00:00:00Z" #uuid "f81d4fae-7dec-11d0-a765-00a0c91e6bf6" #my/tag 1]`)
+
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeBytes(doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,684 @@
+package edn
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// UnmarshalOptions configures Unmarshal, UnmarshalValue and
+// UnmarshalFields.
+type UnmarshalOptions struct {
+	// AllErrors continues past values that cannot be stored, leaving
+	// their fields or elements unchanged, and returns all of them
+	// joined with errors.Join, sorted by their path.  Each of the
+	// errors is a *DecodeError.
+	AllErrors bool
+}
+
+// Unmarshal reads the first value from data and stores it in the value
+// pointed to by v, see UnmarshalValue.
+func Unmarshal(data []byte, v interface{}) error {
+	return UnmarshalOptions{}.Unmarshal(data, v)
+}
+
+// Unmarshal is like the package function, using the options.
+func (o UnmarshalOptions) Unmarshal(data []byte, v interface{}) error {
+	val, err := DecodeBytes(data)
+	if err != nil {
+		return err
+	}
+
+	return withLiterals(data, o.UnmarshalValue(val, v))
+}
+
+// UnmarshalValue stores the decoded value val in the value pointed to
+// by v, similar to encoding/json:
+//
+//   - maps are stored in structs, with keys matched to the fields as
+//     described below, and in Go maps.  Keyword keys are stored in
+//     maps with string keys as their full name, e.g. "user/id", and
+//     as they are in maps with Keyword or Symbol keys, e.g.
+//     map[Keyword]int.  Maps built in Go, such as a
+//     map[Keyword]interface{}, are stored like decoded maps.
+//   - vectors, lists and sets are stored in slices and arrays, the
+//     elements of sets sorted by their String representation.  Sets
+//     are also stored in maps with bool values.
+//   - integers are stored in integer and float fields, if they fit,
+//     floats in float fields.  big.Int fields take all integers,
+//     big.Float fields all numbers except NaN.
+//   - values of the types the reader produces, e.g. Keyword or
+//     time.Time, are stored in fields of their type.
+//   - all values are stored in interface{} fields as they are.
+//   - nil sets pointers, slices, maps and interfaces to nil and leaves
+//     other values unchanged.
+//
+// Struct fields are matched to the keyword or string keys named in
+// their edn tag, e.g. `edn:"user/id"` for :user/id or "user/id".  The
+// options after the name, e.g. omitempty in the tags generated by
+// edn2go, are ignored.  Fields without a tag match keys whose name
+// equals the field name, ignoring case, hyphens and underscores, so
+// that UserID matches :user-id.  Fields tagged with "-" and unexported fields are
+// skipped, as are keys without a matching field.  The fields of
+// embedded structs without a tag are matched as if they were fields of
+// the outer struct, with the same rules for conflicting names as for
+// promoted fields in Go.
+//
+// Values that cannot be stored result in a *DecodeError.  The entries of
+// maps are stored in no particular order, so the fields of other
+// entries may or may not have been stored.  See UnmarshalOptions for
+// reporting all of them.
+func UnmarshalValue(val, v interface{}) error {
+	return UnmarshalOptions{}.UnmarshalValue(val, v)
+}
+
+// UnmarshalValue is like the package function, using the options.
+func (o UnmarshalOptions) UnmarshalValue(val, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("cannot unmarshal into %T, it must be a non-nil pointer", v)
+	}
+
+	u := &unmarshaler{allErrors: o.AllErrors}
+	return u.result(u.unmarshal(rv.Elem(), val, []interface{}{}))
+}
+
+// UnmarshalFields is like Unmarshal for a map stored in the struct
+// pointed to by v, but only decodes the entries whose keys are named in
+// fields, e.g. "user/id" for :user/id.  The other entries are only
+// scanned, see DecodeLazyMap, which is much cheaper if few of the
+// entries of a large map are needed.  Their values are not checked and
+// the fields they match are left unchanged.
+func UnmarshalFields(data []byte, v interface{}, fields ...string) error {
+	return UnmarshalOptions{}.UnmarshalFields(data, v, fields...)
+}
+
+// UnmarshalFields is like the package function, using the options.
+func (o UnmarshalOptions) UnmarshalFields(data []byte, v interface{}, fields ...string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot unmarshal into %T, it must be a non-nil pointer to a struct", v)
+	}
+
+	m, err := DecodeLazyMap(data)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		wanted[field] = true
+	}
+
+	entries := make(map[interface{}]interface{}, len(fields))
+	for _, key := range m.Keys() {
+		if name, ok := keyName(key); !ok || !wanted[name] {
+			continue
+		}
+
+		val, _, err := m.Get(key)
+		if err != nil {
+			return err
+		}
+		entries[key] = val
+	}
+
+	u := &unmarshaler{allErrors: o.AllErrors}
+	return withLiterals(data, u.result(u.unmarshalStruct(rv.Elem(), entries, []interface{}{})))
+}
+
+// DecodeError describes a value that cannot be stored by Unmarshal.
+type DecodeError struct {
+	path  []interface{}
+	value interface{}
+	typ   reflect.Type
+	text  string
+	err   error
+}
+
+// Path returns the path leading to the value, e.g. [:config :db :port],
+// see KeywordInfo.Paths for the format.
+func (e *DecodeError) Path() []interface{} {
+	return e.path
+}
+
+// Value returns the decoded value.
+func (e *DecodeError) Value() interface{} {
+	return e.value
+}
+
+// Type returns the type the value cannot be stored in.
+func (e *DecodeError) Type() reflect.Type {
+	return e.typ
+}
+
+// Text returns the text of the value in the input of Unmarshal, e.g.
+// "1e300" or #inst "2020-01-01T00:00:00Z", or its String representation
+// if the input is not known, as for UnmarshalValue.
+func (e *DecodeError) Text() string {
+	if e.text != "" {
+		return e.text
+	}
+	return String(e.value)
+}
+
+// Unwrap returns the error converting the value, e.g. decoding a
+// CompositeKey, if any.
+func (e *DecodeError) Unwrap() error {
+	return e.err
+}
+
+func (e *DecodeError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("cannot unmarshal %s into %s at %s: %s", e.Text(), e.typ, String(e.path), e.err)
+	}
+	return fmt.Sprintf("cannot unmarshal %s into %s at %s", e.Text(), e.typ, String(e.path))
+}
+
+// withLiterals sets the text of the *DecodeErrors in err to the text
+// of their values in data.
+func withLiterals(data []byte, err error) error {
+	switch err := err.(type) {
+	case *DecodeError:
+		if text, ok := literalAt(data, err.path); ok {
+			err.text = string(text)
+		}
+	case interface{ Unwrap() []error }:
+		for _, err := range err.Unwrap() {
+			withLiterals(data, err)
+		}
+	}
+	return err
+}
+
+// literalAt returns the text of the form at path in the first form of
+// data, descending into maps, vectors and lists.  ok is false if there
+// is no such form, e.g. for a path into a set or a namespaced map.
+func literalAt(data []byte, path []interface{}) (form []byte, ok bool) {
+	start, end, err := scanForm(data, 0)
+	if err != nil {
+		return nil, false
+	}
+
+	form = data[start:end]
+	for _, key := range path {
+		switch i, isIndex := key.(int); {
+		case form[0] == '{':
+			m, err := DecodeLazyMap(form)
+			if err != nil {
+				return nil, false
+			}
+			if form, ok = m.Raw(key); !ok {
+				return nil, false
+			}
+		case (form[0] == '[' || form[0] == '(') && isIndex:
+			// the elements, without the closing delimiter
+			elems := form[:len(form)-1]
+			for pos := 1; ; i-- {
+				start, end, err := scanForm(elems, pos)
+				if err != nil {
+					return nil, false
+				} else if i == 0 {
+					form = elems[start:end]
+					break
+				}
+				pos = end
+			}
+		default:
+			return nil, false
+		}
+	}
+
+	return form, true
+}
+
+// unmarshaler stores decoded values, collecting the errors if all of
+// them are reported.
+type unmarshaler struct {
+	allErrors bool
+	errs      []error
+}
+
+// collect reports whether err is collected, so that the caller can
+// continue with the next value.
+func (u *unmarshaler) collect(err error) bool {
+	if _, ok := err.(*DecodeError); !ok || !u.allErrors {
+		return false
+	}
+
+	u.errs = append(u.errs, err)
+	return true
+}
+
+// result returns err, or the collected errors if there are any.
+func (u *unmarshaler) result(err error) error {
+	if err != nil && !u.collect(err) {
+		return err
+	} else if len(u.errs) == 0 {
+		return nil
+	}
+
+	paths := make(map[error]string, len(u.errs))
+	for _, err := range u.errs {
+		paths[err] = String(err.(*DecodeError).path)
+	}
+	sort.SliceStable(u.errs, func(i, j int) bool { return paths[u.errs[i]] < paths[u.errs[j]] })
+	return errors.Join(u.errs...)
+}
+
+func (u *unmarshaler) unmarshal(dst reflect.Value, val interface{}, path []interface{}) error {
+	fail := func() error {
+		return &DecodeError{path: append([]interface{}{}, path...), value: val, typ: dst.Type()}
+	}
+
+	if val == nil {
+		switch dst.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			dst.SetZero()
+		}
+		return nil
+	}
+
+	if rval := reflect.ValueOf(val); rval.Type().AssignableTo(dst.Type()) {
+		dst.Set(rval)
+		return nil
+	}
+
+	if k, ok := val.(CompositeKey); ok {
+		decoded, err := k.Value()
+		if err != nil {
+			derr := fail().(*DecodeError)
+			derr.err = err
+			return derr
+		}
+		return u.unmarshal(dst, decoded, path)
+	}
+
+	switch dst.Kind() {
+	case reflect.Pointer:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return u.unmarshal(dst.Elem(), val, path)
+	case reflect.Interface:
+		// non-empty interfaces that val does not implement
+		return fail()
+	case reflect.Bool:
+		b, ok := val.(bool)
+		if !ok {
+			return fail()
+		}
+		dst.SetBool(b)
+	case reflect.String:
+		s, ok := val.(string)
+		if !ok {
+			return fail()
+		}
+		dst.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := intValue(val)
+		if !ok || dst.OverflowInt(i) {
+			return fail()
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, ok := uintValue(val)
+		if !ok || dst.OverflowUint(u) {
+			return fail()
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, ok := floatValue(val)
+		if !ok || (dst.Kind() == reflect.Float32 && !math.IsInf(f, 0) && dst.OverflowFloat(f)) {
+			return fail()
+		}
+		dst.SetFloat(f)
+	case reflect.Slice:
+		elems, ok := elements(val)
+		if !ok {
+			return fail()
+		}
+		s := reflect.MakeSlice(dst.Type(), len(elems), len(elems))
+		for i, elem := range elems {
+			if err := u.unmarshal(s.Index(i), elem, append(path, i)); err != nil && !u.collect(err) {
+				return err
+			}
+		}
+		dst.Set(s)
+	case reflect.Array:
+		elems, ok := elements(val)
+		if !ok || len(elems) != dst.Len() {
+			return fail()
+		}
+		for i, elem := range elems {
+			if err := u.unmarshal(dst.Index(i), elem, append(path, i)); err != nil && !u.collect(err) {
+				return err
+			}
+		}
+	case reflect.Map:
+		return u.unmarshalMap(dst, val, path, fail)
+	case reflect.Struct:
+		switch dst.Type() {
+		case bigIntType:
+			i, ok := bigInt(val)
+			if !ok {
+				return fail()
+			}
+			dst.Addr().Interface().(*big.Int).Set(i)
+			return nil
+		case bigFloatType:
+			f, ok := bigFloatValue(val)
+			if !ok {
+				return fail()
+			}
+			dst.Addr().Interface().(*big.Float).Set(f)
+			return nil
+		}
+
+		m, ok := mapEntries(val)
+		if !ok {
+			return fail()
+		}
+		return u.unmarshalStruct(dst, m, path)
+	default:
+		return fail()
+	}
+
+	return nil
+}
+
+func (u *unmarshaler) unmarshalMap(dst reflect.Value, val interface{}, path []interface{}, fail func() error) error {
+	var entries map[interface{}]interface{}
+	if set, ok := val.(map[interface{}]bool); ok {
+		entries = make(map[interface{}]interface{}, len(set))
+		for elem := range set {
+			entries[elem] = true
+		}
+	} else if entries, ok = mapEntries(val); !ok {
+		return fail()
+	}
+
+	m := reflect.MakeMapWithSize(dst.Type(), len(entries))
+	keyType, elemType := dst.Type().Key(), dst.Type().Elem()
+	for key, elem := range entries {
+		k := reflect.New(keyType).Elem()
+		if kw, ok := key.(Keyword); ok && keyType.Kind() == reflect.String {
+			k.SetString(kw.FullName())
+		} else if err := u.unmarshal(k, key, path); err != nil {
+			if u.collect(err) {
+				continue
+			}
+			return err
+		}
+
+		e := reflect.New(elemType).Elem()
+		if err := u.unmarshal(e, elem, append(path, key)); err != nil {
+			if u.collect(err) {
+				continue
+			}
+			return err
+		}
+
+		m.SetMapIndex(k, e)
+	}
+
+	dst.Set(m)
+	return nil
+}
+
+func (u *unmarshaler) unmarshalStruct(dst reflect.Value, m map[interface{}]interface{}, path []interface{}) error {
+	fields := structFields(dst.Type())
+	for key, val := range m {
+		name, ok := keyName(key)
+		if !ok {
+			continue
+		}
+
+		index, ok := fields.tagged[name]
+		if !ok {
+			index, ok = fields.untagged[foldName(name)]
+		}
+		if !ok {
+			continue
+		}
+
+		if err := u.unmarshal(fieldByIndex(dst, index), val, append(path, key)); err != nil && !u.collect(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keyName returns the name struct fields are matched by of a keyword
+// or string key.
+func keyName(key interface{}) (string, bool) {
+	switch key := key.(type) {
+	case Keyword:
+		return key.FullName(), true
+	case string:
+		return key, true
+	default:
+		return "", false
+	}
+}
+
+// fieldByIndex returns the field of v at index, allocating the embedded
+// structs it is promoted from if they are nil pointers.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index[:len(index)-1] {
+		v = v.Field(i)
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+	}
+
+	return v.Field(index[len(index)-1])
+}
+
+// fields are the indices of the fields of a struct type by the key
+// names they match, including those promoted from embedded structs.
+type fields struct {
+	tagged   map[string][]int
+	untagged map[string][]int
+}
+
+var fieldCache sync.Map // reflect.Type -> *fields
+
+func structFields(t reflect.Type) *fields {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.(*fields)
+	}
+
+	// as in Go, the shallowest field of a name wins, and several at the
+	// same depth hide each other
+	type candidate struct {
+		index []int
+		depth int
+	}
+	tagged := map[string][]candidate{}
+	untagged := map[string][]candidate{}
+
+	var walk func(t reflect.Type, index []int, seen map[reflect.Type]bool)
+	walk = func(t reflect.Type, index []int, seen map[reflect.Type]bool) {
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			fieldIndex := append(index[:len(index):len(index)], i)
+
+			tag, ok := field.Tag.Lookup("edn")
+			name, _, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				continue
+			}
+
+			typ := field.Type
+			if typ.Kind() == reflect.Pointer {
+				typ = typ.Elem()
+			}
+			if field.Anonymous && name == "" && typ.Kind() == reflect.Struct {
+				// pointers to unexported types can't be allocated
+				if field.IsExported() || field.Type.Kind() != reflect.Pointer {
+					walk(typ, fieldIndex, seen)
+				}
+				continue
+			}
+
+			if !field.IsExported() {
+				continue
+			}
+			if ok && name != "" {
+				tagged[name] = append(tagged[name], candidate{fieldIndex, len(index)})
+			} else {
+				key := foldName(field.Name)
+				untagged[key] = append(untagged[key], candidate{fieldIndex, len(index)})
+			}
+		}
+	}
+	walk(t, nil, map[reflect.Type]bool{})
+
+	resolve := func(candidates map[string][]candidate) map[string][]int {
+		res := make(map[string][]int, len(candidates))
+		for name, cs := range candidates {
+			sort.SliceStable(cs, func(i, j int) bool { return cs[i].depth < cs[j].depth })
+			if len(cs) == 1 || cs[0].depth < cs[1].depth {
+				res[name] = cs[0].index
+			}
+		}
+		return res
+	}
+
+	f := &fields{tagged: resolve(tagged), untagged: resolve(untagged)}
+	fieldCache.Store(t, f)
+	return f
+}
+
+// foldName returns name in lower case without hyphens and underscores.
+func foldName(name string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+}
+
+func intValue(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case *big.Int:
+		return v.Int64(), v.IsInt64()
+	default:
+		return 0, false
+	}
+}
+
+func uintValue(v interface{}) (uint64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return uint64(v), v >= 0
+	case int:
+		return uint64(v), v >= 0
+	case *big.Int:
+		return v.Uint64(), v.IsUint64()
+	default:
+		return 0, false
+	}
+}
+
+func floatValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f, true
+	case *big.Rat:
+		f, _ := v.Float64()
+		return f, true
+	default:
+		return 0, false
+	}
+}
+
+// mapEntries returns the entries of a decoded map, or of a map built in
+// Go, e.g. a map[Keyword]interface{}.
+func mapEntries(v interface{}) (map[interface{}]interface{}, bool) {
+	if m, ok := v.(map[interface{}]interface{}); ok {
+		return m, true
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map {
+		return nil, false
+	}
+
+	m := make(map[interface{}]interface{}, rv.Len())
+	for iter := rv.MapRange(); iter.Next(); {
+		m[iter.Key().Interface()] = iter.Value().Interface()
+	}
+	return m, true
+}
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+)
+
+func bigFloatValue(v interface{}) (*big.Float, bool) {
+	switch v := v.(type) {
+	case float64:
+		if math.IsNaN(v) {
+			return nil, false
+		}
+		return big.NewFloat(v), true
+	case int64:
+		return new(big.Float).SetInt64(v), true
+	case int:
+		return new(big.Float).SetInt64(int64(v)), true
+	case *big.Int:
+		if v == nil {
+			return nil, false
+		}
+		return new(big.Float).SetInt(v), true
+	case *big.Rat:
+		if v == nil {
+			return nil, false
+		}
+		return new(big.Float).SetRat(v), true
+	default:
+		return nil, false
+	}
+}
+
+// elements returns the elements of a vector, list or set.
+func elements(v interface{}) ([]interface{}, bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		return v, true
+	case map[interface{}]bool:
+		elems := make([]interface{}, 0, len(v))
+		for elem := range v {
+			elems = append(elems, elem)
+		}
+		sortByString(elems)
+		return elems, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,290 @@
+package edn
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testAddress struct {
+	City string `edn:"address/city"`
+	Zip  *int   `edn:"address/zip,omitempty"`
+}
+
+type testUser struct {
+	UserID   int64
+	Name     string `edn:"name"`
+	Role     Keyword
+	Tags     []string
+	Scores   [2]float32
+	Address  *testAddress
+	Created  time.Time
+	Big      *big.Int
+	Extra    interface{}
+	Labels   map[string]int
+	Flags    map[Keyword]bool
+	Ignored  string `edn:"-"`
+	internal string
+}
+
+func TestUnmarshal(t *testing.T) {
+	doc := `{:user-id 42
+	         :name "Jane"
+	         :role :admin
+	         :tags #{"b" "a"}
+	         :scores [1 2.5]
+	         :address {:address/city "Berlin" :address/zip 10115 :unknown 1}
+	         :created #inst "2020-01-02T03:04:05Z"
+	         :big 123456789012345678901234567890N
+	         :extra [1 :two]
+	         :labels {:x 1 "y" 2}
+	         :flags #{:a}
+	         :ignored "no"
+	         :internal "no"
+	         :other "skipped"}`
+
+	var u testUser
+	if err := Unmarshal([]byte(doc), &u); err != nil {
+		t.Fatal(err)
+	}
+
+	n, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	zip := 10115
+	expected := testUser{
+		UserID:  42,
+		Name:    "Jane",
+		Role:    Keyword{"", "admin"},
+		Tags:    []string{"a", "b"},
+		Scores:  [2]float32{1, 2.5},
+		Address: &testAddress{City: "Berlin", Zip: &zip},
+		Created: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Big:     n,
+		Extra:   []interface{}{int64(1), Keyword{"", "two"}},
+		Labels:  map[string]int{"x": 1, "y": 2},
+		Flags:   map[Keyword]bool{{"", "a"}: true},
+	}
+	if !reflect.DeepEqual(u, expected) {
+		t.Errorf("expected %+v, got %+v", expected, u)
+	}
+
+	if err := Unmarshal([]byte(`{:name nil :address nil}`), &u); err != nil || u.Name != "Jane" || u.Address != nil {
+		t.Errorf("expected nil to keep strings and clear pointers, got %+v, %v", u, err)
+	}
+}
+
+func TestUnmarshalKeywordMaps(t *testing.T) {
+	var byKeyword map[Keyword]int
+	if err := Unmarshal([]byte(`{:a 1 :b/c 2}`), &byKeyword); err != nil {
+		t.Fatal(err)
+	}
+	if expected := (map[Keyword]int{{"", "a"}: 1, {"b", "c"}: 2}); !reflect.DeepEqual(byKeyword, expected) {
+		t.Errorf("expected %v, got %v", expected, byKeyword)
+	}
+
+	var bySymbol map[Symbol]string
+	if err := Unmarshal([]byte(`{a "1" b/c "2"}`), &bySymbol); err != nil {
+		t.Fatal(err)
+	}
+	if expected := (map[Symbol]string{{"", "a"}: "1", {"b", "c"}: "2"}); !reflect.DeepEqual(bySymbol, expected) {
+		t.Errorf("expected %v, got %v", expected, bySymbol)
+	}
+
+	// maps built in Go
+	var u testUser
+	built := map[Keyword]interface{}{{"", "name"}: "Jane", {"", "labels"}: map[Keyword]int64{{"", "x"}: 1}}
+	if err := UnmarshalValue(built, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "Jane" || !reflect.DeepEqual(u.Labels, map[string]int{"x": 1}) {
+		t.Errorf("unexpected value %+v", u)
+	}
+
+	if err := Unmarshal([]byte(`{1 2}`), &byKeyword); err == nil {
+		t.Error("expected error for a non-keyword key")
+	}
+}
+
+func TestUnmarshalFields(t *testing.T) {
+	// the other entries are not decoded, so their errors don't matter
+	doc := `{:user-id 42 :name "Jane" :created #inst "not a time" :tags [1 2] :extra {:a [1 2 3]}}`
+
+	u := testUser{Name: "kept"}
+	if err := UnmarshalFields([]byte(doc), &u, "user-id", "tags"); err == nil {
+		t.Error("expected error for tags that aren't strings")
+	}
+
+	u = testUser{Name: "kept"}
+	if err := UnmarshalFields([]byte(doc), &u, "user-id", "extra"); err != nil {
+		t.Fatal(err)
+	}
+	expected := testUser{UserID: 42, Name: "kept", Extra: map[interface{}]interface{}{Keyword{"", "a"}: []interface{}{int64(1), int64(2), int64(3)}}}
+	if !reflect.DeepEqual(u, expected) {
+		t.Errorf("expected %+v, got %+v", expected, u)
+	}
+
+	if err := UnmarshalFields([]byte(doc), &u, "created"); err == nil {
+		t.Error("expected error for the projected invalid #inst")
+	}
+	if err := UnmarshalFields([]byte(`[1 2]`), &u, "name"); err == nil {
+		t.Error("expected error for a vector")
+	}
+	var m map[string]int
+	if err := UnmarshalFields([]byte(`{}`), &m, "name"); err == nil {
+		t.Error("expected error for a map target")
+	}
+}
+
+type testBase struct {
+	ID   int64
+	Name string `edn:"name"`
+}
+
+type testMeta struct {
+	Name    string
+	Version int
+}
+
+type testEmbedding struct {
+	testBase
+	*testMeta
+	Version string `edn:"version"`
+	Amount  *big.Float
+	Count   big.Int
+	Total   *big.Int
+}
+
+func TestUnmarshalEmbedded(t *testing.T) {
+	var e testEmbedding
+	doc := `{:id 1 :name "base" :version "v2" :amount 1.5 :count 12345678901234567890N :total 3}`
+	if err := Unmarshal([]byte(doc), &e); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.ID != 1 || e.testBase.Name != "base" || e.Version != "v2" {
+		t.Errorf("unexpected value %+v", e)
+	}
+	// :name is taken by the tagged field, and :version by the outer one
+	if e.testMeta != nil {
+		t.Errorf("expected the embedded pointer to stay nil, got %+v", e.testMeta)
+	}
+	if e.Amount == nil || e.Amount.String() != "1.5" {
+		t.Errorf("unexpected amount %v", e.Amount)
+	}
+	if e.Count.String() != "12345678901234567890" || e.Total == nil || e.Total.Int64() != 3 {
+		t.Errorf("unexpected integers %v %v", &e.Count, e.Total)
+	}
+
+	var m struct {
+		*testMeta
+		Other string
+	}
+	if err := Unmarshal([]byte(`{:name "meta" :version 2}`), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.testMeta != nil {
+		t.Errorf("expected pointers to unexported types to be skipped, got %+v", m.testMeta)
+	}
+
+	var exported struct {
+		*Tagged
+		Keyword
+	}
+	if err := Unmarshal([]byte(`{:tag foo :value 1 :namespace "ns"}`), &exported); err != nil {
+		t.Fatal(err)
+	}
+	if exported.Tagged == nil || exported.Tag != (Symbol{"", "foo"}) || exported.Value != int64(1) || exported.Namespace != "ns" {
+		t.Errorf("unexpected value %+v", exported)
+	}
+
+	for _, invalid := range []string{`{:amount "1"}`, `{:amount ##NaN}`, `{:count 1.5}`, `{:total 1/2}`} {
+		if err := Unmarshal([]byte(invalid), &e); err == nil {
+			t.Errorf("expected error for %s", invalid)
+		}
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		path string
+		text string
+	}{
+		{`{:user-id "42"}`, "[:user-id]", `"42"`},
+		{`{:scores [1 2 3]}`, "[:scores]", "[1 2 3]"},
+		{`{:scores [1 #_ 2 1e300]}`, "[:scores 1]", "1e300"},
+		{`{:tags ("a" ;; comment
+                  :b)}`, "[:tags 1]", ":b"},
+		{`{:address {:address/zip 1.50}}`, "[:address :address/zip]", "1.50"},
+		{`{:labels {:x 1 :y #my/tag "2"}}`, "[:labels :y]", `#my/tag "2"`},
+		{`[1]`, "[]", "[1]"},
+	}
+
+	for _, test := range tests {
+		var u testUser
+		err := Unmarshal([]byte(test.doc), &u)
+
+		var derr *DecodeError
+		if !errors.As(err, &derr) {
+			t.Errorf("%s: expected *DecodeError, got %v", test.doc, err)
+		} else if String(derr.Path()) != test.path || derr.Text() != test.text {
+			t.Errorf("%s: expected error at %s for %s, got %v", test.doc, test.path, test.text, err)
+		}
+	}
+
+	var small int8
+	if err := Unmarshal([]byte(`300`), &small); err == nil {
+		t.Error("expected error for an int8 overflow")
+	}
+
+	var u testUser
+	if err := Unmarshal([]byte(`{}`), u); err == nil {
+		t.Error("expected error for a non-pointer")
+	}
+
+	if err := Unmarshal([]byte(`{:a`), &u); err == nil {
+		t.Error("expected syntax error")
+	}
+
+	var derr *DecodeError
+	err := UnmarshalValue(map[interface{}]interface{}{Keyword{"", "tags"}: CompositeKey("[1")}, &u)
+	if !errors.As(err, &derr) || derr.Unwrap() == nil || String(derr.Path()) != "[:tags]" || derr.Value() != CompositeKey("[1") {
+		t.Errorf("expected *DecodeError for an invalid CompositeKey, got %v", err)
+	}
+
+	err = UnmarshalValue(map[interface{}]interface{}{Keyword{"", "user-id"}: 1e300}, &u)
+	if !errors.As(err, &derr) || derr.Text() != "1e+300" || derr.Type() != reflect.TypeOf(int64(0)) {
+		t.Errorf("expected the String representation without input, got %v", err)
+	}
+}
+
+func TestUnmarshalAllErrors(t *testing.T) {
+	doc := `{:user-id "42" :name "jane" :scores [1 "2"] :labels {:x "1" :y 2} :address {:address/city 3}}`
+
+	var u testUser
+	err := UnmarshalOptions{AllErrors: true}.Unmarshal([]byte(doc), &u)
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("expected joined errors, got %v", err)
+	}
+
+	var got []string
+	for _, err := range joined.Unwrap() {
+		derr := err.(*DecodeError)
+		got = append(got, String(derr.Path())+" "+derr.Text())
+	}
+	want := []string{`[:address :address/city] 3`, `[:labels :x] "1"`, `[:scores 1] "2"`, `[:user-id] "42"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected errors %q, got %q", want, got)
+	}
+
+	if u.Name != "jane" || u.Scores != [2]float32{1, 0} || u.Labels["y"] != 2 {
+		t.Errorf("expected the other values to be stored, got %+v", u)
+	}
+
+	if err := (UnmarshalOptions{AllErrors: true}).Unmarshal([]byte(`{:name "jane"}`), &u); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package edn
+
+// KeyOptions configures KeywordizeKeys and StringifyKeys.
+type KeyOptions struct {
+	// Namespace is the namespace of keywords for strings without one,
+	// e.g. :user/name for "name" if it is "user".
+	Namespace string
+
+	// OmitNamespace drops the namespace of keywords when stringifying
+	// them, as clojure.walk/stringify-keys does, so that :user/name is
+	// "name" instead of "user/name".
+	OmitNamespace bool
+}
+
+// KeywordizeKeys returns a copy of v in which all map keys that are
+// strings are keywords, like clojure.walk/keywordize-keys.  "a/b"
+// becomes :a/b, strings that are not valid keywords remain strings.
+func KeywordizeKeys(v interface{}) interface{} {
+	return KeyOptions{}.KeywordizeKeys(v)
+}
+
+// StringifyKeys returns a copy of v in which all map keys that are
+// keywords are strings, :a/b becomes "a/b".  Unlike
+// clojure.walk/stringify-keys, the namespace is kept by default.
+func StringifyKeys(v interface{}) interface{} {
+	return KeyOptions{}.StringifyKeys(v)
+}
+
+// KeywordizeKeys is like the package function, using the options.
+func (o KeyOptions) KeywordizeKeys(v interface{}) interface{} {
+	return walkKeys(v, func(key interface{}) interface{} {
+		s, ok := key.(string)
+		if !ok || !IsValidKeyword(":"+s) {
+			return key
+		}
+
+		kw := readWholeToken(":" + s).(Keyword)
+		if kw.Namespace == "" {
+			kw.Namespace = o.Namespace
+		}
+		return kw
+	})
+}
+
+// StringifyKeys is like the package function, using the options.
+func (o KeyOptions) StringifyKeys(v interface{}) interface{} {
+	return walkKeys(v, func(key interface{}) interface{} {
+		kw, ok := key.(Keyword)
+		if !ok {
+			return key
+		}
+
+		if o.OmitNamespace {
+			return kw.Name
+		}
+		return kw.FullName()
+	})
+}
+
+// walkKeys returns a copy of v with fn applied to the keys of all maps
+// within v, including those in map keys and set elements.
+func walkKeys(v interface{}, fn func(interface{}) interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, elem := range v {
+			res[i] = walkKeys(elem, fn)
+		}
+		return res
+	case map[interface{}]interface{}:
+		res := make(map[interface{}]interface{}, len(v))
+		for key, val := range v {
+			res[fn(walkKey(key, fn))] = walkKeys(val, fn)
+		}
+		return res
+	case map[interface{}]bool:
+		res := make(map[interface{}]bool, len(v))
+		for elem := range v {
+			res[walkKey(elem, fn)] = true
+		}
+		return res
+	case Tagged:
+		return Tagged{Tag: v.Tag, Value: walkKeys(v.Value, fn)}
+	default:
+		return v
+	}
+}
+
+// walkKey walks the value of a map key or set element, which is
+// decoded first if it is a CompositeKey.
+func walkKey(key interface{}, fn func(interface{}) interface{}) interface{} {
+	k, ok := key.(CompositeKey)
+	if !ok {
+		return walkKeys(key, fn)
+	}
+
+	val, err := k.Value()
+	if err != nil {
+		return key
+	}
+	return KeyOf(walkKeys(val, fn))
+}
@@ -0,0 +1,41 @@
+package edn
+
+import (
+	"testing"
+)
+
+func TestKeywordizeStringifyKeys(t *testing.T) {
+	val, err := DecodeString(`{"name" "jane" "user/id" 1 "not a keyword" 2 3 4
+	  "nested" [{"a" "b"}] "set" #{{"c" 1}} :kw {"d" #foo {"e" 1}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		val      interface{}
+		expected string
+	}{
+		{KeywordizeKeys(val), `{:name "jane" :user/id 1 "not a keyword" 2 3 4
+		  :nested [{:a "b"}] :set #{{:c 1}} :kw {:d #foo {:e 1}}}`},
+		{KeyOptions{Namespace: "my"}.KeywordizeKeys(val), `{:my/name "jane" :user/id 1 "not a keyword" 2 3 4
+		  :my/nested [{:my/a "b"}] :my/set #{{:my/c 1}} :kw {:my/d #foo {:my/e 1}}}`},
+		{StringifyKeys(KeywordizeKeys(val)), `{"name" "jane" "user/id" 1 "not a keyword" 2 3 4
+		  "nested" [{"a" "b"}] "set" #{{"c" 1}} "kw" {"d" #foo {"e" 1}}}`},
+		{KeyOptions{OmitNamespace: true}.StringifyKeys(KeywordizeKeys(val)), `{"name" "jane" "id" 1 "not a keyword" 2 3 4
+		  "nested" [{"a" "b"}] "set" #{{"c" 1}} "kw" {"d" #foo {"e" 1}}}`},
+	}
+
+	for i, test := range tests {
+		expected, err := DecodeString(test.expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(test.val, expected) {
+			t.Errorf("%d: expected %s, got %s", i, String(expected), String(test.val))
+		}
+	}
+
+	if _, ok := val.(map[interface{}]interface{})["name"]; !ok {
+		t.Errorf("original value was modified")
+	}
+}
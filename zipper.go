@@ -0,0 +1,217 @@
+package edn
+
+// Zipper is a position in a decoded value, for navigating and editing
+// it like clojure.zip does.  Zippers are immutable: the moves and edits
+// return new zippers, and editing a value copies only the collections
+// on the path to it, never modifying the original value.
+//
+// The children of vectors and lists are their elements, those of maps
+// their values, sorted by key, those of sets their elements in the same
+// order, and the child of a Tagged is its value.  Keys of maps are not
+// children, see Key.
+//
+// Moves that are not possible return nil, e.g. Down at a string or
+// Left at the first child.  To edit all values of a kind, walk the
+// value with Next and keep the last zipper for Root:
+//
+//	z := edn.NewZipper(v)
+//	for next := z; next != nil; next = z.Next() {
+//		z = next
+//		if n, ok := z.Node().(int64); ok {
+//			z = z.Replace(n + 1)
+//		}
+//	}
+//	incremented := z.Root()
+type Zipper struct {
+	node interface{}
+
+	parent   *Zipper
+	keys     []interface{}
+	siblings []interface{}
+	index    int
+	changed  bool
+}
+
+// NewZipper returns a zipper at root.
+func NewZipper(root interface{}) *Zipper {
+	return &Zipper{node: root}
+}
+
+// Node returns the value at the position.
+func (z *Zipper) Node() interface{} {
+	return z.node
+}
+
+// Key returns the key of the value in its parent: the map key for map
+// values, the index for elements of vectors, lists and sets, and nil
+// for the value of a Tagged and the root.
+func (z *Zipper) Key() interface{} {
+	if z.keys != nil {
+		return z.keys[z.index]
+	}
+	if z.parent == nil {
+		return nil
+	}
+	if _, ok := z.parent.node.(Tagged); ok {
+		return nil
+	}
+	return z.index
+}
+
+// Down moves to the first child.
+func (z *Zipper) Down() *Zipper {
+	keys, children, ok := zipChildren(z.node)
+	if !ok || len(children) == 0 {
+		return nil
+	}
+
+	return &Zipper{node: children[0], parent: z, keys: keys, siblings: children, index: 0}
+}
+
+// Up moves to the parent, with the edits made to its children.
+func (z *Zipper) Up() *Zipper {
+	if z.parent == nil {
+		return nil
+	}
+	if !z.changed {
+		return z.parent
+	}
+
+	up := *z.parent
+	up.node = zipRebuild(z.parent.node, z.keys, z.siblings)
+	up.changed = true
+	up.siblings = up.replaced(up.node)
+	return &up
+}
+
+// Left moves to the previous sibling.
+func (z *Zipper) Left() *Zipper {
+	return z.sibling(z.index - 1)
+}
+
+// Right moves to the next sibling.
+func (z *Zipper) Right() *Zipper {
+	return z.sibling(z.index + 1)
+}
+
+func (z *Zipper) sibling(i int) *Zipper {
+	if z.parent == nil || i < 0 || i >= len(z.siblings) {
+		return nil
+	}
+
+	s := *z
+	s.node = z.siblings[i]
+	s.index = i
+	return &s
+}
+
+// Next moves to the next value in a depth-first walk of the root, and
+// returns nil after the last one.
+func (z *Zipper) Next() *Zipper {
+	if down := z.Down(); down != nil {
+		return down
+	}
+
+	for ; z != nil; z = z.Up() {
+		if right := z.Right(); right != nil {
+			return right
+		}
+	}
+
+	return nil
+}
+
+// Replace replaces the value at the position with v.
+func (z *Zipper) Replace(v interface{}) *Zipper {
+	r := *z
+	r.node = v
+	r.changed = true
+	r.siblings = z.replaced(v)
+	return &r
+}
+
+// Edit replaces the value at the position with the result of fn.
+func (z *Zipper) Edit(fn func(interface{}) interface{}) *Zipper {
+	return z.Replace(fn(z.node))
+}
+
+// replaced returns a copy of the siblings with the value at the
+// position replaced by v.
+func (z *Zipper) replaced(v interface{}) []interface{} {
+	if z.parent == nil {
+		return nil
+	}
+
+	siblings := make([]interface{}, len(z.siblings))
+	copy(siblings, z.siblings)
+	siblings[z.index] = v
+	return siblings
+}
+
+// Root returns the root value with all edits.
+func (z *Zipper) Root() interface{} {
+	for z.parent != nil {
+		z = z.Up()
+	}
+	return z.node
+}
+
+// zipChildren returns the children of v and, for maps, their keys.
+func zipChildren(v interface{}) (keys, children []interface{}, ok bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		return nil, v, true
+	case map[interface{}]interface{}:
+		keys = make([]interface{}, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sortByString(keys)
+
+		children = make([]interface{}, len(keys))
+		for i, key := range keys {
+			children[i] = v[key]
+		}
+		return keys, children, true
+	case map[interface{}]bool:
+		children = make([]interface{}, 0, len(v))
+		for elem := range v {
+			if k, ok := elem.(CompositeKey); ok {
+				if val, err := k.Value(); err == nil {
+					elem = val
+				}
+			}
+			children = append(children, elem)
+		}
+		sortByString(children)
+		return nil, children, true
+	case Tagged:
+		return nil, []interface{}{v.Value}, true
+	default:
+		return nil, nil, false
+	}
+}
+
+// zipRebuild returns a copy of the collection v with the children.
+func zipRebuild(v interface{}, keys, children []interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return children
+	case map[interface{}]interface{}:
+		m := make(map[interface{}]interface{}, len(children))
+		for i, key := range keys {
+			m[key] = children[i]
+		}
+		return m
+	case map[interface{}]bool:
+		set := make(map[interface{}]bool, len(children))
+		for _, elem := range children {
+			set[KeyOf(elem)] = true
+		}
+		return set
+	case Tagged:
+		return Tagged{Tag: v.Tag, Value: children[0]}
+	default:
+		return v
+	}
+}
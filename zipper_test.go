@@ -0,0 +1,77 @@
+package edn
+
+import (
+	"testing"
+)
+
+func TestZipperNavigation(t *testing.T) {
+	v := mustDecode(t, `{:a [1 2 3] :b #inst "2020-01-01T00:00:00Z" :c #foo "bar"}`)
+
+	z := NewZipper(v)
+	if z.Up() != nil || z.Left() != nil || z.Right() != nil || z.Key() != nil {
+		t.Errorf("root should have no parent or siblings")
+	}
+
+	a := z.Down()
+	if a.Key() != (Keyword{"", "a"}) || !Equal(a.Node(), mustDecode(t, `[1 2 3]`)) {
+		t.Errorf("unexpected first child %v %s", a.Key(), String(a.Node()))
+	}
+	if a.Left() != nil {
+		t.Errorf("first child should have no left sibling")
+	}
+
+	two := a.Down().Right()
+	if two.Node() != int64(2) || two.Key() != 1 {
+		t.Errorf("unexpected element %v at %v", two.Node(), two.Key())
+	}
+	if two.Down() != nil {
+		t.Errorf("integers should have no children")
+	}
+	if two.Right().Right() != nil {
+		t.Errorf("last element should have no right sibling")
+	}
+
+	c := a.Right().Right()
+	if c.Key() != (Keyword{"", "c"}) || c.Down().Node() != "bar" || c.Down().Key() != nil {
+		t.Errorf("unexpected child %v %s", c.Key(), String(c.Node()))
+	}
+	if c.Down().Up().Node() == nil || c.Right() != nil {
+		t.Errorf("unexpected navigation from %s", String(c.Node()))
+	}
+
+	if !Equal(two.Root(), v) {
+		t.Errorf("unexpected root without edits: %s", String(two.Root()))
+	}
+}
+
+func TestZipperEdit(t *testing.T) {
+	src := `{:a [1 2 {:b 3}] :c #{4 [5]} :d #foo 6}`
+	v := mustDecode(t, src)
+
+	z := NewZipper(v)
+	for next := z; next != nil; next = z.Next() {
+		z = next
+		if n, ok := z.Node().(int64); ok {
+			z = z.Replace(n * 10)
+		}
+	}
+
+	expected := mustDecode(t, `{:a [10 20 {:b 30}] :c #{40 [50]} :d #foo 60}`)
+	if root := z.Root(); !Equal(root, expected) {
+		t.Errorf("expected %s, got %s", String(expected), String(root))
+	}
+	if !Equal(v, mustDecode(t, src)) {
+		t.Errorf("original value was modified: %s", String(v))
+	}
+
+	edited := NewZipper(v).Down().Down().Edit(func(v interface{}) interface{} {
+		return Keyword{"", "first"}
+	}).Right().Replace("second").Root()
+	if !Equal(edited, mustDecode(t, `{:a [:first "second" {:b 3}] :c #{4 [5]} :d #foo 6}`)) {
+		t.Errorf("unexpected result %s", String(edited))
+	}
+
+	if root := NewZipper(v).Replace(int64(1)).Root(); root != int64(1) {
+		t.Errorf("unexpected root %s", String(root))
+	}
+}